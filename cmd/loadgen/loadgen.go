@@ -0,0 +1,11 @@
+package main
+
+import (
+	"os"
+
+	"github.com/javorszky/form3takehome/pkg/loadgen"
+)
+
+func main() {
+	os.Exit(loadgen.Run(os.Args[1:], os.Stdout, os.Stderr))
+}
@@ -0,0 +1,245 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// applyOutcome is what happened to a single account, and what apply prints in the json and go-template formats.
+type applyOutcome struct {
+	ID     string `json:"id"`
+	Action string `json:"action"`
+	Error  string `json:"error,omitempty"`
+}
+
+// desiredFlags are the flags of the commands that compare a file of desired accounts with the server to plan changes.
+type desiredFlags struct {
+	file     string
+	prune    bool
+	pageSize uint
+}
+
+// register adds the flags to a command's flag set.
+func (f *desiredFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.file, "file", "-", "read the desired accounts as a JSON array from this file, - for stdin. Every "+
+		"account needs an id, and the attributes it should have")
+	fs.BoolVar(&f.prune, "prune", false, "delete the accounts on the server that are not in the file")
+	fs.UintVar(&f.pageSize, "size", defaultPageSize, "number of accounts to request at once")
+}
+
+// runPlan prints the changes apply would make to the accounts on the server to get to the ones in a file, and makes
+// none of them. In the json format, it prints the plan itself, which apply --plan makes once it has been reviewed.
+func runPlan(a *app, args []string) error {
+	var (
+		common  commonFlags
+		desired desiredFlags
+	)
+
+	fs := a.newFlagSet("plan", "")
+	common.register(fs)
+	desired.register(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	plan, err := a.planDesired(c, desired)
+	if err != nil {
+		return err
+	}
+
+	return printPlan(out, plan)
+}
+
+// runApply makes the accounts on the server the ones in a file, in the format diff reads: it creates the missing ones
+// with their IDs, and updates the attributes the file sets where they drifted. With --prune it also deletes the
+// accounts the file does not have. With --dry-run it only prints what it would change, the way plan does. With --plan
+// it makes the changes of a plan that plan printed instead, and nothing else.
+func runApply(a *app, args []string) error {
+	var (
+		common   commonFlags
+		desired  desiredFlags
+		dryRun   bool
+		planFile string
+	)
+
+	fs := a.newFlagSet("apply", "")
+	common.register(fs)
+	desired.register(fs)
+	fs.BoolVar(&dryRun, "dry-run", false, "print the changes that would be made, and make none of them")
+	fs.StringVar(&planFile, "plan", "", "make the changes of a plan written by plan -o json to this file, - for "+
+		"stdin, instead of planning them from --file")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	var plan client.Plan
+
+	if planFile != "" {
+		plan, err = a.readPlan(planFile)
+	} else {
+		plan, err = a.planDesired(c, desired)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if dryRun {
+		return printPlan(out, plan)
+	}
+
+	// The error only says which changes failed, which their outcomes say too.
+	result, _ := c.ApplyPlan(context.Background(), plan)
+
+	outcomes := make([]applyOutcome, 0, len(result.Outcomes))
+	failed := 0
+
+	for _, o := range result.Outcomes {
+		outcome := applyOutcome{ID: o.ID, Action: o.Action}
+		if o.Err != nil {
+			outcome.Error = o.Err.Error()
+			failed++
+		}
+
+		outcomes = append(outcomes, outcome)
+	}
+
+	err = printApply(out, outcomes)
+	if err != nil {
+		return err
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("%w: %d of %d changes failed", errBulkFailed, failed, len(outcomes))
+	}
+
+	return nil
+}
+
+// planDesired reads the desired accounts of the file of the flags, and plans the changes to get to them.
+func (a *app) planDesired(c client.Client, f desiredFlags) (client.Plan, error) {
+	content, err := a.readFile(f.file)
+	if err != nil {
+		return client.Plan{}, err
+	}
+
+	accounts, err := decodeDesired(f.file, content)
+	if err != nil {
+		return client.Plan{}, err
+	}
+
+	plan, err := c.Plan(context.Background(), accounts, client.ApplyOptions{Prune: f.prune, PageSize: f.pageSize})
+	if err != nil {
+		return client.Plan{}, fmt.Errorf("planning changes: %w", err)
+	}
+
+	return plan, nil
+}
+
+// readPlan reads and decodes a plan written by plan -o json, - for stdin.
+func (a *app) readPlan(name string) (client.Plan, error) {
+	content, err := a.readFile(name)
+	if err != nil {
+		return client.Plan{}, err
+	}
+
+	var plan client.Plan
+
+	err = json.Unmarshal(content, &plan)
+	if err != nil {
+		return client.Plan{}, fmt.Errorf("decoding plan %s: %w", name, err)
+	}
+
+	return plan, nil
+}
+
+// printPlan prints the changes of a plan like diff prints differences in the text and quiet formats, and the plan
+// itself in the others, so it can be reviewed, and made with apply --plan.
+func printPlan(out printer, plan client.Plan) error {
+	d := planDiff(plan)
+
+	switch out.format {
+	case outputText:
+		printDiff(out, d)
+
+		return nil
+	case outputQuiet:
+		return out.ids(append(append(d.Missing, d.Extra...), driftData(d.Drift)...)...)
+	}
+
+	return out.structured(plan)
+}
+
+// printApply writes what apply did to every account, in the order it did it.
+func printApply(out printer, outcomes []applyOutcome) error {
+	switch out.format {
+	case outputText:
+		if len(outcomes) == 0 {
+			_, _ = fmt.Fprintln(out.w, out.colors.success("no changes"))
+		}
+
+		for _, o := range outcomes {
+			if o.Error != "" {
+				_, _ = fmt.Fprintf(out.w, "%s %s %s: %s\n", out.colors.failure("failed to"), o.Action, o.ID, o.Error)
+
+				continue
+			}
+
+			_, _ = fmt.Fprintf(out.w, "%s %s\n", out.colors.success(o.Action+"d"), o.ID)
+		}
+
+		return nil
+	case outputQuiet:
+		changed := make([]client.Data, 0, len(outcomes))
+
+		for _, o := range outcomes {
+			if o.Error == "" {
+				changed = append(changed, client.Data{ID: o.ID})
+			}
+		}
+
+		return out.ids(changed...)
+	}
+
+	return out.structured(outcomes)
+}
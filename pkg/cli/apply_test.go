@@ -0,0 +1,186 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Apply(t *testing.T) {
+	const (
+		missingID = "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0"
+		extraID   = "ffa7706b-d8fc-40b2-be6b-67d2a628cadf"
+	)
+
+	desired := `[
+		{"id": "` + testAccountID + `", "attributes": {"country": "GB", "bank_id": "123456"}},
+		{"id": "` + missingID + `", "attributes": {"country": "GB", "bank_id": "400300", "bank_id_code": "GBDSC",
+			"bic": "NWBKGB22"}}
+	]`
+
+	tests := []struct {
+		name         string
+		args         []string
+		updateStatus int
+		wantCode     int
+		wantRequests []string
+		wantStdout   []string
+		wantStderr   []string
+	}{
+		{
+			name:     "creates missing and updates drifted accounts",
+			args:     []string{"apply"},
+			wantCode: cli.ExitOK,
+			wantRequests: []string{
+				"GET /v1/organisation/accounts",
+				"POST /v1/organisation/accounts",
+				"PATCH /v1/organisation/accounts/" + testAccountID,
+			},
+			wantStdout: []string{"created " + missingID + "\n", "updated " + testAccountID + "\n"},
+		},
+		{
+			name:     "deletes extra accounts with --prune",
+			args:     []string{"apply", "--prune", "-q"},
+			wantCode: cli.ExitOK,
+			wantRequests: []string{
+				"GET /v1/organisation/accounts",
+				"POST /v1/organisation/accounts",
+				"PATCH /v1/organisation/accounts/" + testAccountID,
+				"DELETE /v1/organisation/accounts/" + extraID,
+			},
+			wantStdout: []string{missingID + "\n" + testAccountID + "\n" + extraID + "\n"},
+		},
+		{
+			name:         "changes nothing with --dry-run",
+			args:         []string{"apply", "--dry-run", "--prune"},
+			wantCode:     cli.ExitOK,
+			wantRequests: []string{"GET /v1/organisation/accounts"},
+			wantStdout: []string{
+				"+ " + missingID + " (GB): missing on the server\n",
+				"- " + extraID + " (GB): not in the file\n",
+				"~ " + testAccountID + ": attributes differ\n    bank_id: server has \"89282dd\", file wants \"123456\"\n",
+			},
+		},
+		{
+			name:         "reports the changes that failed",
+			args:         []string{"apply", "-o", "json"},
+			updateStatus: http.StatusConflict,
+			wantCode:     cli.ExitFailure,
+			wantRequests: []string{
+				"GET /v1/organisation/accounts",
+				"POST /v1/organisation/accounts",
+				"PATCH /v1/organisation/accounts/" + testAccountID,
+			},
+			wantStdout: []string{`"action": "create"`, `"action": "update"`, `"error": "client.Update: conflict`},
+			wantStderr: []string{"1 of 2 changes failed"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests []string
+			)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				mu.Unlock()
+
+				switch r.Method {
+				case http.MethodGet:
+					_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+				case http.MethodPost:
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+				case http.MethodPatch:
+					if tt.updateStatus != 0 {
+						w.WriteHeader(tt.updateStatus)
+
+						return
+					}
+
+					_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+				case http.MethodDelete:
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(append(tt.args, "--retries", "0"), strings.NewReader(desired), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+			assert.Equal(t, tt.wantRequests, requests)
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestRun_Plan(t *testing.T) {
+	const extraID = "ffa7706b-d8fc-40b2-be6b-67d2a628cadf"
+
+	var (
+		mu       sync.Mutex
+		requests []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+		case http.MethodPatch:
+			_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	var planOut, stderr bytes.Buffer
+
+	desired := `[{"id": "` + testAccountID + `", "attributes": {"bank_id": "123456"}}]`
+
+	code := cli.Run([]string{"plan", "--prune", "-o", "json"}, strings.NewReader(desired), &planOut, &stderr)
+
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.Equal(t, []string{"GET /v1/organisation/accounts"}, requests, "planning changes nothing")
+	assert.Contains(t, planOut.String(), `"path": "bank_id"`)
+	assert.Contains(t, planOut.String(), `"attributes": {`+"\n"+`        "bank_id": "123456"`)
+
+	requests = nil
+
+	var stdout bytes.Buffer
+
+	code = cli.Run([]string{"apply", "--plan", "-"}, &planOut, &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.Equal(t, []string{
+		"PATCH /v1/organisation/accounts/" + testAccountID,
+		"DELETE /v1/organisation/accounts/" + extraID,
+	}, requests, "the plan is made as it is, without listing the accounts again")
+	assert.Equal(t, "updated "+testAccountID+"\ndeleted "+extraID+"\n", stdout.String())
+}
@@ -0,0 +1,124 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+const (
+	auditFileMode = 0o600
+
+	auditOutcomeSuccess = "success"
+	auditOutcomeFailure = "failure"
+	auditOutcomeError   = "error"
+)
+
+// auditEntry is a single line of the audit log: who did what to which account, when, and how it went.
+type auditEntry struct {
+	Time           time.Time `json:"time"`
+	User           string    `json:"user"`
+	OrganisationID string    `json:"organisation_id"`
+	Operation      string    `json:"operation"`
+	ResourceID     string    `json:"resource_id"`
+	Version        uint      `json:"version"`
+	Status         int       `json:"status,omitempty"`
+	Outcome        string    `json:"outcome"`
+	Error          string    `json:"error,omitempty"`
+}
+
+// auditLog is the client.AuditSink of the audit log, which appends an auditEntry for every create, update, and delete
+// once it finished, with its final outcome. Entries of operations that run at the same time are written one after the
+// other.
+type auditLog struct {
+	path   string
+	user   string
+	stderr io.Writer
+	mu     *sync.Mutex
+}
+
+// newAuditLog returns a sink that records operations in the audit log at path, and warns on stderr about those it
+// could not record.
+func newAuditLog(path string, stderr io.Writer) *auditLog {
+	return &auditLog{
+		path:   path,
+		user:   currentUser(),
+		stderr: stderr,
+		mu:     &sync.Mutex{},
+	}
+}
+
+// Record appends the record to the audit log. The change was made whether it could be recorded or not, so a failure to
+// record it is a warning rather than an error of the command.
+func (l *auditLog) Record(r client.AuditRecord) {
+	entry := auditEntry{
+		Time:           r.Time,
+		User:           l.user,
+		OrganisationID: r.OrganisationID,
+		Operation:      r.Operation,
+		ResourceID:     r.AccountID,
+		Version:        r.Version,
+		Outcome:        auditOutcomeSuccess,
+	}
+
+	var apiErr *client.APIError
+
+	switch {
+	case errors.As(r.Err, &apiErr):
+		entry.Outcome, entry.Status, entry.Error = auditOutcomeFailure, apiErr.StatusCode, r.Err.Error()
+	case r.Err != nil:
+		entry.Outcome, entry.Error = auditOutcomeError, r.Err.Error()
+	}
+
+	err := l.write(entry)
+	if err != nil {
+		_, _ = fmt.Fprintf(l.stderr, "warning: %s %s was not recorded in the audit log: %s\n", r.Operation, r.AccountID, err)
+	}
+}
+
+// write appends the entry to the audit log as a line of JSON.
+func (l *auditLog) write(entry auditEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return fmt.Errorf("auditLog.write: %w", err)
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	f, err := os.OpenFile(l.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, auditFileMode)
+	if err != nil {
+		return fmt.Errorf("auditLog.write: %w", err)
+	}
+
+	_, err = f.Write(append(line, '\n'))
+	if err != nil {
+		_ = f.Close()
+
+		return fmt.Errorf("auditLog.write: %w", err)
+	}
+
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("auditLog.write: %w", err)
+	}
+
+	return nil
+}
+
+// currentUser returns the name of the user running the command, or the value of $USER if it can't be looked up.
+func currentUser() string {
+	u, err := user.Current()
+	if err != nil {
+		return os.Getenv("USER")
+	}
+
+	return u.Username
+}
@@ -0,0 +1,31 @@
+package cli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+func TestConnect_auditLogKeepsTransportTuning(t *testing.T) {
+	os.Clearenv()
+
+	_ = os.Setenv(config.AccountsAPIURLKey, "http://localhost:8080")
+	_ = os.Setenv(config.OrganisationIDKey, "org")
+	_ = os.Setenv(config.AuditLogKey, filepath.Join(t.TempDir(), "audit.log"))
+
+	a := &app{stderr: ioutil.Discard}
+
+	c, err := a.newBulkClient(commonFlags{}, bulkFlags{concurrency: 4})
+	assert.NoError(t, err)
+
+	next, ok := c.HttpClient.Transport.(*http.Transport)
+	if assert.True(t, ok, "the audit log is kept by the client, not the transport, got %T", c.HttpClient.Transport) {
+		assert.Equal(t, 4, next.MaxIdleConnsPerHost)
+	}
+}
@@ -0,0 +1,136 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+func TestRun_AuditLog(t *testing.T) {
+	var posts int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		case http.MethodPost:
+			if atomic.AddInt32(&posts, 1) == 1 {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+
+			w.WriteHeader(http.StatusCreated)
+		}
+
+		_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "audit")
+	if err != nil {
+		assert.FailNowf(t, "could not create temp dir", "error: %s", err)
+	}
+	defer os.RemoveAll(dir)
+
+	path := filepath.Join(dir, "audit.log")
+
+	setTestEnv(t, ts.URL)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"create", "--audit-log", path, "-q", "--retries", "1", "--retry-backoff", "1ms",
+		"--country", "GB", "--bank-id", "123456", "--bank-id-code", "GBDSC", "--bic", "BARCGB22XXX",
+	}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+
+	_ = os.Setenv(config.AuditLogKey, path)
+
+	code = cli.Run([]string{"fetch", testAccountID}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+
+	code = cli.Run([]string{"delete", "--version", "3", testAccountID}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitNotFound, code, "stderr: %s", stderr.String())
+
+	type entry struct {
+		User           string `json:"user"`
+		OrganisationID string `json:"organisation_id"`
+		Operation      string `json:"operation"`
+		ResourceID     string `json:"resource_id"`
+		Version        uint   `json:"version"`
+		Status         int    `json:"status"`
+		Outcome        string `json:"outcome"`
+	}
+
+	var entries []entry
+
+	for _, line := range strings.Split(strings.TrimSpace(string(readFile(t, path))), "\n") {
+		var e entry
+
+		assert.NoError(t, json.Unmarshal([]byte(line), &e))
+
+		entries = append(entries, e)
+	}
+
+	if !assert.Len(t, entries, 2, "the retry of the create is not recorded, and neither is the fetch") {
+		return
+	}
+
+	assert.Equal(t, "create", entries[0].Operation)
+	assert.NotEmpty(t, entries[0].ResourceID)
+	assert.Equal(t, "success", entries[0].Outcome)
+	assert.Equal(t, testOrganisationID, entries[0].OrganisationID)
+
+	assert.Equal(t, entry{
+		User:           entries[0].User,
+		OrganisationID: testOrganisationID,
+		Operation:      "delete",
+		ResourceID:     testAccountID,
+		Version:        3,
+		Status:         http.StatusNotFound,
+		Outcome:        "failure",
+	}, entries[1])
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+}
+
+func TestRun_AuditLogWriteFailure(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	path := filepath.Join(t.TempDir(), "missing", "audit.log")
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{
+		"create", "--audit-log", path, "-q",
+		"--country", "GB", "--bank-id", "123456", "--bank-id-code", "GBDSC", "--bic", "BARCGB22XXX",
+	}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitOK, code, "the account was created, so the command succeeds")
+	assert.NotEmpty(t, stdout.String())
+	assert.Contains(t, stderr.String(), "warning: create ")
+	assert.Contains(t, stderr.String(), "was not recorded in the audit log")
+}
@@ -0,0 +1,350 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/config"
+	"github.com/javorszky/form3takehome/pkg/dump"
+)
+
+const (
+	cursorSuffix   = ".cursor"
+	backupFileMode = 0o600
+)
+
+var (
+	errNoBackupFile = errors.New("--out is required")
+	errNoCursor     = errors.New("no unfinished backup to resume")
+	errPageSize     = errors.New("--size differs from the page size of the backup being resumed")
+	errResume       = errors.New("backup can't be resumed")
+)
+
+// backupCursor records how far a backup got. It is rewritten next to the backup after every page, and removed once the
+// backup is complete.
+type backupCursor struct {
+	PageSize uint  `json:"page_size"`
+	NextPage uint  `json:"next_page"`
+	Offset   int64 `json:"offset"`
+	Records  int   `json:"records"`
+}
+
+// runBackup streams every account page by page to a dump, the newline delimited JSON of package dump, encrypted if a
+// backup key is configured. An interrupted backup can be continued with --resume from the first page it did not
+// finish.
+func runBackup(a *app, args []string) error {
+	var (
+		common   commonFlags
+		bulk     bulkFlags
+		outPath  string
+		pageSize uint
+		resume   bool
+	)
+
+	fs := a.newFlagSet("backup", "")
+	common.registerConnection(fs)
+	bulk.register(fs)
+	registerBackupKeyFlag(fs, &common.backupKeyFile)
+	fs.StringVar(&outPath, "out", "", "write the accounts to this file, one JSON object per line")
+	fs.UintVar(&pageSize, "size", defaultPageSize, "number of accounts to request at once")
+	fs.BoolVar(&resume, "resume", false, "continue an interrupted backup into the same file")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	if outPath == "" {
+		return errNoBackupFile
+	}
+
+	opts, err := a.dumpOptions(common)
+	if err != nil {
+		return err
+	}
+
+	cur := backupCursor{PageSize: pageSize}
+
+	var pos dump.Position
+
+	if resume {
+		cur, err = readCursor(outPath)
+		if err != nil {
+			return err
+		}
+
+		if isFlagSet(fs, "size") && pageSize != cur.PageSize {
+			return fmt.Errorf("%w: %d", errPageSize, cur.PageSize)
+		}
+
+		if cur.Offset > 0 {
+			pos, err = checkResume(outPath, cur.Offset, opts)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	p, err := a.newProgress(bulk, "backup", 0)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newBulkClient(common, bulk)
+	if err != nil {
+		return err
+	}
+
+	f, err := openBackup(outPath, cur.Offset)
+	if err != nil {
+		return err
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	err = writeCursor(outPath, cur)
+	if err != nil {
+		return err
+	}
+
+	w := bufio.NewWriter(f)
+	start := cur.Offset
+	dw := dump.AppendWriter(w, pos, opts...)
+
+	if start == 0 {
+		dw, err = dump.NewWriter(w, dump.Header{CreatedAt: time.Now().UTC(), OrganisationID: c.OrganisationID}, opts...)
+		if err != nil {
+			return fmt.Errorf("writing %s: %w", outPath, err)
+		}
+	}
+
+	err = c.ListPagesFrom(cur.NextPage, cur.PageSize, func(mp client.MultiPayload) error {
+		written := 0
+
+		for _, d := range mp.Data {
+			p.record(d.ID, nil)
+
+			// The list endpoint returns the accounts of every organisation, and restore would create the others in
+			// the configured one, so they are left out.
+			if d.OrganisationID != c.OrganisationID {
+				continue
+			}
+
+			writeErr := dw.Write(d)
+			if writeErr != nil {
+				return fmt.Errorf("writing %s: %w", outPath, writeErr)
+			}
+
+			written++
+		}
+
+		flushErr := w.Flush()
+		if flushErr != nil {
+			return fmt.Errorf("writing %s: %w", outPath, flushErr)
+		}
+
+		syncErr := f.Sync()
+		if syncErr != nil {
+			return fmt.Errorf("writing %s: %w", outPath, syncErr)
+		}
+
+		cur.Offset = start + dw.Written()
+		cur.NextPage++
+		cur.Records += written
+
+		return writeCursor(outPath, cur)
+	})
+
+	p.finish()
+
+	if err != nil {
+		return fmt.Errorf("backing up accounts, run again with --resume to continue: %w", err)
+	}
+
+	err = dw.Close()
+	if err == nil {
+		err = w.Flush()
+	}
+
+	if err == nil {
+		err = f.Sync()
+	}
+
+	if err != nil {
+		return fmt.Errorf("writing %s: %w", outPath, err)
+	}
+
+	err = os.Remove(outPath + cursorSuffix)
+	if err != nil {
+		return fmt.Errorf("removing cursor: %w", err)
+	}
+
+	_, _ = fmt.Fprintf(a.stdout, "backed up %d accounts to %s\n", cur.Records, outPath)
+
+	return nil
+}
+
+// registerBackupKeyFlag adds the --key-file flag, which overrides the backup key file of the profile.
+func registerBackupKeyFlag(fs *flag.FlagSet, path *string) {
+	fs.StringVar(path, "key-file", "", "file with the base64 encoded 32 byte key backups are encrypted with, "+
+		"overrides the backup_key_file setting of the profile and $"+config.BackupKeyFileKey)
+}
+
+// dumpOptions returns the options of the dumps backup writes and restore reads: encryption with the backup key, if one
+// is configured, and none otherwise.
+func (a *app) dumpOptions(f commonFlags) ([]dump.Option, error) {
+	cfg, err := a.loadConfig(f)
+	if err != nil {
+		return nil, err
+	}
+
+	if cfg.BackupKeyFile == "" {
+		return nil, nil
+	}
+
+	content, err := ioutil.ReadFile(cfg.BackupKeyFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading backup key: %w", err)
+	}
+
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(content)))
+	if err != nil {
+		return nil, fmt.Errorf("decoding backup key %s: %w", cfg.BackupKeyFile, err)
+	}
+
+	c, err := dump.AESGCM(key)
+	if err != nil {
+		return nil, fmt.Errorf("backup key %s: %w", cfg.BackupKeyFile, err)
+	}
+
+	return []dump.Option{dump.WithCipher(c)}, nil
+}
+
+// checkResume reads the part of a backup that is done, to make sure the accounts appended to it are written like the
+// ones before them: in the same version of the format, and encrypted with the same key, or not at all. opts are the
+// ones dumpOptions returned, so there are some only if a key is configured. It returns the position the backup carries
+// on from, after the last account of that part, which has no trailer yet.
+func checkResume(name string, offset int64, opts []dump.Option) (dump.Position, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return dump.Position{}, fmt.Errorf("opening %s: %w", name, err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	r, err := dump.NewReader(io.LimitReader(f, offset), opts...)
+	if errors.Is(err, dump.ErrNotEncrypted) {
+		return dump.Position{}, fmt.Errorf("%w: %s is not encrypted, and a backup key is configured", errResume, name)
+	}
+
+	if err != nil {
+		return dump.Position{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	if r.Header().SchemaVersion == 0 {
+		return dump.Position{}, fmt.Errorf("%w: %s was started by an older version, start it again without --resume",
+			errResume, name)
+	}
+
+	// Every account is read, as each one is only decrypted with the key the backup was started with, in its place.
+	for err == nil {
+		_, err = r.Next()
+	}
+
+	if err != io.EOF && !errors.Is(err, dump.ErrIncomplete) {
+		return dump.Position{}, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	return r.Position(), nil
+}
+
+// openBackup opens the backup file for writing at offset, dropping anything after it, like the half written page of
+// an interrupted backup. An offset of 0 starts a new backup.
+func openBackup(name string, offset int64) (*os.File, error) {
+	flags := os.O_WRONLY | os.O_CREATE
+	if offset == 0 {
+		flags |= os.O_TRUNC
+	}
+
+	f, err := os.OpenFile(name, flags, backupFileMode)
+	if err != nil {
+		return nil, fmt.Errorf("opening %s: %w", name, err)
+	}
+
+	err = f.Truncate(offset)
+	if err == nil {
+		_, err = f.Seek(offset, io.SeekStart)
+	}
+
+	if err != nil {
+		_ = f.Close()
+
+		return nil, fmt.Errorf("rewinding %s: %w", name, err)
+	}
+
+	return f, nil
+}
+
+// readCursor reads the cursor of an unfinished backup.
+func readCursor(backup string) (backupCursor, error) {
+	var cur backupCursor
+
+	content, err := ioutil.ReadFile(backup + cursorSuffix)
+	if errors.Is(err, os.ErrNotExist) {
+		return backupCursor{}, fmt.Errorf("%w: %s has no %s file", errNoCursor, backup, cursorSuffix)
+	}
+
+	if err != nil {
+		return backupCursor{}, fmt.Errorf("reading cursor: %w", err)
+	}
+
+	err = json.Unmarshal(content, &cur)
+	if err != nil {
+		return backupCursor{}, fmt.Errorf("decoding cursor: %w", err)
+	}
+
+	return cur, nil
+}
+
+// writeCursor replaces the cursor of the backup. It writes a temporary file and renames it over the old one, so an
+// interruption never leaves a half written cursor behind.
+func writeCursor(backup string, cur backupCursor) error {
+	content, err := json.Marshal(cur)
+	if err != nil {
+		return fmt.Errorf("encoding cursor: %w", err)
+	}
+
+	tmp := backup + cursorSuffix + ".tmp"
+
+	err = ioutil.WriteFile(tmp, content, backupFileMode)
+	if err != nil {
+		return fmt.Errorf("writing cursor: %w", err)
+	}
+
+	err = os.Rename(tmp, backup+cursorSuffix)
+	if err != nil {
+		return fmt.Errorf("writing cursor: %w", err)
+	}
+
+	return nil
+}
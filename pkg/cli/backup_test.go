@@ -0,0 +1,329 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/config"
+	"github.com/javorszky/form3takehome/pkg/dump"
+)
+
+func TestRun_BackupResume(t *testing.T) {
+	failPage := "1"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var number, size int
+
+		page := r.URL.Query().Get("page[number]")
+		_, _ = fmt.Sscan(page, &number)
+		_, _ = fmt.Sscan(r.URL.Query().Get("page[size]"), &size)
+
+		if page == failPage {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		mp := client.MultiPayload{Data: []client.Data{}}
+
+		for i := number * size; i < 5 && i < (number+1)*size; i++ {
+			mp.Data = append(mp.Data, client.Data{
+				ID:             fmt.Sprintf("account-%d", i),
+				OrganisationID: testOrganisationID,
+				Type:           "accounts",
+				Attributes:     client.Resource{Country: "GB"},
+			})
+		}
+
+		if (number+1)*size < 5 {
+			mp.Links.Next = "next"
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(mp)
+	}))
+	defer ts.Close()
+
+	key := bytes.Repeat([]byte{7}, 32)
+
+	aesGCM, err := dump.AESGCM(key)
+	assert.NoError(t, err)
+
+	tests := []struct {
+		name        string
+		key         bool
+		opts        []dump.Option
+		wantVersion int
+	}{
+		{
+			name:        "not encrypted",
+			wantVersion: 1,
+		},
+		{
+			name:        "encrypted",
+			key:         true,
+			opts:        []dump.Option{dump.WithCipher(aesGCM)},
+			wantVersion: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			failPage = "1"
+
+			setTestEnv(t, ts.URL)
+
+			dir := t.TempDir()
+			out := filepath.Join(dir, "backup.ndjson")
+
+			if tt.key {
+				_ = os.Setenv(config.BackupKeyFileKey, writeKeyFile(t, dir, base64.StdEncoding.EncodeToString(key)))
+			}
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run([]string{"backup", "--out", out, "--size", "2", "--progress", "none"},
+				strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, cli.ExitFailure, code)
+			assert.Contains(t, stderr.String(), "run again with --resume to continue: client.ListPagesFrom: page 1")
+			assert.FileExists(t, out+".cursor")
+
+			// Simulate a page that was half written when the backup was interrupted.
+			f, err := os.OpenFile(out, os.O_APPEND|os.O_WRONLY, 0o600)
+			assert.NoError(t, err)
+			_, _ = f.WriteString(`{"id":"half`)
+			_ = f.Close()
+
+			failPage = ""
+
+			stdout.Reset()
+			stderr.Reset()
+
+			code = cli.Run([]string{"backup", "--out", out, "--resume", "--progress", "none"},
+				strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+			assert.Equal(t, "backed up 5 accounts to "+out+"\n", stdout.String())
+			assert.NoFileExists(t, out+".cursor")
+
+			content, err := ioutil.ReadFile(out)
+			assert.NoError(t, err)
+
+			header, accounts, err := dump.ReadAll(bytes.NewReader(content), tt.opts...)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, header.SchemaVersion)
+			assert.Equal(t, testOrganisationID, header.OrganisationID)
+
+			if assert.Len(t, accounts, 5) {
+				for i, d := range accounts {
+					assert.Equal(t, fmt.Sprintf("account-%d", i), d.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestRun_BackupEncrypted(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(client.MultiPayload{Data: []client.Data{
+			{ID: testAccountID, OrganisationID: testOrganisationID, Type: "accounts",
+				Attributes: client.Resource{Country: "GB", Name: [4]string{"Jane Doe"}}},
+		}})
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	dir := t.TempDir()
+	out := filepath.Join(dir, "backup.ndjson")
+	key := bytes.Repeat([]byte{7}, 32)
+	keyFile := writeKeyFile(t, dir, base64.StdEncoding.EncodeToString(key)+"\n")
+
+	_ = os.Setenv(config.BackupKeyFileKey, keyFile)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"backup", "--out", out, "--progress", "none"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+
+	content, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(content), testAccountID)
+	assert.NotContains(t, string(content), "Jane Doe")
+
+	c, err := dump.AESGCM(key)
+	assert.NoError(t, err)
+
+	header, accounts, err := dump.ReadAll(bytes.NewReader(content), dump.WithCipher(c))
+	assert.NoError(t, err)
+	assert.Equal(t, "aes-256-gcm", header.Encryption)
+
+	if assert.Len(t, accounts, 1) {
+		assert.Equal(t, testAccountID, accounts[0].ID)
+		assert.Equal(t, "Jane Doe", accounts[0].Attributes.Name[0])
+	}
+
+	plain := filepath.Join(dir, "plain.ndjson")
+	assert.NoError(t, ioutil.WriteFile(plain, []byte(`{"format":"form3-accounts-dump","schema_version":1}`+"\n"), 0o600))
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantStderr string
+	}{
+		{
+			name:       "restoring without the key",
+			args:       []string{"restore", "--file", out, "--key-file", ""},
+			wantStderr: "dump is encrypted, and no key was given",
+		},
+		{
+			name:       "restoring with the wrong key",
+			args:       []string{"restore", "--file", out, "--key-file", writeKeyFile(t, dir, strings.Repeat("A", 43)+"=")},
+			wantStderr: "line 2: decrypting failed",
+		},
+		{
+			name:       "restoring a backup that isn't encrypted with the key",
+			args:       []string{"restore", "--file", plain, "--key-file", keyFile},
+			wantStderr: "dump is not encrypted, and a key was given",
+		},
+		{
+			name:       "a key of the wrong size",
+			args:       []string{"restore", "--file", out, "--key-file", writeKeyFile(t, dir, "c2hvcnQ=")},
+			wantStderr: "wrong key size",
+		},
+		{
+			name:       "a key that isn't base64",
+			args:       []string{"backup", "--out", out, "--key-file", writeKeyFile(t, dir, "not base64")},
+			wantStderr: "decoding backup key",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_ = os.Unsetenv(config.BackupKeyFileKey)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, cli.ExitFailure, code)
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+		})
+	}
+}
+
+func TestRun_BackupResumeErrors(t *testing.T) {
+	dir := t.TempDir()
+	out := filepath.Join(dir, "backup.ndjson")
+	keyFile := writeKeyFile(t, dir, base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+
+	tests := []struct {
+		name       string
+		args       []string
+		cursor     string
+		backup     string
+		wantStderr string
+	}{
+		{
+			name:       "requires an output file",
+			args:       []string{"backup"},
+			wantStderr: "--out is required",
+		},
+		{
+			name:       "fails to resume without a cursor",
+			args:       []string{"backup", "--out", out, "--resume"},
+			wantStderr: "no unfinished backup to resume",
+		},
+		{
+			name:       "fails to resume with a different page size",
+			args:       []string{"backup", "--out", out, "--resume", "--size", "10"},
+			cursor:     `{"page_size": 2, "next_page": 1, "offset": 0, "records": 2}`,
+			wantStderr: "--size differs from the page size of the backup being resumed: 2",
+		},
+		{
+			name:       "fails to resume a backup started without a header",
+			args:       []string{"backup", "--out", out, "--resume"},
+			cursor:     `{"page_size": 1, "next_page": 1, "offset": 15, "records": 1}`,
+			backup:     `{"id":"first"}` + "\n",
+			wantStderr: "was started by an older version, start it again without --resume",
+		},
+		{
+			name:   "fails to resume a backup that isn't encrypted with a key",
+			args:   []string{"backup", "--out", out, "--resume", "--key-file", keyFile},
+			cursor: `{"page_size": 1, "next_page": 1, "offset": 88, "records": 0}`,
+			backup: `{"format":"form3-accounts-dump","schema_version":1,"created_at":"2021-03-01T12:00:00Z"}` +
+				"\n",
+			wantStderr: "is not encrypted, and a backup key is configured",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, "http://127.0.0.1:1")
+
+			if tt.cursor != "" {
+				assert.NoError(t, ioutil.WriteFile(out+".cursor", []byte(tt.cursor), 0o600))
+			}
+
+			if tt.backup != "" {
+				assert.NoError(t, ioutil.WriteFile(out, []byte(tt.backup), 0o600))
+			}
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, cli.ExitFailure, code)
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+		})
+	}
+}
+
+// writeKeyFile writes a backup key file to dir, and returns its path.
+func writeKeyFile(t *testing.T, dir, content string) string {
+	t.Helper()
+
+	f, err := ioutil.TempFile(dir, "*.key")
+	assert.NoError(t, err)
+
+	_, err = f.WriteString(content)
+	assert.NoError(t, err)
+	assert.NoError(t, f.Close())
+
+	return f.Name()
+}
+
+func TestRun_BackupOtherOrganisations(t *testing.T) {
+	ts := httptest.NewServer(otherOrganisationHandler(t))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	out := filepath.Join(t.TempDir(), "backup.ndjson")
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"backup", "--out", out, "--progress", "none"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.Equal(t, "backed up 1 accounts to "+out+"\n", stdout.String())
+
+	content, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+
+	_, accounts, err := dump.ReadAll(bytes.NewReader(content))
+	assert.NoError(t, err)
+
+	if assert.Len(t, accounts, 1, "the account of another organisation is left out") {
+		assert.Equal(t, testAccountID, accounts[0].ID)
+	}
+}
@@ -0,0 +1,321 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+var (
+	errBulkFailed = errors.New("some accounts failed")
+	errNotAnArray = errors.New("expected a JSON array of accounts")
+	errPlaintext  = errors.New("a backup key is configured, and export writes the accounts unencrypted")
+)
+
+// runExport writes every account of the configured organisation as a JSON array, in the same shape as the data the
+// API sends, to stdout or a file. With --output ndjson it writes one account per line instead, as the pages arrive.
+// The accounts are always written in plaintext, so with a backup key configured, it refuses to, unless --plaintext
+// says that is what's wanted.
+func runExport(a *app, args []string) error {
+	var (
+		common    commonFlags
+		bulk      bulkFlags
+		outPath   string
+		output    string
+		pageSize  uint
+		autoSize  bool
+		plaintext bool
+	)
+
+	fs := a.newFlagSet("export", "")
+	common.registerConnection(fs)
+	bulk.register(fs)
+	fs.StringVar(&outPath, "out", "-", "write the accounts to this file, - for stdout")
+	fs.UintVar(&pageSize, "size", defaultPageSize, "number of accounts to request at once")
+	fs.BoolVar(&autoSize, "auto-size", false, "start at --size, and tune the page size to the latency of the service, "+
+		"one page at a time")
+	fs.BoolVar(&plaintext, "plaintext", false, "export the accounts unencrypted even though a backup key is configured")
+
+	outputUsage := "output format: json for a JSON array, or ndjson for one account per line"
+	fs.StringVar(&output, "output", outputJSON, outputUsage)
+	fs.StringVar(&output, "o", outputJSON, outputUsage+" (shorthand)")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	if output != outputJSON && output != outputNDJSON {
+		return fmt.Errorf("%w: %s, use %s or %s", errOutputFormat, output, outputJSON, outputNDJSON)
+	}
+
+	opts, err := a.dumpOptions(common)
+	if err != nil {
+		return err
+	}
+
+	if len(opts) > 0 && !plaintext {
+		return fmt.Errorf("%w: use backup for an encrypted copy, or --plaintext to export them anyway", errPlaintext)
+	}
+
+	p, err := a.newProgress(bulk, "export", 0)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newBulkClient(common, bulk)
+	if err != nil {
+		return err
+	}
+
+	w := a.stdout
+
+	if outPath != "-" {
+		f, createErr := os.Create(outPath)
+		if createErr != nil {
+			return fmt.Errorf("creating %s: %w", outPath, createErr)
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		w = f
+	}
+
+	var enc accountEncoder = &arrayEncoder{w: w}
+	if output == outputNDJSON {
+		enc = &lineEncoder{enc: json.NewEncoder(w)}
+	}
+
+	writeAccount := func(d client.Data) error {
+		// The list endpoint returns the accounts of every organisation, which are counted, but not exported.
+		if d.OrganisationID == c.OrganisationID {
+			encodeErr := enc.encode(d)
+			if encodeErr != nil {
+				return encodeErr
+			}
+		}
+
+		p.record(d.ID, nil)
+
+		return nil
+	}
+
+	writePage := func(mp client.MultiPayload) error {
+		if total, ok := mp.TotalRecords(); ok {
+			p.setTotal(total)
+		}
+
+		for _, d := range mp.Data {
+			if writeErr := writeAccount(d); writeErr != nil {
+				return writeErr
+			}
+		}
+
+		return nil
+	}
+
+	switch {
+	case autoSize:
+		err = c.ListPagesAuto(client.PageSizeTuning{Initial: pageSize}, writePage)
+	case bulk.concurrency > 1:
+		err = c.ListPages(pageSize, writePage)
+	default:
+		// One page at a time, the accounts are written as they are decoded, without holding a page of them.
+		err = c.ListEach(context.Background(), pageSize, writeAccount)
+	}
+
+	p.finish()
+
+	if err != nil {
+		return fmt.Errorf("exporting accounts: %w", err)
+	}
+
+	return enc.close()
+}
+
+// runImport creates every account in a JSON array, either of account attributes or of the data written by export. It
+// carries on past accounts that fail, and reports how many did at the end.
+func runImport(a *app, args []string) error {
+	var (
+		common commonFlags
+		bulk   bulkFlags
+		file   string
+	)
+
+	fs := a.newFlagSet("import", "")
+	common.registerConnection(fs)
+	bulk.register(fs)
+	fs.StringVar(&file, "file", "-", "read the accounts as a JSON array from this file, - for stdin")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	accounts, err := a.readResources(file)
+	if err != nil {
+		return err
+	}
+
+	p, err := a.newProgress(bulk, "import", len(accounts))
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newBulkClient(common, bulk)
+	if err != nil {
+		return err
+	}
+
+	c.CreateBatch(accounts, func(i int, _ client.Payload, createErr error) {
+		p.record(fmt.Sprintf("account %d", i+1), createErr)
+	})
+
+	p.finish()
+
+	if p.failed > 0 {
+		return fmt.Errorf("%w: %d of %d failed to import", errBulkFailed, p.failed, p.total)
+	}
+
+	return nil
+}
+
+// readResources decodes a JSON array of accounts from a file, or from stdin if the name is -. Elements that have an
+// attributes key are taken to be data written by export, every other element to be account attributes.
+func (a *app) readResources(name string) ([]client.Resource, error) {
+	content, err := a.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return decodeResources(name, content)
+}
+
+// decodeResources decodes a JSON array of accounts from the content of the named file.
+func decodeResources(name string, content []byte) ([]client.Resource, error) {
+	var raw []json.RawMessage
+
+	err := json.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w: %s", name, errNotAnArray, err)
+	}
+
+	accounts := make([]client.Resource, 0, len(raw))
+
+	for i, r := range raw {
+		var data struct {
+			Attributes *client.Resource `json:"attributes"`
+		}
+
+		err = json.Unmarshal(r, &data)
+		if err != nil {
+			return nil, fmt.Errorf("decoding account %d of %s: %w", i+1, name, err)
+		}
+
+		if data.Attributes != nil {
+			accounts = append(accounts, *data.Attributes)
+
+			continue
+		}
+
+		var account client.Resource
+
+		err = json.Unmarshal(r, &account)
+		if err != nil {
+			return nil, fmt.Errorf("decoding account %d of %s: %w", i+1, name, err)
+		}
+
+		accounts = append(accounts, account)
+	}
+
+	return accounts, nil
+}
+
+// accountEncoder writes the accounts of an export one by one as they arrive, and finishes the output at the end.
+type accountEncoder interface {
+	encode(v interface{}) error
+	close() error
+}
+
+// lineEncoder writes values one by one as JSON, each on its own line.
+type lineEncoder struct {
+	enc *json.Encoder
+}
+
+// encode writes the next line.
+func (e *lineEncoder) encode(v interface{}) error {
+	err := e.enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("lineEncoder.encode: %w", err)
+	}
+
+	return nil
+}
+
+// close does nothing, as every line is complete on its own.
+func (e *lineEncoder) close() error {
+	return nil
+}
+
+// arrayEncoder writes values one by one as the elements of an indented JSON array, so an export does not have to hold
+// every account in memory.
+type arrayEncoder struct {
+	w     io.Writer
+	count int
+}
+
+// encode writes the next element of the array.
+func (e *arrayEncoder) encode(v interface{}) error {
+	content, err := json.MarshalIndent(v, "  ", "  ")
+	if err != nil {
+		return fmt.Errorf("arrayEncoder.encode: %w", err)
+	}
+
+	separator := ",\n  "
+	if e.count == 0 {
+		separator = "[\n  "
+	}
+
+	e.count++
+
+	_, err = fmt.Fprintf(e.w, "%s%s", separator, content)
+	if err != nil {
+		return fmt.Errorf("arrayEncoder.encode: %w", err)
+	}
+
+	return nil
+}
+
+// close ends the array, writing an empty one if there were no elements.
+func (e *arrayEncoder) close() error {
+	end := "\n]\n"
+	if e.count == 0 {
+		end = "[]\n"
+	}
+
+	_, err := io.WriteString(e.w, end)
+	if err != nil {
+		return fmt.Errorf("arrayEncoder.close: %w", err)
+	}
+
+	return nil
+}
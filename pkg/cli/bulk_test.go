@@ -0,0 +1,288 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+const (
+	importAccount  = `{"country": "GB", "bank_id": "123456", "bank_id_code": "GBDSC", "bic": "BARCGB22XXX"}`
+	importAccounts = `[
+  ` + importAccount + `,
+  {"id": "ignored", "attributes": ` + importAccount + `},
+  {"country": "GB"}
+]`
+)
+
+func TestRun_Bulk(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerFunc http.HandlerFunc
+		args        []string
+		stdin       string
+		wantCode    int
+		wantStdout  []string
+		skipStdout  []string
+		wantStderr  []string
+	}{
+		{
+			name: "export writes every account as a JSON array",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			},
+			args:       []string{"export"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{"[\n  {\n", testAccountID, "ffa7706b-d8fc-40b2-be6b-67d2a628cadf", "\n]\n"},
+			wantStderr: []string{"export: 2 records"},
+		},
+		{
+			name: "export reports progress against the total of the meta section",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				mp := readFile(t, "./testdata/multipayload.json")
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(append(mp[:bytes.LastIndexByte(mp, '}')], []byte(`, "meta": {"total": 2}}`)...))
+			},
+			args:       []string{"export", "--concurrency", "2", "--progress", "plain", "--progress-every", "1"},
+			wantCode:   cli.ExitOK,
+			wantStderr: []string{"export: 1/2 records (50%)", "export: 2/2 records (100%)"},
+		},
+		{
+			name:        "export leaves out the accounts of other organisations",
+			handlerFunc: otherOrganisationHandler(t),
+			args:        []string{"export", "--progress", "none"},
+			wantCode:    cli.ExitOK,
+			wantStdout:  []string{testAccountID},
+			skipStdout:  []string{otherOrganisationID},
+		},
+		{
+			name:        "export leaves them out of whole pages too",
+			handlerFunc: otherOrganisationHandler(t),
+			args:        []string{"export", "-o", "ndjson", "--concurrency", "2", "--progress", "none"},
+			wantCode:    cli.ExitOK,
+			wantStdout:  []string{testAccountID},
+			skipStdout:  []string{otherOrganisationID},
+		},
+		{
+			name: "export writes an empty array when there are no accounts",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(`{"data": [], "links": {}}`))
+			},
+			args:       []string{"export", "--progress", "none"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{"[]\n"},
+		},
+		{
+			name: "export writes one account per line with --output ndjson",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			},
+			args:       []string{"export", "-o", "ndjson", "--progress", "none"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{`{"id":"` + testAccountID + `",`, "}\n{\"id\":\"ffa7706b-d8fc-40b2-be6b-67d2a628cadf\","},
+		},
+		{
+			name: "export tunes the page size with --auto-size",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "25", r.URL.Query().Get("page[size]"))
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			},
+			args:       []string{"export", "--auto-size", "--size", "25", "--progress", "none"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{testAccountID, "ffa7706b-d8fc-40b2-be6b-67d2a628cadf"},
+		},
+		{
+			name:       "export fails on an output format other than json or ndjson",
+			args:       []string{"export", "-o", "text"},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"unknown output format: text, use json or ndjson"},
+		},
+		{
+			name:       "export fails on an unknown progress mode",
+			args:       []string{"export", "--progress", "spinner"},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"unknown progress mode: spinner"},
+		},
+		{
+			name: "import creates every account, carries on past failures, and reports them",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args:     []string{"import", "--progress", "plain", "--progress-every", "2"},
+			stdin:    importAccounts,
+			wantCode: cli.ExitFailure,
+			wantStderr: []string{
+				"import: account 3: client.Create: validation failed",
+				"import: 2/3 records (66%)",
+				"import: 3/3 records (100%)",
+				"1 errors",
+				"1 of 3 failed to import",
+			},
+		},
+		{
+			name: "import creates accounts concurrently at a capped rate",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args: []string{
+				"import", "--progress", "plain", "--concurrency", "3", "--rate", "100", "--backpressure",
+			},
+			stdin:      "[" + strings.Repeat(importAccount+",", 5) + "{}]",
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"import: 6/6 records (100%)", "1 of 6 failed to import"},
+		},
+		{
+			name:       "import fails on anything but an array",
+			args:       []string{"import"},
+			stdin:      `{"country": "GB"}`,
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"expected a JSON array of accounts"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handlerFunc
+			if handler == nil {
+				handler = func(w http.ResponseWriter, r *http.Request) {
+					assert.Fail(t, "unexpected request", "%s %s", r.Method, r.URL)
+				}
+			}
+
+			ts := httptest.NewServer(handler)
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, skip := range tt.skipStdout {
+				assert.NotContains(t, stdout.String(), skip)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
+
+// otherOrganisationID is the ID of an account otherOrganisationHandler lists for another organisation.
+const otherOrganisationID = "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0"
+
+// otherOrganisationHandler lists testAccountID of the organisation of setTestEnv, and otherOrganisationID of another.
+func otherOrganisationHandler(t *testing.T) http.HandlerFunc {
+	t.Helper()
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(client.MultiPayload{Data: []client.Data{
+			{ID: testAccountID, OrganisationID: testOrganisationID, Type: "accounts",
+				Attributes: client.Resource{Country: "GB"}},
+			{ID: otherOrganisationID, OrganisationID: "someone-else", Type: "accounts",
+				Attributes: client.Resource{Country: "GB"}},
+		}})
+	}
+}
+
+func TestRun_ExportToFile(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	out := filepath.Join(t.TempDir(), "accounts.json")
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"export", "--out", out}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.Empty(t, stdout.String())
+
+	content, err := ioutil.ReadFile(out)
+	assert.NoError(t, err)
+
+	var accounts []client.Data
+
+	assert.NoError(t, json.Unmarshal(content, &accounts))
+	assert.Len(t, accounts, 2)
+	assert.Equal(t, testAccountID, accounts[0].ID)
+}
+
+func TestRun_ExportWithBackupKey(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name     string
+		args     []string
+		wantCode int
+		wantOut  bool
+	}{
+		{
+			name:     "refuses to write the accounts unencrypted",
+			args:     []string{"export"},
+			wantCode: cli.ExitFailure,
+		},
+		{
+			name:     "writes them with --plaintext",
+			args:     []string{"export", "--plaintext"},
+			wantCode: cli.ExitOK,
+			wantOut:  true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setTestEnv(t, ts.URL)
+
+			keyFile := writeKeyFile(t, t.TempDir(), base64.StdEncoding.EncodeToString(bytes.Repeat([]byte{7}, 32)))
+			_ = os.Setenv(config.BackupKeyFileKey, keyFile)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			if !tt.wantOut {
+				assert.Empty(t, stdout.String())
+				assert.Contains(t, stderr.String(), "a backup key is configured")
+
+				return
+			}
+
+			assert.Contains(t, stdout.String(), testAccountID)
+		})
+	}
+}
@@ -0,0 +1,353 @@
+// Package cli implements the accountsclient command line interface on top of the client package. The main package only
+// calls Run, so every command can be exercised in tests without building a binary.
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+const (
+	programName         = "accountsclient"
+	defaultTimeout      = 5 * time.Second
+	defaultRetryBackoff = 200 * time.Millisecond
+)
+
+// app carries the streams every command reads from and writes to, and the settings shared by all commands.
+type app struct {
+	stdin   io.Reader
+	stdout  io.Writer
+	stderr  io.Writer
+	noColor bool
+
+	// flags is the flag set of the command that ran last, so Run can print its failure in the picked output format.
+	flags *flag.FlagSet
+
+	// shell is the state kept between the commands of an interactive session. It is nil outside of one.
+	shell *shell
+}
+
+// command is a single subcommand of the CLI.
+type command struct {
+	name    string
+	summary string
+	run     func(a *app, args []string) error
+}
+
+// commonFlags are the flags every command that talks to the API accepts.
+type commonFlags struct {
+	configPath     string
+	profile        string
+	organisationID string
+	output         string
+	quiet          bool
+	verbose        bool
+	veryVerbose    bool
+
+	timeout      time.Duration
+	deadline     time.Duration
+	retries      uint
+	retryBackoff time.Duration
+	auditLog     string
+
+	// backupKeyFile is only registered by the commands that read or write backups.
+	backupKeyFile string
+
+	// idleConns is the number of idle connections the transport keeps alive, set from --concurrency by
+	// newBulkClient. It is part of the connection, so the shell keeps a client for every number it is run with.
+	idleConns uint
+}
+
+// register adds the common flags to a command's flag set.
+func (f *commonFlags) register(fs *flag.FlagSet) {
+	f.registerConnection(fs)
+
+	registerOutputFlag(fs, &f.output)
+
+	quietUsage := "only print account IDs, one per line, overrides --output"
+	fs.BoolVar(&f.quiet, "quiet", false, quietUsage)
+	fs.BoolVar(&f.quiet, "q", false, quietUsage+" (shorthand)")
+}
+
+// registerConnection adds only the common flags that configure the client, for commands that do not print accounts.
+func (f *commonFlags) registerConnection(fs *flag.FlagSet) {
+	registerConfigFlag(fs, &f.configPath)
+	registerProfileFlag(fs, &f.profile)
+	registerOrganisationFlag(fs, &f.organisationID)
+	fs.BoolVar(&f.verbose, "v", false, "print the timing of every request to stderr")
+	fs.BoolVar(&f.veryVerbose, "vv", false, "print the timing and a full dump of every request and response to stderr")
+	fs.DurationVar(&f.timeout, "timeout", 0, "timeout of a single request, overrides the config file, 0 to keep it")
+	fs.DurationVar(&f.deadline, "deadline", 0, "time limit of a single operation, including its retries and the pages "+
+		"of a list, 0 for none")
+	fs.UintVar(&f.retries, "retries", 0, "number of times to retry requests that fail with a connection error, 429, "+
+		"or a temporary 5xx status")
+	fs.DurationVar(&f.retryBackoff, "retry-backoff", defaultRetryBackoff, "wait before the first retry, doubled for "+
+		"every retry after it")
+	fs.StringVar(&f.auditLog, "audit-log", "", "append a JSON line for every create, update, and delete to this file, "+
+		"overrides the audit_log setting of the profile and $"+config.AuditLogKey)
+}
+
+// Run executes the command named by the first element of args with the rest of args as its flags and arguments, and
+// returns the exit code the process should terminate with.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) int {
+	a := &app{
+		stdin:  stdin,
+		stdout: stdout,
+		stderr: stderr,
+	}
+
+	if len(args) == 0 {
+		a.usage()
+
+		return ExitFailure
+	}
+
+	cmd, ok := commandByName(args[0])
+	if !ok {
+		if args[0] == "help" || args[0] == "-h" || args[0] == "--help" {
+			a.usage()
+
+			return ExitOK
+		}
+
+		_, _ = fmt.Fprintf(stderr, "%s: unknown command %q\n\n", programName, args[0])
+		a.usage()
+
+		return ExitFailure
+	}
+
+	return a.execute(cmd, args[1:])
+}
+
+// execute runs a command, prints its failure to stderr if it has one, and returns its exit code.
+func (a *app) execute(cmd command, args []string) int {
+	err := cmd.run(a, args)
+	if errors.Is(err, flag.ErrHelp) {
+		return ExitOK
+	}
+
+	switch {
+	case err == nil:
+	case a.outputFormat() == outputJSON, a.outputFormat() == outputNDJSON:
+		_ = printFailure(a.stderr, err)
+	default:
+		_, _ = fmt.Fprintf(a.stderr, "%s %s: %s\n", programName, cmd.name, a.colors(a.stderr).failure(err.Error()))
+	}
+
+	return exitCode(err)
+}
+
+// commands returns every command the CLI knows about.
+func commands() []command {
+	return []command{
+		{name: "create", summary: "create an account", run: runCreate},
+		{name: "fetch", summary: "fetch an account by its ID", run: runFetch},
+		{name: "get", summary: "fetch the account that matches an IBAN, account number, or bank ID", run: runGet},
+		{name: "list", summary: "list a page of accounts", run: runList},
+		{name: "count", summary: "print the number of accounts", run: runCount},
+		{name: "update", summary: "change attributes of an account by its ID and version", run: runUpdate},
+		{name: "delete", summary: "delete an account by its ID and version", run: runDelete},
+		{name: "config", summary: "create, view, and change the config file", run: runConfig},
+		{name: "doctor", summary: "diagnose the connection to the API", run: runDoctor},
+		{name: "export", summary: "write every account to a JSON file", run: runExport},
+		{name: "import", summary: "create every account in a JSON file", run: runImport},
+		{name: "backup", summary: "stream every account to a resumable newline delimited JSON file", run: runBackup},
+		{name: "restore", summary: "re-create the accounts of a backup", run: runRestore},
+		{name: "purge", summary: "delete every account of the organisation", run: runPurge},
+		{name: "delete-all", summary: "same as purge", run: runPurge},
+		{name: "diff", summary: "compare accounts in a file with the accounts on the server", run: runDiff},
+		{name: "plan", summary: "print the changes apply would make, and make none of them", run: runPlan},
+		{name: "apply", summary: "make the accounts on the server the ones in a file", run: runApply},
+		{name: "validate", summary: "check accounts in a file against the validation rules offline", run: runValidate},
+		{name: "gen-fixture", summary: "generate valid example accounts for a country", run: runGenFixture},
+		{name: "shell", summary: "run commands interactively, reusing the connection", run: runShell},
+		{name: "version", summary: "print the version and build information", run: runVersion},
+	}
+}
+
+// commandByName looks up a command.
+func commandByName(name string) (command, bool) {
+	for _, c := range commands() {
+		if c.name == name {
+			return c, true
+		}
+	}
+
+	return command{}, false
+}
+
+// usage prints the list of available commands to stderr.
+func (a *app) usage() {
+	cmds := commands()
+	sort.Slice(cmds, func(i, j int) bool {
+		return cmds[i].name < cmds[j].name
+	})
+
+	_, _ = fmt.Fprintf(a.stderr, "usage: %s <command> [flags] [arguments]\n\ncommands:\n", programName)
+
+	for _, c := range cmds {
+		_, _ = fmt.Fprintf(a.stderr, "  %-12s %s\n", c.name, c.summary)
+	}
+
+	_, _ = fmt.Fprintf(a.stderr, "\nRun '%s <command> -h' for the flags of a command.\n", programName)
+}
+
+// newFlagSet returns a flag set for a command that reports errors instead of exiting, and prints its usage to stderr.
+// Every command accepts --no-color.
+func (a *app) newFlagSet(name, arguments string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	a.flags = fs
+	fs.SetOutput(a.stderr)
+	fs.BoolVar(&a.noColor, "no-color", false, "disable colored output, same as setting "+NoColorKey)
+	fs.Usage = func() {
+		_, _ = fmt.Fprintf(a.stderr, "usage: %s %s [flags] %s\n\nflags:\n", programName, name, arguments)
+		fs.PrintDefaults()
+	}
+
+	return fs
+}
+
+// parseArgs parses flags and positional arguments in any order, so both `fetch -v <id>` and `fetch <id> -v` work. It
+// returns the positional arguments.
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	positional := make([]string, 0)
+
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, fmt.Errorf("parsing flags: %w", err)
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// isFlagSet reports whether the flag with the given name was passed on the command line, as opposed to having its
+// default value.
+func isFlagSet(fs *flag.FlagSet, name string) bool {
+	set := false
+
+	fs.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+
+	return set
+}
+
+// newClient configures a client.Client from the config file, the environment, and the common flags. Commands can pass
+// extra options on top of those, which must not change the transport, as that is shared with the client the shell
+// keeps. In the shell, clients are reused between commands with the same connection flags.
+func (a *app) newClient(f commonFlags, extra ...client.Option) (client.Client, error) {
+	key := f.connectionKey()
+
+	c, ok := a.shell.client(key)
+	if !ok {
+		var err error
+
+		c, err = a.connect(f)
+		if err != nil {
+			return client.Client{}, err
+		}
+
+		a.shell.keepClient(key, c)
+	}
+
+	for _, opt := range extra {
+		opt(&c)
+	}
+
+	return c, nil
+}
+
+// newBulkClient configures a client for a bulk command, with a connection kept alive for every request in flight, and
+// the pace set with the bulk flags.
+func (a *app) newBulkClient(f commonFlags, bulk bulkFlags) (client.Client, error) {
+	f.idleConns = bulk.concurrency
+
+	return a.newClient(f, bulk.clientOptions()...)
+}
+
+// loadConfig loads the configuration from the config file, the environment, and the common flags.
+func (a *app) loadConfig(f commonFlags) (config.Config, error) {
+	cfg, err := config.LoadProfile(configPath(f.configPath), f.profile, config.Profile{
+		OrganisationID: f.organisationID,
+		AuditLog:       f.auditLog,
+		BackupKeyFile:  f.backupKeyFile,
+	})
+	if err != nil {
+		return config.Config{}, fmt.Errorf("loading configuration: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// connect configures a client.Client from the config file, the environment, and the common flags.
+func (a *app) connect(f commonFlags) (client.Client, error) {
+	cfg, err := a.loadConfig(f)
+	if err != nil {
+		return client.Client{}, err
+	}
+
+	timeout := cfg.Timeout
+	if f.timeout > 0 {
+		timeout = f.timeout
+	}
+
+	if timeout == 0 {
+		timeout = defaultTimeout
+	}
+
+	opts := []client.Option{
+		client.WithRetries(int(f.retries), f.retryBackoff),
+		client.WithAttemptTimeout(timeout),
+		client.WithOperationTimeout(f.deadline),
+	}
+
+	if f.verbose || f.veryVerbose {
+		opts = append(opts, client.WithTrace(a.stderr), client.WithOnRetry(func(attempt int, err error, wait time.Duration) {
+			_, _ = fmt.Fprintf(a.stderr, "retry: attempt %d failed: %s, retrying in %s\n", attempt, err, wait)
+		}))
+	}
+
+	if f.veryVerbose {
+		opts = append(opts, client.WithDebug(a.stderr))
+	}
+
+	if f.idleConns > 0 {
+		opts = append(opts, client.WithMaxIdleConnsPerHost(int(f.idleConns)))
+	}
+
+	if cfg.AuditLog != "" {
+		opts = append(opts, client.WithAuditSink(newAuditLog(cfg.AuditLog, a.stderr)))
+	}
+
+	c, err := client.New(cfg, http.Client{}, opts...)
+	if err != nil {
+		return client.Client{}, fmt.Errorf("configuring client: %w", err)
+	}
+
+	return c, nil
+}
+
+// connectionKey identifies the flags that go into the configuration of a client, leaving out the output flags.
+func (f commonFlags) connectionKey() string {
+	f.output, f.quiet = "", false
+
+	return fmt.Sprintf("%+v", f)
+}
@@ -0,0 +1,428 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+const (
+	testAccountID      = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+	testOrganisationID = "7442ea6b-164a-4818-b470-d98abfbc24ae"
+)
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerFunc http.HandlerFunc
+		args        []string
+		stdin       string
+		wantCode    int
+		wantStdout  []string
+		wantStderr  []string
+	}{
+		{
+			name:       "prints usage and fails without a command",
+			args:       []string{},
+			wantCode:   1,
+			wantStderr: []string{"usage: accountsclient <command>", "create", "fetch", "list", "delete"},
+		},
+		{
+			name:       "prints usage and succeeds when asked for help",
+			args:       []string{"help"},
+			wantCode:   0,
+			wantStderr: []string{"usage: accountsclient <command>"},
+		},
+		{
+			name:       "fails on an unknown command",
+			args:       []string{"frobnicate"},
+			wantCode:   1,
+			wantStderr: []string{`unknown command "frobnicate"`},
+		},
+		{
+			name:       "prints flags of a command and succeeds with -h",
+			args:       []string{"list", "-h"},
+			wantCode:   0,
+			wantStderr: []string{"usage: accountsclient list [flags]", "-size"},
+		},
+		{
+			name: "creates an account from flags",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPost, r.Method)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args: []string{
+				"create", "--country", "GB", "--bank-id", "123456", "--bank-id-code", "GBDSC", "--bic", "BARCGB22XXX",
+			},
+			wantCode:   0,
+			wantStdout: []string{"created account " + testAccountID, "Country:", "GB"},
+		},
+		{
+			name: "creates an account in another organisation with --organisation-id",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				var p struct {
+					Data struct {
+						OrganisationID string `json:"organisation_id"`
+					} `json:"data"`
+				}
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+				assert.Equal(t, "other-org", p.Data.OrganisationID)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args: []string{
+				"create", "--organisation-id", "other-org", "-q",
+				"--country", "GB", "--bank-id", "123456", "--bank-id-code", "GBDSC", "--bic", "BARCGB22XXX",
+			},
+			wantCode:   0,
+			wantStdout: []string{testAccountID},
+		},
+		{
+			name: "creates an account from a file on stdin",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				var p struct {
+					Data struct {
+						Attributes map[string]interface{} `json:"attributes"`
+					} `json:"data"`
+				}
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+				assert.Equal(t, "123456", p.Data.Attributes["bank_id"])
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args:       []string{"create", "--file", "-"},
+			stdin:      string(readFile(t, "./testdata/resource.json")),
+			wantCode:   0,
+			wantStdout: []string{testAccountID},
+		},
+		{
+			name:       "refuses to create an account that fails validation",
+			args:       []string{"create", "--country", "GB"},
+			wantCode:   cli.ExitValidation,
+			wantStderr: []string{"accountsclient create: creating account"},
+		},
+		{
+			name: "fetches an account with flags after the id",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/organisation/accounts/"+testAccountID, r.URL.Path)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args:       []string{"fetch", testAccountID, "-v", "--no-color"},
+			wantCode:   0,
+			wantStdout: []string{testAccountID, "Version:", "line1, line2, line3, line4"},
+			wantStderr: []string{"trace: GET", "200 OK", "ttfb"},
+		},
+		{
+			name: "lists a page of accounts one per line with --output ndjson",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			},
+			args:       []string{"list", "--output", "ndjson"},
+			wantCode:   0,
+			wantStdout: []string{`{"id":"` + testAccountID + `",`, "}\n{\"id\":\"ffa7706b-d8fc-40b2-be6b-67d2a628cadf\","},
+		},
+		{
+			name:       "fails to fetch without an id",
+			args:       []string{"fetch"},
+			wantCode:   1,
+			wantStderr: []string{"wrong number of arguments"},
+		},
+		{
+			name: "lists a page of accounts",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "2", r.URL.Query().Get("page[number]"))
+				assert.Equal(t, "5", r.URL.Query().Get("page[size]"))
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			},
+			args:     []string{"list", "--page", "2", "--size", "5"},
+			wantCode: 0,
+			wantStdout: []string{
+				"ID", "ORGANISATION", "VERSION", testAccountID, "ffa7706b-d8fc-40b2-be6b-67d2a628cadf",
+				"7442ea6b-164a-4818-b470-d98abfbc24ae",
+			},
+		},
+		{
+			name: "deletes an account and dumps the exchange with -vv",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodDelete, r.Method)
+				assert.Equal(t, "4", r.URL.Query().Get("version"))
+				w.WriteHeader(http.StatusNoContent)
+			},
+			args:       []string{"delete", "-vv", "--version", "4", testAccountID},
+			wantCode:   0,
+			wantStdout: []string{"deleted " + testAccountID},
+			wantStderr: []string{"trace: DELETE", "debug: request:", "DELETE /v1/organisation/accounts/", "debug: response:"},
+		},
+		{
+			name: "prints an account as json",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args:       []string{"fetch", "-o", "json", testAccountID},
+			wantCode:   0,
+			wantStdout: []string{`"id": "` + testAccountID + `"`, `"iban": "iban1234"`},
+		},
+		{
+			name: "prints an account with a go template",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args:       []string{"fetch", "--output", "go-template={{.Data.ID}} {{.Data.Attributes.IBAN}}", testAccountID},
+			wantCode:   0,
+			wantStdout: []string{testAccountID + " iban1234"},
+		},
+		{
+			name: "prints a page of accounts with a go template",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			},
+			args:       []string{"list", "-o", `go-template={{range .Data}}{{.ID}},{{.Attributes.BIC}};{{end}}`},
+			wantCode:   0,
+			wantStdout: []string{testAccountID + ",bic1234;ffa7706b-d8fc-40b2-be6b-67d2a628cadf,bic5678;"},
+		},
+		{
+			name: "prints a deleted account as json",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNoContent)
+			},
+			args:       []string{"delete", "-o", "json", "--version", "2", testAccountID},
+			wantCode:   0,
+			wantStdout: []string{`"id": "` + testAccountID + `"`, `"version": 2`},
+		},
+		{
+			name: "counts a missing account as deleted with --ignore-missing",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			args:       []string{"delete", "--ignore-missing", "--version", "2", testAccountID},
+			wantCode:   0,
+			wantStdout: []string{"deleted " + testAccountID},
+		},
+		{
+			name: "counts a missing account as deleted with --ignore-missing and --latest",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				w.WriteHeader(http.StatusNotFound)
+			},
+			args:       []string{"delete", "--ignore-missing", "--latest", testAccountID},
+			wantCode:   0,
+			wantStdout: []string{"deleted " + testAccountID},
+		},
+		{
+			name:       "refuses an unknown output format before calling the API",
+			args:       []string{"fetch", "-o", "yaml", testAccountID},
+			wantCode:   1,
+			wantStderr: []string{"unknown output format: yaml"},
+		},
+		{
+			name:       "refuses a broken output template before calling the API",
+			args:       []string{"fetch", "-o", "go-template={{.Data.ID", testAccountID},
+			wantCode:   1,
+			wantStderr: []string{"parsing output template"},
+		},
+		{
+			name: "fails when the output template references a missing field",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args:       []string{"fetch", "-o", "go-template={{.Data.Nope}}", testAccountID},
+			wantCode:   1,
+			wantStderr: []string{"printing go-template output"},
+		},
+		{
+			name: "reports errors from the service",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+			},
+			args:       []string{"delete", testAccountID},
+			wantCode:   cli.ExitConflict,
+			wantStderr: []string{"accountsclient delete: deleting account"},
+		},
+		{
+			name: "exits with the not found code when the account does not exist",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			args:       []string{"fetch", testAccountID},
+			wantCode:   cli.ExitNotFound,
+			wantStderr: []string{"unexpected response code: 404"},
+		},
+		{
+			name: "exits with the rate limited code when the service throttles",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusTooManyRequests)
+			},
+			args:     []string{"list"},
+			wantCode: cli.ExitRateLimited,
+		},
+		{
+			name: "exits with the generic failure code on other unexpected responses",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusInternalServerError)
+			},
+			args:     []string{"list"},
+			wantCode: cli.ExitFailure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handlerFunc
+			if handler == nil {
+				handler = func(w http.ResponseWriter, r *http.Request) {
+					assert.Fail(t, "no request should have been made")
+				}
+			}
+
+			ts := httptest.NewServer(handler)
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestRun_Resilience(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		failures     int32
+		delay        time.Duration
+		wantCode     int
+		wantAttempts int32
+		wantStderr   string
+	}{
+		{
+			name:         "fails on the first temporary failure by default",
+			args:         []string{"fetch", testAccountID},
+			failures:     1,
+			wantCode:     cli.ExitFailure,
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries temporary failures with --retries",
+			args:         []string{"fetch", "--retries", "2", "--retry-backoff", "1ms", testAccountID},
+			failures:     2,
+			wantCode:     cli.ExitOK,
+			wantAttempts: 3,
+		},
+		{
+			name:         "reports retries with -v",
+			args:         []string{"fetch", "-v", "--retries", "1", "--retry-backoff", "1ms", testAccountID},
+			failures:     1,
+			wantCode:     cli.ExitOK,
+			wantAttempts: 2,
+			wantStderr:   "retry: attempt 1 failed: unexpected response code: 503, retrying in 1ms\n",
+		},
+		{
+			name:         "gives up on slow responses with --timeout",
+			args:         []string{"fetch", "--timeout", "10ms", testAccountID},
+			delay:        200 * time.Millisecond,
+			wantCode:     cli.ExitTransport,
+			wantAttempts: 1,
+		},
+		{
+			name:         "stops retrying at --deadline",
+			args:         []string{"fetch", "--retries", "5", "--retry-backoff", "40ms", "--deadline", "100ms", testAccountID},
+			failures:     6,
+			wantCode:     cli.ExitTransport,
+			wantAttempts: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+
+					return
+				}
+
+				time.Sleep(tt.delay)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+			assert.Equal(t, tt.wantAttempts, atomic.LoadInt32(&attempts))
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+		})
+	}
+}
+
+func TestRun_TransportError(t *testing.T) {
+	ts := httptest.NewServer(http.NotFoundHandler())
+	ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"fetch", testAccountID}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitTransport, code, "stderr: %s", stderr.String())
+	assert.Empty(t, stdout.String())
+}
+
+// setTestEnv clears the environment and points the configuration at the given address.
+func setTestEnv(t *testing.T, address string) {
+	t.Helper()
+
+	os.Clearenv()
+
+	_ = os.Setenv(config.AccountsAPIURLKey, address)
+	_ = os.Setenv(config.OrganisationIDKey, testOrganisationID)
+}
+
+func readFile(t *testing.T, filename string) []byte {
+	t.Helper()
+
+	content, err := ioutil.ReadFile(filename)
+	if err != nil {
+		assert.FailNowf(t, "could not read file", "error: %s", err)
+	}
+
+	return content
+}
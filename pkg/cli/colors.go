@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"io"
+	"os"
+	"strings"
+)
+
+const (
+	// NoColorKey is the environment variable that, when set to anything, disables colored output. See
+	// https://no-color.org.
+	NoColorKey = "NO_COLOR"
+
+	ansiReset  = "\x1b[0m"
+	ansiRed    = "\x1b[31m"
+	ansiGreen  = "\x1b[32m"
+	ansiYellow = "\x1b[33m"
+)
+
+// palette colors text for a terminal. A disabled palette returns all text unchanged, which is what every writer that
+// is not a terminal gets.
+type palette struct {
+	enabled bool
+}
+
+// colors returns the palette to use for w: enabled only if w is a terminal, and neither --no-color nor NO_COLOR is set.
+func (a *app) colors(w io.Writer) palette {
+	_, noColorEnv := os.LookupEnv(NoColorKey)
+
+	return palette{enabled: !a.noColor && !noColorEnv && isTerminal(w)}
+}
+
+// isTerminal reports whether w is a character device, like an interactive terminal, as opposed to a file or a pipe.
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+
+	info, err := f.Stat()
+	if err != nil {
+		return false
+	}
+
+	return info.Mode()&os.ModeCharDevice != 0
+}
+
+// success colors s green.
+func (p palette) success(s string) string {
+	return p.paint(ansiGreen, s)
+}
+
+// warning colors s yellow.
+func (p palette) warning(s string) string {
+	return p.paint(ansiYellow, s)
+}
+
+// failure colors s red.
+func (p palette) failure(s string) string {
+	return p.paint(ansiRed, s)
+}
+
+// status colors the status of an account by how settled it is: confirmed accounts are green, pending ones yellow, and
+// failed or closed ones red.
+func (p palette) status(s string) string {
+	switch strings.ToLower(s) {
+	case "confirmed":
+		return p.success(s)
+	case "pending":
+		return p.warning(s)
+	case "failed", "closed":
+		return p.failure(s)
+	}
+
+	return s
+}
+
+func (p palette) paint(color, s string) string {
+	if !p.enabled || s == "" {
+		return s
+	}
+
+	return color + s + ansiReset
+}
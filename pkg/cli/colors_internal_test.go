@@ -0,0 +1,93 @@
+package cli
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPalette(t *testing.T) {
+	tests := []struct {
+		name    string
+		palette palette
+		paint   func(p palette) string
+		want    string
+	}{
+		{
+			name:    "success is green",
+			palette: palette{enabled: true},
+			paint:   func(p palette) string { return p.success("created") },
+			want:    "\x1b[32mcreated\x1b[0m",
+		},
+		{
+			name:    "warning is yellow",
+			palette: palette{enabled: true},
+			paint:   func(p palette) string { return p.warning("SKIP") },
+			want:    "\x1b[33mSKIP\x1b[0m",
+		},
+		{
+			name:    "failure is red",
+			palette: palette{enabled: true},
+			paint:   func(p palette) string { return p.failure("boom") },
+			want:    "\x1b[31mboom\x1b[0m",
+		},
+		{
+			name:    "pending status is yellow",
+			palette: palette{enabled: true},
+			paint:   func(p palette) string { return p.status("pending") },
+			want:    "\x1b[33mpending\x1b[0m",
+		},
+		{
+			name:    "closed status is red",
+			palette: palette{enabled: true},
+			paint:   func(p palette) string { return p.status("closed") },
+			want:    "\x1b[31mclosed\x1b[0m",
+		},
+		{
+			name:    "unknown status is left alone",
+			palette: palette{enabled: true},
+			paint:   func(p palette) string { return p.status("mystery") },
+			want:    "mystery",
+		},
+		{
+			name:    "empty text is left alone",
+			palette: palette{enabled: true},
+			paint:   func(p palette) string { return p.success("") },
+			want:    "",
+		},
+		{
+			name:    "disabled palette returns text unchanged",
+			palette: palette{enabled: false},
+			paint:   func(p palette) string { return p.status("confirmed") },
+			want:    "confirmed",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.paint(tt.palette))
+		})
+	}
+}
+
+func TestApp_colors(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "output"))
+	if err != nil {
+		assert.FailNowf(t, "could not create file", "error: %s", err)
+	}
+
+	defer func() {
+		_ = f.Close()
+	}()
+
+	os.Clearenv()
+
+	assert.False(t, isTerminal(&bytes.Buffer{}), "buffers are not terminals")
+	assert.False(t, isTerminal(f), "regular files are not terminals")
+	assert.False(t, (&app{}).colors(f).enabled, "no colors when not writing to a terminal")
+
+	_ = os.Setenv(NoColorKey, "")
+	assert.False(t, (&app{}).colors(f).enabled, "no colors when NO_COLOR is set, even if empty")
+}
@@ -0,0 +1,298 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+const defaultPageSize = 100
+
+var (
+	errArguments = errors.New("wrong number of arguments")
+	errNoVersion = errors.New("the account came without a version")
+)
+
+// runCreate creates an account from a JSON file of its attributes, flags, or both. Flags override values in the file.
+func runCreate(a *app, args []string) error {
+	var (
+		common  commonFlags
+		file    string
+		account client.Resource
+		flagged client.Resource
+	)
+
+	fs := a.newFlagSet("create", "")
+	common.register(fs)
+	fs.StringVar(&file, "file", "", "read the account attributes as JSON from this file, - for stdin")
+	fs.StringVar(&flagged.Country, "country", "", "ISO 3166-1 code of the country of the account")
+	fs.StringVar(&flagged.BaseCurrency, "base-currency", "", "ISO 4217 code of the currency of the account")
+	fs.StringVar(&flagged.BankID, "bank-id", "", "local country bank identifier")
+	fs.StringVar(&flagged.BankIDCode, "bank-id-code", "", "identifies the type of bank ID being used")
+	fs.StringVar(&flagged.BIC, "bic", "", "SWIFT BIC in either 8 or 11 character format")
+	fs.StringVar(&flagged.AccountNumber, "account-number", "", "account number, generated if not provided")
+	fs.StringVar(&flagged.IBAN, "iban", "", "IBAN of the account, generated if not provided")
+	fs.StringVar(&flagged.Name[0], "name", "", "name of the account holder")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	if file != "" {
+		account, err = a.readResource(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	account = mergeResource(account, flagged)
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.Create(account)
+	if err != nil {
+		return fmt.Errorf("creating account: %w", err)
+	}
+
+	return out.created(p)
+}
+
+// runFetch prints a single account.
+func runFetch(a *app, args []string) error {
+	var common commonFlags
+
+	fs := a.newFlagSet("fetch", "<account id>")
+	common.register(fs)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	p, err := c.Fetch(positional[0])
+	if err != nil {
+		return fmt.Errorf("fetching account: %w", err)
+	}
+
+	return out.payload(p)
+}
+
+// runList prints one page of accounts.
+func runList(a *app, args []string) error {
+	var (
+		common     commonFlags
+		pageNumber uint
+		pageSize   uint
+	)
+
+	fs := a.newFlagSet("list", "")
+	common.register(fs)
+	fs.UintVar(&pageNumber, "page", 0, "number of the page to list, starting from 0")
+	fs.UintVar(&pageSize, "size", defaultPageSize, "number of accounts on a page")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	mp, err := c.List(pageNumber, pageSize)
+	if err != nil {
+		return fmt.Errorf("listing accounts: %w", err)
+	}
+
+	return out.list(mp)
+}
+
+// runDelete deletes a single account at the given version, or at whatever its current version is with --latest. With
+// --ignore-missing, an account that does not exist counts as deleted.
+func runDelete(a *app, args []string) error {
+	var (
+		common        commonFlags
+		version       uint
+		latest        bool
+		ignoreMissing bool
+	)
+
+	fs := a.newFlagSet("delete", "<account id>")
+	common.register(fs)
+	fs.UintVar(&version, "version", 0, "version of the account to delete")
+	fs.BoolVar(&latest, "latest", false, "fetch the account first and delete its current version")
+	fs.BoolVar(&ignoreMissing, "ignore-missing", false, "succeed if the account does not exist")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	if latest && isFlagSet(fs, "version") {
+		return errors.New("--latest and --version are mutually exclusive")
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	var opts []client.Option
+	if ignoreMissing {
+		opts = append(opts, client.WithIdempotentDelete())
+	}
+
+	c, err := a.newClient(common, opts...)
+	if err != nil {
+		return err
+	}
+
+	if latest {
+		p, fetchErr := c.Fetch(positional[0])
+		if ignoreMissing && errors.Is(fetchErr, client.ErrNotFound) {
+			return out.deleted(positional[0], version)
+		}
+
+		if fetchErr != nil {
+			return fmt.Errorf("fetching current version: %w", fetchErr)
+		}
+
+		current, known := p.Data.CurrentVersion()
+		if !known {
+			return fmt.Errorf("fetching current version: %w", errNoVersion)
+		}
+
+		version = current
+	}
+
+	err = c.Delete(positional[0], version)
+	if err != nil {
+		return fmt.Errorf("deleting account: %w", err)
+	}
+
+	return out.deleted(positional[0], version)
+}
+
+// readResource decodes account attributes from a JSON file, or from stdin if the name is -.
+func (a *app) readResource(name string) (client.Resource, error) {
+	content, err := a.readFile(name)
+	if err != nil {
+		return client.Resource{}, err
+	}
+
+	return decodeResource(name, content)
+}
+
+// decodeResource decodes account attributes from the JSON content of the named file.
+func decodeResource(name string, content []byte) (client.Resource, error) {
+	var r client.Resource
+
+	err := json.Unmarshal(content, &r)
+	if err != nil {
+		return client.Resource{}, fmt.Errorf("decoding %s: %w", name, err)
+	}
+
+	return r, nil
+}
+
+// readFile reads the named file, or stdin if the name is -.
+func (a *app) readFile(name string) ([]byte, error) {
+	var r io.Reader = a.stdin
+
+	if name != "-" {
+		f, err := os.Open(name)
+		if err != nil {
+			return nil, fmt.Errorf("opening %s: %w", name, err)
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		r = f
+	}
+
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	return content, nil
+}
+
+// mergeResource returns base with every non empty field of override copied over it.
+func mergeResource(base, override client.Resource) client.Resource {
+	for _, pair := range []struct {
+		dst *string
+		src string
+	}{
+		{&base.Country, override.Country},
+		{&base.BaseCurrency, override.BaseCurrency},
+		{&base.BankID, override.BankID},
+		{&base.BankIDCode, override.BankIDCode},
+		{&base.BIC, override.BIC},
+		{&base.AccountNumber, override.AccountNumber},
+		{&base.IBAN, override.IBAN},
+		{&base.Name[0], override.Name[0]},
+	} {
+		if pair.src != "" {
+			*pair.dst = pair.src
+		}
+	}
+
+	return base
+}
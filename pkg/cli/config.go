@@ -0,0 +1,288 @@
+package cli
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+const (
+	settingProfile        = "profile"
+	settingAccountsURL    = "accounts_address"
+	settingOrganisationID = "organisation_id"
+	settingTimeout        = "timeout"
+	settingToken          = "token"
+	settingAuditLog       = "audit_log"
+	settingBackupKeyFile  = "backup_key_file"
+
+	redactedToken = "[redacted]"
+)
+
+var errUnknownSetting = errors.New("unknown setting")
+
+// registerConfigFlag adds the --config flag, which picks the config file to use.
+func registerConfigFlag(fs *flag.FlagSet, path *string) {
+	fs.StringVar(path, "config", "", "path of the config file, defaults to $"+config.FileKey+
+		" or accountsclient/config.json in the user config directory")
+}
+
+// registerProfileFlag adds the --profile flag, which picks the profile of the config file to use.
+func registerProfileFlag(fs *flag.FlagSet, profile *string) {
+	fs.StringVar(profile, "profile", "", "name of the profile in the config file to use, defaults to $"+
+		config.ProfileKey+" or the profile selected in the file")
+}
+
+// registerOrganisationFlag adds the --organisation-id flag, which overrides the organisation of the profile.
+func registerOrganisationFlag(fs *flag.FlagSet, organisationID *string) {
+	fs.StringVar(organisationID, "organisation-id", "", "ID of the organisation to work with, overrides the profile "+
+		"and $"+config.OrganisationIDKey)
+}
+
+// configPath returns the config file to use: the flag value if set, otherwise the default location. If the default
+// location can't be determined, for example because there is no home directory, the returned path is empty, meaning
+// only the environment is used.
+func configPath(flagValue string) string {
+	if flagValue != "" {
+		return flagValue
+	}
+
+	path, err := config.DefaultFilePath()
+	if err != nil {
+		return ""
+	}
+
+	return path
+}
+
+// runConfig dispatches the config subcommands.
+func runConfig(a *app, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "init":
+			return runConfigInit(a, args[1:])
+		case "view":
+			return runConfigView(a, args[1:])
+		case "set":
+			return runConfigSet(a, args[1:])
+		}
+	}
+
+	_, _ = fmt.Fprintf(a.stderr, "usage: %s config <init|view|set> [flags] [arguments]\n\n"+
+		"  init  interactively write the settings of a profile to the config file\n"+
+		"  view  print the config file\n"+
+		"  set   change a single setting: %s, %s, %s, %s, %s, %s, or %s\n",
+		programName, settingProfile, settingAccountsURL, settingOrganisationID, settingTimeout, settingToken,
+		settingAuditLog, settingBackupKeyFile)
+
+	return errArguments
+}
+
+// runConfigInit asks for every setting of a profile on stdin, offering the current values as defaults, then writes
+// the profile to the config file and makes it the one in use.
+func runConfigInit(a *app, args []string) error {
+	var path string
+
+	fs := a.newFlagSet("config init", "")
+	registerConfigFlag(fs, &path)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	path = configPath(path)
+	if path == "" {
+		return errors.New("could not determine the location of the config file, use --config")
+	}
+
+	f, err := config.LoadFile(path)
+	if err != nil {
+		return fmt.Errorf("loading config file: %w", err)
+	}
+
+	in := bufio.NewReader(a.stdin)
+
+	name, err := a.prompt(in, "Profile name", f.Profile)
+	if err != nil {
+		return err
+	}
+
+	p := f.Profiles[name]
+
+	for _, setting := range []struct {
+		label string
+		value *string
+		def   string
+	}{
+		{"Accounts API address", &p.AccountsAPIURL, p.AccountsAPIURL},
+		{"Organisation ID", &p.OrganisationID, p.OrganisationID},
+		{"Request timeout", &p.Timeout, defaultString(p.Timeout, defaultTimeout.String())},
+	} {
+		*setting.value, err = a.prompt(in, setting.label, setting.def)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = p.Validate()
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+
+	f.Profile = name
+	f.Profiles[name] = p
+
+	err = f.Save(path)
+	if err != nil {
+		return fmt.Errorf("saving config file: %w", err)
+	}
+
+	_, err = fmt.Fprintf(a.stdout, "wrote profile %s to %s\n", name, path)
+
+	return err
+}
+
+// runConfigView prints the config file, with tokens redacted.
+func runConfigView(a *app, args []string) error {
+	var path string
+
+	fs := a.newFlagSet("config view", "")
+	registerConfigFlag(fs, &path)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	f, err := config.LoadFile(configPath(path))
+	if err != nil {
+		return fmt.Errorf("loading config file: %w", err)
+	}
+
+	for name, p := range f.Profiles {
+		if p.Token != "" {
+			p.Token = redactedToken
+			f.Profiles[name] = p
+		}
+	}
+
+	enc := json.NewEncoder(a.stdout)
+	enc.SetIndent("", "  ")
+
+	err = enc.Encode(f)
+	if err != nil {
+		return fmt.Errorf("printing config file: %w", err)
+	}
+
+	return nil
+}
+
+// runConfigSet changes one setting of the profile in use, or of the one named with --profile, or switches to another
+// profile.
+func runConfigSet(a *app, args []string) error {
+	var path, profile string
+
+	fs := a.newFlagSet("config set", "<setting> <value>")
+	registerConfigFlag(fs, &path)
+	fs.StringVar(&profile, "profile", "", "name of the profile to change, defaults to the one in use")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 2 { //nolint:gomnd
+		fs.Usage()
+
+		return errArguments
+	}
+
+	path = configPath(path)
+	if path == "" {
+		return errors.New("could not determine the location of the config file, use --config")
+	}
+
+	f, err := config.LoadFile(path)
+	if err != nil {
+		return fmt.Errorf("loading config file: %w", err)
+	}
+
+	key, value := positional[0], positional[1]
+	name := defaultString(profile, f.Profile)
+	p := f.Profiles[name]
+
+	switch key {
+	case settingProfile:
+		f.Profile = value
+		name = value
+		p = f.Current()
+	case settingAccountsURL:
+		p.AccountsAPIURL = value
+	case settingOrganisationID:
+		p.OrganisationID = value
+	case settingTimeout:
+		p.Timeout = value
+	case settingToken:
+		p.Token = value
+	case settingAuditLog:
+		p.AuditLog = value
+	case settingBackupKeyFile:
+		p.BackupKeyFile = value
+	default:
+		return fmt.Errorf("%w: %s", errUnknownSetting, key)
+	}
+
+	err = p.Validate()
+	if err != nil {
+		return fmt.Errorf("profile %s: %w", name, err)
+	}
+
+	f.Profiles[name] = p
+
+	err = f.Save(path)
+	if err != nil {
+		return fmt.Errorf("saving config file: %w", err)
+	}
+
+	return nil
+}
+
+// prompt asks for a single value on stdout and reads the answer from in. An empty answer, or no answer at all, keeps
+// the default.
+func (a *app) prompt(in *bufio.Reader, label, def string) (string, error) {
+	_, _ = fmt.Fprintf(a.stdout, "%s [%s]: ", label, def)
+
+	answer, err := in.ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("reading %s: %w", label, err)
+	}
+
+	return defaultString(strings.TrimSpace(answer), def), nil
+}
+
+// defaultString returns s, or def if s is empty.
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+
+	return s
+}
@@ -0,0 +1,127 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+func TestRun_Config(t *testing.T) {
+	var gotAuth string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+	}))
+	defer ts.Close()
+
+	os.Clearenv()
+
+	path := filepath.Join(t.TempDir(), "config.json")
+
+	run := func(stdin string, args ...string) (int, string, string) {
+		var stdout, stderr bytes.Buffer
+
+		code := cli.Run(args, strings.NewReader(stdin), &stdout, &stderr)
+
+		return code, stdout.String(), stderr.String()
+	}
+
+	// Without any configuration commands that talk to the API fail and point at config init.
+	code, _, stderr := run("", "fetch", "--config", path, testAccountID)
+	assert.Equal(t, cli.ExitFailure, code)
+	assert.Contains(t, stderr, "accountsclient config init")
+
+	// Answer every prompt of config init, except the timeout which keeps its default.
+	code, stdout, stderr := run("sandbox\n"+ts.URL+"\norg-id\n\n", "config", "init", "--config", path)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+	assert.Contains(t, stdout, "Profile name [default]:")
+	assert.Contains(t, stdout, "Request timeout [5s]:")
+	assert.Contains(t, stdout, "wrote profile sandbox to "+path)
+
+	f, err := config.LoadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, config.File{
+		Profile: "sandbox",
+		Profiles: map[string]config.Profile{
+			"sandbox": {AccountsAPIURL: ts.URL, OrganisationID: "org-id", Timeout: "5s"},
+		},
+	}, f)
+
+	// The written profile is enough to talk to the API.
+	code, stdout, stderr = run("", "fetch", "--config", path, testAccountID)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+	assert.Contains(t, stdout, testAccountID)
+
+	// Set changes a single setting and rejects invalid values and unknown settings.
+	code, _, stderr = run("", "config", "set", "--config", path, "timeout", "10s")
+	assert.Equal(t, cli.ExitOK, code, stderr)
+
+	code, _, stderr = run("", "config", "set", "--config", path, "timeout", "eventually")
+	assert.Equal(t, cli.ExitFailure, code)
+	assert.Contains(t, stderr, "timeout")
+
+	code, _, stderr = run("", "config", "set", "--config", path, "colour", "blue")
+	assert.Equal(t, cli.ExitFailure, code)
+	assert.Contains(t, stderr, "unknown setting: colour")
+
+	// View prints the file.
+	code, stdout, stderr = run("", "config", "view", "--config", path)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+	assert.Contains(t, stdout, `"profile": "sandbox"`)
+	assert.Contains(t, stdout, `"timeout": "10s"`)
+
+	// Set with --profile adds another profile without switching to it, and tokens stay out of view.
+	code, _, stderr = run("", "config", "set", "--config", path, "--profile", "prod-eu", "accounts_address", ts.URL)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+
+	code, _, stderr = run("", "config", "set", "--config", path, "--profile", "prod-eu", "organisation_id", "eu-org")
+	assert.Equal(t, cli.ExitOK, code, stderr)
+
+	code, _, stderr = run("", "config", "set", "--config", path, "--profile", "prod-eu", "token", "s3cret")
+	assert.Equal(t, cli.ExitOK, code, stderr)
+
+	code, stdout, stderr = run("", "config", "view", "--config", path)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+	assert.Contains(t, stdout, `"profile": "sandbox"`)
+	assert.Contains(t, stdout, `"token": "[redacted]"`)
+	assert.NotContains(t, stdout, "s3cret")
+
+	// The profile is picked with --profile, or with the environment, and carries its own token.
+	code, _, stderr = run("", "fetch", "--config", path, "--profile", "prod-eu", testAccountID)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+	assert.Equal(t, "Bearer s3cret", gotAuth)
+
+	code, _, stderr = run("", "fetch", "--config", path, testAccountID)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+	assert.Equal(t, "", gotAuth)
+
+	_ = os.Setenv(config.ProfileKey, "prod-eu")
+	code, _, stderr = run("", "fetch", "--config", path, testAccountID)
+	assert.Equal(t, cli.ExitOK, code, stderr)
+	assert.Equal(t, "Bearer s3cret", gotAuth)
+	os.Clearenv()
+
+	code, _, stderr = run("", "fetch", "--config", path, "--profile", "prod-us", testAccountID)
+	assert.Equal(t, cli.ExitFailure, code)
+	assert.Contains(t, stderr, "profile prod-us is not in the config file")
+
+	// Init refuses to write an invalid profile.
+	code, _, _ = run("sandbox\nnot a url\n\n\n", "config", "init", "--config", path)
+	assert.Equal(t, cli.ExitFailure, code)
+
+	// Without a subcommand the usage is printed.
+	code, _, stderr = run("", "config")
+	assert.Equal(t, cli.ExitFailure, code)
+	assert.Contains(t, stderr, "config <init|view|set>")
+}
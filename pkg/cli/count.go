@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// errCounted stops paging once the service has sent the total in the meta section of a page.
+var errCounted = errors.New("counted")
+
+// runCount prints the number of accounts, or of the accounts of one country with --country, and nothing else. It uses
+// the total in the meta section of the response when the service sends one, and pages through the accounts otherwise.
+func runCount(a *app, args []string) error {
+	var (
+		common   commonFlags
+		country  string
+		pageSize uint
+	)
+
+	fs := a.newFlagSet("count", "")
+	common.registerConnection(fs)
+	fs.StringVar(&country, "country", "", "only count the accounts of this ISO 3166-1 country code")
+	fs.UintVar(&pageSize, "size", defaultPageSize, "number of accounts to request at once")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	filter := client.Filter{}
+	if country != "" {
+		filter["country"] = country
+	}
+
+	count := 0
+
+	err = c.ListPagesFiltered(filter, pageSize, func(mp client.MultiPayload) error {
+		if mp.Meta != nil {
+			count = mp.Meta.Total
+
+			return errCounted
+		}
+
+		count += len(mp.Data)
+
+		return nil
+	})
+	if err != nil && !errors.Is(err, errCounted) {
+		return fmt.Errorf("counting accounts: %w", err)
+	}
+
+	_, err = fmt.Fprintln(a.stdout, count)
+	if err != nil {
+		return fmt.Errorf("printing count: %w", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,84 @@
+package cli_test
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Count(t *testing.T) {
+	tests := []struct {
+		name         string
+		args         []string
+		meta         bool
+		wantCountry  string
+		wantRequests int32
+		wantStdout   string
+	}{
+		{
+			name:         "pages through every account without a total",
+			args:         []string{"count", "--size", "2"},
+			wantRequests: 3,
+			wantStdout:   "5\n",
+		},
+		{
+			name:         "filters by country",
+			args:         []string{"count", "--size", "2", "--country", "GB"},
+			wantCountry:  "GB",
+			wantRequests: 3,
+			wantStdout:   "5\n",
+		},
+		{
+			name:         "uses the total of the first page when the service sends one",
+			args:         []string{"count", "--size", "2"},
+			meta:         true,
+			wantRequests: 1,
+			wantStdout:   "42\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&requests, 1)
+				assert.Equal(t, tt.wantCountry, r.URL.Query().Get("filter[country]"))
+
+				page := r.URL.Query().Get("page[number]")
+				ids := map[string][]string{"0": {"a", "b"}, "1": {"c", "d"}, "2": {"e"}}[page]
+
+				data := make([]string, 0, len(ids))
+				for _, id := range ids {
+					data = append(data, fmt.Sprintf(`{"id": "%s", "attributes": {"country": "GB"}}`, id))
+				}
+
+				meta := ""
+				if tt.meta {
+					meta = `, "meta": {"total": 42}`
+				}
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = fmt.Fprintf(w, `{"data": [%s], "links": {"next": "/next"}%s}`, strings.Join(data, ","), meta)
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+			assert.Equal(t, tt.wantStdout, stdout.String())
+			assert.Equal(t, tt.wantRequests, atomic.LoadInt32(&requests))
+		})
+	}
+}
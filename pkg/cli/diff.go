@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+var (
+	errDifferences = errors.New("the server does not match the file")
+	errMissingID   = errors.New("has no id")
+)
+
+// accountDiff is what diff prints: accounts in the file the server does not have, accounts on the server the file
+// does not have, and accounts on both whose attributes differ.
+type accountDiff struct {
+	Missing []client.Data  `json:"missing"`
+	Extra   []client.Data  `json:"extra"`
+	Drift   []accountDrift `json:"drift"`
+}
+
+// accountDrift lists the attributes of a single account that differ between the file and the server.
+type accountDrift struct {
+	ID     string           `json:"id"`
+	Fields []attributeDrift `json:"fields"`
+}
+
+// attributeDrift is a single attribute that differs.
+type attributeDrift struct {
+	Field   string      `json:"field"`
+	Desired interface{} `json:"desired"`
+	Actual  interface{} `json:"actual"`
+}
+
+// count returns the number of differences.
+func (d accountDiff) count() int {
+	return len(d.Missing) + len(d.Extra) + len(d.Drift)
+}
+
+// runDiff compares the accounts in a file, in the format export writes, with the accounts on the server.
+func runDiff(a *app, args []string) error {
+	var (
+		common   commonFlags
+		file     string
+		pageSize uint
+	)
+
+	fs := a.newFlagSet("diff", "")
+	common.register(fs)
+	fs.StringVar(&file, "file", "-", "read the desired accounts as a JSON array from this file, - for stdin. Every "+
+		"account needs an id, and the attributes it should have")
+	fs.UintVar(&pageSize, "size", defaultPageSize, "number of accounts to request at once")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	content, err := a.readFile(file)
+	if err != nil {
+		return err
+	}
+
+	desired, err := decodeDesired(file, content)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	// Plan compares the file with the accounts of the configured organisation only, the same way plan and apply do.
+	plan, err := c.Plan(context.Background(), desired, client.ApplyOptions{Prune: true, PageSize: pageSize})
+	if err != nil {
+		return fmt.Errorf("comparing accounts: %w", err)
+	}
+
+	d := planDiff(plan)
+
+	switch out.format {
+	case outputText:
+		printDiff(out, d)
+	case outputQuiet:
+		err = out.ids(append(append(d.Missing, d.Extra...), driftData(d.Drift)...)...)
+	default:
+		err = out.structured(d)
+	}
+
+	if err != nil {
+		return err
+	}
+
+	if d.count() > 0 {
+		return fmt.Errorf("%w: %d differences", errDifferences, d.count())
+	}
+
+	return nil
+}
+
+// decodeDesired decodes a JSON array of accounts with their IDs, and manages only the attributes each one sets, so
+// attributes the server fills in, like the status, do not show up as drift unless the file says what they should be.
+func decodeDesired(name string, content []byte) ([]client.DesiredAccount, error) {
+	var raw []struct {
+		ID         string                     `json:"id"`
+		Attributes map[string]json.RawMessage `json:"attributes"`
+	}
+
+	err := json.Unmarshal(content, &raw)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w: %s", name, errNotAnArray, err)
+	}
+
+	desired := make([]client.DesiredAccount, 0, len(raw))
+
+	for i, r := range raw {
+		if r.ID == "" {
+			return nil, fmt.Errorf("account %d of %s %w", i+1, name, errMissingID)
+		}
+
+		d := client.DesiredAccount{ID: r.ID, Fields: make([]string, 0, len(r.Attributes))}
+
+		for key := range r.Attributes {
+			d.Fields = append(d.Fields, key)
+		}
+
+		sort.Strings(d.Fields)
+
+		attributes, err := json.Marshal(r.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("decoding account %d of %s: %w", i+1, name, err)
+		}
+
+		err = json.Unmarshal(attributes, &d.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("decoding account %d of %s: %w", i+1, name, err)
+		}
+
+		desired = append(desired, d)
+	}
+
+	return desired, nil
+}
+
+// planDiff returns the changes of a plan as the differences diff prints: the accounts it would create are missing,
+// the ones it would delete are extra, and the changes of the ones it would update are drift, with the value on the
+// server as the actual one, and the one of the file as desired.
+func planDiff(plan client.Plan) accountDiff {
+	d := accountDiff{Missing: plan.Create, Extra: plan.Delete, Drift: make([]accountDrift, 0, len(plan.Update))}
+
+	for _, u := range plan.Update {
+		fields := make([]attributeDrift, 0, len(u.Changes))
+
+		for _, c := range u.Changes {
+			fields = append(fields, attributeDrift{Field: c.Path, Desired: c.To, Actual: c.From})
+		}
+
+		d.Drift = append(d.Drift, accountDrift{ID: u.ID, Fields: fields})
+	}
+
+	return d
+}
+
+// driftData returns the IDs of drifted accounts as Data, for the quiet format.
+func driftData(drift []accountDrift) []client.Data {
+	data := make([]client.Data, 0, len(drift))
+
+	for _, d := range drift {
+		data = append(data, client.Data{ID: d.ID})
+	}
+
+	return data
+}
+
+// printDiff writes the differences with a + for missing, - for extra, and ~ for drifted accounts.
+func printDiff(out printer, d accountDiff) {
+	if d.count() == 0 {
+		_, _ = fmt.Fprintln(out.w, out.colors.success("no differences"))
+
+		return
+	}
+
+	for _, m := range d.Missing {
+		_, _ = fmt.Fprintln(out.w, out.colors.success(fmt.Sprintf("+ %s (%s): missing on the server", m.ID,
+			m.Attributes.Country)))
+	}
+
+	for _, e := range d.Extra {
+		_, _ = fmt.Fprintln(out.w, out.colors.failure(fmt.Sprintf("- %s (%s): not in the file", e.ID,
+			e.Attributes.Country)))
+	}
+
+	for _, drift := range d.Drift {
+		_, _ = fmt.Fprintln(out.w, out.colors.warning(fmt.Sprintf("~ %s: attributes differ", drift.ID)))
+
+		for _, f := range drift.Fields {
+			desired, _ := json.Marshal(f.Desired)
+			actual, _ := json.Marshal(f.Actual)
+			_, _ = fmt.Fprintf(out.w, "    %s: server has %s, file wants %s\n", f.Field, actual, desired)
+		}
+	}
+}
@@ -0,0 +1,137 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestRun_Diff(t *testing.T) {
+	const (
+		missingID = "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0"
+		extraID   = "ffa7706b-d8fc-40b2-be6b-67d2a628cadf"
+	)
+
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name: "reports missing, extra, and drifted accounts",
+			args: []string{"diff"},
+			stdin: `[
+				{"id": "` + testAccountID + `", "attributes": {"country": "GB", "bank_id": "123456"}},
+				{"id": "` + missingID + `", "attributes": {"country": "FR"}}
+			]`,
+			wantCode: cli.ExitFailure,
+			wantStdout: []string{
+				"+ " + missingID + " (FR): missing on the server\n",
+				"- " + extraID + " (GB): not in the file\n",
+				"~ " + testAccountID + ": attributes differ\n    bank_id: server has \"89282dd\", file wants \"123456\"\n",
+			},
+			wantStderr: []string{"the server does not match the file: 3 differences"},
+		},
+		{
+			name: "only compares the attributes the file sets",
+			args: []string{"diff", "-o", "json"},
+			stdin: `[
+				{"id": "` + testAccountID + `", "attributes": {"country": "GB", "bank_id": "89282dd",
+					"name": ["line1", "line2", "line3", "line4"]}},
+				{"id": "` + extraID + `", "attributes": {"bank_id_code": "999999"}}
+			]`,
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{`"missing": []`, `"extra": []`, `"drift": []`},
+		},
+		{
+			name: "compares lists line by line",
+			args: []string{"diff"},
+			stdin: `[
+				{"id": "` + testAccountID + `", "attributes": {"name": ["line1", "changed"]}},
+				{"id": "` + extraID + `", "attributes": {}}
+			]`,
+			wantCode: cli.ExitFailure,
+			wantStdout: []string{
+				"~ " + testAccountID + ": attributes differ\n" +
+					"    name[1]: server has \"line2\", file wants \"changed\"\n" +
+					"    name[2]: server has \"line3\", file wants null\n" +
+					"    name[3]: server has \"line4\", file wants null\n",
+			},
+		},
+		{
+			name:       "prints the ids of every difference in quiet mode",
+			args:       []string{"diff", "-q"},
+			stdin:      `[{"id": "` + missingID + `", "attributes": {"country": "FR"}}]`,
+			wantCode:   cli.ExitFailure,
+			wantStdout: []string{missingID + "\n" + testAccountID + "\n" + extraID + "\n"},
+		},
+		{
+			name:       "fails on accounts without an id",
+			args:       []string{"diff"},
+			stdin:      `[{"attributes": {"country": "FR"}}]`,
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"account 1 of - has no id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestRun_DiffOtherOrganisations(t *testing.T) {
+	const otherID = "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(client.MultiPayload{Data: []client.Data{
+			{ID: testAccountID, OrganisationID: testOrganisationID, Type: "accounts",
+				Attributes: client.Resource{Country: "GB", BankID: "123456"}},
+			{ID: otherID, OrganisationID: "someone-else", Type: "accounts", Attributes: client.Resource{Country: "GB"}},
+		}})
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"diff"},
+		strings.NewReader(`[{"id": "`+testAccountID+`", "attributes": {"country": "GB", "bank_id": "123456"}}]`),
+		&stdout, &stderr)
+
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.NotContains(t, stdout.String(), otherID, "the account of another organisation isn't extra")
+}
@@ -0,0 +1,218 @@
+package cli
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"text/tabwriter"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+const (
+	healthEndpoint = "/v1/health"
+	maxClockSkew   = 30 * time.Second
+	httpsPort      = "443"
+)
+
+var (
+	errSkipped      = errors.New("skipped")
+	errChecksFailed = errors.New("some checks failed")
+)
+
+// doctorCheck is a single diagnostic. run returns a short description of what it found, or an error if the check did
+// not pass. Returning errSkipped marks the check as not applicable.
+type doctorCheck struct {
+	name string
+	run  func(ctx context.Context) (string, error)
+}
+
+// doctorState is shared between the checks, so later checks can use what earlier ones found.
+type doctorState struct {
+	profile   string
+	overrides config.Profile
+	cfg       config.Config
+	baseURL   *url.URL
+	date      time.Time
+}
+
+// runDoctor checks every link between the CLI and the API one by one and prints a pass/fail report.
+func runDoctor(a *app, args []string) error {
+	var path, profile, organisationID string
+
+	fs := a.newFlagSet("doctor", "")
+	registerConfigFlag(fs, &path)
+	registerProfileFlag(fs, &profile)
+	registerOrganisationFlag(fs, &organisationID)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	s := &doctorState{profile: profile, overrides: config.Profile{OrganisationID: organisationID}}
+	failed := false
+	colors := a.colors(a.stdout)
+	tw := tabwriter.NewWriter(a.stdout, tabMinWidth, tabWidth, tabPadding, ' ', 0)
+
+	for _, c := range s.checks(configPath(path)) {
+		if failed {
+			_, _ = fmt.Fprintf(tw, "%s\t%s\tan earlier check failed\n", colors.warning("SKIP"), c.name)
+
+			continue
+		}
+
+		status := colors.success("PASS")
+
+		ctx, cancel := context.WithTimeout(context.Background(), defaultTimeout)
+		detail, checkErr := c.run(ctx)
+
+		cancel()
+
+		switch {
+		case errors.Is(checkErr, errSkipped):
+			status = colors.warning("SKIP")
+		case checkErr != nil:
+			status, detail, failed = colors.failure("FAIL"), checkErr.Error(), true
+		}
+
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", status, c.name, detail)
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("printing report: %w", err)
+	}
+
+	if failed {
+		return errChecksFailed
+	}
+
+	return nil
+}
+
+// checks returns the diagnostics in the order they need to run.
+func (s *doctorState) checks(path string) []doctorCheck {
+	return []doctorCheck{
+		{name: "configuration", run: func(context.Context) (string, error) {
+			return s.checkConfig(path)
+		}},
+		{name: "dns", run: s.checkDNS},
+		{name: "tls", run: s.checkTLS},
+		{name: "health", run: s.checkHealth},
+		{name: "clock skew", run: func(context.Context) (string, error) {
+			return s.checkClockSkew()
+		}},
+	}
+}
+
+// checkConfig loads the configuration the same way every other command does.
+func (s *doctorState) checkConfig(path string) (string, error) {
+	cfg, err := config.LoadProfile(path, s.profile, s.overrides)
+	if err != nil {
+		return "", fmt.Errorf("loading: %w", err)
+	}
+
+	u, err := url.Parse(cfg.AccountsAPIURL)
+	if err != nil {
+		return "", fmt.Errorf("accounts address: %w", err)
+	}
+
+	s.cfg, s.baseURL = cfg, u
+
+	return fmt.Sprintf("accounts address %s, organisation %s", cfg.AccountsAPIURL, cfg.OrganisationID), nil
+}
+
+// checkDNS resolves the host of the API, unless it is an IP address.
+func (s *doctorState) checkDNS(ctx context.Context) (string, error) {
+	host := s.baseURL.Hostname()
+	if net.ParseIP(host) != nil {
+		return host + " is an IP address, nothing to resolve", errSkipped
+	}
+
+	addrs, err := net.DefaultResolver.LookupHost(ctx, host)
+	if err != nil {
+		return "", fmt.Errorf("resolving %s: %w", host, err)
+	}
+
+	return fmt.Sprintf("%s resolves to %v", host, addrs), nil
+}
+
+// checkTLS completes a TLS handshake with the API, if it is served over https.
+func (s *doctorState) checkTLS(ctx context.Context) (string, error) {
+	if s.baseURL.Scheme != "https" {
+		return "plain " + s.baseURL.Scheme + ", no TLS to check", errSkipped
+	}
+
+	host := s.baseURL.Host
+	if s.baseURL.Port() == "" {
+		host = net.JoinHostPort(s.baseURL.Hostname(), httpsPort)
+	}
+
+	d := tls.Dialer{Config: &tls.Config{ServerName: s.baseURL.Hostname(), MinVersion: tls.VersionTLS12}}
+
+	conn, err := d.DialContext(ctx, "tcp", host)
+	if err != nil {
+		return "", fmt.Errorf("handshake with %s: %w", host, err)
+	}
+
+	defer func() {
+		_ = conn.Close()
+	}()
+
+	state := conn.(*tls.Conn).ConnectionState()
+
+	return fmt.Sprintf("handshake with %s succeeded, certificate issued to %s", host,
+		state.PeerCertificates[0].Subject.CommonName), nil
+}
+
+// checkHealth calls the health endpoint of the API, and remembers the Date header of the response.
+func (s *doctorState) checkHealth(ctx context.Context) (string, error) {
+	endpoint := s.cfg.AccountsAPIURL + healthEndpoint
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", fmt.Errorf("creating request: %w", err)
+	}
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("calling %s: %w", endpoint, err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s responded with %s", endpoint, resp.Status)
+	}
+
+	s.date, _ = http.ParseTime(resp.Header.Get("Date"))
+
+	return fmt.Sprintf("%s responded with %s", endpoint, resp.Status), nil
+}
+
+// checkClockSkew compares the local clock to the Date header of the health response. The Date header only has second
+// precision, so anything below maxClockSkew passes.
+func (s *doctorState) checkClockSkew() (string, error) {
+	if s.date.IsZero() {
+		return "the API did not send a Date header", errSkipped
+	}
+
+	skew := time.Since(s.date).Round(time.Second)
+	if skew > maxClockSkew || skew < -maxClockSkew {
+		return "", fmt.Errorf("local clock is %s off the API's clock, more than the allowed %s", skew, maxClockSkew)
+	}
+
+	return fmt.Sprintf("local clock is %s off the API's clock", skew), nil
+}
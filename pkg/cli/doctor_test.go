@@ -0,0 +1,101 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Doctor(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerFunc http.HandlerFunc
+		tls         bool
+		noConfig    bool
+		wantCode    int
+		wantStdout  []string
+	}{
+		{
+			name: "passes every check against a healthy API",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/health", r.URL.Path)
+				w.WriteHeader(http.StatusOK)
+			},
+			wantCode: cli.ExitOK,
+			wantStdout: []string{
+				"PASS  configuration",
+				"SKIP  dns            127.0.0.1 is an IP address",
+				"SKIP  tls            plain http",
+				"PASS  health",
+				"PASS  clock skew",
+			},
+		},
+		{
+			name: "fails when the API's clock is too far off",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Date", time.Now().Add(-time.Hour).UTC().Format(http.TimeFormat))
+				w.WriteHeader(http.StatusOK)
+			},
+			wantCode:   cli.ExitFailure,
+			wantStdout: []string{"PASS  health", "FAIL  clock skew     local clock is 1h0m"},
+		},
+		{
+			name: "fails and skips the rest when the health endpoint is unhealthy",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			wantCode:   cli.ExitFailure,
+			wantStdout: []string{"FAIL  health", "503 Service Unavailable", "SKIP  clock skew     an earlier check failed"},
+		},
+		{
+			name: "fails the tls check on an untrusted certificate",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			},
+			tls:        true,
+			wantCode:   cli.ExitFailure,
+			wantStdout: []string{"FAIL  tls", "SKIP  health"},
+		},
+		{
+			name:       "fails on missing configuration",
+			noConfig:   true,
+			wantCode:   cli.ExitFailure,
+			wantStdout: []string{"FAIL  configuration", "SKIP  dns"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var ts *httptest.Server
+			if tt.tls {
+				ts = httptest.NewTLSServer(tt.handlerFunc)
+			} else {
+				ts = httptest.NewServer(tt.handlerFunc)
+			}
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			if tt.noConfig {
+				os.Clearenv()
+			}
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run([]string{"doctor"}, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stdout: %s", stdout.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"net/url"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// Exit codes returned by Run, so shell scripts can branch on the outcome of a command.
+const (
+	// ExitOK means the command succeeded.
+	ExitOK = 0
+
+	// ExitFailure means the command failed for a reason not covered by a more specific exit code, like a
+	// configuration problem, wrong arguments, or an unexpected response from the service.
+	ExitFailure = 1
+
+	// ExitValidation means the account failed client side validation and was not sent to the service.
+	ExitValidation = 2
+
+	// ExitNotFound means the service responded with 404 Not Found.
+	ExitNotFound = 3
+
+	// ExitConflict means the service responded with 409 Conflict, for example because of a stale version.
+	ExitConflict = 4
+
+	// ExitRateLimited means the service responded with 429 Too Many Requests.
+	ExitRateLimited = 5
+
+	// ExitTransport means the request never got a response: the connection failed, or it, or the whole operation, timed
+	// out.
+	ExitTransport = 10
+)
+
+// exitCode maps an error returned by a command to the exit code of the process.
+func exitCode(err error) int {
+	var urlErr *url.Error
+
+	switch {
+	case err == nil:
+		return ExitOK
+	case errors.Is(err, client.ErrValidation):
+		return ExitValidation
+	case errors.Is(err, client.ErrNotFound):
+		return ExitNotFound
+	case errors.Is(err, client.ErrConflict):
+		return ExitConflict
+	case errors.Is(err, client.ErrRateLimited):
+		return ExitRateLimited
+	case errors.As(err, &urlErr), errors.Is(err, context.DeadlineExceeded):
+		return ExitTransport
+	}
+
+	return ExitFailure
+}
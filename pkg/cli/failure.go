@@ -0,0 +1,79 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// Error codes of the machine readable failures, one for every exit code.
+const (
+	errorCodeFailure     = "failure"
+	errorCodeValidation  = "validation_failed"
+	errorCodeNotFound    = "not_found"
+	errorCodeConflict    = "conflict"
+	errorCodeRateLimited = "rate_limited"
+	errorCodeTransport   = "transport"
+)
+
+// failure is what a failing command prints to stderr with --output json, wrapped in an error key.
+type failure struct {
+	Code    string              `json:"code"`
+	Status  int                 `json:"status,omitempty"`
+	Message string              `json:"message"`
+	Fields  []client.FieldError `json:"fields,omitempty"`
+}
+
+// newFailure describes err for automation: a stable code that matches the exit code, the status code of the response
+// if the service sent one, the message, and the findings of a failed validation.
+func newFailure(err error) failure {
+	f := failure{
+		Code:    errorCodeFailure,
+		Message: err.Error(),
+	}
+
+	switch exitCode(err) {
+	case ExitValidation:
+		f.Code = errorCodeValidation
+	case ExitNotFound:
+		f.Code = errorCodeNotFound
+	case ExitConflict:
+		f.Code = errorCodeConflict
+	case ExitRateLimited:
+		f.Code = errorCodeRateLimited
+	case ExitTransport:
+		f.Code = errorCodeTransport
+	}
+
+	var apiErr *client.APIError
+	if errors.As(err, &apiErr) {
+		f.Status = apiErr.StatusCode
+	}
+
+	var validationErr *client.ValidationError
+	if errors.As(err, &validationErr) {
+		f.Fields = validationErr.Fields
+	}
+
+	return f
+}
+
+// printFailure writes err to w as a single line of JSON.
+func printFailure(w io.Writer, err error) error {
+	content, marshalErr := json.Marshal(struct {
+		Error failure `json:"error"`
+	}{newFailure(err)})
+	if marshalErr != nil {
+		return fmt.Errorf("printFailure: %w", marshalErr)
+	}
+
+	_, writeErr := fmt.Fprintf(w, "%s\n", content)
+	if writeErr != nil {
+		return fmt.Errorf("printFailure: %w", writeErr)
+	}
+
+	return nil
+}
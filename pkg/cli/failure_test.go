@@ -0,0 +1,70 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_FailureOutput(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		status     int
+		wantCode   int
+		wantStderr string
+	}{
+		{
+			name:     "prints failures of the service as JSON with --output json",
+			args:     []string{"fetch", "--output", "json", testAccountID},
+			status:   http.StatusConflict,
+			wantCode: cli.ExitConflict,
+			wantStderr: `{"error": {"code": "conflict", "status": 409,
+				"message": "fetching account: client.Fetch: unexpected response code: 409"}}`,
+		},
+		{
+			name:     "prints validation findings as JSON with -o json",
+			args:     []string{"create", "-o", "json", "--country", "GB"},
+			wantCode: cli.ExitValidation,
+			wantStderr: `{"error": {"code": "validation_failed",
+				"message": "creating account: client.Create: validation failed: BIC is required, was empty; GB bank id ` +
+				`is not in correct format. ''; bank ID Code is not 'GBDSC', got ",
+				"fields": [
+					{"field": "bic", "message": "BIC is required, was empty"},
+					{"field": "bank_id", "message": "GB bank id is not in correct format. ''"},
+					{"field": "bank_id_code", "message": "bank ID Code is not 'GBDSC', got "}
+				]}}`,
+		},
+		{
+			name:       "prints usage failures as JSON too",
+			args:       []string{"fetch", "-o", "json"},
+			wantCode:   cli.ExitFailure,
+			wantStderr: `{"error": {"code": "failure", "message": "wrong number of arguments"}}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+			assert.JSONEq(t, tt.wantStderr, lines[len(lines)-1])
+		})
+	}
+}
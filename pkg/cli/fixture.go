@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+var errCountry = errors.New("unsupported country")
+
+// runGenFixture writes valid example accounts for a country as a JSON array, in the format import reads, to seed test
+// environments and demos.
+func runGenFixture(a *app, args []string) error {
+	var (
+		country string
+		count   uint
+		seed    int64
+		outPath string
+	)
+
+	fs := a.newFlagSet("gen-fixture", "")
+	fs.StringVar(&country, "country", "", "ISO 3166-1 code of the country of the accounts, one of "+
+		strings.Join(client.SupportedCountries(), ", "))
+	fs.UintVar(&count, "count", 1, "number of accounts to generate")
+	fs.Int64Var(&seed, "seed", 0, "seed of the random generator, to get the same accounts every time. Random if 0")
+	fs.StringVar(&outPath, "out", "-", "write the accounts to this file, - for stdout")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	country = strings.ToUpper(country)
+	supported := client.SupportedCountries()
+
+	if !containsString(supported, country) {
+		return fmt.Errorf("%w: %q, use one of %s", errCountry, country, strings.Join(supported, ", "))
+	}
+
+	if seed == 0 {
+		seed = time.Now().UnixNano()
+	}
+
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec // fixtures do not need a secure generator
+
+	w := a.stdout
+
+	if outPath != "-" {
+		f, createErr := os.Create(outPath)
+		if createErr != nil {
+			return fmt.Errorf("creating %s: %w", outPath, createErr)
+		}
+
+		defer func() {
+			_ = f.Close()
+		}()
+
+		w = f
+	}
+
+	enc := &arrayEncoder{w: w}
+
+	for i := 1; i <= int(count); i++ {
+		r, genErr := client.GenerateResource(country, rnd)
+		if genErr != nil {
+			return fmt.Errorf("generating account %d: %w", i, genErr)
+		}
+
+		r.Name[0] = fmt.Sprintf("Fixture account %d", i)
+
+		err = enc.encode(r)
+		if err != nil {
+			return err
+		}
+	}
+
+	return enc.close()
+}
+
+// containsString reports whether s is one of the values.
+func containsString(values []string, s string) bool {
+	for _, v := range values {
+		if v == s {
+			return true
+		}
+	}
+
+	return false
+}
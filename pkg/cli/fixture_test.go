@@ -0,0 +1,57 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestRun_GenFixture(t *testing.T) {
+	os.Clearenv()
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"gen-fixture", "--country", "fr", "--count", "10", "--seed", "7"},
+		strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+
+	var accounts []client.Resource
+
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &accounts))
+	assert.Len(t, accounts, 10)
+
+	for _, a := range accounts {
+		assert.Equal(t, "FR", a.Country)
+		assert.NoError(t, client.ValidateResource(a))
+	}
+
+	assert.Equal(t, "Fixture account 10", accounts[9].Name[0])
+
+	// The same seed gives the same accounts, and validate accepts all of them.
+	var again bytes.Buffer
+
+	code = cli.Run([]string{"gen-fixture", "--country", "FR", "--count", "10", "--seed", "7"},
+		strings.NewReader(""), &again, &stderr)
+	assert.Equal(t, cli.ExitOK, code)
+	assert.Equal(t, stdout.String(), again.String())
+
+	code = cli.Run([]string{"validate"}, &again, &bytes.Buffer{}, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+}
+
+func TestRun_GenFixtureUnsupportedCountry(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"gen-fixture", "--country", "XX"}, strings.NewReader(""), &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitFailure, code)
+	assert.Contains(t, stderr.String(), `unsupported country: "XX", use one of AU, BE`)
+	assert.Empty(t, stdout.String())
+}
@@ -0,0 +1,104 @@
+package cli
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// getMatchLimit is how many matching accounts get asks for, which is also how many IDs an ambiguous lookup lists.
+const getMatchLimit = 10
+
+var (
+	errNoFilter  = errors.New("nothing to look up by, use --iban, --account-number, or --bank-id")
+	errAmbiguous = errors.New("more than one account matches")
+)
+
+// runGet prints the single account that matches every given attribute, for when the ID of the account is not known.
+// It fails if no account or more than one account matches.
+func runGet(a *app, args []string) error {
+	var (
+		common        commonFlags
+		iban          string
+		accountNumber string
+		bankID        string
+	)
+
+	fs := a.newFlagSet("get", "")
+	common.register(fs)
+	fs.StringVar(&iban, "iban", "", "IBAN of the account")
+	fs.StringVar(&accountNumber, "account-number", "", "account number of the account")
+	fs.StringVar(&bankID, "bank-id", "", "local country bank identifier of the account")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	filter := client.Filter{}
+
+	for key, value := range map[string]string{
+		"iban":           iban,
+		"account_number": accountNumber,
+		"bank_id":        bankID,
+	} {
+		if value != "" {
+			filter[key] = value
+		}
+	}
+
+	if len(filter) == 0 {
+		return errNoFilter
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	mp, err := c.ListFiltered(filter, 0, getMatchLimit)
+	if err != nil {
+		return fmt.Errorf("looking up account: %w", err)
+	}
+
+	switch {
+	case len(mp.Data) == 0:
+		return fmt.Errorf("looking up account: no account matches %s: %w", describeFilter(filter), client.ErrNotFound)
+	case len(mp.Data) > 1:
+		count := fmt.Sprintf("%d", len(mp.Data))
+		if len(mp.Data) == getMatchLimit {
+			count = "at least " + count
+		}
+
+		return fmt.Errorf("looking up account: %w %s, %s do: %s",
+			errAmbiguous, describeFilter(filter), count, strings.Join(mp.IDs(), ", "))
+	}
+
+	return out.payload(client.Payload{Data: mp.Data[0]})
+}
+
+// describeFilter returns the pairs of the filter as key=value, in the order the service receives them.
+func describeFilter(filter client.Filter) string {
+	pairs := make([]string, 0, len(filter))
+
+	for _, key := range []string{"account_number", "bank_id", "iban"} {
+		if value, ok := filter[key]; ok {
+			pairs = append(pairs, key+"="+value)
+		}
+	}
+
+	return strings.Join(pairs, " ")
+}
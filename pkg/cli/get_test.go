@@ -0,0 +1,97 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Get(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		body       string
+		multiple   bool
+		wantFilter map[string]string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name: "prints the only account that matches",
+			args: []string{"get", "--iban", "iban1234", "--bank-id", "89282dd", "-q"},
+			body: `{"data": [{"id": "` + testAccountID + `", "type": "accounts", "version": 0,
+				"attributes": {"country": "GB", "iban": "iban1234", "bank_id": "89282dd"}}], "links": {}}`,
+			wantFilter: map[string]string{"filter[iban]": "iban1234", "filter[bank_id]": "89282dd"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{testAccountID + "\n"},
+		},
+		{
+			name:       "fails with the not found exit code when nothing matches",
+			args:       []string{"get", "--account-number", "12345678"},
+			wantFilter: map[string]string{"filter[account_number]": "12345678"},
+			wantCode:   cli.ExitNotFound,
+			wantStderr: []string{"no account matches account_number=12345678"},
+		},
+		{
+			name:       "lists the IDs of every account that matches when there are several",
+			args:       []string{"get", "--bank-id", "89282dd"},
+			multiple:   true,
+			wantFilter: map[string]string{"filter[bank_id]": "89282dd"},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{
+				"more than one account matches bank_id=89282dd, 2 do: " + testAccountID +
+					", ffa7706b-d8fc-40b2-be6b-67d2a628cadf",
+			},
+		},
+		{
+			name:       "needs something to look up by",
+			args:       []string{"get"},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"nothing to look up by"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				for key, want := range tt.wantFilter {
+					assert.Equal(t, want, r.URL.Query().Get(key))
+				}
+
+				w.WriteHeader(http.StatusOK)
+
+				switch {
+				case tt.multiple:
+					_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+				case tt.body != "":
+					_, _ = w.Write([]byte(tt.body))
+				default:
+					_, _ = w.Write([]byte(`{"data": [], "links": {}}`))
+				}
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
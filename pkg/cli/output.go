@@ -0,0 +1,304 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+const (
+	tabMinWidth = 0
+	tabWidth    = 4
+	tabPadding  = 2
+
+	outputText       = "text"
+	outputJSON       = "json"
+	outputNDJSON     = "ndjson"
+	outputGoTemplate = "go-template"
+	outputQuiet      = "quiet"
+)
+
+var errOutputFormat = errors.New("unknown output format")
+
+// printer renders the results of commands in the format picked with --output: a human readable text by default, the
+// JSON the API sent, one JSON object per account and line, or a Go template executed against it. With --quiet it
+// prints account IDs only.
+type printer struct {
+	w      io.Writer
+	colors palette
+	format string
+	tmpl   *template.Template
+
+	// remember, if set, is told about every account that is printed.
+	remember func(...client.Data)
+}
+
+// deletion is what delete prints in the json and go-template formats.
+type deletion struct {
+	ID      string `json:"id"`
+	Version uint   `json:"version"`
+}
+
+// registerOutputFlag adds --output and its -o shorthand to a command's flag set.
+func registerOutputFlag(fs *flag.FlagSet, output *string) {
+	outputUsage := "output format: text, json, ndjson, or go-template=<template>"
+	fs.StringVar(output, "output", outputText, outputUsage)
+	fs.StringVar(output, "o", outputText, outputUsage+" (shorthand)")
+}
+
+// outputFormat returns the value of the --output flag of the command that ran last, or text if it has none.
+func (a *app) outputFormat() string {
+	if a.flags == nil {
+		return outputText
+	}
+
+	f := a.flags.Lookup("output")
+	if f == nil {
+		return outputText
+	}
+
+	return f.Value.String()
+}
+
+// newPrinter returns a printer that writes to stdout in the format picked with the --output and --quiet flags.
+func (a *app) newPrinter(f commonFlags) (printer, error) {
+	if f.quiet {
+		return printer{w: a.stdout, format: outputQuiet, remember: a.shell.remember}, nil
+	}
+
+	p, err := newPrinter(a.stdout, f.output, a.colors(a.stdout))
+	p.remember = a.shell.remember
+
+	return p, err
+}
+
+// newPrinter parses the value of the --output flag. Templates are passed kubectl style, as go-template=<template>.
+// Colors are only ever used in the text format.
+func newPrinter(w io.Writer, output string, colors palette) (printer, error) {
+	format, text := output, ""
+	if i := strings.Index(output, "="); i >= 0 {
+		format, text = output[:i], output[i+1:]
+	}
+
+	switch format {
+	case "", outputText, outputJSON, outputNDJSON:
+		return printer{w: w, colors: colors, format: defaultString(format, outputText)}, nil
+	case outputGoTemplate:
+		tmpl, err := template.New("output").Option("missingkey=error").Parse(text)
+		if err != nil {
+			return printer{}, fmt.Errorf("parsing output template: %w", err)
+		}
+
+		return printer{w: w, format: format, tmpl: tmpl}, nil
+	}
+
+	return printer{}, fmt.Errorf("%w: %s, use one of %s, %s, %s, or %s=<template>",
+		errOutputFormat, output, outputText, outputJSON, outputNDJSON, outputGoTemplate)
+}
+
+// payload prints a single account.
+func (p printer) payload(pl client.Payload) error {
+	p.seen(pl.Data)
+
+	switch p.format {
+	case outputText:
+		return printPayload(p.w, pl, p.colors)
+	case outputQuiet:
+		return p.ids(pl.Data)
+	case outputNDJSON:
+		return p.lines(pl.Data)
+	}
+
+	return p.structured(pl)
+}
+
+// created prints an account that has just been created.
+func (p printer) created(pl client.Payload) error {
+	if p.format == outputText {
+		_, _ = fmt.Fprintln(p.w, p.colors.success("created account "+pl.ID()))
+	}
+
+	return p.payload(pl)
+}
+
+// updated prints an account that has just been updated.
+func (p printer) updated(pl client.Payload) error {
+	if p.format == outputText {
+		_, _ = fmt.Fprintln(p.w, p.colors.success("updated account "+pl.ID()))
+	}
+
+	return p.payload(pl)
+}
+
+// list prints a page of accounts. Templates are executed once, against the whole page.
+func (p printer) list(mp client.MultiPayload) error {
+	p.seen(mp.Data...)
+
+	switch p.format {
+	case outputText:
+		return printList(p.w, mp)
+	case outputQuiet:
+		return p.ids(mp.Data...)
+	case outputNDJSON:
+		return p.lines(mp.Data...)
+	}
+
+	return p.structured(mp)
+}
+
+// deleted prints the account that has just been deleted. In quiet mode it prints nothing.
+func (p printer) deleted(id string, version uint) error {
+	if p.format == outputQuiet {
+		return nil
+	}
+
+	if p.format == outputText {
+		_, err := fmt.Fprintln(p.w, p.colors.success("deleted "+id))
+		if err != nil {
+			return fmt.Errorf("printer.deleted: %w", err)
+		}
+
+		return nil
+	}
+
+	return p.structured(deletion{ID: id, Version: version})
+}
+
+// seen passes the accounts on to remember, if set.
+func (p printer) seen(data ...client.Data) {
+	if p.remember != nil {
+		p.remember(data...)
+	}
+}
+
+// ids prints the ID of every account on its own line.
+func (p printer) ids(data ...client.Data) error {
+	for _, d := range data {
+		_, err := fmt.Fprintln(p.w, d.ID)
+		if err != nil {
+			return fmt.Errorf("printer.ids: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// lines prints every account as JSON on its own line.
+func (p printer) lines(data ...client.Data) error {
+	enc := json.NewEncoder(p.w)
+
+	for _, d := range data {
+		err := enc.Encode(d)
+		if err != nil {
+			return fmt.Errorf("printer.lines: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// structured prints v as indented JSON, as JSON on a single line in the ndjson format, or executes the template
+// against it.
+func (p printer) structured(v interface{}) error {
+	var err error
+
+	switch {
+	case p.tmpl != nil:
+		err = p.tmpl.Execute(p.w, v)
+	case p.format == outputNDJSON:
+		err = json.NewEncoder(p.w).Encode(v)
+	default:
+		enc := json.NewEncoder(p.w)
+		enc.SetIndent("", "  ")
+		err = enc.Encode(v)
+	}
+
+	if err != nil {
+		return fmt.Errorf("printing %s output: %w", p.format, err)
+	}
+
+	return nil
+}
+
+// printPayload writes a human readable description of a single account.
+func printPayload(w io.Writer, p client.Payload, colors palette) error {
+	tw := tabwriter.NewWriter(w, tabMinWidth, tabWidth, tabPadding, ' ', 0)
+	d := p.Data
+	a := d.Attributes
+
+	for _, line := range [][2]string{
+		{"ID", d.ID},
+		{"Organisation ID", d.OrganisationID},
+		{"Version", fmt.Sprint(d.Version)},
+		{"Created on", formatTime(d.CreatedOn.Time)},
+		{"Modified on", formatTime(d.ModifiedOn.Time)},
+		{"Country", a.Country},
+		{"Base currency", a.BaseCurrency},
+		{"Bank ID", a.BankID},
+		{"Bank ID code", a.BankIDCode},
+		{"BIC", a.BIC},
+		{"Account number", a.AccountNumber},
+		{"IBAN", a.IBAN},
+		{"Name", joinNonEmpty(a.Name[:])},
+		{"Status", colors.status(a.Status)},
+	} {
+		_, _ = fmt.Fprintf(tw, "%s:\t%s\n", line[0], line[1])
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return fmt.Errorf("printPayload: %w", err)
+	}
+
+	return nil
+}
+
+// printList writes a table with one account per row.
+func printList(w io.Writer, mp client.MultiPayload) error {
+	tw := tabwriter.NewWriter(w, tabMinWidth, tabWidth, tabPadding, ' ', 0)
+
+	_, _ = fmt.Fprintln(tw, "ID\tORGANISATION\tVERSION\tCOUNTRY\tBANK ID\tBIC\tACCOUNT NUMBER\tIBAN")
+
+	for _, d := range mp.Data {
+		a := d.Attributes
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%d\t%s\t%s\t%s\t%s\t%s\n",
+			d.ID, d.OrganisationID, d.Version, a.Country, a.BankID, a.BIC, a.AccountNumber, a.IBAN)
+	}
+
+	err := tw.Flush()
+	if err != nil {
+		return fmt.Errorf("printList: %w", err)
+	}
+
+	return nil
+}
+
+// formatTime renders a timestamp, leaving it empty if the server did not send one.
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
+
+// joinNonEmpty joins the non empty lines of a name with a comma.
+func joinNonEmpty(lines []string) string {
+	parts := make([]string, 0, len(lines))
+
+	for _, l := range lines {
+		if l != "" {
+			parts = append(parts, l)
+		}
+	}
+
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,212 @@
+package cli
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+const (
+	progressAuto  = "auto"
+	progressBar   = "bar"
+	progressPlain = "plain"
+	progressNone  = "none"
+
+	defaultProgressEvery = 100
+	progressRedraw       = 100 * time.Millisecond
+	progressBarWidth     = 30
+	percent              = 100
+
+	ansiClearLine = "\r\x1b[K"
+)
+
+var errProgressMode = errors.New("unknown progress mode")
+
+// bulkFlags are the flags of commands that work on many accounts at once.
+type bulkFlags struct {
+	progress     string
+	every        uint
+	concurrency  uint
+	rate         float64
+	backpressure bool
+}
+
+// register adds the bulk flags to a command's flag set.
+func (f *bulkFlags) register(fs *flag.FlagSet) {
+	fs.StringVar(&f.progress, "progress", progressAuto, "how to report progress on stderr: bar, plain (a line every "+
+		"--progress-every records, for CI logs), none, or auto, which is bar on a terminal and plain otherwise")
+	fs.UintVar(&f.every, "progress-every", defaultProgressEvery, "records between two lines in plain progress mode")
+	fs.UintVar(&f.concurrency, "concurrency", 1, "number of requests to have in flight at once")
+	fs.Float64Var(&f.rate, "rate", 0, "maximum number of requests to start per second, 0 for no limit")
+	fs.BoolVar(&f.backpressure, "backpressure", false, "have fewer requests in flight, and start them slower, while "+
+		"the API responds with 429 or 503")
+}
+
+// clientOptions returns the options that make the client work through a batch at the pace set with the flags. The
+// connection for every request in flight is kept alive by newBulkClient, through the connection flags.
+func (f bulkFlags) clientOptions() []client.Option {
+	opts := []client.Option{
+		client.WithConcurrency(int(f.concurrency)),
+		client.WithRateLimit(f.rate),
+	}
+
+	if f.backpressure {
+		opts = append(opts, client.WithBackpressure(0))
+	}
+
+	return opts
+}
+
+// progress reports how far a bulk operation got to stderr: records done out of the total, rate, estimated time
+// remaining, and the number of errors.
+type progress struct {
+	w      io.Writer
+	colors palette
+	label  string
+	mode   string
+	every  int
+	total  int
+	done   int
+	failed int
+	start  time.Time
+	drawn  time.Time
+	now    func() time.Time
+}
+
+// newProgress returns a progress reporter for the operation named label. A total of 0 means it is not known yet.
+func (a *app) newProgress(f bulkFlags, label string, total int) (*progress, error) {
+	mode := f.progress
+
+	switch mode {
+	case progressAuto:
+		mode = progressPlain
+		if isTerminal(a.stderr) {
+			mode = progressBar
+		}
+	case progressBar, progressPlain, progressNone:
+	default:
+		return nil, fmt.Errorf("%w: %s, use one of %s, %s, %s, or %s",
+			errProgressMode, f.progress, progressAuto, progressBar, progressPlain, progressNone)
+	}
+
+	every := int(f.every)
+	if every == 0 {
+		every = defaultProgressEvery
+	}
+
+	return &progress{
+		w:      a.stderr,
+		colors: a.colors(a.stderr),
+		label:  label,
+		mode:   mode,
+		every:  every,
+		total:  total,
+		start:  time.Now(),
+		now:    time.Now,
+	}, nil
+}
+
+// setTotal sets the number of records once it becomes known.
+func (p *progress) setTotal(total int) {
+	p.total = total
+}
+
+// record counts a finished record, and prints its error if it failed.
+func (p *progress) record(id string, err error) {
+	p.done++
+
+	if err != nil {
+		p.failed++
+
+		if p.mode != progressNone {
+			prefix := ""
+			if p.mode == progressBar {
+				prefix = ansiClearLine
+			}
+
+			_, _ = fmt.Fprintf(p.w, "%s%s: %s: %s\n", prefix, p.label, id, p.colors.failure(err.Error()))
+		}
+	}
+
+	switch p.mode {
+	case progressBar:
+		if p.now().Sub(p.drawn) >= progressRedraw || p.done == p.total {
+			p.drawBar()
+		}
+	case progressPlain:
+		if p.done%p.every == 0 {
+			p.drawLine()
+		}
+	}
+}
+
+// finish prints the final state of the operation.
+func (p *progress) finish() {
+	switch p.mode {
+	case progressBar:
+		p.drawBar()
+		_, _ = fmt.Fprintln(p.w)
+	case progressPlain:
+		if p.done%p.every != 0 || p.done == 0 {
+			p.drawLine()
+		}
+	}
+}
+
+// drawBar redraws the progress bar in place.
+func (p *progress) drawBar() {
+	p.drawn = p.now()
+
+	bar := strings.Repeat(" ", progressBarWidth)
+
+	if p.total > 0 {
+		filled := p.done * progressBarWidth / p.total
+		bar = strings.Repeat("=", filled) + strings.Repeat(" ", progressBarWidth-filled)
+	}
+
+	_, _ = fmt.Fprintf(p.w, "%s%s [%s] %s", ansiClearLine, p.label, bar, p)
+}
+
+// drawLine prints the progress as a line of its own.
+func (p *progress) drawLine() {
+	_, _ = fmt.Fprintf(p.w, "%s: %s\n", p.label, p)
+}
+
+// String formats the counters, rate, and estimated time remaining.
+func (p *progress) String() string {
+	elapsed := p.now().Sub(p.start).Seconds()
+	rate := 0.0
+
+	if elapsed > 0 {
+		rate = float64(p.done) / elapsed
+	}
+
+	b := &strings.Builder{}
+
+	if p.total > 0 {
+		_, _ = fmt.Fprintf(b, "%d/%d records (%d%%)", p.done, p.total, p.done*percent/p.total)
+	} else {
+		_, _ = fmt.Fprintf(b, "%d records", p.done)
+	}
+
+	_, _ = fmt.Fprintf(b, ", %.1f/s", rate)
+
+	if p.total > 0 && rate > 0 && p.done < p.total {
+		eta := time.Duration(float64(p.total-p.done) / rate * float64(time.Second))
+		_, _ = fmt.Fprintf(b, ", ETA %s", eta.Round(time.Second))
+	}
+
+	errorCount := fmt.Sprintf("%d errors", p.failed)
+	if p.failed > 0 {
+		errorCount = p.colors.failure(errorCount)
+	}
+
+	_, _ = fmt.Fprintf(b, ", %s", errorCount)
+
+	return b.String()
+}
@@ -0,0 +1,81 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProgress(t *testing.T) {
+	start := time.Date(2021, time.January, 4, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		mode    string
+		total   int
+		results []error
+		want    string
+	}{
+		{
+			name:    "plain prints a line every n records and at the end",
+			mode:    progressPlain,
+			total:   5,
+			results: []error{nil, nil, nil, nil, nil},
+			want: "test: 2/5 records (40%), 1.0/s, ETA 3s, 0 errors\n" +
+				"test: 4/5 records (80%), 1.0/s, ETA 1s, 0 errors\n" +
+				"test: 5/5 records (100%), 1.0/s, 0 errors\n",
+		},
+		{
+			name:    "plain prints errors and counts them",
+			mode:    progressPlain,
+			results: []error{nil, errors.New("boom")},
+			want:    "test: record 2: boom\ntest: 2 records, 1.0/s, 1 errors\n",
+		},
+		{
+			name:    "bar redraws in place and ends with a newline",
+			mode:    progressBar,
+			total:   2,
+			results: []error{nil, errors.New("boom")},
+			want: ansiClearLine + "test [===============               ] 1/2 records (50%), 1.0/s, ETA 1s, 0 errors" +
+				ansiClearLine + "test: record 2: boom\n" +
+				ansiClearLine + "test [==============================] 2/2 records (100%), 1.0/s, 1 errors" +
+				ansiClearLine + "test [==============================] 2/2 records (100%), 1.0/s, 1 errors\n",
+		},
+		{
+			name:    "none prints nothing",
+			mode:    progressNone,
+			total:   2,
+			results: []error{nil, errors.New("boom")},
+			want:    "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+
+			now := start
+			p := &progress{
+				w:     &buf,
+				label: "test",
+				mode:  tt.mode,
+				every: 2,
+				total: tt.total,
+				start: start,
+				now:   func() time.Time { return now },
+			}
+
+			for i, err := range tt.results {
+				now = now.Add(time.Second)
+				p.record("record "+string(rune('1'+i)), err)
+			}
+
+			p.finish()
+
+			assert.Equal(t, tt.want, buf.String())
+			assert.Equal(t, len(tt.results), p.done)
+		})
+	}
+}
@@ -0,0 +1,131 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+var errNotConfirmed = errors.New("confirmation did not match the organisation ID, nothing was deleted")
+
+// runPurge deletes every account of the configured organisation, each at its current version. As there is no undoing
+// it, it asks for the organisation ID to be typed in first, unless --yes is passed. With --dry-run it only prints what
+// it would delete.
+func runPurge(a *app, args []string) error {
+	var (
+		common   commonFlags
+		bulk     bulkFlags
+		yes      bool
+		dryRun   bool
+		pageSize uint
+	)
+
+	fs := a.newFlagSet("purge", "")
+	common.registerConnection(fs)
+	bulk.register(fs)
+	fs.BoolVar(&yes, "yes", false, "do not ask for confirmation")
+	fs.BoolVar(&dryRun, "dry-run", false, "print the accounts that would be deleted, and delete none of them")
+	fs.UintVar(&pageSize, "size", defaultPageSize, "number of accounts to request at once")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	p, err := a.newProgress(bulk, "purge", 0)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newBulkClient(common, bulk)
+	if err != nil {
+		return err
+	}
+
+	listed, err := c.ListAll(pageSize)
+	if err != nil {
+		return fmt.Errorf("listing accounts: %w", err)
+	}
+
+	accounts := organisationAccounts(listed, c.OrganisationID)
+
+	if dryRun {
+		for _, d := range accounts {
+			_, _ = fmt.Fprintf(a.stdout, "would delete %s at version %d\n", d.ID, d.Version)
+		}
+
+		_, _ = fmt.Fprintf(a.stderr, "%d accounts of organisation %s would be deleted\n", len(accounts), c.OrganisationID)
+
+		return nil
+	}
+
+	if len(accounts) == 0 {
+		_, _ = fmt.Fprintf(a.stderr, "organisation %s has no accounts, nothing to purge\n", c.OrganisationID)
+
+		return nil
+	}
+
+	if !yes {
+		err = a.confirmPurge(len(accounts), c.OrganisationID)
+		if err != nil {
+			return err
+		}
+	}
+
+	p.setTotal(len(accounts))
+
+	c.DeleteBatch(accounts, func(d client.Data, deleteErr error) {
+		p.record(d.ID, deleteErr)
+	})
+
+	p.finish()
+
+	if p.failed > 0 {
+		return fmt.Errorf("%w: %d of %d failed to delete", errBulkFailed, p.failed, p.total)
+	}
+
+	_, _ = fmt.Fprintf(a.stdout, "purged %d accounts\n", p.total)
+
+	return nil
+}
+
+// organisationAccounts returns the accounts of organisationID. The list endpoint returns the accounts of every
+// organisation, so anything that deletes what it listed has to leave out the ones of the others.
+func organisationAccounts(accounts []client.Data, organisationID string) []client.Data {
+	own := make([]client.Data, 0, len(accounts))
+
+	for _, d := range accounts {
+		if d.OrganisationID == organisationID {
+			own = append(own, d)
+		}
+	}
+
+	return own
+}
+
+// confirmPurge asks for the organisation ID on stderr, and reads the answer from stdin.
+func (a *app) confirmPurge(count int, organisationID string) error {
+	_, _ = fmt.Fprintf(a.stderr, "This deletes all %d accounts of organisation %s.\nType the organisation ID to confirm: ",
+		count, organisationID)
+
+	answer, err := bufio.NewReader(a.stdin).ReadString('\n')
+	if err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("reading confirmation: %w", err)
+	}
+
+	if strings.TrimSpace(answer) != organisationID {
+		return errNotConfirmed
+	}
+
+	return nil
+}
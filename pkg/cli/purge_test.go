@@ -0,0 +1,179 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestRun_Purge(t *testing.T) {
+	const organisationID = "7442ea6b-164a-4818-b470-d98abfbc24ae"
+
+	tests := []struct {
+		name        string
+		args        []string
+		stdin       string
+		conflict    bool
+		empty       bool
+		wantCode    int
+		wantDeletes int32
+		wantStdout  []string
+		wantStderr  []string
+	}{
+		{
+			name:        "deletes every account at its version after the organisation ID is typed in",
+			args:        []string{"purge", "--progress", "none"},
+			stdin:       organisationID + "\n",
+			wantCode:    cli.ExitOK,
+			wantDeletes: 2,
+			wantStdout:  []string{"purged 2 accounts"},
+			wantStderr:  []string{"This deletes all 2 accounts of organisation " + organisationID},
+		},
+		{
+			name:        "deletes nothing when the confirmation does not match",
+			args:        []string{"purge"},
+			stdin:       "yes\n",
+			wantCode:    cli.ExitFailure,
+			wantDeletes: 0,
+			wantStderr:  []string{"confirmation did not match the organisation ID, nothing was deleted"},
+		},
+		{
+			name:        "deletes nothing on a dry run",
+			args:        []string{"purge", "--dry-run"},
+			wantCode:    cli.ExitOK,
+			wantDeletes: 0,
+			wantStdout: []string{
+				"would delete " + testAccountID + " at version 0\n",
+				"would delete ffa7706b-d8fc-40b2-be6b-67d2a628cadf at version 0\n",
+			},
+			wantStderr: []string{"2 accounts of organisation " + organisationID + " would be deleted"},
+		},
+		{
+			name:        "does not ask when there is nothing to purge",
+			args:        []string{"purge"},
+			empty:       true,
+			wantCode:    cli.ExitOK,
+			wantDeletes: 0,
+			wantStderr:  []string{"has no accounts, nothing to purge"},
+		},
+		{
+			name:        "carries on past failures with --yes and concurrency, and reports them",
+			args:        []string{"delete-all", "--yes", "--progress", "plain", "--concurrency", "2"},
+			conflict:    true,
+			wantCode:    cli.ExitFailure,
+			wantDeletes: 1,
+			wantStderr: []string{
+				"purge: " + testAccountID + ": client.Delete: conflict: version 0 of account " + testAccountID +
+					": unexpected response code: 409",
+				"purge: 2/2 records (100%)",
+				"1 of 2 failed to delete",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deletes int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					w.WriteHeader(http.StatusOK)
+
+					if tt.empty {
+						_, _ = w.Write([]byte(`{"data": [], "links": {}}`))
+
+						return
+					}
+
+					_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+				case http.MethodDelete:
+					assert.Equal(t, "0", r.URL.Query().Get("version"))
+
+					if tt.conflict && strings.HasSuffix(r.URL.Path, testAccountID) {
+						w.WriteHeader(http.StatusConflict)
+
+						return
+					}
+
+					atomic.AddInt32(&deletes, 1)
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+			assert.Equal(t, tt.wantDeletes, atomic.LoadInt32(&deletes))
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestRun_PurgeOtherOrganisations(t *testing.T) {
+	const otherID = "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0"
+
+	gb := client.Resource{Country: "GB"}
+
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(client.MultiPayload{Data: []client.Data{
+				{ID: testAccountID, OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae", Attributes: gb},
+				{ID: otherID, OrganisationID: "someone-else", Attributes: gb},
+			}})
+		case http.MethodDelete:
+			mu.Lock()
+			deleted = append(deleted, path.Base(r.URL.Path))
+			mu.Unlock()
+
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"purge", "--dry-run"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.NotContains(t, stdout.String(), otherID)
+	assert.Contains(t, stderr.String(), "1 accounts of organisation")
+
+	stdout.Reset()
+	stderr.Reset()
+
+	code = cli.Run([]string{"purge", "--yes", "--progress", "none"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.Equal(t, "purged 1 accounts\n", stdout.String())
+	assert.Equal(t, []string{testAccountID}, deleted)
+}
@@ -0,0 +1,113 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_QuietAndLatest(t *testing.T) {
+	payloadVersion3 := strings.Replace(string(readFile(t, "./testdata/payload.json")), `"version": 0`, `"version": 3`, 1)
+
+	tests := []struct {
+		name        string
+		handlerFunc http.HandlerFunc
+		args        []string
+		wantCode    int
+		wantStdout  string
+	}{
+		{
+			name: "create prints only the new id",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			},
+			args: []string{
+				"create", "-q", "--country", "GB", "--bank-id", "123456", "--bank-id-code", "GBDSC", "--bic", "BARCGB22XXX",
+			},
+			wantCode:   cli.ExitOK,
+			wantStdout: testAccountID + "\n",
+		},
+		{
+			name: "list prints one id per line and quiet overrides output",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+			},
+			args:       []string{"list", "--quiet", "-o", "json"},
+			wantCode:   cli.ExitOK,
+			wantStdout: testAccountID + "\nffa7706b-d8fc-40b2-be6b-67d2a628cadf\n",
+		},
+		{
+			name: "delete with latest deletes the current version and prints nothing in quiet mode",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				switch r.Method {
+				case http.MethodGet:
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write([]byte(payloadVersion3))
+				case http.MethodDelete:
+					assert.Equal(t, "3", r.URL.Query().Get("version"))
+					w.WriteHeader(http.StatusNoContent)
+				}
+			},
+			args:       []string{"delete", "-q", "--latest", testAccountID},
+			wantCode:   cli.ExitOK,
+			wantStdout: "",
+		},
+		{
+			name: "delete with latest fails with the not found code if the account is gone",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				w.WriteHeader(http.StatusNotFound)
+			},
+			args:       []string{"delete", "--latest", testAccountID},
+			wantCode:   cli.ExitNotFound,
+			wantStdout: "",
+		},
+		{
+			name: "delete with latest refuses an account without a version",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodGet, r.Method)
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(strings.Replace(payloadVersion3, `"version": 3,`, "", 1)))
+			},
+			args:       []string{"delete", "--latest", testAccountID},
+			wantCode:   cli.ExitFailure,
+			wantStdout: "",
+		},
+		{
+			name:       "delete refuses latest and version together",
+			args:       []string{"delete", "--latest", "--version", "1", testAccountID},
+			wantCode:   cli.ExitFailure,
+			wantStdout: "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := tt.handlerFunc
+			if handler == nil {
+				handler = func(w http.ResponseWriter, r *http.Request) {
+					assert.Fail(t, "no request should have been made")
+				}
+			}
+
+			ts := httptest.NewServer(handler)
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+			assert.Equal(t, tt.wantStdout, stdout.String())
+		})
+	}
+}
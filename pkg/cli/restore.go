@@ -0,0 +1,227 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/dump"
+)
+
+var errOtherOrganisation = errors.New("backup is of another organisation")
+
+const (
+	outcomeCreated = "created"
+	outcomeSkipped = "skipped"
+	outcomeFailed  = "failed"
+)
+
+// restoreOutcome is what happened to a single account of the backup, and what restore prints in the json and
+// go-template formats.
+type restoreOutcome struct {
+	ID      string `json:"id"`
+	NewID   string `json:"new_id,omitempty"`
+	Outcome string `json:"outcome"`
+	Error   string `json:"error,omitempty"`
+}
+
+// runRestore re-creates the accounts of a backup written by the backup command, in any version of the dump format up to
+// the newest, decrypting it with the backup key if it is encrypted. Accounts whose ID is already on the server are
+// skipped, and so is every account the server reports a conflict for. The accounts are created in the configured
+// organisation, so a backup of another one is refused unless --other-organisation says that is what's wanted.
+func runRestore(a *app, args []string) error {
+	var (
+		common      commonFlags
+		bulk        bulkFlags
+		file        string
+		preserveIDs bool
+		pageSize    uint
+		otherOrg    bool
+	)
+
+	fs := a.newFlagSet("restore", "")
+	common.register(fs)
+	bulk.register(fs)
+	registerBackupKeyFlag(fs, &common.backupKeyFile)
+	fs.StringVar(&file, "file", "-", "read the backup from this file, - for stdin")
+	fs.BoolVar(&preserveIDs, "preserve-ids", true, "create the accounts with the IDs they have in the backup, so "+
+		"running the restore again skips them. With --preserve-ids=false they get new IDs, and every run creates them "+
+		"again")
+	fs.UintVar(&pageSize, "size", defaultPageSize, "number of accounts to request at once while listing existing ones")
+	fs.BoolVar(&otherOrg, "other-organisation", false, "restore a backup of another organisation into the configured one")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	opts, err := a.dumpOptions(common)
+	if err != nil {
+		return err
+	}
+
+	content, err := a.readFile(file)
+	if err != nil {
+		return err
+	}
+
+	header, records, err := decodeBackup(file, content, opts...)
+	if err != nil {
+		return err
+	}
+
+	c, err := a.newBulkClient(common, bulk)
+	if err != nil {
+		return err
+	}
+
+	if header.OrganisationID != "" && header.OrganisationID != c.OrganisationID && !otherOrg {
+		return fmt.Errorf("%w: %s is a backup of %s, not %s, use --other-organisation to restore it anyway",
+			errOtherOrganisation, file, header.OrganisationID, c.OrganisationID)
+	}
+
+	p, err := a.newProgress(bulk, "restore", len(records))
+	if err != nil {
+		return err
+	}
+
+	existing, err := c.ListAll(pageSize)
+	if err != nil {
+		return fmt.Errorf("listing existing accounts: %w", err)
+	}
+
+	outcomes := restoreAccounts(c, records, existing, preserveIDs, p)
+
+	p.finish()
+
+	err = printRestore(out, outcomes)
+	if err != nil {
+		return err
+	}
+
+	if p.failed > 0 {
+		return fmt.Errorf("%w: %d of %d failed to restore", errBulkFailed, p.failed, p.total)
+	}
+
+	return nil
+}
+
+// restoreAccounts creates every record that is not in existing, and returns the outcome of every record in the order
+// of the backup.
+func restoreAccounts(
+	c client.Client, records, existing []client.Data, preserveIDs bool, p *progress,
+) []restoreOutcome {
+	onServer := make(map[string]bool, len(existing))
+
+	for _, d := range existing {
+		onServer[d.ID] = true
+	}
+
+	outcomes := make([]restoreOutcome, len(records))
+	pending := make([]int, 0, len(records))
+
+	for i, r := range records {
+		outcomes[i] = restoreOutcome{ID: r.ID, Outcome: outcomeSkipped}
+
+		if onServer[r.ID] {
+			p.record(r.ID, nil)
+
+			continue
+		}
+
+		pending = append(pending, i)
+	}
+
+	record := func(j int, created client.Payload, err error) {
+		i := pending[j]
+
+		switch {
+		case errors.Is(err, client.ErrConflict):
+			outcomes[i].Outcome = outcomeSkipped
+			err = nil
+		case err != nil:
+			outcomes[i].Outcome = outcomeFailed
+			outcomes[i].Error = err.Error()
+		default:
+			outcomes[i].Outcome = outcomeCreated
+			outcomes[i].NewID = created.ID()
+		}
+
+		p.record(records[i].ID, err)
+	}
+
+	if preserveIDs {
+		batch := make([]client.Data, 0, len(pending))
+
+		for _, i := range pending {
+			batch = append(batch, records[i])
+		}
+
+		c.CreateBatchWithIDs(batch, record)
+
+		return outcomes
+	}
+
+	batch := make([]client.Resource, 0, len(pending))
+
+	for _, i := range pending {
+		batch = append(batch, records[i].Attributes)
+	}
+
+	c.CreateBatch(batch, record)
+
+	return outcomes
+}
+
+// decodeBackup decodes the header and the accounts of a dump, after decrypting them and checking their checksums.
+func decodeBackup(name string, content []byte, opts ...dump.Option) (dump.Header, []client.Data, error) {
+	header, records, err := dump.ReadAll(bytes.NewReader(content), opts...)
+	if err != nil {
+		return dump.Header{}, nil, fmt.Errorf("reading %s: %w", name, err)
+	}
+
+	return header, records, nil
+}
+
+// printRestore prints the outcome of every record. In quiet mode it prints the IDs of the accounts created.
+func printRestore(out printer, outcomes []restoreOutcome) error {
+	switch out.format {
+	case outputText:
+		for _, o := range outcomes {
+			switch o.Outcome {
+			case outcomeCreated:
+				_, _ = fmt.Fprintf(out.w, "%s %s as %s\n", out.colors.success(o.Outcome), o.ID, o.NewID)
+			case outcomeSkipped:
+				_, _ = fmt.Fprintf(out.w, "%s %s: already exists\n", out.colors.warning(o.Outcome), o.ID)
+			default:
+				_, _ = fmt.Fprintf(out.w, "%s %s: %s\n", out.colors.failure(o.Outcome), o.ID, o.Error)
+			}
+		}
+
+		return nil
+	case outputQuiet:
+		created := make([]client.Data, 0, len(outcomes))
+
+		for _, o := range outcomes {
+			if o.Outcome == outcomeCreated {
+				created = append(created, client.Data{ID: o.NewID})
+			}
+		}
+
+		return out.ids(created...)
+	}
+
+	return out.structured(outcomes)
+}
@@ -0,0 +1,229 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/dump"
+)
+
+func TestRun_Restore(t *testing.T) {
+	const (
+		newID      = "5a4a5c4b-6bd4-4a5e-9a04-2a6ef1d7e0c1"
+		conflictID = "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0"
+		invalidID  = "9f3c0f3e-4a2d-4c65-b4d9-1cde7c3a5b11"
+	)
+
+	backup := strings.Join([]string{
+		`{"id": "` + testAccountID + `", "attributes": ` + importAccount + `}`,
+		`{"id": "` + newID + `", "attributes": ` + importAccount + `}`,
+		``,
+		`{"id": "` + conflictID + `", "attributes": ` + importAccount + `}`,
+		`{"id": "` + invalidID + `", "attributes": {"country": "GB"}}`,
+	}, "\n")
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:     "preserves ids, skips existing accounts and conflicts, and reports failures",
+			args:     []string{"restore", "--progress", "none"},
+			wantCode: cli.ExitFailure,
+			wantStdout: []string{
+				"skipped " + testAccountID + ": already exists\n",
+				"created " + newID + " as " + newID + "\n",
+				"skipped " + conflictID + ": already exists\n",
+				"failed " + invalidID + ": client.CreateWithID: validation failed: BIC is required",
+			},
+			wantStderr: []string{"1 of 4 failed to restore"},
+		},
+		{
+			name:       "creates accounts with new ids",
+			args:       []string{"restore", "--preserve-ids=false", "-o", "json", "--progress", "none"},
+			wantCode:   cli.ExitFailure,
+			wantStdout: []string{`"outcome": "created"`, `"new_id": "`, `"error": "client.Create: validation failed`},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+
+					return
+				}
+
+				body, _ := ioutil.ReadAll(r.Body)
+
+				var p struct {
+					Data struct {
+						ID string `json:"id"`
+					} `json:"data"`
+				}
+
+				assert.NoError(t, json.Unmarshal(body, &p))
+				assert.NotEqual(t, testAccountID, p.Data.ID)
+
+				if p.Data.ID == conflictID {
+					w.WriteHeader(http.StatusConflict)
+
+					return
+				}
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(body)
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(backup), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
+
+func TestRun_RestoreTwice(t *testing.T) {
+	const newID = "5a4a5c4b-6bd4-4a5e-9a04-2a6ef1d7e0c1"
+
+	backup := strings.Join([]string{
+		`{"id": "` + testAccountID + `", "attributes": ` + importAccount + `}`,
+		`{"id": "` + newID + `", "attributes": ` + importAccount + `}`,
+	}, "\n")
+
+	var (
+		mu      sync.Mutex
+		created []json.RawMessage
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if r.Method == http.MethodGet {
+			w.WriteHeader(http.StatusOK)
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"data": created})
+
+			return
+		}
+
+		var p struct {
+			Data json.RawMessage `json:"data"`
+		}
+
+		body, _ := ioutil.ReadAll(r.Body)
+		assert.NoError(t, json.Unmarshal(body, &p))
+
+		created = append(created, p.Data)
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write(body)
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	for run, want := range []string{"created", "skipped"} {
+		var stdout, stderr bytes.Buffer
+
+		code := cli.Run([]string{"restore", "--progress", "none"}, strings.NewReader(backup), &stdout, &stderr)
+
+		assert.Equal(t, cli.ExitOK, code, "run %d, stderr: %s", run, stderr.String())
+		assert.Contains(t, stdout.String(), want+" "+testAccountID, "run %d", run)
+		assert.Contains(t, stdout.String(), want+" "+newID, "run %d", run)
+	}
+
+	assert.Len(t, created, 2)
+}
+
+func TestRun_RestoreOtherOrganisation(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := dump.NewWriter(&buf, dump.Header{OrganisationID: "someone-else"})
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(client.Data{ID: testAccountID, OrganisationID: "someone-else", Type: "accounts",
+		Attributes: client.Resource{Country: "GB", BankID: "123456", BankIDCode: "GBDSC", BIC: "BARCGB22XXX"}}))
+	assert.NoError(t, w.Close())
+
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+		wantPosts  int
+	}{
+		{
+			name:       "refuses a backup of another organisation",
+			args:       []string{"restore", "--progress", "none"},
+			wantCode:   cli.ExitFailure,
+			wantStderr: "- is a backup of someone-else, not " + testOrganisationID + ", use --other-organisation",
+		},
+		{
+			name:       "restores it into the configured one with --other-organisation",
+			args:       []string{"restore", "--other-organisation", "--progress", "none"},
+			wantCode:   cli.ExitOK,
+			wantStdout: "created " + testAccountID,
+			wantPosts:  1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var posts int
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet {
+					_, _ = w.Write([]byte(`{"data": [], "links": {}}`))
+
+					return
+				}
+
+				posts++
+
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.Contains(t, string(body), `"organisation_id":"`+testOrganisationID+`"`)
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write(body)
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, bytes.NewReader(buf.Bytes()), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+			assert.Contains(t, stdout.String(), tt.wantStdout)
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+			assert.Equal(t, tt.wantPosts, posts)
+		})
+	}
+}
@@ -0,0 +1,328 @@
+package cli
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+const (
+	shellPrompt = programName + "> "
+
+	// completionMarker is what a tab typed on a terminal in line mode ends up as: a word that ends with it is
+	// completed when the line is run.
+	completionMarker = '\t'
+)
+
+var (
+	errUnterminatedQuote = errors.New("unterminated quote")
+	errNoCompletion      = errors.New("nothing to complete")
+	errAmbiguousWord     = errors.New("ambiguous")
+	errHistory           = errors.New("no such command in the history")
+)
+
+// shell is the state an interactive session keeps between commands: the lines run so far, the IDs of the accounts
+// seen in the output, and the clients created, so a session talks to the API over the same clients throughout. Every
+// method can be called on a nil shell, which keeps nothing.
+type shell struct {
+	history []string
+	ids     []string
+	seen    map[string]bool
+	clients map[string]client.Client
+}
+
+// word is a single argument of a line typed into the shell. complete is set if it ended with a tab.
+type word struct {
+	text     string
+	complete bool
+}
+
+// runShell reads commands from stdin one line at a time and runs them the same way Run would, until exit, quit, or
+// the end of the input. A word that ends with a tab is completed from the command names if it is the first word, and
+// from the IDs of the accounts printed so far otherwise. history lists the lines run so far, and !! or !<number> runs
+// one of them again.
+func runShell(a *app, args []string) error {
+	fs := a.newFlagSet("shell", "")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	a.shell = &shell{seen: map[string]bool{}, clients: map[string]client.Client{}}
+
+	defer func() {
+		a.shell = nil
+	}()
+
+	in := bufio.NewScanner(a.stdin)
+
+	for {
+		_, _ = fmt.Fprint(a.stdout, shellPrompt)
+
+		if !in.Scan() {
+			_, _ = fmt.Fprintln(a.stdout)
+
+			return in.Err()
+		}
+
+		if a.shell.runLine(a, in.Text()) {
+			return nil
+		}
+	}
+}
+
+// runLine runs a single line of input, and reports whether it ended the session.
+func (s *shell) runLine(a *app, line string) bool {
+	line, err := s.recall(strings.TrimRight(line, " \r\n"))
+	if err != nil {
+		_, _ = fmt.Fprintf(a.stderr, "%s: %s\n", programName, err)
+
+		return false
+	}
+
+	words, err := splitWords(line)
+	if err != nil {
+		_, _ = fmt.Fprintf(a.stderr, "%s: %s\n", programName, err)
+
+		return false
+	}
+
+	if len(words) == 0 {
+		return false
+	}
+
+	args, err := s.complete(words)
+	if err != nil {
+		_, _ = fmt.Fprintf(a.stderr, "%s: %s\n", programName, err)
+
+		return false
+	}
+
+	s.history = append(s.history, strings.Join(quoteWords(args), " "))
+
+	switch args[0] {
+	case "exit", "quit":
+		return true
+	case "help":
+		a.usage()
+
+		return false
+	case "history":
+		for i, h := range s.history {
+			_, _ = fmt.Fprintf(a.stdout, "%5d  %s\n", i+1, h)
+		}
+
+		return false
+	}
+
+	cmd, ok := commandByName(args[0])
+	if !ok || cmd.name == "shell" {
+		_, _ = fmt.Fprintf(a.stderr, "%s: unknown command %q, type help for the list of commands\n", programName, args[0])
+
+		return false
+	}
+
+	a.execute(cmd, args[1:])
+
+	return false
+}
+
+// recall replaces a line of !! with the last line of the history, and !<number> with the line of that number.
+func (s *shell) recall(line string) (string, error) {
+	if !strings.HasPrefix(line, "!") {
+		return line, nil
+	}
+
+	n := len(s.history)
+
+	if line != "!!" {
+		var err error
+
+		n, err = strconv.Atoi(line[1:])
+		if err != nil {
+			return "", fmt.Errorf("%w: %s", errHistory, line)
+		}
+	}
+
+	if n < 1 || n > len(s.history) {
+		return "", fmt.Errorf("%w: %s", errHistory, line)
+	}
+
+	return s.history[n-1], nil
+}
+
+// complete returns the words as arguments, with the ones that ended with a tab completed. The first word is completed
+// from the command names, every other from the IDs seen so far. A word that matches nothing, or more than one
+// candidate, is an error that lists the candidates.
+func (s *shell) complete(words []word) ([]string, error) {
+	args := make([]string, 0, len(words))
+
+	for i, w := range words {
+		if !w.complete {
+			args = append(args, w.text)
+
+			continue
+		}
+
+		candidates := s.ids
+		if i == 0 {
+			candidates = commandNames()
+		}
+
+		matches := withPrefix(w.text, candidates)
+
+		switch len(matches) {
+		case 0:
+			return nil, fmt.Errorf("%w: %s", errNoCompletion, w.text)
+		case 1:
+			args = append(args, matches[0])
+		default:
+			return nil, fmt.Errorf("%s is %w: %s", w.text, errAmbiguousWord, strings.Join(matches, ", "))
+		}
+	}
+
+	return args, nil
+}
+
+// remember keeps the IDs of accounts that were printed, in the order they were first seen, for completion.
+func (s *shell) remember(data ...client.Data) {
+	if s == nil {
+		return
+	}
+
+	for _, d := range data {
+		if d.ID != "" && !s.seen[d.ID] {
+			s.seen[d.ID] = true
+			s.ids = append(s.ids, d.ID)
+		}
+	}
+}
+
+// client returns the client kept for the connection flags identified by key.
+func (s *shell) client(key string) (client.Client, bool) {
+	if s == nil {
+		return client.Client{}, false
+	}
+
+	c, ok := s.clients[key]
+
+	return c, ok
+}
+
+// keepClient keeps a client for the connection flags identified by key, so later commands with the same flags use it.
+func (s *shell) keepClient(key string, c client.Client) {
+	if s == nil {
+		return
+	}
+
+	s.clients[key] = c
+}
+
+// splitWords splits a line on spaces. Single or double quotes keep spaces in a word, and a tab outside of quotes ends
+// the word it follows and marks it for completion.
+func splitWords(line string) ([]word, error) {
+	var (
+		words   []word
+		current strings.Builder
+		quote   rune
+		inWord  bool
+	)
+
+	end := func(complete bool) {
+		if inWord {
+			words = append(words, word{text: current.String(), complete: complete})
+		}
+
+		current.Reset()
+
+		inWord = false
+	}
+
+	for _, r := range line {
+		switch {
+		case quote != 0 && r == quote:
+			quote = 0
+		case quote != 0:
+			current.WriteRune(r)
+		case r == '\'' || r == '"':
+			quote, inWord = r, true
+		case r == completionMarker:
+			end(true)
+		case r == ' ':
+			end(false)
+		default:
+			current.WriteRune(r)
+
+			inWord = true
+		}
+	}
+
+	if quote != 0 {
+		return nil, errUnterminatedQuote
+	}
+
+	end(false)
+
+	return words, nil
+}
+
+// quoteWords quotes the arguments that need it to be split back into the same words, for the history.
+func quoteWords(args []string) []string {
+	quoted := make([]string, 0, len(args))
+
+	for _, arg := range args {
+		switch {
+		case strings.ContainsRune(arg, '\''):
+			arg = `"` + arg + `"`
+		case arg == "" || strings.ContainsAny(arg, " \t\""):
+			arg = "'" + arg + "'"
+		}
+
+		quoted = append(quoted, arg)
+	}
+
+	return quoted
+}
+
+// commandNames returns the names of every command, sorted.
+func commandNames() []string {
+	cmds := commands()
+	names := make([]string, 0, len(cmds))
+
+	for _, c := range cmds {
+		names = append(names, c.name)
+	}
+
+	sort.Strings(names)
+
+	return names
+}
+
+// withPrefix returns the candidates that start with prefix. A candidate equal to the prefix is the only match.
+func withPrefix(prefix string, candidates []string) []string {
+	var matches []string
+
+	for _, c := range candidates {
+		if c == prefix {
+			return []string{c}
+		}
+
+		if strings.HasPrefix(c, prefix) {
+			matches = append(matches, c)
+		}
+	}
+
+	return matches
+}
@@ -0,0 +1,102 @@
+package cli
+
+import (
+	"io/ioutil"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+func TestSplitWords(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		want    []word
+		wantErr error
+	}{
+		{
+			name: "splits on spaces and skips runs of them",
+			line: "  fetch   -v id ",
+			want: []word{{text: "fetch"}, {text: "-v"}, {text: "id"}},
+		},
+		{
+			name: "keeps spaces in quotes",
+			line: `list -o 'go-template={{range .Data}}{{.ID}} {{end}}' "a b"`,
+			want: []word{{text: "list"}, {text: "-o"}, {text: "go-template={{range .Data}}{{.ID}} {{end}}"}, {text: "a b"}},
+		},
+		{
+			name: "marks words followed by a tab for completion",
+			line: "fe\tab12\t --latest",
+			want: []word{{text: "fe", complete: true}, {text: "ab12", complete: true}, {text: "--latest"}},
+		},
+		{
+			name:    "fails on an unterminated quote",
+			line:    `fetch "id`,
+			wantErr: errUnterminatedQuote,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := splitWords(tt.line)
+
+			assert.Equal(t, tt.wantErr, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestShell_reusesClients(t *testing.T) {
+	os.Clearenv()
+
+	_ = os.Setenv(config.AccountsAPIURLKey, "http://localhost:8080")
+	_ = os.Setenv(config.OrganisationIDKey, "org")
+
+	a := &app{stderr: ioutil.Discard, shell: &shell{clients: map[string]client.Client{}}}
+
+	_, err := a.newClient(commonFlags{output: outputJSON})
+	assert.NoError(t, err)
+
+	_, err = a.newClient(commonFlags{quiet: true}, client.WithConcurrency(4))
+	assert.NoError(t, err)
+
+	assert.Len(t, a.shell.clients, 1)
+
+	_, err = a.newClient(commonFlags{organisationID: "other"})
+	assert.NoError(t, err)
+
+	assert.Len(t, a.shell.clients, 2)
+}
+
+func TestShell_reusesTransportOfBulkClients(t *testing.T) {
+	os.Clearenv()
+
+	_ = os.Setenv(config.AccountsAPIURLKey, "http://localhost:8080")
+	_ = os.Setenv(config.OrganisationIDKey, "org")
+
+	a := &app{stderr: ioutil.Discard, shell: &shell{clients: map[string]client.Client{}}}
+
+	first, err := a.newBulkClient(commonFlags{}, bulkFlags{concurrency: 4})
+	assert.NoError(t, err)
+
+	second, err := a.newBulkClient(commonFlags{}, bulkFlags{concurrency: 4, rate: 10})
+	assert.NoError(t, err)
+
+	transport, ok := first.HttpClient.Transport.(*http.Transport)
+	if assert.True(t, ok, "got %T", first.HttpClient.Transport) {
+		assert.Equal(t, 4, transport.MaxIdleConnsPerHost)
+	}
+
+	assert.Same(t, first.HttpClient.Transport, second.HttpClient.Transport)
+	assert.Len(t, a.shell.clients, 1)
+
+	other, err := a.newBulkClient(commonFlags{}, bulkFlags{concurrency: 8})
+	assert.NoError(t, err)
+
+	assert.NotSame(t, first.HttpClient.Transport, other.HttpClient.Transport)
+	assert.Len(t, a.shell.clients, 2)
+}
@@ -0,0 +1,70 @@
+package cli_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Shell(t *testing.T) {
+	var paths []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+
+		w.WriteHeader(http.StatusOK)
+
+		if r.URL.Path == "/v1/organisation/accounts" {
+			_, _ = w.Write(readFile(t, "./testdata/multipayload.json"))
+
+			return
+		}
+
+		_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+	}))
+	defer ts.Close()
+
+	setTestEnv(t, ts.URL)
+
+	stdin := strings.Join([]string{
+		"li\t -q",
+		"fetch a6c1\t -o 'go-template={{.Data.ID}} done'",
+		"de\t",
+		"fetch zzz\t",
+		"shell",
+		"",
+		"!2",
+		"history",
+		"exit",
+		"fetch never-run",
+	}, "\n")
+
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"shell"}, strings.NewReader(stdin), &stdout, &stderr)
+
+	assert.Equal(t, cli.ExitOK, code, "stderr: %s", stderr.String())
+	assert.Equal(t, []string{
+		"/v1/organisation/accounts",
+		"/v1/organisation/accounts/" + testAccountID,
+		"/v1/organisation/accounts/" + testAccountID,
+	}, paths)
+
+	assert.Contains(t, stdout.String(), "accountsclient> "+testAccountID+"\nffa7706b-d8fc-40b2-be6b-67d2a628cadf\n")
+	assert.Equal(t, 2, strings.Count(stdout.String(), testAccountID+" done"))
+	assert.Contains(t, stdout.String(), "    1  list -q\n"+
+		"    2  fetch "+testAccountID+" -o 'go-template={{.Data.ID}} done'\n"+
+		"    3  shell\n"+
+		"    4  fetch "+testAccountID+" -o 'go-template={{.Data.ID}} done'\n"+
+		"    5  history\n")
+
+	assert.Contains(t, stderr.String(), "de is ambiguous: delete, delete-all")
+	assert.Contains(t, stderr.String(), "nothing to complete: zzz")
+	assert.Contains(t, stderr.String(), `unknown command "shell"`)
+}
@@ -0,0 +1,225 @@
+package cli
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+var (
+	errNoChanges     = errors.New("nothing to update, use --set or --file")
+	errUnknownField  = errors.New("unknown attribute")
+	errBadAssignment = errors.New("expected attribute=value")
+)
+
+// assignments collects the attribute=value pairs of a repeated flag in the order they were given.
+type assignments []string
+
+// String returns the pairs separated by commas.
+func (a *assignments) String() string {
+	return strings.Join(*a, ",")
+}
+
+// Set adds another pair.
+func (a *assignments) Set(value string) error {
+	*a = append(*a, value)
+
+	return nil
+}
+
+// runUpdate changes the attributes of a single account at the given version, or at whatever its current version is
+// with --latest. Only the attributes in the file and the --set flags are sent, and --set overrides the file.
+func runUpdate(a *app, args []string) error {
+	var (
+		common  commonFlags
+		file    string
+		set     assignments
+		version uint
+		latest  bool
+	)
+
+	fs := a.newFlagSet("update", "<account id>")
+	common.register(fs)
+	fs.StringVar(&file, "file", "", "read the attributes to change as a JSON object from this file, - for stdin")
+	fs.Var(&set, "set", "attribute=value to change, like status=closed, can be repeated")
+	fs.UintVar(&version, "version", 0, "version of the account to update")
+	fs.BoolVar(&latest, "latest", false, "fetch the account first and update its current version")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 1 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	if latest && isFlagSet(fs, "version") {
+		return errors.New("--latest and --version are mutually exclusive")
+	}
+
+	out, err := a.newPrinter(common)
+	if err != nil {
+		return err
+	}
+
+	attributes := map[string]interface{}{}
+
+	if file != "" {
+		attributes, err = a.readPatch(file)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, s := range set {
+		key, value, parseErr := parseAssignment(s)
+		if parseErr != nil {
+			return parseErr
+		}
+
+		attributes[key] = value
+	}
+
+	if len(attributes) == 0 {
+		return errNoChanges
+	}
+
+	c, err := a.newClient(common)
+	if err != nil {
+		return err
+	}
+
+	if latest {
+		p, fetchErr := c.Fetch(positional[0])
+		if fetchErr != nil {
+			return fmt.Errorf("fetching current version: %w", fetchErr)
+		}
+
+		current, known := p.Data.CurrentVersion()
+		if !known {
+			return fmt.Errorf("fetching current version: %w", errNoVersion)
+		}
+
+		version = current
+	}
+
+	p, err := c.Update(positional[0], version, attributes)
+	if err != nil {
+		return fmt.Errorf("updating account: %w", err)
+	}
+
+	return out.updated(p)
+}
+
+// readPatch decodes the attributes to change from a JSON object in a file, or from stdin if the name is -. Every key
+// has to be an attribute of an account.
+func (a *app) readPatch(name string) (map[string]interface{}, error) {
+	content, err := a.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	var attributes map[string]interface{}
+
+	err = json.Unmarshal(content, &attributes)
+	if err != nil {
+		return nil, fmt.Errorf("decoding %s: %w", name, err)
+	}
+
+	kinds := attributeKinds()
+
+	for key := range attributes {
+		if _, ok := kinds[key]; !ok {
+			return nil, fmt.Errorf("decoding %s: %w", name, unknownField(key, kinds))
+		}
+	}
+
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+
+	return attributes, nil
+}
+
+// parseAssignment splits attribute=value, and converts the value to the type of the attribute. Booleans are parsed,
+// and list attributes like name take either a JSON array or a single value that becomes a list of one.
+func parseAssignment(s string) (string, interface{}, error) {
+	parts := strings.SplitN(s, "=", 2) //nolint:gomnd
+	if len(parts) != 2 || parts[0] == "" {
+		return "", nil, fmt.Errorf("--set %s: %w", s, errBadAssignment)
+	}
+
+	key, value := parts[0], parts[1]
+	kinds := attributeKinds()
+
+	kind, ok := kinds[key]
+	if !ok {
+		return "", nil, fmt.Errorf("--set %s: %w", s, unknownField(key, kinds))
+	}
+
+	switch kind {
+	case reflect.Bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", nil, fmt.Errorf("--set %s: %s is true or false: %w", s, key, err)
+		}
+
+		return key, b, nil
+	case reflect.Array:
+		if !strings.HasPrefix(value, "[") {
+			return key, []string{value}, nil
+		}
+
+		var list []string
+
+		err := json.Unmarshal([]byte(value), &list)
+		if err != nil {
+			return "", nil, fmt.Errorf("--set %s: %s is a JSON array of strings: %w", s, key, err)
+		}
+
+		return key, list, nil
+	}
+
+	return key, value, nil
+}
+
+// attributeKinds maps the JSON name of every attribute of an account to the kind of its field.
+func attributeKinds() map[string]reflect.Kind {
+	t := reflect.TypeOf(client.Resource{})
+	kinds := make(map[string]reflect.Kind, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name := strings.Split(f.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+
+		kinds[name] = f.Type.Kind()
+	}
+
+	return kinds
+}
+
+// unknownField returns an error for a key that is not an attribute, listing the ones that are.
+func unknownField(key string, kinds map[string]reflect.Kind) error {
+	names := make([]string, 0, len(kinds))
+
+	for name := range kinds {
+		names = append(names, name)
+	}
+
+	sort.Strings(names)
+
+	return fmt.Errorf("%w %s, use one of %s", errUnknownField, key, strings.Join(names, ", "))
+}
@@ -0,0 +1,145 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Update(t *testing.T) {
+	dir := t.TempDir()
+	patch := filepath.Join(dir, "patch.json")
+
+	err := ioutil.WriteFile(patch, []byte(`{"status": "closed", "bic": "NWBKGB22"}`), 0o600)
+	if err != nil {
+		assert.FailNowf(t, "could not write patch", "error: %s", err)
+	}
+
+	tests := []struct {
+		name           string
+		args           []string
+		conflict       bool
+		wantCode       int
+		wantVersion    float64
+		wantAttributes string
+		wantStdout     []string
+		wantStderr     []string
+	}{
+		{
+			name: "sends typed attributes from --set at the given version",
+			args: []string{
+				"update", "--version", "2", "--set", "status=closed", "--set", "switched=true", testAccountID,
+			},
+			wantCode:       cli.ExitOK,
+			wantVersion:    2,
+			wantAttributes: `{"status": "closed", "switched": true}`,
+			wantStdout:     []string{"updated account " + testAccountID},
+		},
+		{
+			name:           "merges --set over the file and takes lists as JSON or a single value",
+			args:           []string{"update", "--file", patch, "--set", "bic=NWBKGB33", "--set", "name=Jane", testAccountID},
+			wantCode:       cli.ExitOK,
+			wantAttributes: `{"status": "closed", "bic": "NWBKGB33", "name": ["Jane"]}`,
+		},
+		{
+			name:           "updates the current version with --latest",
+			args:           []string{"update", "--latest", "-q", "--set", `alternative_names=["a","b"]`, testAccountID},
+			wantCode:       cli.ExitOK,
+			wantVersion:    0,
+			wantAttributes: `{"alternative_names": ["a", "b"]}`,
+			wantStdout:     []string{testAccountID + "\n"},
+		},
+		{
+			name:       "rejects unknown attributes",
+			args:       []string{"update", "--set", "colour=blue", testAccountID},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"unknown attribute colour, use one of account_classification"},
+		},
+		{
+			name:       "rejects booleans that do not parse",
+			args:       []string{"update", "--set", "joint_account=maybe", testAccountID},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"joint_account is true or false"},
+		},
+		{
+			name:       "needs something to change",
+			args:       []string{"update", testAccountID},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"nothing to update, use --set or --file"},
+		},
+		{
+			name:       "rejects --latest with --version",
+			args:       []string{"update", "--latest", "--version", "1", "--set", "status=closed", testAccountID},
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"--latest and --version are mutually exclusive"},
+		},
+		{
+			name:     "maps a stale version to the conflict exit code",
+			args:     []string{"update", "--set", "status=closed", testAccountID},
+			conflict: true,
+			wantCode: cli.ExitConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v1/organisation/accounts/"+testAccountID, r.URL.Path)
+
+				if r.Method == http.MethodGet {
+					w.WriteHeader(http.StatusOK)
+					_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+
+					return
+				}
+
+				assert.Equal(t, http.MethodPatch, r.Method)
+
+				if tt.conflict {
+					w.WriteHeader(http.StatusConflict)
+
+					return
+				}
+
+				var body struct {
+					Data struct {
+						Version    float64         `json:"version"`
+						Attributes json.RawMessage `json:"attributes"`
+					} `json:"data"`
+				}
+
+				assert.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+				assert.Equal(t, tt.wantVersion, body.Data.Version)
+				assert.JSONEq(t, tt.wantAttributes, string(body.Data.Attributes))
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write(readFile(t, "./testdata/payload.json"))
+			}))
+			defer ts.Close()
+
+			setTestEnv(t, ts.URL)
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
@@ -0,0 +1,120 @@
+package cli
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// validation is the outcome of validating a single account, and what validate prints in the json and go-template
+// formats.
+type validation struct {
+	Index    int                 `json:"index"`
+	Country  string              `json:"country"`
+	Valid    bool                `json:"valid"`
+	Findings []client.FieldError `json:"findings"`
+}
+
+// runValidate runs the client side validation rules over the accounts in a file, and prints what is wrong with each
+// one. It never talks to the API, so it needs neither connectivity nor configuration.
+func runValidate(a *app, args []string) error {
+	var (
+		output string
+		file   string
+	)
+
+	fs := a.newFlagSet("validate", "")
+	registerOutputFlag(fs, &output)
+	fs.StringVar(&file, "file", "-", "read the account attributes as JSON from this file, - for stdin. Either a single "+
+		"account, or an array of them, like import reads")
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := newPrinter(a.stdout, output, a.colors(a.stdout))
+	if err != nil {
+		return err
+	}
+
+	accounts, err := a.readAccounts(file)
+	if err != nil {
+		return err
+	}
+
+	results := make([]validation, 0, len(accounts))
+	invalid := 0
+
+	for i, account := range accounts {
+		result := validation{Index: i + 1, Country: account.Country, Valid: true, Findings: []client.FieldError{}}
+
+		var validationErr *client.ValidationError
+		if errors.As(client.ValidateResource(account), &validationErr) {
+			result.Valid = false
+			result.Findings = validationErr.Fields
+			invalid++
+		}
+
+		results = append(results, result)
+	}
+
+	if out.format == outputText {
+		printValidations(out, results)
+	} else {
+		err = out.structured(results)
+		if err != nil {
+			return err
+		}
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d accounts are invalid: %w", invalid, len(accounts), client.ErrValidation)
+	}
+
+	return nil
+}
+
+// readAccounts decodes either a single account or an array of them from a file, or from stdin if the name is -.
+func (a *app) readAccounts(name string) ([]client.Resource, error) {
+	content, err := a.readFile(name)
+	if err != nil {
+		return nil, err
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(content), []byte("{")) {
+		return decodeResources(name, content)
+	}
+
+	account, err := decodeResource(name, content)
+	if err != nil {
+		return nil, err
+	}
+
+	return []client.Resource{account}, nil
+}
+
+// printValidations writes one line per account, followed by an indented line for every finding.
+func printValidations(out printer, results []validation) {
+	for _, r := range results {
+		if r.Valid {
+			_, _ = fmt.Fprintf(out.w, "account %d (%s): %s\n", r.Index, r.Country, out.colors.success("valid"))
+
+			continue
+		}
+
+		_, _ = fmt.Fprintf(out.w, "account %d (%s): %s\n", r.Index, r.Country, out.colors.failure("invalid"))
+
+		for _, f := range r.Findings {
+			_, _ = fmt.Fprintf(out.w, "  %s: %s\n", f.Field, f.Message)
+		}
+	}
+}
@@ -0,0 +1,81 @@
+package cli_test
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Validate(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		stdin      string
+		wantCode   int
+		wantStdout []string
+		wantStderr []string
+	}{
+		{
+			name:       "accepts a single valid account from a file",
+			args:       []string{"validate", "--file", "./testdata/resource.json"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{"account 1 (GB): valid\n"},
+		},
+		{
+			name:     "prints every finding of every invalid account in an array",
+			args:     []string{"validate"},
+			stdin:    `[` + importAccount + `, {"country": "GB", "bank_id": "12", "bank_id_code": "GBDSC"}, {"country": "XX"}]`,
+			wantCode: cli.ExitValidation,
+			wantStdout: []string{
+				"account 1 (GB): valid\n",
+				"account 2 (GB): invalid\n  bic: BIC is required, was empty\n" +
+					"  bank_id: GB bank id is not in correct format. '12'\n",
+				"account 3 (XX): invalid\n  country: unsupported country code: XX\n",
+			},
+			wantStderr: []string{"2 of 3 accounts are invalid"},
+		},
+		{
+			name:     "prints findings as json",
+			args:     []string{"validate", "-o", "json"},
+			stdin:    `{"country": "XX"}`,
+			wantCode: cli.ExitValidation,
+			wantStdout: []string{
+				`"valid": false`,
+				`"field": "country"`,
+				`"message": "unsupported country code: XX"`,
+			},
+		},
+		{
+			name:       "fails on malformed JSON",
+			args:       []string{"validate"},
+			stdin:      `[{`,
+			wantCode:   cli.ExitFailure,
+			wantStderr: []string{"decoding -"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Validation is offline, so it has to work without any configuration.
+			os.Clearenv()
+
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(tt.stdin), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+
+			for _, want := range tt.wantStderr {
+				assert.Contains(t, stderr.String(), want)
+			}
+		})
+	}
+}
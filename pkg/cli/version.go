@@ -0,0 +1,100 @@
+package cli
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"text/tabwriter"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// Build metadata stamped by the build target of the Makefile with -ldflags "-X ...". They stay empty in a plain go
+// build.
+//
+//nolint:gochecknoglobals // set with -ldflags at build time
+var (
+	commit    string
+	buildDate string
+)
+
+const unknown = "unknown"
+
+// buildInfo is what version prints, and what support asks for in bug reports.
+type buildInfo struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	BuildDate string `json:"build_date"`
+	GoVersion string `json:"go_version"`
+	Platform  string `json:"platform"`
+}
+
+// currentBuildInfo collects the build metadata. When the binary was not stamped with a version, but was installed with
+// go get or go install at a tagged version, it takes the module version from the build info embedded by the toolchain.
+func currentBuildInfo() buildInfo {
+	info := buildInfo{
+		Version:   client.Version,
+		Commit:    defaultString(commit, unknown),
+		BuildDate: defaultString(buildDate, unknown),
+		GoVersion: runtime.Version(),
+		Platform:  runtime.GOOS + "/" + runtime.GOARCH,
+	}
+
+	if bi, ok := debug.ReadBuildInfo(); ok && info.Version == "dev" {
+		if v := bi.Main.Version; v != "" && v != "(devel)" {
+			info.Version = v
+		}
+	}
+
+	return info
+}
+
+// runVersion prints the version of the library, the commit and date it was built from, and the Go version it was built
+// with.
+func runVersion(a *app, args []string) error {
+	var output string
+
+	fs := a.newFlagSet("version", "")
+	registerOutputFlag(fs, &output)
+
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return err
+	}
+
+	if len(positional) != 0 {
+		fs.Usage()
+
+		return errArguments
+	}
+
+	out, err := newPrinter(a.stdout, output, a.colors(a.stdout))
+	if err != nil {
+		return err
+	}
+
+	info := currentBuildInfo()
+
+	if out.format != outputText {
+		return out.structured(info)
+	}
+
+	tw := tabwriter.NewWriter(a.stdout, tabMinWidth, tabWidth, tabPadding, ' ', 0)
+
+	for _, line := range [][2]string{
+		{"Version", info.Version},
+		{"Commit", info.Commit},
+		{"Built", info.BuildDate},
+		{"Go version", info.GoVersion},
+		{"Platform", info.Platform},
+	} {
+		_, _ = fmt.Fprintf(tw, "%s:\t%s\n", line[0], line[1])
+	}
+
+	err = tw.Flush()
+	if err != nil {
+		return fmt.Errorf("runVersion: %w", err)
+	}
+
+	return nil
+}
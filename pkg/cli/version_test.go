@@ -0,0 +1,74 @@
+package cli_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/cli"
+)
+
+func TestRun_Version(t *testing.T) {
+	tests := []struct {
+		name       string
+		args       []string
+		wantCode   int
+		wantStdout []string
+	}{
+		{
+			name:     "prints build information as text",
+			args:     []string{"version"},
+			wantCode: cli.ExitOK,
+			wantStdout: []string{
+				"Version:     dev\n",
+				"Commit:      unknown\n",
+				"Built:       unknown\n",
+				"Go version:  " + runtime.Version() + "\n",
+				"Platform:    " + runtime.GOOS + "/" + runtime.GOARCH + "\n",
+			},
+		},
+		{
+			name:       "executes a template",
+			args:       []string{"version", "-o", "go-template={{.GoVersion}}"},
+			wantCode:   cli.ExitOK,
+			wantStdout: []string{runtime.Version()},
+		},
+		{
+			name:     "fails on arguments",
+			args:     []string{"version", "extra"},
+			wantCode: cli.ExitFailure,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stdout, stderr bytes.Buffer
+
+			code := cli.Run(tt.args, strings.NewReader(""), &stdout, &stderr)
+
+			assert.Equal(t, tt.wantCode, code, "stderr: %s", stderr.String())
+
+			for _, want := range tt.wantStdout {
+				assert.Contains(t, stdout.String(), want)
+			}
+		})
+	}
+}
+
+func TestRun_VersionJSON(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+
+	code := cli.Run([]string{"version", "--output", "json"}, strings.NewReader(""), &stdout, &stderr)
+	assert.Equal(t, cli.ExitOK, code)
+
+	var got map[string]string
+
+	assert.NoError(t, json.Unmarshal(stdout.Bytes(), &got))
+	assert.Equal(t, "dev", got["version"])
+	assert.Equal(t, runtime.Version(), got["go_version"])
+	assert.Contains(t, got, "commit")
+	assert.Contains(t, got, "build_date")
+}
@@ -0,0 +1,391 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// defaultApplyPageSize is the page size Apply lists the accounts on the service with, unless ApplyOptions says
+// otherwise.
+const defaultApplyPageSize = 100
+
+// The actions of an ApplyOutcome.
+const (
+	ActionCreate = "create"
+	ActionUpdate = "update"
+	ActionDelete = "delete"
+)
+
+// DesiredAccount is an account as it should be on the service, for Apply and PlanApply.
+type DesiredAccount struct {
+	ID         string
+	Attributes Resource
+
+	// Fields are the JSON names of the attributes that are managed, like bank_id or name. The rest are left the way
+	// the service has them. Nil means every attribute the Attributes encode, except the status while it is empty, as
+	// the service sets that. An empty, non-nil slice manages none, so the account only has to exist.
+	Fields []string
+}
+
+// ApplyOptions are the options of Apply and PlanApply.
+type ApplyOptions struct {
+	// Prune deletes the accounts on the service that aren't desired. Without it, they are left alone.
+	Prune bool
+
+	// DryRun only plans the changes, and makes none of them.
+	DryRun bool
+
+	// PageSize is the number of accounts per page Apply lists the accounts on the service with, 100 if it is 0.
+	PageSize uint
+}
+
+// Plan is what Apply changes to make the accounts on the service the desired ones, each list in the order of the
+// desired accounts, or of the service for deletes.
+type Plan struct {
+	// Create are the desired accounts the service doesn't have.
+	Create []Data `json:"create"`
+
+	// Update are the accounts whose managed attributes differ.
+	Update []AccountDrift `json:"update"`
+
+	// Delete are the accounts on the service that aren't desired, if ApplyOptions.Prune is set.
+	Delete []Data `json:"delete"`
+}
+
+// Len returns the number of changes in the Plan.
+func (p Plan) Len() int {
+	return len(p.Create) + len(p.Update) + len(p.Delete)
+}
+
+// AccountDrift is an account whose managed attributes differ from the desired ones. Changes are the values that
+// differ, with their path under the attributes, like bank_id or name[1], From the value on the service, and To the
+// desired one. Attributes are the managed attributes that differ, with their desired values, which is what the update
+// sends, at Version. VersionKnown works like the one of Data, and the version is encoded as null if it is not set.
+type AccountDrift struct {
+	ID           string                 `json:"id"`
+	Version      int                    `json:"version"`
+	VersionKnown bool                   `json:"-"`
+	Changes      []Change               `json:"changes"`
+	Attributes   map[string]interface{} `json:"attributes"`
+}
+
+// accountDriftJSON is AccountDrift without its methods, so they can encode and decode it.
+type accountDriftJSON AccountDrift
+
+// CurrentVersion returns the version of the account, and whether it is known.
+func (d AccountDrift) CurrentVersion() (uint, bool) {
+	return uint(d.Version), d.VersionKnown
+}
+
+// MarshalJSON encodes the AccountDrift, with a version of null if it is not known.
+func (d AccountDrift) MarshalJSON() ([]byte, error) {
+	aux := struct {
+		accountDriftJSON
+		Version *int `json:"version"`
+	}{accountDriftJSON: accountDriftJSON(d)}
+
+	if d.VersionKnown {
+		aux.Version = &d.Version
+	}
+
+	return json.Marshal(aux)
+}
+
+// UnmarshalJSON decodes an AccountDrift, and sets VersionKnown if the JSON has a version that is not null.
+func (d *AccountDrift) UnmarshalJSON(b []byte) error {
+	aux := struct {
+		*accountDriftJSON
+		Version *int `json:"version"`
+	}{accountDriftJSON: (*accountDriftJSON)(d)}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	d.Version, d.VersionKnown = 0, aux.Version != nil
+	if aux.Version != nil {
+		d.Version = *aux.Version
+	}
+
+	return nil
+}
+
+// ApplyResult is the Plan of an Apply, and the outcome of every change it made, in the order it made them: creates,
+// then updates, then deletes.
+type ApplyResult struct {
+	Plan     Plan
+	Outcomes []ApplyOutcome
+}
+
+// ApplyOutcome is the outcome of a single change of an Apply. Err is nil if the change was made.
+type ApplyOutcome struct {
+	ID     string
+	Action string
+	Err    error
+}
+
+// Apply makes the accounts on the service the desired ones: it lists every account of the organisation of the Client,
+// creates the desired accounts that are missing with their IDs, updates the managed attributes of the ones that
+// drifted at their current version, and, with ApplyOptions.Prune, deletes the ones that aren't desired. It is Plan
+// followed by ApplyPlan, so it works the way they do. With ApplyOptions.DryRun, the result only has the Plan.
+func (c Client) Apply(ctx context.Context, desired []DesiredAccount, opts ApplyOptions) (ApplyResult, error) {
+	plan, err := c.plan(ctx, desired, opts)
+	if err != nil {
+		return ApplyResult{}, fmt.Errorf("client.Apply: %w", err)
+	}
+
+	if opts.DryRun {
+		return ApplyResult{Plan: plan, Outcomes: []ApplyOutcome{}}, nil
+	}
+
+	result, err := c.applyPlan(ctx, plan)
+	if err != nil {
+		return result, fmt.Errorf("client.Apply: %w", err)
+	}
+
+	return result, nil
+}
+
+// Plan lists every account of the organisation of the Client, and returns the changes Apply would make to them to get
+// to the desired ones, with the values of every attribute it would change, without making any of them, so they can be
+// reviewed, and then made with ApplyPlan. It fails if an ID is desired more than once.
+func (c Client) Plan(ctx context.Context, desired []DesiredAccount, opts ApplyOptions) (Plan, error) {
+	plan, err := c.plan(ctx, desired, opts)
+	if err != nil {
+		return Plan{}, fmt.Errorf("client.Plan: %w", err)
+	}
+
+	return plan, nil
+}
+
+// plan lists every account, and returns the Plan of an Apply onto the ones of the organisation of the Client. The list
+// endpoint returns the accounts of every organisation, and the others are never the Client's to update or prune. The
+// callers wrap its errors.
+func (c Client) plan(ctx context.Context, desired []DesiredAccount, opts ApplyOptions) (Plan, error) {
+	pageSize := opts.PageSize
+	if pageSize == 0 {
+		pageSize = defaultApplyPageSize
+	}
+
+	listed, err := c.WithContext(ctx).ListAll(pageSize)
+	if err != nil {
+		return Plan{}, err
+	}
+
+	actual := make([]Data, 0, len(listed))
+
+	for _, d := range listed {
+		if d.OrganisationID == c.OrganisationID {
+			actual = append(actual, d)
+		}
+	}
+
+	return planApply(desired, actual, opts)
+}
+
+// ApplyPlan makes the changes of plan, like one Plan returned and someone approved, exactly as they are, and nothing
+// else: creates, then updates, then deletes. Updates and deletes are made at the version in the plan, so an account
+// that changed since fails with an error that matches ErrConflict, rather than having a change made to it that no one
+// reviewed. The changes are made as batches, with as many requests in flight as the Client's concurrency allows, and it
+// carries on past changes that fail. If any did, it returns an error that wraps all of them, along with the result.
+func (c Client) ApplyPlan(ctx context.Context, plan Plan) (ApplyResult, error) {
+	result, err := c.applyPlan(ctx, plan)
+	if err != nil {
+		return result, fmt.Errorf("client.ApplyPlan: %w", err)
+	}
+
+	return result, nil
+}
+
+// applyPlan makes the changes of plan. The callers wrap its errors.
+func (c Client) applyPlan(ctx context.Context, plan Plan) (ApplyResult, error) {
+	c = c.WithContext(ctx)
+	result := ApplyResult{Plan: plan, Outcomes: make([]ApplyOutcome, 0, plan.Len())}
+
+	result.Outcomes = append(result.Outcomes, c.applyCreates(plan.Create)...)
+	result.Outcomes = append(result.Outcomes, c.applyUpdates(plan.Update)...)
+	result.Outcomes = append(result.Outcomes, c.applyDeletes(plan.Delete)...)
+
+	errs := make([]error, 0)
+
+	for _, o := range result.Outcomes {
+		if o.Err != nil {
+			errs = append(errs, o.Err)
+		}
+	}
+
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%d of %d changes failed: %w", len(errs), plan.Len(), errors.Join(errs...))
+	}
+
+	return result, nil
+}
+
+// PlanApply returns the Plan of an Apply of desired onto the accounts in actual, like the ones of ListAll, matching
+// them by ID, without sending anything. It fails if an ID is desired more than once.
+func PlanApply(desired []DesiredAccount, actual []Data, opts ApplyOptions) (Plan, error) {
+	plan, err := planApply(desired, actual, opts)
+	if err != nil {
+		return Plan{}, fmt.Errorf("client.PlanApply: %w", err)
+	}
+
+	return plan, nil
+}
+
+// planApply returns the Plan of an Apply. The callers wrap its errors.
+func planApply(desired []DesiredAccount, actual []Data, opts ApplyOptions) (Plan, error) {
+	plan := Plan{Create: []Data{}, Update: []AccountDrift{}, Delete: []Data{}}
+	byID := make(map[string]Data, len(actual))
+
+	for _, a := range actual {
+		byID[a.ID] = a
+	}
+
+	wanted := make(map[string]bool, len(desired))
+
+	for _, want := range desired {
+		if wanted[want.ID] {
+			return Plan{}, fmt.Errorf("account %s is desired more than once", want.ID)
+		}
+
+		wanted[want.ID] = true
+
+		got, ok := byID[want.ID]
+		if !ok {
+			plan.Create = append(plan.Create, Data{ID: want.ID, Attributes: want.Attributes})
+
+			continue
+		}
+
+		if drift := driftOf(want, got); len(drift.Changes) > 0 {
+			plan.Update = append(plan.Update, drift)
+		}
+	}
+
+	if !opts.Prune {
+		return plan, nil
+	}
+
+	for _, a := range actual {
+		if !wanted[a.ID] {
+			plan.Delete = append(plan.Delete, a)
+		}
+	}
+
+	return plan, nil
+}
+
+// driftOf compares the managed attributes of the desired account with the ones of got through Diff. Both sides go
+// through the JSON encoding of Resource, which leaves out trailing empty lines, so a name of one line equals the same
+// name padded to four lines, and a line the desired account leaves out is wanted as null. Attributes that are lists
+// are compared line by line, like name[1], but updated whole.
+func driftOf(want DesiredAccount, got Data) AccountDrift {
+	desiredAttributes := attributeValues(want.Attributes)
+	managed := managedFields(want, desiredAttributes)
+
+	drift := AccountDrift{
+		ID:           got.ID,
+		Version:      got.Version,
+		VersionKnown: got.VersionKnown,
+		Changes:      []Change{},
+		Attributes:   map[string]interface{}{},
+	}
+	changes := Diff(Payload{Data: Data{Attributes: got.Attributes}}, Payload{Data: Data{Attributes: want.Attributes}})
+
+	for _, c := range changes {
+		path := strings.TrimPrefix(c.Path, "data.attributes.")
+		field := strings.SplitN(path, "[", 2)[0]
+
+		if !managed[field] {
+			continue
+		}
+
+		c.Path = path
+		drift.Changes = append(drift.Changes, c)
+		drift.Attributes[field] = desiredAttributes[field]
+	}
+
+	return drift
+}
+
+// attributeValues returns the attributes of r as encoding/json decodes their encoding, by their JSON name.
+func attributeValues(r Resource) map[string]interface{} {
+	content, _ := json.Marshal(r)
+
+	var values map[string]interface{}
+
+	_ = json.Unmarshal(content, &values)
+
+	return values
+}
+
+// managedFields returns the attributes of want that are managed, out of the ones its Attributes encode into.
+func managedFields(want DesiredAccount, encoded map[string]interface{}) map[string]bool {
+	managed := make(map[string]bool, len(encoded))
+
+	if want.Fields != nil {
+		for _, f := range want.Fields {
+			managed[f] = true
+		}
+
+		return managed
+	}
+
+	for f := range encoded {
+		managed[f] = true
+	}
+
+	if want.Attributes.Status == "" {
+		delete(managed, fieldStatus)
+	}
+
+	return managed
+}
+
+// applyCreates creates the accounts of the Plan, and returns their outcomes in order.
+func (c Client) applyCreates(accounts []Data) []ApplyOutcome {
+	outcomes := make([]ApplyOutcome, len(accounts))
+
+	c.CreateBatchWithIDs(accounts, func(i int, _ Payload, err error) {
+		outcomes[i] = ApplyOutcome{ID: accounts[i].ID, Action: ActionCreate, Err: err}
+	})
+
+	return outcomes
+}
+
+// applyUpdates updates the drifted attributes of the accounts of the Plan, and returns their outcomes in order. An
+// account whose version is not known fails with an error that matches ErrVersionUnknown, without a request.
+func (c Client) applyUpdates(drift []AccountDrift) []ApplyOutcome {
+	outcomes := make([]ApplyOutcome, len(drift))
+
+	c.runBatch(len(drift), func(i int) {
+		err := fmt.Errorf("client.ApplyPlan: account %s: %w", drift[i].ID, ErrVersionUnknown)
+
+		if version, known := drift[i].CurrentVersion(); known {
+			_, err = c.Update(drift[i].ID, version, drift[i].Attributes)
+		}
+
+		outcomes[i] = ApplyOutcome{ID: drift[i].ID, Action: ActionUpdate, Err: err}
+	})
+
+	return outcomes
+}
+
+// applyDeletes deletes the accounts of the Plan, and returns their outcomes in order.
+func (c Client) applyDeletes(accounts []Data) []ApplyOutcome {
+	index := make(map[string]int, len(accounts))
+	outcomes := make([]ApplyOutcome, len(accounts))
+
+	for i, d := range accounts {
+		index[d.ID] = i
+	}
+
+	c.DeleteBatch(accounts, func(d Data, err error) {
+		outcomes[index[d.ID]] = ApplyOutcome{ID: d.ID, Action: ActionDelete, Err: err}
+	})
+
+	return outcomes
+}
@@ -0,0 +1,384 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestPlanApply(t *testing.T) {
+	onServer := client.Data{ID: "on-server", Version: 3, Attributes: client.Resource{
+		Country: "GB",
+		BankID:  "400300",
+		Name:    [4]string{"Samantha Holder", "Trading as Sam"},
+		Status:  client.StatusConfirmed,
+	}}
+	extra := client.Data{ID: "extra", Attributes: client.Resource{Country: "FR"}}
+
+	tests := []struct {
+		name    string
+		desired []client.DesiredAccount
+		opts    client.ApplyOptions
+		want    client.Plan
+		wantErr bool
+	}{
+		{
+			name: "creates the missing, and leaves the extra without Prune",
+			desired: []client.DesiredAccount{
+				{ID: "on-server", Fields: []string{}},
+				{ID: "missing", Attributes: client.Resource{Country: "DE"}},
+			},
+			want: client.Plan{
+				Create: []client.Data{{ID: "missing", Attributes: client.Resource{Country: "DE"}}},
+				Update: []client.AccountDrift{},
+				Delete: []client.Data{},
+			},
+		},
+		{
+			name:    "deletes the extra with Prune",
+			desired: []client.DesiredAccount{{ID: "on-server", Fields: []string{}}},
+			opts:    client.ApplyOptions{Prune: true},
+			want: client.Plan{
+				Create: []client.Data{},
+				Update: []client.AccountDrift{},
+				Delete: []client.Data{extra},
+			},
+		},
+		{
+			name: "nil Fields manage every attribute but an empty status",
+			desired: []client.DesiredAccount{{ID: "on-server", Attributes: client.Resource{
+				Country: "GB",
+				BankID:  "400300",
+				Name:    [4]string{"Samantha Holder"},
+			}}},
+			want: client.Plan{
+				Create: []client.Data{},
+				Update: []client.AccountDrift{{ID: "on-server", Version: 3, Changes: []client.Change{
+					{Path: "name[1]", From: "Trading as Sam", To: nil},
+				}, Attributes: map[string]interface{}{"name": []interface{}{"Samantha Holder"}}}},
+				Delete: []client.Data{},
+			},
+		},
+		{
+			name: "Fields manage only the ones they name",
+			desired: []client.DesiredAccount{{
+				ID:         "on-server",
+				Attributes: client.Resource{BankID: "123456", BIC: "NWBKGB22"},
+				Fields:     []string{"bank_id", "iban"},
+			}},
+			want: client.Plan{
+				Create: []client.Data{},
+				Update: []client.AccountDrift{{ID: "on-server", Version: 3, Changes: []client.Change{
+					{Path: "bank_id", From: "400300", To: "123456"},
+				}, Attributes: map[string]interface{}{"bank_id": "123456"}}},
+				Delete: []client.Data{},
+			},
+		},
+		{
+			name: "an account desired twice",
+			desired: []client.DesiredAccount{
+				{ID: "missing", Attributes: client.Resource{Country: "DE"}},
+				{ID: "missing", Attributes: client.Resource{Country: "FR"}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.PlanApply(tt.desired, []client.Data{onServer, extra}, tt.opts)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestClient_Apply(t *testing.T) {
+	const (
+		driftedID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+		missingID = "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0"
+	)
+
+	drifted := client.Data{ID: driftedID, Version: 2, Attributes: fixtures.ValidGB()}
+	extra := client.Data{ID: "ffa7706b-d8fc-40b2-be6b-67d2a628cadf", Version: 1, Attributes: fixtures.ValidGB()}
+
+	want := drifted.Attributes
+	want.BankID = "123456"
+
+	desired := []client.DesiredAccount{
+		{ID: driftedID, Attributes: want},
+		{ID: missingID, Attributes: fixtures.ValidGB()},
+	}
+
+	tests := []struct {
+		name         string
+		opts         client.ApplyOptions
+		updateStatus int
+		wantRequests []string
+		wantActions  []string
+		wantErr      bool
+	}{
+		{
+			name: "creates and updates",
+			wantRequests: []string{
+				"GET /v1/organisation/accounts",
+				"POST /v1/organisation/accounts",
+				"PATCH /v1/organisation/accounts/" + driftedID,
+			},
+			wantActions: []string{client.ActionCreate, client.ActionUpdate},
+		},
+		{
+			name: "deletes with Prune",
+			opts: client.ApplyOptions{Prune: true},
+			wantRequests: []string{
+				"GET /v1/organisation/accounts",
+				"POST /v1/organisation/accounts",
+				"PATCH /v1/organisation/accounts/" + driftedID,
+				"DELETE /v1/organisation/accounts/" + extra.ID,
+			},
+			wantActions: []string{client.ActionCreate, client.ActionUpdate, client.ActionDelete},
+		},
+		{
+			name:         "only plans with DryRun",
+			opts:         client.ApplyOptions{Prune: true, DryRun: true},
+			wantRequests: []string{"GET /v1/organisation/accounts"},
+			wantActions:  []string{},
+		},
+		{
+			name:         "carries on past a change that fails",
+			updateStatus: http.StatusConflict,
+			wantRequests: []string{
+				"GET /v1/organisation/accounts",
+				"POST /v1/organisation/accounts",
+				"PATCH /v1/organisation/accounts/" + driftedID,
+			},
+			wantActions: []string{client.ActionCreate, client.ActionUpdate},
+			wantErr:     true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu       sync.Mutex
+				requests []string
+				update   map[string]interface{}
+			)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				requests = append(requests, r.Method+" "+r.URL.Path)
+				mu.Unlock()
+
+				switch r.Method {
+				case http.MethodGet:
+					b, _ := json.Marshal(client.MultiPayload{Data: []client.Data{drifted, extra}})
+					_, _ = w.Write(b)
+				case http.MethodPost:
+					w.WriteHeader(http.StatusCreated)
+					_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+				case http.MethodPatch:
+					var body struct {
+						Data struct {
+							Version    int                    `json:"version"`
+							Attributes map[string]interface{} `json:"attributes"`
+						} `json:"data"`
+					}
+
+					b, _ := ioutil.ReadAll(r.Body)
+					assert.NoError(t, json.Unmarshal(b, &body))
+					assert.Equal(t, drifted.Version, body.Data.Version)
+
+					update = body.Data.Attributes
+
+					if tt.updateStatus != 0 {
+						w.WriteHeader(tt.updateStatus)
+
+						return
+					}
+
+					_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+				case http.MethodDelete:
+					assert.Equal(t, "1", r.URL.Query().Get("version"))
+					w.WriteHeader(http.StatusNoContent)
+				}
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+
+			got, err := c.Apply(context.Background(), desired, tt.opts)
+
+			assert.Equal(t, tt.wantRequests, requests)
+
+			actions := make([]string, 0, len(got.Outcomes))
+			for _, o := range got.Outcomes {
+				actions = append(actions, o.Action)
+			}
+
+			assert.Equal(t, tt.wantActions, actions)
+
+			if update != nil {
+				assert.Equal(t, map[string]interface{}{"bank_id": "123456"}, update, "only the drifted attributes are sent")
+			}
+
+			if tt.wantErr {
+				assert.True(t, errors.Is(err, client.ErrConflict), "got %v", err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_Plan_ApplyPlan(t *testing.T) {
+	const driftedID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	drifted := client.Data{ID: driftedID, Version: 2, Attributes: fixtures.ValidGB()}
+
+	want := drifted.Attributes
+	want.BankID = "123456"
+
+	var (
+		mu       sync.Mutex
+		requests []string
+		update   map[string]interface{}
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		mu.Unlock()
+
+		switch r.Method {
+		case http.MethodGet:
+			b, _ := json.Marshal(client.MultiPayload{Data: []client.Data{drifted}})
+			_, _ = w.Write(b)
+		case http.MethodPatch:
+			var body struct {
+				Data struct {
+					Attributes map[string]interface{} `json:"attributes"`
+				} `json:"data"`
+			}
+
+			b, _ := ioutil.ReadAll(r.Body)
+			assert.NoError(t, json.Unmarshal(b, &body))
+
+			update = body.Data.Attributes
+
+			_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+		}
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+
+	plan, err := c.Plan(context.Background(), []client.DesiredAccount{{ID: driftedID, Attributes: want}},
+		client.ApplyOptions{})
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"GET /v1/organisation/accounts"}, requests, "planning changes nothing")
+
+	if assert.Len(t, plan.Update, 1) {
+		assert.Equal(t, []client.Change{{Path: "bank_id", From: drifted.Attributes.BankID, To: "123456"}},
+			plan.Update[0].Changes)
+	}
+
+	// A plan is reviewed as JSON, and approved as it is.
+	content, err := json.Marshal(plan)
+	assert.NoError(t, err)
+
+	var approved client.Plan
+
+	assert.NoError(t, json.Unmarshal(content, &approved))
+
+	result, err := c.ApplyPlan(context.Background(), approved)
+	assert.NoError(t, err)
+	assert.Equal(t, []client.ApplyOutcome{{ID: driftedID, Action: client.ActionUpdate}}, result.Outcomes)
+	assert.Equal(t, []string{"GET /v1/organisation/accounts", "PATCH /v1/organisation/accounts/" + driftedID}, requests)
+	assert.Equal(t, map[string]interface{}{"bank_id": "123456"}, update)
+}
+
+func TestClient_ApplyPlan_versionUnknown(t *testing.T) {
+	var requests int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+
+	var plan client.Plan
+
+	assert.NoError(t, json.Unmarshal([]byte(`{
+		"create": [],
+		"update": [{"id": "drifted", "version": null, "changes": [], "attributes": {"bank_id": "123456"}}],
+		"delete": [{"id": "extra", "type": "accounts"}]
+	}`), &plan))
+
+	if assert.Len(t, plan.Update, 1) {
+		assert.False(t, plan.Update[0].VersionKnown)
+
+		content, err := json.Marshal(plan.Update[0])
+		assert.NoError(t, err)
+		assert.Contains(t, string(content), `"version":null`)
+	}
+
+	result, err := c.ApplyPlan(context.Background(), plan)
+	assert.Error(t, err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&requests), "nothing is sent at a version that is not known")
+
+	if assert.Len(t, result.Outcomes, 2) {
+		for _, o := range result.Outcomes {
+			assert.True(t, errors.Is(o.Err, client.ErrVersionUnknown), "%s %s: got %v", o.Action, o.ID, o.Err)
+		}
+	}
+}
+
+func TestClient_Plan_otherOrganisations(t *testing.T) {
+	const organisationID = "7442ea6b-164a-4818-b470-d98abfbc24ae"
+
+	own := client.Data{ID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b", OrganisationID: organisationID, Version: 1,
+		Attributes: fixtures.ValidGB()}
+	foreign := client.Data{ID: "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0", OrganisationID: "someone-else", Version: 1,
+		Attributes: fixtures.ValidGB()}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		b, _ := json.Marshal(client.MultiPayload{Data: []client.Data{own, foreign}})
+		_, _ = w.Write(b)
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL, OrganisationID: organisationID}
+
+	want := foreign.Attributes
+	want.BankID = "123456"
+
+	plan, err := c.Plan(context.Background(), []client.DesiredAccount{{ID: foreign.ID, Attributes: want}},
+		client.ApplyOptions{Prune: true})
+	assert.NoError(t, err)
+
+	assert.Equal(t, []string{own.ID}, client.MultiPayload{Data: plan.Delete}.IDs(),
+		"the account of another organisation is never pruned")
+	assert.Empty(t, plan.Update, "nor updated")
+	assert.Equal(t, []string{foreign.ID}, client.MultiPayload{Data: plan.Create}.IDs(),
+		"it isn't the organisation's, so it is created, which the service refuses")
+}
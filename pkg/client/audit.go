@@ -0,0 +1,64 @@
+package client
+
+import "time"
+
+const (
+	auditCreate = "create"
+	auditUpdate = "update"
+	auditDelete = "delete"
+
+	// AuditSuccess is the Outcome of an AuditRecord of an operation that changed the account.
+	AuditSuccess = "success"
+
+	// AuditFailure is the Outcome of an AuditRecord of an operation that failed, Err tells why.
+	AuditFailure = "failure"
+)
+
+// AuditSink receives an AuditRecord for every operation that changes an account, for compliance pipelines. Record is
+// called once the operation finished, on the goroutine that ran it, so it has to be safe to call from several
+// goroutines at once when the Client is used concurrently, like by CreateBatch.
+type AuditSink interface {
+	Record(r AuditRecord)
+}
+
+// AuditSinkFunc adapts a function to AuditSink.
+type AuditSinkFunc func(r AuditRecord)
+
+// Record calls f.
+func (f AuditSinkFunc) Record(r AuditRecord) {
+	f(r)
+}
+
+// AuditRecord describes a single create, update, or delete of an account: which organisation changed which account
+// at what version, when, and whether it worked. Version is 0 for creates.
+type AuditRecord struct {
+	Time           time.Time
+	Operation      string
+	OrganisationID string
+	AccountID      string
+	Version        uint
+	Outcome        string
+	Err            error
+}
+
+// audit sends the record of an operation to the AuditSink, if there is one.
+func (c Client) audit(operation, accountID string, version uint, err error) {
+	if c.audits == nil {
+		return
+	}
+
+	outcome := AuditSuccess
+	if err != nil {
+		outcome = AuditFailure
+	}
+
+	c.audits.Record(AuditRecord{
+		Time:           c.now().UTC(),
+		Operation:      operation,
+		OrganisationID: c.OrganisationID,
+		AccountID:      accountID,
+		Version:        version,
+		Outcome:        outcome,
+		Err:            err,
+	})
+}
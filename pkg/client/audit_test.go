@@ -0,0 +1,83 @@
+package client_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestWithAuditSink(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+		case http.MethodPatch:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusConflict)
+		default:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+		}
+	}))
+	defer ts.Close()
+
+	const accountID = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+
+	var records []client.AuditRecord
+
+	c := client.Client{BaseURL: ts.URL, OrganisationID: "orgid"}
+	client.WithAuditSink(client.AuditSinkFunc(func(r client.AuditRecord) {
+		records = append(records, r)
+	}))(&c)
+
+	start := time.Now()
+
+	_, err := c.CreateWithID(accountID, fixtures.ValidGB())
+	assert.NoError(t, err)
+
+	_, err = c.Create(client.Resource{Country: "GB"})
+	assert.Error(t, err)
+
+	_, err = c.Update(accountID, 0, map[string]interface{}{"name": []string{"Jane"}})
+	assert.NoError(t, err)
+
+	assert.Error(t, c.Delete(accountID, 1))
+
+	_, err = c.Fetch(accountID)
+	assert.NoError(t, err)
+
+	if !assert.Len(t, records, 4, "fetches are not recorded") {
+		return
+	}
+
+	for i, r := range records {
+		assert.False(t, r.Time.Before(start.UTC().Truncate(time.Second)))
+		assert.Equal(t, time.UTC, r.Time.Location())
+
+		records[i].Time = time.Time{}
+	}
+
+	assert.True(t, errors.Is(records[1].Err, client.ErrValidation))
+	assert.True(t, errors.Is(records[3].Err, client.ErrConflict))
+
+	assert.NotEmpty(t, records[1].AccountID)
+
+	records[1].AccountID, records[1].Err, records[3].Err = "", nil, nil
+
+	assert.Equal(t, []client.AuditRecord{
+		{Operation: "create", OrganisationID: "orgid", AccountID: accountID, Outcome: client.AuditSuccess},
+		{Operation: "create", OrganisationID: "orgid", Outcome: client.AuditFailure},
+		{Operation: "update", OrganisationID: "orgid", AccountID: accountID, Outcome: client.AuditSuccess},
+		{Operation: "delete", OrganisationID: "orgid", AccountID: accountID, Version: 1, Outcome: client.AuditFailure},
+	}, records)
+}
@@ -0,0 +1,145 @@
+package client
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Defaults of the zero fields of a PageSizeTuning.
+const (
+	DefaultMinPageSize   = 10
+	DefaultMaxPageSize   = 1000
+	DefaultTargetLatency = time.Second
+)
+
+// PageSizeTuning is how ListPagesAuto adjusts the size of the pages it requests. It starts at Initial, doubles the size
+// after a full page that took less than half of TargetLatency, and halves it after a page that took longer than
+// TargetLatency, staying between Min and Max. Latency grows with the size of the response, so this settles on pages
+// as large as the service and network serve in about TargetLatency. Zero fields take the Default values, and a zero
+// Initial starts at Min.
+type PageSizeTuning struct {
+	Initial       uint
+	Min           uint
+	Max           uint
+	TargetLatency time.Duration
+}
+
+// withDefaults fills in the zero fields, and keeps Initial between Min and Max.
+func (t PageSizeTuning) withDefaults() PageSizeTuning {
+	if t.Min == 0 {
+		t.Min = DefaultMinPageSize
+	}
+
+	if t.Max == 0 {
+		t.Max = DefaultMaxPageSize
+	}
+
+	if t.Max < t.Min {
+		t.Max = t.Min
+	}
+
+	if t.TargetLatency <= 0 {
+		t.TargetLatency = DefaultTargetLatency
+	}
+
+	if t.Initial < t.Min {
+		t.Initial = t.Min
+	}
+
+	if t.Initial > t.Max {
+		t.Initial = t.Max
+	}
+
+	return t
+}
+
+// next returns the size to request after a page of size that took latency, and was full or not.
+func (t PageSizeTuning) next(size uint, latency time.Duration, full bool) uint {
+	switch {
+	case latency > t.TargetLatency:
+		size /= 2
+	case full && latency < t.TargetLatency/2:
+		size *= 2
+	}
+
+	if size < t.Min {
+		return t.Min
+	}
+
+	if size > t.Max {
+		return t.Max
+	}
+
+	return size
+}
+
+// ListPagesAuto works like ListPages, but tunes the page size as it goes, as PageSizeTuning describes, instead of using
+// a fixed one, so a full listing runs at the best throughput of every environment without tuning it by hand. If the
+// service rejects a page size with 400 Bad Request, as it does over its limit, the page is requested again at half the
+// size, which also becomes the maximum, down to Min. Pages are requested one at a time whatever the concurrency.
+//
+// The service pages by number, so the first account of a page is its number times its size. A new size is only used if
+// a page of it starts right after the accounts already listed, or else the largest size below it that does, which can
+// be smaller than Min.
+func (c Client) ListPagesAuto(tuning PageSizeTuning, fn func(MultiPayload) error) error {
+	c, cancel := c.startOperation()
+	defer cancel()
+
+	t := tuning.withDefaults()
+	size := t.Initial
+
+	for offset := uint(0); ; {
+		size = alignedSize(offset, size)
+
+		start := time.Now()
+		mp, err := c.list(nil, offset/size, size)
+		latency := time.Since(start)
+
+		var apiErr *APIError
+
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusBadRequest && size > t.Min {
+			t.Max = size / 2
+			if t.Max < t.Min {
+				t.Max = t.Min
+			}
+
+			size = t.Max
+
+			continue
+		}
+
+		if err != nil {
+			return fmt.Errorf("client.ListPagesAuto: page %d of %d: %w", offset/size, size, err)
+		}
+
+		if len(mp.Data) == 0 {
+			return nil
+		}
+
+		err = fn(mp)
+		if err != nil {
+			return fmt.Errorf("client.ListPagesAuto: page %d of %d: %w", offset/size, size, err)
+		}
+
+		full := uint(len(mp.Data)) == size
+		if !full || mp.Links.Next == "" {
+			return nil
+		}
+
+		offset += size
+		size = t.next(size, latency, full)
+	}
+}
+
+// alignedSize returns the largest page size up to want that starts a page at offset, which is one that divides it.
+func alignedSize(offset, want uint) uint {
+	for size := want; size > 1; size-- {
+		if offset%size == 0 {
+			return size
+		}
+	}
+
+	return 1
+}
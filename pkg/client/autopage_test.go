@@ -0,0 +1,105 @@
+package client_test
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestClient_ListPagesAuto(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   int
+		limit     int
+		tuning    client.PageSizeTuning
+		wantSizes []string
+		wantErr   string
+	}{
+		{
+			name:      "doubles the size of fast pages up to the maximum",
+			records:   200,
+			tuning:    client.PageSizeTuning{Initial: 10, Max: 40, TargetLatency: time.Hour},
+			wantSizes: []string{"0/10", "1/10", "1/20", "1/40", "2/40", "3/40", "4/40"},
+		},
+		{
+			name:      "halves the size of slow pages down to the minimum",
+			records:   50,
+			tuning:    client.PageSizeTuning{Initial: 20, Min: 5, TargetLatency: time.Nanosecond},
+			wantSizes: []string{"0/20", "2/10", "6/5", "7/5", "8/5", "9/5"},
+		},
+		{
+			name:      "halves the size the service rejects, and keeps it as the maximum",
+			records:   100,
+			limit:     30,
+			tuning:    client.PageSizeTuning{Initial: 40, Min: 5, TargetLatency: time.Hour},
+			wantSizes: []string{"0/40", "0/20", "1/20", "2/20", "3/20", "4/20"},
+		},
+		{
+			name:      "fails when the minimum is rejected",
+			records:   100,
+			limit:     4,
+			tuning:    client.PageSizeTuning{Initial: 10, Min: 5, TargetLatency: time.Hour},
+			wantSizes: []string{"0/10", "0/5"},
+			wantErr:   "client.ListPagesAuto: page 0 of 5: unexpected response code: 400",
+		},
+		{
+			name:      "makes a single request when there are no records",
+			tuning:    client.PageSizeTuning{},
+			wantSizes: []string{"0/10"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotSizes := make([]string, 0)
+			paging := pagingHandler(t, tt.records, "", "", new([]string))
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				size, _ := strconv.Atoi(r.URL.Query().Get("page[size]"))
+				gotSizes = append(gotSizes, r.URL.Query().Get("page[number]")+"/"+strconv.Itoa(size))
+
+				if tt.limit > 0 && size > tt.limit {
+					w.WriteHeader(http.StatusBadRequest)
+
+					return
+				}
+
+				paging(w, r)
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			got := make([]string, 0)
+
+			err := c.ListPagesAuto(tt.tuning, func(mp client.MultiPayload) error {
+				for _, d := range mp.Data {
+					got = append(got, d.ID)
+				}
+
+				return nil
+			})
+
+			assert.Equal(t, tt.wantSizes, gotSizes)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			if assert.Len(t, got, tt.records) {
+				for i, id := range got {
+					assert.Equal(t, fmt.Sprintf("account-%d", i), id)
+				}
+			}
+		})
+	}
+}
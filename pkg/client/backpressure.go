@@ -0,0 +1,169 @@
+package client
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// minWindow is the smallest window backpressure shrinks to. Below 1, requests go one at a time, and each waits for
+	// part of a round trip before it starts, so a window of 0.25 starts one request every four round trips.
+	minWindow = 1.0 / 16
+	// latencySmoothing is how much of the difference to the latest latency the smoothed latency takes in.
+	latencySmoothing = 8
+)
+
+// throttle adapts how many requests the Client has in flight to what the service can take, with the additive increase
+// and multiplicative decrease (AIMD) TCP adapts to congestion with. Its window starts at the concurrency of the Client.
+// Every response that is not a sign of congestion grows it by about one request per round trip, up to the
+// concurrency, and a 429 Too Many Requests, a 503 Service Unavailable, or a latency over the target halves it, down to
+// minWindow. Once it halved the window, it ignores the signs of congestion of the requests that were already in flight,
+// as they were sent before it shrank. It is shared by every copy of the Client it was configured on.
+type throttle struct {
+	target time.Duration
+
+	mu       sync.Mutex
+	window   float64
+	inFlight int
+	holdoff  int
+	latency  time.Duration
+	next     time.Time
+	changed  chan struct{}
+}
+
+// newThrottle returns a throttle that treats latencies over target as congestion, or only the status of responses if
+// target is 0.
+func newThrottle(target time.Duration) *throttle {
+	return &throttle{target: target, changed: make(chan struct{})}
+}
+
+// acquire blocks until the window lets another request start, with at most concurrency of them in flight, or ctx is
+// done. It returns the share of the concurrency the window allows, which the rate limiter scales its rate by, so the
+// two slow down together. Every request it lets through has to be released.
+func (t *throttle) acquire(ctx context.Context, concurrency int) (float64, error) {
+	if t == nil {
+		return 1, nil
+	}
+
+	for {
+		t.mu.Lock()
+
+		w := t.current(concurrency)
+
+		if t.inFlight < int(math.Max(1, w)) {
+			t.inFlight++
+			wait := time.Until(t.next)
+			t.mu.Unlock()
+
+			if err := sleepContext(ctx, wait); err != nil {
+				t.release()
+
+				return 0, err
+			}
+
+			return w / float64(concurrency), nil
+		}
+
+		changed := t.changed
+		t.mu.Unlock()
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+}
+
+// observe adapts the window to the outcome of a request that took latency. Requests that got no response leave it as
+// it is.
+func (t *throttle) observe(concurrency int, resp *http.Response, err error, latency time.Duration) {
+	if t == nil || err != nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.latency == 0 {
+		t.latency = latency
+	} else {
+		t.latency += (latency - t.latency) / latencySmoothing
+	}
+
+	w := t.current(concurrency)
+
+	stale := t.holdoff > 0
+	if stale {
+		t.holdoff--
+	}
+
+	switch {
+	case !t.congested(resp, latency):
+		w = math.Min(float64(concurrency), w+math.Min(w, 1/w))
+	case !stale:
+		w = math.Max(minWindow, w/2) //nolint:gomnd
+		t.holdoff = t.inFlight - 1
+	}
+
+	t.window = w
+	t.next = time.Time{}
+
+	if w < 1 {
+		t.next = time.Now().Add(time.Duration(float64(t.latency) * (1/w - 1)))
+	}
+}
+
+// release ends a request acquire let through, and wakes up the ones waiting for it.
+func (t *throttle) release() {
+	if t == nil {
+		return
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.inFlight--
+
+	close(t.changed)
+	t.changed = make(chan struct{})
+}
+
+// current returns the window, which is the concurrency until the first response, and never more than it.
+func (t *throttle) current(concurrency int) float64 {
+	if t.window == 0 || t.window > float64(concurrency) {
+		return float64(concurrency)
+	}
+
+	return t.window
+}
+
+// congested reports whether the response is a sign that the service is overloaded.
+func (t *throttle) congested(resp *http.Response, latency time.Duration) bool {
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests, http.StatusServiceUnavailable:
+		return true
+	}
+
+	return t.target > 0 && latency > t.target
+}
+
+// sleepContext waits for d, or until ctx is done, whichever comes first.
+func sleepContext(ctx context.Context, d time.Duration) error {
+	if d <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
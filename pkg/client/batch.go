@@ -0,0 +1,182 @@
+package client
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// CreateBatch will create every Resource in accounts, keeping as many requests in flight as the Client's concurrency
+// allows. The accounts are validated and encoded in order ahead of the requests, up to one per request in flight, so
+// the next payload is ready as soon as a response arrives, without holding the whole batch encoded. It carries on past
+// accounts that fail, and calls fn with the index, the result and the error of each one as it finishes, so not in
+// order. Calls to fn never overlap, so it does not need to synchronise.
+func (c Client) CreateBatch(accounts []Resource, fn func(index int, p Payload, err error)) {
+	c.createPipeline(len(accounts), "client.Create", func(i int) (string, Resource, error) {
+		id, err := c.generateID()
+		if err != nil {
+			return "", Resource{}, fmt.Errorf("client.Create new uuid: %w", err)
+		}
+
+		return id.String(), accounts[i], nil
+	}, fn)
+}
+
+// CreateBatchWithIDs works like CreateBatch, but creates every account with the ID it has in accounts, like
+// CreateWithID.
+func (c Client) CreateBatchWithIDs(accounts []Data, fn func(index int, p Payload, err error)) {
+	c.createPipeline(len(accounts), "client.CreateWithID", func(i int) (string, Resource, error) {
+		_, err := uuid.Parse(accounts[i].ID)
+		if err != nil {
+			return "", Resource{}, fmt.Errorf("client.CreateWithID id: %w", err)
+		}
+
+		return accounts[i].ID, accounts[i].Attributes, nil
+	}, fn)
+}
+
+// preparedCreate is an account of a batch that is ready to send, or the error that stopped it being prepared.
+type preparedCreate struct {
+	index   int
+	id      string
+	account Resource
+	body    []byte
+	err     error
+}
+
+// createPipeline creates n accounts in two stages. A single goroutine takes the ID and Resource of each from next, in
+// order, and validates and encodes them, while the workers send what it prepared, one request each at a time. The
+// channel between them holds one prepared account per worker, so encoding stays just ahead of the requests. Errors of
+// next are passed to fn as they are, and the rest are wrapped with op, the way Create and CreateWithID wrap them.
+func (c Client) createPipeline(
+	n int, op string, next func(i int) (string, Resource, error), fn func(index int, p Payload, err error),
+) {
+	prepared := make(chan preparedCreate, c.concurrency())
+
+	go func() {
+		defer close(prepared)
+
+		for i := 0; i < n; i++ {
+			prepared <- c.prepareBatchCreate(i, op, next)
+		}
+	}()
+
+	var mu sync.Mutex
+
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < c.concurrency(); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for pc := range prepared {
+				p, err := c.sendBatchCreate(pc, op)
+
+				mu.Lock()
+				fn(pc.index, p, err)
+				mu.Unlock()
+			}
+		}()
+	}
+
+	wg.Wait()
+}
+
+// prepareBatchCreate prepares the account at index i of a batch.
+func (c Client) prepareBatchCreate(i int, op string, next func(i int) (string, Resource, error)) preparedCreate {
+	id, account, err := next(i)
+	if err != nil {
+		return preparedCreate{index: i, err: err}
+	}
+
+	stop := c.profileOperation(c.context(), opCreate, account.Country)
+	body, err := c.prepareCreate(id, account)
+
+	stop()
+
+	if err != nil {
+		c.audit(auditCreate, id, 0, err)
+
+		return preparedCreate{index: i, err: fmt.Errorf("%s: %w", op, err)}
+	}
+
+	return preparedCreate{index: i, id: id, account: account, body: body}
+}
+
+// sendBatchCreate sends an account prepareBatchCreate prepared, unless it failed to be.
+func (c Client) sendBatchCreate(pc preparedCreate, op string) (Payload, error) {
+	if pc.err != nil {
+		return Payload{}, pc.err
+	}
+
+	stop := c.profileOperation(c.context(), opCreate, pc.account.Country)
+	p, err := c.sendCreate(pc.id, pc.account, pc.body)
+
+	stop()
+	c.audit(auditCreate, pc.id, 0, err)
+
+	if err != nil {
+		return existingAccount(p, err), fmt.Errorf("%s: %w", op, err)
+	}
+
+	return p, nil
+}
+
+// DeleteBatch will delete every account in accounts at the version it has in there, using as many concurrent requests
+// as the Client's concurrency allows. An account whose version is not known, as CurrentVersion reports it, fails with
+// an error that matches ErrVersionUnknown, without a request. It carries on past accounts that fail, and calls fn with
+// each account and its error as it finishes, so not in order. Calls to fn never overlap, so it does not need to
+// synchronise.
+func (c Client) DeleteBatch(accounts []Data, fn func(d Data, err error)) {
+	var mu sync.Mutex
+
+	c.runBatch(len(accounts), func(i int) {
+		err := fmt.Errorf("client.DeleteBatch: account %s: %w", accounts[i].ID, ErrVersionUnknown)
+
+		if version, known := accounts[i].CurrentVersion(); known {
+			err = c.Delete(accounts[i].ID, version)
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		fn(accounts[i], err)
+	})
+}
+
+// runBatch calls do with every index from 0 to n-1 on a pool of workers, and returns when all of them returned.
+func (c Client) runBatch(n int, do func(i int)) {
+	indexes := make(chan int)
+	wg := sync.WaitGroup{}
+
+	for w := 0; w < c.concurrency(); w++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			for i := range indexes {
+				do(i)
+			}
+		}()
+	}
+
+	for i := 0; i < n; i++ {
+		indexes <- i
+	}
+
+	close(indexes)
+	wg.Wait()
+}
+
+// concurrency returns the number of requests a batch may have in flight at once, which is at least 1.
+func (c Client) concurrency() int {
+	if c.workers < 1 {
+		return 1
+	}
+
+	return c.workers
+}
@@ -0,0 +1,363 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestClient_CreateBatch(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+		accounts    []client.Resource
+		wantFailed  []int
+	}{
+		{
+			name:        "creates every account one at a time by default",
+			concurrency: 0,
+			accounts:    []client.Resource{validBatchResource(), validBatchResource()},
+			wantFailed:  []int{},
+		},
+		{
+			name:        "creates every account concurrently and carries on past failures",
+			concurrency: 3,
+			accounts: []client.Resource{
+				validBatchResource(), {Country: "GB"}, validBatchResource(), validBatchResource(), {Country: "GB"},
+			},
+			wantFailed: []int{1, 4},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var inFlight, maxInFlight int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+
+				for {
+					max := atomic.LoadInt32(&maxInFlight)
+					if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+						break
+					}
+				}
+
+				time.Sleep(20 * time.Millisecond)
+				w.WriteHeader(http.StatusCreated)
+				_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+			}))
+			defer ts.Close()
+
+			c := batchClient(t, ts.URL, tt.concurrency)
+
+			var done int
+
+			failed := make([]int, 0)
+
+			c.CreateBatch(tt.accounts, func(index int, p client.Payload, err error) {
+				done++
+
+				if err != nil {
+					assert.True(t, errors.Is(err, client.ErrValidation))
+
+					failed = append(failed, index)
+
+					return
+				}
+
+				assert.NotEmpty(t, p.Data.ID)
+			})
+
+			assert.Equal(t, len(tt.accounts), done)
+			assert.ElementsMatch(t, tt.wantFailed, failed)
+			assert.LessOrEqual(t, int(maxInFlight), maxInt(tt.concurrency, 1))
+		})
+	}
+}
+
+func TestClient_CreateBatch_encodesAhead(t *testing.T) {
+	release := make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	codec := &countingCodec{}
+	marshals := func() int {
+		codec.mu.Lock()
+		defer codec.mu.Unlock()
+
+		return codec.marshals
+	}
+
+	c := batchClient(t, ts.URL, 2)
+	client.WithCodec(codec)(&c)
+
+	accounts := make([]client.Resource, 8)
+	for i := range accounts {
+		accounts[i] = validBatchResource()
+	}
+
+	done := make(chan int)
+
+	go func() {
+		var created int
+
+		c.CreateBatch(accounts, func(index int, p client.Payload, err error) {
+			assert.NoError(t, err)
+
+			created++
+		})
+
+		done <- created
+	}()
+
+	// With both requests stuck, two more accounts wait in the pipeline, and a fifth is encoded and waits to join them.
+	assert.Eventually(t, func() bool { return marshals() == 5 }, time.Second, time.Millisecond)
+	time.Sleep(20 * time.Millisecond)
+	assert.Equal(t, 5, marshals(), "encodes no further ahead")
+
+	close(release)
+
+	assert.Equal(t, len(accounts), <-done)
+	assert.Equal(t, len(accounts), marshals())
+}
+
+func TestClient_DeleteBatch(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		deleted []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, http.MethodDelete, r.Method)
+
+		id := r.URL.Path[strings.LastIndex(r.URL.Path, "/")+1:]
+
+		if id == "gone" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		mu.Lock()
+		deleted = append(deleted, id+"@"+r.URL.Query().Get("version"))
+		mu.Unlock()
+
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := batchClient(t, ts.URL, 2)
+
+	results := make(map[string]error)
+
+	c.DeleteBatch([]client.Data{
+		{ID: "one", Version: 1, VersionKnown: true},
+		{ID: "gone", VersionKnown: true},
+		{ID: "three", Version: 3, VersionKnown: true},
+		{ID: "unversioned"},
+	}, func(d client.Data, err error) {
+		results[d.ID] = err
+	})
+
+	assert.ElementsMatch(t, []string{"one@1", "three@3"}, deleted)
+	assert.Len(t, results, 4)
+	assert.NoError(t, results["one"])
+	assert.True(t, errors.Is(results["gone"], client.ErrNotFound))
+	assert.NoError(t, results["three"])
+	assert.True(t, errors.Is(results["unversioned"], client.ErrVersionUnknown), "got %v", results["unversioned"])
+}
+
+func TestWithRateLimit(t *testing.T) {
+	var requests int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := batchClient(t, ts.URL, 4)
+	client.WithRateLimit(50)(&c)
+
+	accounts := atVersion0(6)
+	start := time.Now()
+
+	c.DeleteBatch(accounts, func(d client.Data, err error) {
+		assert.NoError(t, err)
+	})
+
+	// Six requests at 50 per second are spaced 20ms apart, so the last one cannot start before 100ms.
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(100*time.Millisecond))
+	assert.Equal(t, int32(6), atomic.LoadInt32(&requests))
+}
+
+func TestWithRateLimit_operationTimeout(t *testing.T) {
+	var requests int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithRateLimit(0.5)(&c)
+	client.WithOperationTimeout(50 * time.Millisecond)(&c)
+
+	assert.NoError(t, c.Delete("first", 0))
+
+	start := time.Now()
+	err := c.Delete("second", 0)
+
+	// The second request may only start two seconds after the first, far past the deadline of the operation.
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "the wait for the rate limit ends with the operation")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&requests))
+}
+
+func TestWithBackpressure(t *testing.T) {
+	// The service takes two requests at a time, and turns away the rest with 429 Too Many Requests.
+	var inFlight int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer atomic.AddInt32(&inFlight, -1)
+
+		if atomic.AddInt32(&inFlight, 1) > 2 {
+			w.WriteHeader(http.StatusTooManyRequests)
+
+			return
+		}
+
+		time.Sleep(5 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	rejected := func(opts ...client.Option) int {
+		c := batchClient(t, ts.URL, 8)
+		for _, opt := range opts {
+			opt(&c)
+		}
+
+		var n int
+
+		c.DeleteBatch(atVersion0(100), func(d client.Data, err error) {
+			if errors.Is(err, client.ErrRateLimited) {
+				n++
+			}
+		})
+
+		return n
+	}
+
+	blind := rejected()
+	adaptive := rejected(client.WithBackpressure(time.Second))
+
+	t.Logf("%d rejected at a fixed concurrency, %d with backpressure", blind, adaptive)
+	assert.Less(t, adaptive*3, blind)
+}
+
+// atVersion0 returns n accounts, at a known version of 0.
+func atVersion0(n int) []client.Data {
+	accounts := make([]client.Data, n)
+	for i := range accounts {
+		accounts[i].ID = strconv.Itoa(i)
+		accounts[i].VersionKnown = true
+	}
+
+	return accounts
+}
+
+func batchClient(t *testing.T, url string, concurrency int) client.Client {
+	t.Helper()
+
+	c := client.Client{
+		BaseURL:        url,
+		OrganisationID: "orgid",
+		HttpClient: http.Client{
+			Timeout: testTimeoutMs * time.Millisecond,
+		},
+	}
+	client.WithConcurrency(concurrency)(&c)
+
+	return c
+}
+
+func validBatchResource() client.Resource {
+	return client.Resource{Country: "GB", BankID: "123456", BankIDCode: "GBDSC", BIC: "BARCGB22XXX"}
+}
+
+func maxInt(a, b int) int {
+	if a > b {
+		return a
+	}
+
+	return b
+}
+
+func TestClient_CreateBatchWithIDs(t *testing.T) {
+	const (
+		newID      = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+		existingID = "ffa7706b-d8fc-40b2-be6b-67d2a628cadf"
+	)
+
+	var (
+		mu  sync.Mutex
+		ids []string
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p client.Payload
+
+		assert.NoError(t, json.NewDecoder(r.Body).Decode(&p))
+
+		mu.Lock()
+		ids = append(ids, p.Data.ID)
+		mu.Unlock()
+
+		if p.Data.ID == existingID {
+			w.WriteHeader(http.StatusConflict)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	c := batchClient(t, ts.URL, 2)
+	results := make(map[int]error)
+
+	c.CreateBatchWithIDs([]client.Data{
+		{ID: newID, Attributes: validBatchResource()},
+		{ID: existingID, Attributes: validBatchResource()},
+		{ID: "not-a-uuid", Attributes: validBatchResource()},
+	}, func(index int, p client.Payload, err error) {
+		results[index] = err
+	})
+
+	assert.ElementsMatch(t, []string{newID, existingID}, ids)
+	assert.NoError(t, results[0])
+	assert.True(t, errors.Is(results[1], client.ErrConflict))
+	assert.EqualError(t, results[2], "client.CreateWithID id: invalid UUID length: 10")
+}
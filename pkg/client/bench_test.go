@@ -0,0 +1,139 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/rand"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// benchmarkData returns n accounts with every attribute a generated account has, the way the service returns them.
+func benchmarkData(b *testing.B, n int) []Data {
+	b.Helper()
+
+	rnd := rand.New(rand.NewSource(1)) //nolint:gosec // benchmarks do not need a secure generator
+	countries := SupportedCountries()
+	data := make([]Data, 0, n)
+
+	for i := 0; i < n; i++ {
+		r, err := GenerateResource(countries[i%len(countries)], rnd)
+		if err != nil {
+			b.Fatalf("generating account: %s", err)
+		}
+
+		r.Name = [4]string{"Jane Doe"}
+
+		data = append(data, Data{
+			ID:             fmt.Sprintf("00000000-0000-4000-8000-%012d", i),
+			OrganisationID: "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c",
+			Type:           typeAccounts,
+			Version:        1,
+			CreatedOn:      Timestamp{Time: time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)},
+			ModifiedOn:     Timestamp{Time: time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)},
+			Attributes:     r,
+		})
+	}
+
+	return data
+}
+
+func Benchmark_marshalPayload(b *testing.B) {
+	d := benchmarkData(b, 1)[0]
+	p := createPayload{Data: createData{
+		ID:             d.ID,
+		OrganisationID: d.OrganisationID,
+		Type:           d.Type,
+		Attributes:     d.Attributes,
+	}}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+
+	for i := 0; i < b.N; i++ {
+		if _, err := marshalPayload(nil, p); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func Benchmark_unmarshalMultiPayload(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		page, err := json.Marshal(MultiPayload{Data: benchmarkData(b, size)})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("page size %d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(page)))
+
+			for i := 0; i < b.N; i++ {
+				if _, err := unmarshalMultiPayload(nil, bytes.NewReader(page)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func Benchmark_decodeEach(b *testing.B) {
+	for _, size := range []int{10, 100, 1000} {
+		page, err := json.Marshal(MultiPayload{Data: benchmarkData(b, size)})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("page size %d", size), func(b *testing.B) {
+			b.ReportAllocs()
+			b.SetBytes(int64(len(page)))
+
+			for i := 0; i < b.N; i++ {
+				if _, _, err := decodeEach(nil, bytes.NewReader(page), func(Data) error { return nil }); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func Benchmark_currentHTTPDate(b *testing.B) {
+	c := Client{}
+
+	b.Run("uncached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = c.now().UTC().Format(http.TimeFormat)
+		}
+	})
+
+	b.Run("cached", func(b *testing.B) {
+		b.ReportAllocs()
+
+		for i := 0; i < b.N; i++ {
+			_ = c.currentHTTPDate()
+		}
+	})
+}
+
+func BenchmarkValidateResource(b *testing.B) {
+	for _, country := range SupportedCountries() {
+		r, err := GenerateResource(country, rand.New(rand.NewSource(1))) //nolint:gosec // not security sensitive
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(country, func(b *testing.B) {
+			b.ReportAllocs()
+
+			for i := 0; i < b.N; i++ {
+				if err := ValidateResource(r); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,240 @@
+package client
+
+import (
+	"fmt"
+)
+
+// AccountBuilder builds the Resource of an account in one country. It is made by the constructor of the country, like
+// NewGBAccount, which takes the attributes the country requires, and fills in the country, the bank ID code, and the
+// currency, so they can't be left out or get the wrong value. The optional attributes are set with its With methods,
+// which return a copy, so a builder can be the common start of several accounts. Build checks the formats the
+// constructors can't, like the number of digits of a bank ID.
+type AccountBuilder struct {
+	r Resource
+
+	// nameLines and altNameLines are the number of lines passed to WithName and WithAlternativeNames, which may be
+	// more than fit.
+	nameLines    int
+	altNameLines int
+}
+
+// newAccountBuilder returns a builder of an account in country with the bank ID and BIC, either of which may be empty,
+// and the bank ID code and currency of the country.
+func newAccountBuilder(country, bankID, bic string) AccountBuilder {
+	rules := rulesRegistry[country]
+
+	return AccountBuilder{r: Resource{
+		Country:      country,
+		BaseCurrency: rules.currency,
+		BankID:       bankID,
+		BankIDCode:   rules.bankIDCodeWant,
+		BIC:          bic,
+	}}
+}
+
+// NewGBAccount returns a builder of an account in the United Kingdom, which needs a sort code and a BIC.
+func NewGBAccount(bankID, bic string) AccountBuilder {
+	return newAccountBuilder("GB", bankID, bic)
+}
+
+// NewAUAccount returns a builder of an account in Australia, which needs a BIC. The BSB code is optional.
+func NewAUAccount(bic string) AccountBuilder {
+	return newAccountBuilder("AU", "", bic)
+}
+
+// NewBEAccount returns a builder of an account in Belgium, which needs a bank code.
+func NewBEAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("BE", bankID, "")
+}
+
+// NewCAAccount returns a builder of an account in Canada, which needs a BIC. The routing number is optional.
+func NewCAAccount(bic string) AccountBuilder {
+	return newAccountBuilder("CA", "", bic)
+}
+
+// NewFRAccount returns a builder of an account in France, which needs a bank and branch code.
+func NewFRAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("FR", bankID, "")
+}
+
+// NewDEAccount returns a builder of an account in Germany, which needs a Bankleitzahl.
+func NewDEAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("DE", bankID, "")
+}
+
+// NewGRAccount returns a builder of an account in Greece, which needs a HEBIC.
+func NewGRAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("GR", bankID, "")
+}
+
+// NewHKAccount returns a builder of an account in Hong Kong, which needs a BIC. The bank code is optional.
+func NewHKAccount(bic string) AccountBuilder {
+	return newAccountBuilder("HK", "", bic)
+}
+
+// NewITAccount returns a builder of an account in Italy, which needs an ABI and CAB code, and a CIN too if the account
+// number is set.
+func NewITAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("IT", bankID, "")
+}
+
+// NewLUAccount returns a builder of an account in Luxembourg, which needs an IBAN bank code.
+func NewLUAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("LU", bankID, "")
+}
+
+// NewNLAccount returns a builder of an account in the Netherlands, which needs a BIC, and has no bank ID.
+func NewNLAccount(bic string) AccountBuilder {
+	return newAccountBuilder("NL", "", bic)
+}
+
+// NewPLAccount returns a builder of an account in Poland, which needs a KNR.
+func NewPLAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("PL", bankID, "")
+}
+
+// NewPTAccount returns a builder of an account in Portugal, which needs a bank and branch code.
+func NewPTAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("PT", bankID, "")
+}
+
+// NewESAccount returns a builder of an account in Spain, which needs a bank and branch code.
+func NewESAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("ES", bankID, "")
+}
+
+// NewCHAccount returns a builder of an account in Switzerland, which needs a bank code.
+func NewCHAccount(bankID string) AccountBuilder {
+	return newAccountBuilder("CH", bankID, "")
+}
+
+// NewUSAccount returns a builder of an account in the United States, which needs an ABA routing number and a BIC.
+func NewUSAccount(bankID, bic string) AccountBuilder {
+	return newAccountBuilder("US", bankID, bic)
+}
+
+// WithBankID sets the bank ID, for the countries where it is optional.
+func (b AccountBuilder) WithBankID(bankID string) AccountBuilder {
+	b.r.BankID = bankID
+
+	return b
+}
+
+// WithBIC sets the BIC, for the countries where it is optional.
+func (b AccountBuilder) WithBIC(bic string) AccountBuilder {
+	b.r.BIC = bic
+
+	return b
+}
+
+// WithAccountNumber sets the account number. Without it, the service generates one.
+func (b AccountBuilder) WithAccountNumber(accountNumber string) AccountBuilder {
+	b.r.AccountNumber = accountNumber
+
+	return b
+}
+
+// WithIBAN sets the IBAN, for the countries that have one. Without it, the service generates one.
+func (b AccountBuilder) WithIBAN(iban string) AccountBuilder {
+	b.r.IBAN = iban
+
+	return b
+}
+
+// WithBaseCurrency sets the currency of the account, instead of the one of the country.
+func (b AccountBuilder) WithBaseCurrency(currency string) AccountBuilder {
+	b.r.BaseCurrency = currency
+
+	return b
+}
+
+// WithName sets the lines of the name of the account holder. There can be up to four of them.
+func (b AccountBuilder) WithName(lines ...string) AccountBuilder {
+	b.r.Name = [4]string{}
+	copy(b.r.Name[:], lines)
+	b.nameLines = len(lines)
+
+	return b
+}
+
+// WithAlternativeNames sets the lines of the alternative names of the account holder. There can be up to three of
+// them.
+func (b AccountBuilder) WithAlternativeNames(lines ...string) AccountBuilder {
+	b.r.AlternativeNames = [3]string{}
+	copy(b.r.AlternativeNames[:], lines)
+	b.altNameLines = len(lines)
+
+	return b
+}
+
+// WithCustomerID sets the ID the customer is known by at the bank.
+func (b AccountBuilder) WithCustomerID(customerID string) AccountBuilder {
+	b.r.CustomerID = customerID
+
+	return b
+}
+
+// WithAccountClassification sets whether the account is Personal or Business.
+func (b AccountBuilder) WithAccountClassification(classification string) AccountBuilder {
+	b.r.AccountClassification = classification
+
+	return b
+}
+
+// WithSecondaryIdentification sets the secondary identification of the account, like a building society roll number.
+func (b AccountBuilder) WithSecondaryIdentification(identification string) AccountBuilder {
+	b.r.SecondaryIdentification = identification
+
+	return b
+}
+
+// WithJointAccount sets whether the account is held by more than one person.
+func (b AccountBuilder) WithJointAccount(joint bool) AccountBuilder {
+	b.r.JointAccount = joint
+
+	return b
+}
+
+// WithAccountMatchingOptOut sets whether the account holder opted out of account matching.
+func (b AccountBuilder) WithAccountMatchingOptOut(optOut bool) AccountBuilder {
+	b.r.AccountMatchingOptOut = optOut
+
+	return b
+}
+
+// WithSwitched sets whether the account has been switched away from the bank.
+func (b AccountBuilder) WithSwitched(switched bool) AccountBuilder {
+	b.r.Switched = switched
+
+	return b
+}
+
+// WithStatus sets the status of the account.
+func (b AccountBuilder) WithStatus(status string) AccountBuilder {
+	b.r.Status = status
+
+	return b
+}
+
+// Build returns the Resource, or a *ValidationError, which matches ErrValidation, with every rule of the country it
+// breaks, as ValidateResource finds them, along with any name or alternative name lines that didn't fit.
+func (b AccountBuilder) Build() (Resource, error) {
+	fields := tooManyLines(nil, fieldName, b.nameLines, len(b.r.Name))
+	fields = tooManyLines(fields, fieldAltNames, b.altNameLines, len(b.r.AlternativeNames))
+	fields = append(fields, validateCountry(&b.r)...)
+
+	if len(fields) > 0 {
+		return Resource{}, &ValidationError{Err: fields, Fields: fields}
+	}
+
+	return b.r, nil
+}
+
+// tooManyLines adds a finding to errs if lines is more than fit.
+func tooManyLines(errs fieldErrors, field string, lines, fit int) fieldErrors {
+	if lines > fit {
+		return addFinding(errs, field, fmt.Sprintf("%s has %d lines, can have up to %d", field, lines, fit))
+	}
+
+	return errs
+}
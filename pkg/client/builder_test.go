@@ -0,0 +1,148 @@
+package client_test
+
+import (
+	"errors"
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestAccountBuilder_everyCountry(t *testing.T) {
+	builders := map[string]func(bankID, bic string) client.AccountBuilder{
+		"GB": client.NewGBAccount,
+		"AU": func(_, bic string) client.AccountBuilder { return client.NewAUAccount(bic) },
+		"BE": func(bankID, _ string) client.AccountBuilder { return client.NewBEAccount(bankID) },
+		"CA": func(_, bic string) client.AccountBuilder { return client.NewCAAccount(bic) },
+		"FR": func(bankID, _ string) client.AccountBuilder { return client.NewFRAccount(bankID) },
+		"DE": func(bankID, _ string) client.AccountBuilder { return client.NewDEAccount(bankID) },
+		"GR": func(bankID, _ string) client.AccountBuilder { return client.NewGRAccount(bankID) },
+		"HK": func(_, bic string) client.AccountBuilder { return client.NewHKAccount(bic) },
+		"IT": func(bankID, _ string) client.AccountBuilder { return client.NewITAccount(bankID) },
+		"LU": func(bankID, _ string) client.AccountBuilder { return client.NewLUAccount(bankID) },
+		"NL": func(_, bic string) client.AccountBuilder { return client.NewNLAccount(bic) },
+		"PL": func(bankID, _ string) client.AccountBuilder { return client.NewPLAccount(bankID) },
+		"PT": func(bankID, _ string) client.AccountBuilder { return client.NewPTAccount(bankID) },
+		"ES": func(bankID, _ string) client.AccountBuilder { return client.NewESAccount(bankID) },
+		"CH": func(bankID, _ string) client.AccountBuilder { return client.NewCHAccount(bankID) },
+		"US": client.NewUSAccount,
+	}
+
+	assert.Len(t, builders, len(client.SupportedCountries()), "every supported country has a builder")
+
+	for _, country := range client.SupportedCountries() {
+		t.Run(country, func(t *testing.T) {
+			want, err := client.GenerateResource(country, rand.New(rand.NewSource(1)))
+			assert.NoError(t, err)
+
+			got, err := builders[country](want.BankID, want.BIC).
+				WithBankID(want.BankID).
+				WithBIC(want.BIC).
+				WithAccountNumber(want.AccountNumber).
+				Build()
+
+			assert.NoError(t, err)
+			assert.Equal(t, want, got)
+		})
+	}
+}
+
+func TestAccountBuilder_Build(t *testing.T) {
+	tests := []struct {
+		name       string
+		builder    client.AccountBuilder
+		want       client.Resource
+		wantFields []string
+	}{
+		{
+			name: "fills in the country, bank ID code, and currency",
+			builder: client.NewGBAccount("400300", "NWBKGB22").
+				WithAccountNumber("41426819").
+				WithName("Samantha Holder", "Trading as Sam").
+				WithAlternativeNames("Sam Holder").
+				WithAccountClassification("Personal").
+				WithJointAccount(true),
+			want: client.Resource{
+				Country:               "GB",
+				BaseCurrency:          "GBP",
+				BankID:                "400300",
+				BankIDCode:            client.GBBankID,
+				BIC:                   "NWBKGB22",
+				AccountNumber:         "41426819",
+				Name:                  [4]string{"Samantha Holder", "Trading as Sam"},
+				AlternativeNames:      [3]string{"Sam Holder"},
+				AccountClassification: "Personal",
+				JointAccount:          true,
+			},
+		},
+		{
+			name:       "a bank ID in the wrong format",
+			builder:    client.NewGBAccount("4003", "NWBKGB22"),
+			wantFields: []string{"bank_id"},
+		},
+		{
+			name:       "an IBAN where the country has none",
+			builder:    client.NewAUAccount("NABAAU3M").WithIBAN("AU12345"),
+			wantFields: []string{"iban"},
+		},
+		{
+			name:       "a missing required BIC",
+			builder:    client.NewUSAccount("021000021", ""),
+			wantFields: []string{"bic"},
+		},
+		{
+			name:       "too many lines of a name, along with the rules of the country",
+			builder:    client.NewDEAccount("1002003").WithName("a", "b", "c", "d", "e"),
+			wantFields: []string{"name", "bank_id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := tt.builder.Build()
+			if tt.wantFields == nil {
+				assert.NoError(t, err)
+				assert.Equal(t, tt.want, got)
+
+				return
+			}
+
+			var vErr *client.ValidationError
+
+			assert.True(t, errors.As(err, &vErr))
+			assert.True(t, errors.Is(err, client.ErrValidation))
+			assert.Equal(t, client.Resource{}, got)
+
+			fields := make([]string, 0, len(vErr.Fields))
+			for _, f := range vErr.Fields {
+				fields = append(fields, f.Field)
+			}
+
+			assert.Equal(t, tt.wantFields, fields)
+		})
+	}
+}
+
+func TestAccountBuilder_copies(t *testing.T) {
+	base := client.NewDEAccount("10020030")
+
+	tooLong := base.WithName("a", "b", "c", "d", "e")
+	first := base.WithName("Erika Mustermann")
+	second := base.WithName("Max Mustermann")
+
+	_, err := tooLong.Build()
+	assert.Error(t, err)
+
+	got1, err := first.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "Erika Mustermann", got1.Name[0])
+
+	got2, err := second.Build()
+	assert.NoError(t, err)
+	assert.Equal(t, "Max Mustermann", got2.Name[0])
+
+	fixed, err := tooLong.WithName("a").Build()
+	assert.NoError(t, err, "setting the name again replaces the lines that didn't fit")
+	assert.Equal(t, "a", fixed.Name[0])
+}
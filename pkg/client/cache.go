@@ -0,0 +1,179 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// minCacheSweep is the number of accounts the cache holds before put first looks for old ones to evict.
+const minCacheSweep = 64
+
+// accountCache keeps the accounts Fetch got, by ID, for WithCache. It is shared by every copy of the Client it was
+// configured on, and by every goroutine of a batch.
+type accountCache struct {
+	mu      sync.Mutex
+	ttl     time.Duration
+	entries map[string]cachedAccount
+
+	// sweepAt is the number of accounts at which put evicts the old ones, twice as many as were left after the last
+	// time, so a cache that only grows is swept a number of times that is logarithmic in its size.
+	sweepAt int
+}
+
+// cachedAccount is an account in the cache, and when it was fetched.
+type cachedAccount struct {
+	p       Payload
+	fetched time.Time
+}
+
+// CacheControl is how a single Fetch uses the cache of WithCache, set with WithCacheControl. The zero value uses
+// cached accounts until they are older than the time to live of the cache.
+type CacheControl struct {
+	// NoCache fetches the account from the service even if the cache has it, and keeps the one it gets, like a
+	// read-after-write check needs.
+	NoCache bool
+
+	// MaxAge is how old a cached account can be to be used, if it's more than zero. It can be shorter than the time to
+	// live of the cache, but not longer.
+	MaxAge time.Duration
+
+	// StaleIfError is how long after it's too old a cached account is still used when fetching it fails, with a
+	// transport error, a 429 Too Many Requests, or a 5xx status. An account that is not found, or any other 4xx,
+	// isn't hidden behind a cached one.
+	StaleIfError time.Duration
+}
+
+// WithCache makes Fetch keep the accounts it gets, and return them again for ttl instead of requesting them, so bulk
+// readers that fetch the same accounts over and over send a request for each only once. Update and Delete of an
+// account through the Client, or any copy of it, drop it from the cache, but changes by anyone else are only seen once
+// it is older than ttl. Callers that need the latest version, like a check after a write, can pass
+// WithCacheControl(CacheControl{NoCache: true}). Accounts older than ttl, and the StaleIfError of the Fetch that
+// keeps a new one, are evicted as the cache grows, so it holds about as many accounts as were fetched within ttl. Fetch
+// returns a copy of a cached account, which the caller can change without changing the cache. Zero or less means no
+// cache, which is the default.
+func WithCache(ttl time.Duration) Option {
+	return func(c *Client) {
+		if ttl <= 0 {
+			c.cache = nil
+
+			return
+		}
+
+		c.cache = &accountCache{ttl: ttl, entries: make(map[string]cachedAccount), sweepAt: minCacheSweep}
+	}
+}
+
+// WithCacheControl returns a copy of the Client whose Fetch uses the cache of WithCache the way cc says. Without a
+// cache, it has no effect.
+func (c Client) WithCacheControl(cc CacheControl) Client {
+	c.cacheControl = cc
+
+	return c
+}
+
+// maxAge returns how old a cached account can be to be used.
+func (c Client) maxAge() time.Duration {
+	if c.cacheControl.MaxAge > 0 && c.cacheControl.MaxAge < c.cache.ttl {
+		return c.cacheControl.MaxAge
+	}
+
+	return c.cache.ttl
+}
+
+// cachedFetch returns a copy of the account with the given ID from the cache, if it is young enough, or fetches it with
+// get and keeps a copy. If get fails, a copy of a cached account within the StaleIfError of the CacheControl is
+// returned instead.
+func (c Client) cachedFetch(accountID string, get func() (Payload, error)) (Payload, error) {
+	if c.cache == nil {
+		return get()
+	}
+
+	entry, ok := c.cache.get(accountID)
+	age := c.now().Sub(entry.fetched)
+
+	if ok && !c.cacheControl.NoCache && age <= c.maxAge() {
+		return clonePayload(entry.p), nil
+	}
+
+	p, err := get()
+	if err == nil {
+		now := c.now()
+		oldest := now.Add(-c.cache.ttl - c.cacheControl.StaleIfError)
+		c.cache.put(accountID, cachedAccount{p: clonePayload(p), fetched: now}, oldest)
+
+		return p, nil
+	}
+
+	if ok && staleable(err) && age <= c.maxAge()+c.cacheControl.StaleIfError {
+		return clonePayload(entry.p), nil
+	}
+
+	return Payload{}, err
+}
+
+// forget drops the account with the given ID from the cache, if there is one.
+func (c Client) forget(accountID string) {
+	if c.cache == nil {
+		return
+	}
+
+	c.cache.mu.Lock()
+	delete(c.cache.entries, accountID)
+	c.cache.mu.Unlock()
+}
+
+// get returns the cached account with the given ID, and whether there is one.
+func (a *accountCache) get(accountID string) (cachedAccount, bool) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	entry, ok := a.entries[accountID]
+
+	return entry, ok
+}
+
+// put keeps entry as the account with the given ID. Once the cache has grown to sweepAt accounts, the ones fetched
+// before oldest are evicted first.
+func (a *accountCache) put(accountID string, entry cachedAccount, oldest time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if len(a.entries) >= a.sweepAt {
+		for id, e := range a.entries {
+			if e.fetched.Before(oldest) {
+				delete(a.entries, id)
+			}
+		}
+
+		a.sweepAt = max(2*len(a.entries), minCacheSweep)
+	}
+
+	a.entries[accountID] = entry
+}
+
+// clonePayload returns a copy of p that shares no maps or slices with it, so a cached account can't be changed through
+// the Payload a Fetch returned.
+func clonePayload(p Payload) Payload {
+	p.Data.Attributes.Extra = cloneExtra(p.Data.Attributes.Extra)
+
+	if p.Meta != nil {
+		meta := *p.Meta
+		meta.Extra = cloneExtra(meta.Extra)
+		p.Meta = &meta
+	}
+
+	return p
+}
+
+// staleable reports whether a fetch that failed with err may fall back on a stale cached account: the service
+// couldn't be reached, or had a problem on its side, rather than said something about the account.
+func staleable(err error) bool {
+	var apiErr *APIError
+	if !errors.As(err, &apiErr) {
+		return true
+	}
+
+	return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= http.StatusInternalServerError
+}
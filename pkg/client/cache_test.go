@@ -0,0 +1,213 @@
+package client_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestWithCache(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	tests := []struct {
+		name         string
+		cache        time.Duration
+		control      client.CacheControl
+		after        time.Duration
+		failWith     int
+		wantRequests int
+		wantErr      bool
+	}{
+		{
+			name:         "without a cache every fetch is a request",
+			after:        time.Second,
+			wantRequests: 2,
+		},
+		{
+			name:         "a young cached account is used",
+			cache:        time.Minute,
+			after:        time.Second,
+			wantRequests: 1,
+		},
+		{
+			name:         "an old cached account is fetched again",
+			cache:        time.Minute,
+			after:        2 * time.Minute,
+			wantRequests: 2,
+		},
+		{
+			name:         "NoCache fetches a young one again",
+			cache:        time.Minute,
+			control:      client.CacheControl{NoCache: true},
+			after:        time.Second,
+			wantRequests: 2,
+		},
+		{
+			name:         "MaxAge shorter than the cache",
+			cache:        time.Minute,
+			control:      client.CacheControl{MaxAge: time.Second},
+			after:        2 * time.Second,
+			wantRequests: 2,
+		},
+		{
+			name:         "MaxAge longer than the cache is the cache",
+			cache:        time.Minute,
+			control:      client.CacheControl{MaxAge: time.Hour},
+			after:        2 * time.Minute,
+			wantRequests: 2,
+		},
+		{
+			name:         "StaleIfError uses an old one when the service fails",
+			cache:        time.Minute,
+			control:      client.CacheControl{StaleIfError: time.Hour},
+			after:        2 * time.Minute,
+			failWith:     http.StatusServiceUnavailable,
+			wantRequests: 2,
+		},
+		{
+			name:         "StaleIfError runs out",
+			cache:        time.Minute,
+			control:      client.CacheControl{StaleIfError: time.Minute},
+			after:        3 * time.Minute,
+			failWith:     http.StatusServiceUnavailable,
+			wantRequests: 2,
+			wantErr:      true,
+		},
+		{
+			name:         "StaleIfError doesn't hide a not found",
+			cache:        time.Minute,
+			control:      client.CacheControl{StaleIfError: time.Hour},
+			after:        2 * time.Minute,
+			failWith:     http.StatusNotFound,
+			wantRequests: 2,
+			wantErr:      true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := 0
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests++
+
+				if requests > 1 && tt.failWith != 0 {
+					w.WriteHeader(tt.failWith)
+
+					return
+				}
+
+				_, _ = fmt.Fprint(w, returnCompactFile(t, "./testdata/payload.json"))
+			}))
+			defer ts.Close()
+
+			now := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithCache(tt.cache)(&c)
+			client.WithClock(func() time.Time { return now })(&c)
+
+			_, err := c.Fetch(accountID)
+			assert.NoError(t, err)
+
+			now = now.Add(tt.after)
+
+			got, err := c.WithCacheControl(tt.control).Fetch(accountID)
+
+			assert.Equal(t, tt.wantRequests, requests)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, accountID, got.Data.ID)
+		})
+	}
+}
+
+func TestWithCache_writes(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	fetches := 0
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			fetches++
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+
+			return
+		}
+
+		_, _ = fmt.Fprint(w, returnCompactFile(t, "./testdata/payload.json"))
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithCache(time.Hour)(&c)
+
+	fetch := func() {
+		_, err := c.Fetch(accountID)
+		assert.NoError(t, err)
+	}
+
+	fetch()
+	fetch()
+	assert.Equal(t, 1, fetches)
+
+	_, err := c.WithRequestID("update").Update(accountID, 0, map[string]interface{}{"status": "closed"})
+	assert.NoError(t, err)
+
+	fetch()
+	assert.Equal(t, 2, fetches, "an update through a copy of the Client drops the cached account")
+
+	assert.NoError(t, c.Delete(accountID, 1))
+
+	fetch()
+	assert.Equal(t, 3, fetches, "a delete drops the cached account")
+}
+
+func TestWithCache_copies(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = fmt.Fprint(w, `{"data":{"id":"`+accountID+`","type":"accounts","version":0,`+
+			`"attributes":{"country":"GB","name":["Jane Doe"],"nickname":"jane"}},"meta":{"total":1,"region":"eu"}}`)
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithCache(time.Hour)(&c)
+
+	first, err := c.Fetch(accountID)
+	assert.NoError(t, err)
+
+	first.Data.Attributes.Name[0] = "changed"
+	first.Data.Attributes.Extra["nickname"][1] = 'J'
+	first.Data.Attributes.Extra["added"] = json.RawMessage(`true`)
+	first.Meta.Extra["region"] = json.RawMessage(`"us"`)
+
+	second, err := c.Fetch(accountID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, "Jane Doe", second.Data.Attributes.Name[0])
+	assert.Equal(t, map[string]json.RawMessage{"nickname": json.RawMessage(`"jane"`)}, second.Data.Attributes.Extra,
+		"changing what a Fetch returned doesn't change the cached account")
+	assert.Equal(t, map[string]json.RawMessage{"region": json.RawMessage(`"eu"`)}, second.Meta.Extra)
+
+	second.Data.Attributes.Extra["added"] = json.RawMessage(`true`)
+
+	third, err := c.Fetch(accountID)
+	assert.NoError(t, err)
+	assert.NotContains(t, third.Data.Attributes.Extra, "added", "nor does changing what a cached Fetch returned")
+}
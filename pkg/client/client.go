@@ -3,13 +3,13 @@ package client
 import (
 	"bytes"
 	"context"
-	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"sync"
 	"time"
 
 	"github.com/google/uuid"
@@ -18,123 +18,488 @@ import (
 )
 
 const (
-	acceptHeaderValue = "application/vnd.api+json"
-	createEndpoint    = "/v1/organisation/accounts"
-	listEndpoint      = "/v1/organisation/accounts?page[number]=%d&page[size]=%d"
-	fetchEndpoint     = "/v1/organisation/accounts/%s"
-	deleteEndpoint    = "/v1/organisation/accounts/%s?version=%d"
-	typeAccounts      = "accounts"
+	defaultMediaType = "application/vnd.api+json"
+	typeAccounts     = "accounts"
+	requestIDHeader  = "X-Request-Id"
 )
 
+// API is the set of operations on single accounts and pages of them that Client implements, for code that wants to
+// swap the Client for a test double, like clienttest.MockClient.
+type API interface {
+	Create(account Resource) (Payload, error)
+	CreateWithID(id string, account Resource) (Payload, error)
+	Fetch(accountID string) (Payload, error)
+	List(pageNumber, pageSize uint) (MultiPayload, error)
+	ListFiltered(filter Filter, pageNumber, pageSize uint) (MultiPayload, error)
+	Update(accountID string, version uint, attributes map[string]interface{}) (Payload, error)
+	Delete(accountID string, version uint) error
+}
+
+var _ API = Client{}
+
+// Client talks to the accounts API. Once configured, it is safe for concurrent use by multiple goroutines, and so are
+// its copies, like the ones WithRequestID returns, which share its rate limit, stats, and log sampling. The exported
+// fields must not be changed while it is in use. Everything passed to its Options that it calls back, like a Logger,
+// StatsHandler, AuditSink, clock, or ID generator, is called from every goroutine that uses it, so it has to be safe
+// for concurrent use too. Only the writers of WithDebug and WithTrace are guarded by the Client itself.
 type Client struct {
 	BaseURL        string
 	OrganisationID string
 	HttpClient     http.Client
-	DateLocation   *time.Location
+
+	httpClient *http.Client
+	base       *baseURL
+
+	debug    io.Writer
+	trace    io.Writer
+	writeMu  *sync.Mutex
+	workers  int
+	limiter  *limiter
+	throttle *throttle
+	token    string
+	logger   Logger
+	stats    StatsHandler
+	phased   bool
+	audits   AuditSink
+	sampler  *sampler
+	codec    Codec
+	statuses map[string][]int
+
+	accept      string
+	contentType string
+
+	redirects *redirectPolicy
+
+	cache        *accountCache
+	cacheControl CacheControl
+
+	idempotentDelete   bool
+	existingOnConflict bool
+	partialResults     bool
+	validateIDs        bool
+	apiVersion         APIVersion
+
+	pprofLabels bool
+	profileHook ProfileHook
+
+	collector *collector
+	redactor  *redactor
+
+	requestID string
+	clock     func() time.Time
+	newID     func() (uuid.UUID, error)
+
+	ctx              context.Context
+	retries          int
+	retryBackoff     time.Duration
+	attemptTimeout   time.Duration
+	operationTimeout time.Duration
+	retryHook        func(attempt int, err error, wait time.Duration)
 }
 
-// New returns a configured Client struct.
-func New(cfg config.Config, c http.Client, gmt *time.Location) Client {
-	return Client{
+// New returns a configured Client struct. Optional behaviour can be switched on by passing any number of Options. The
+// AccountsAPIURL of the Config is parsed here, once, so a URL that is not an absolute http or https one fails New
+// instead of the first request. It may have a path, for services deployed under one, and a trailing slash.
+func New(cfg config.Config, c http.Client, opts ...Option) (Client, error) {
+	base, err := parseBaseURL(cfg.AccountsAPIURL)
+	if err != nil {
+		return Client{}, fmt.Errorf("client.New: %w", err)
+	}
+
+	client := Client{
 		BaseURL:        cfg.AccountsAPIURL,
 		OrganisationID: cfg.OrganisationID,
 		HttpClient:     c,
-		DateLocation:   gmt,
+		token:          cfg.Token,
+		base:           base,
+	}
+
+	for _, opt := range opts {
+		opt(&client)
 	}
+
+	return client, nil
+}
+
+// WithRequestID returns a copy of the Client that sends id in the X-Request-Id header of its requests, so a caller can
+// follow a call it already has an ID for through the service and its logs. Without it, every call gets a new random
+// ID. Retries of a call send the same ID. The ID of ContextWithRequestID wins over it.
+func (c Client) WithRequestID(id string) Client {
+	c.requestID = id
+
+	return c
 }
 
 // Create will create a Resource that belongs to organisation ID set on the Client if the Resource passes validation for
 // the given dataset.
 func (c Client) Create(account Resource) (Payload, error) {
-	id, err := uuid.NewRandom()
+	id, err := c.generateID()
 	if err != nil {
 		return Payload{}, fmt.Errorf("client.Create new uuid: %w", err)
 	}
 
-	err = ValidateResource(account)
+	p, err := c.create(id.String(), account)
+	c.audit(auditCreate, id.String(), 0, err)
+
+	if err != nil {
+		return existingAccount(p, err), fmt.Errorf("client.Create: %w", err)
+	}
+
+	return p, nil
+}
+
+// CreateWithID works like Create, but uses the given ID for the account instead of generating one, for example to
+// restore an account from a backup. The ID has to be a UUID. If an account with the ID already exists, the service
+// responds with a conflict, and the returned error matches ErrConflict. With WithExistingOnConflict, it also returns
+// the account that exists, along with an *AlreadyExistsError.
+func (c Client) CreateWithID(id string, account Resource) (Payload, error) {
+	_, err := uuid.Parse(id)
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.CreateWithID id: %w", err)
+	}
+
+	p, err := c.create(id, account)
+	c.audit(auditCreate, id, 0, err)
+
+	if err != nil {
+		return existingAccount(p, err), fmt.Errorf("client.CreateWithID: %w", err)
+	}
+
+	return p, nil
+}
+
+// create validates the Resource and sends it to the service with the given ID. The callers wrap its errors.
+func (c Client) create(id string, account Resource) (Payload, error) {
+	defer c.profileOperation(c.context(), opCreate, account.Country)()
+
+	body, err := c.prepareCreate(id, account)
+	if err != nil {
+		return Payload{}, err
+	}
+
+	return c.sendCreate(id, account, body)
+}
+
+// prepareCreate validates the Resource, and encodes the payload that creates it with the given ID. The callers wrap its
+// errors.
+func (c Client) prepareCreate(id string, account Resource) ([]byte, error) {
+	err := ValidateResourceFor(account, c.apiVersion)
 	if err != nil {
-		return Payload{}, fmt.Errorf("client.Create: %w", err)
+		c.logValidationFailure(id, err)
+
+		return nil, err
 	}
 
-	requestPayload := Payload{
-		Data: Data{
-			ID:             id.String(),
+	requestPayload := createPayload{
+		Data: createData{
+			ID:             id,
 			OrganisationID: c.OrganisationID,
 			Type:           typeAccounts,
 			Attributes:     account,
 		},
 	}
 
-	jsonPayload, err := marshalPayload(requestPayload)
-	if err != nil {
-		return Payload{}, fmt.Errorf("client.Create: %w", err)
-	}
+	return marshalPayload(c.codec, requestPayload)
+}
 
-	resp, err := c.do(http.MethodPost, createEndpoint, jsonPayload)
-	if err != nil {
-		return Payload{}, fmt.Errorf("client.Create: %w", err)
+// sendCreate sends a payload encoded by prepareCreate for the given account and ID to the service. Some deployments
+// respond with 201 Created, a Location header, and no body, in which case the account is fetched by its ID, so the
+// caller still gets the Payload the service stored. A retry that conflicts is looked into the same way, as an attempt
+// before it may have created the account and lost its response, and so is any conflict with WithExistingOnConflict.
+// The callers wrap its errors.
+func (c Client) sendCreate(id string, account Resource, body []byte) (Payload, error) {
+	c, cancel := c.startOperation()
+	defer cancel()
+
+	var (
+		p       Payload
+		empty   bool
+		retried bool
+	)
+
+	err := c.noticeRetries(&retried).exchange(c.context(), opCreate, http.MethodPost, accountsEndpoint().String(), body,
+		func(r io.Reader) (err error) {
+			r, empty = emptyBody(r)
+			if empty {
+				return nil
+			}
+
+			p, err = unmarshalPayload(c.codec, r)
+
+			return err
+		},
+	)
+	if retried && errors.Is(err, ErrConflict) {
+		created, ok := c.createdBefore(id, account)
+		if ok {
+			return created, nil
+		}
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	if c.existingOnConflict && errors.Is(err, ErrConflict) {
+		return c.fetchExisting(id, err)
+	}
 
-	if resp.StatusCode != http.StatusCreated {
-		return Payload{}, fmt.Errorf("client.Create response unexpected response code: %d", resp.StatusCode)
+	if err != nil || !empty {
+		return p, err
 	}
 
-	p, err := unmarshalPayload(resp.Body)
+	p, err = c.fetch(id)
 	if err != nil {
-		return Payload{}, fmt.Errorf("client.Create: %w", err)
+		return Payload{}, fmt.Errorf("fetching created account: %w", err)
 	}
 
 	return p, nil
 }
 
+// createdBefore fetches the account with the ID a retried create conflicted with, and returns it if it is the account
+// the create sent, in the organisation of the Client. Only then did an earlier attempt create it; an account that
+// differs was there already, and the conflict stands.
+func (c Client) createdBefore(id string, account Resource) (Payload, bool) {
+	p, err := c.fetch(id)
+	if err != nil || p.Data.OrganisationID != c.OrganisationID || !p.Data.Attributes.Equal(account) {
+		return Payload{}, false
+	}
+
+	return p, true
+}
+
+// fetchExisting fetches the account with the ID a create conflicted with, and returns it with an *AlreadyExistsError
+// that wraps err. If there is no such account, the conflict was about something else, so err is returned as it is.
+func (c Client) fetchExisting(id string, err error) (Payload, error) {
+	p, fetchErr := c.fetch(id)
+	if fetchErr != nil {
+		return Payload{}, err
+	}
+
+	return p, &AlreadyExistsError{ID: id, Err: err}
+}
+
+// existingAccount returns p if err is an *AlreadyExistsError, as p is then the account that exists, and an empty
+// Payload otherwise.
+func existingAccount(p Payload, err error) Payload {
+	if errors.Is(err, ErrAlreadyExists) {
+		return p
+	}
+
+	return Payload{}
+}
+
+// emptyBody reports whether r has nothing to read, and returns a reader with everything it has otherwise.
+func emptyBody(r io.Reader) (io.Reader, bool) {
+	var first [1]byte
+
+	n, err := io.ReadFull(r, first[:])
+	if n == 0 && errors.Is(err, io.EOF) {
+		return r, true
+	}
+
+	return io.MultiReader(bytes.NewReader(first[:n]), r), false
+}
+
 // List will list all the Resources that belong to given organisation ID, pageSize per request, and if multi paged, on
 // the given pageNumber.
 func (c Client) List(pageNumber, pageSize uint) (MultiPayload, error) {
-	requestPath := fmt.Sprintf(listEndpoint, pageNumber, pageSize)
-
-	resp, err := c.do(http.MethodGet, requestPath, nil)
+	mp, err := c.list(nil, pageNumber, pageSize)
 	if err != nil {
 		return MultiPayload{}, fmt.Errorf("client.List: %w", err)
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	return mp, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return MultiPayload{}, fmt.Errorf("client.List unexpected http response status: %d", resp.StatusCode)
+// ListFiltered works like List, but only lists the Resources that match every pair in filter.
+func (c Client) ListFiltered(filter Filter, pageNumber, pageSize uint) (MultiPayload, error) {
+	mp, err := c.list(filter, pageNumber, pageSize)
+	if err != nil {
+		return MultiPayload{}, fmt.Errorf("client.ListFiltered: %w", err)
 	}
 
-	mp, err := unmarshalMultiPayload(resp.Body)
+	return mp, nil
+}
+
+// list requests a single page of the Resources that match filter, which may be empty to list all of them. Errors are
+// returned unwrapped, so List and ListFiltered can add their own name.
+func (c Client) list(filter Filter, pageNumber, pageSize uint) (MultiPayload, error) {
+	c, cancel := c.startOperation()
+	defer cancel()
+
+	defer c.profileOperation(c.context(), opList, "")()
+
+	return c.getPage(c.context(), accountsEndpoint().page(pageNumber, pageSize).filter(filter).String())
+}
+
+// getPage requests the page of accounts at requestPath. Errors are returned unwrapped.
+func (c Client) getPage(ctx context.Context, requestPath string) (MultiPayload, error) {
+	var mp MultiPayload
+
+	err := c.exchange(ctx, opList, http.MethodGet, requestPath, nil,
+		func(r io.Reader) (err error) {
+			mp, err = unmarshalMultiPayload(c.codec, r)
+
+			return err
+		},
+	)
+
+	return mp, err
+}
+
+// ListPages will request every page of Resources, pageSize per request, starting from the first one, and call fn with
+// each page in order. It stops at the last page, which is the first one that is not full or has no next link, or as
+// soon as fn or a request returns an error. With a concurrency above 1 it requests the first page on its own, and then
+// that many pages at once, still calling fn in order. Only if the pages say nothing about where the last one is, are
+// up to concurrency-1 requests past it wasted.
+func (c Client) ListPages(pageSize uint, fn func(MultiPayload) error) error {
+	err := c.ListPagesFrom(0, pageSize, fn)
 	if err != nil {
-		return MultiPayload{}, fmt.Errorf("client.List: %w", err)
+		return fmt.Errorf("client.ListPages: %w", err)
 	}
 
-	return mp, nil
+	return nil
 }
 
-// Fetch will return a Resource struct identified by an ID, if exists.
-func (c Client) Fetch(accountID string) (Payload, error) {
-	requestPath := fmt.Sprintf(fetchEndpoint, accountID)
+// ListPagesFrom works like ListPages, but starts from the page numbered firstPage, so a caller that remembers how many
+// pages it got through can pick up where it left off.
+func (c Client) ListPagesFrom(firstPage, pageSize uint, fn func(MultiPayload) error) error {
+	err := c.listPages(nil, firstPage, pageSize, fn)
+	if err != nil {
+		return fmt.Errorf("client.ListPagesFrom: %w", err)
+	}
+
+	return nil
+}
+
+// ListPagesFiltered works like ListPages, but only lists the Resources that match every pair in filter.
+func (c Client) ListPagesFiltered(filter Filter, pageSize uint, fn func(MultiPayload) error) error {
+	err := c.listPages(filter, 0, pageSize, fn)
+	if err != nil {
+		return fmt.Errorf("client.ListPagesFiltered: %w", err)
+	}
+
+	return nil
+}
+
+// listPages requests the pages of the Resources that match filter from firstPage onwards, concurrency pages at once,
+// and calls fn with each of them in order. Errors are returned unwrapped, so the callers can add their own name.
+//
+// With a concurrency above 1, the first page is requested on its own. If it says where the last page is, through the
+// total of its meta or a page number in its last link, the rest are requested concurrency at a time up to that page
+// and no further. Without that, or past it when accounts were added in the meantime, every batch requests concurrency
+// pages, and the ones past the end come back empty.
+func (c Client) listPages(filter Filter, firstPage, pageSize uint, fn func(MultiPayload) error) error {
+	if pageSize == 0 {
+		return errors.New("pageSize has to be at least 1")
+	}
+
+	c, cancel := c.startOperation()
+	defer cancel()
 
-	resp, err := c.do(http.MethodGet, requestPath, nil)
+	window := uint(c.concurrency())
+	pages := make([]MultiPayload, window)
+	errs := make([]error, window)
+
+	var (
+		last  uint
+		known bool
+	)
+
+	for first, n := firstPage, uint(1); ; first += n {
+		n = window
+		if first == firstPage && window > 1 {
+			n = 1
+		} else if known && first <= last && last-first+1 < n {
+			n = last - first + 1
+		}
+
+		c.runBatch(int(n), func(i int) {
+			pages[i], errs[i] = c.list(filter, first+uint(i), pageSize)
+		})
+
+		for i, mp := range pages[:n] {
+			pageNumber := first + uint(i)
+
+			if errs[i] != nil {
+				return fmt.Errorf("page %d: %w", pageNumber, errs[i])
+			}
+
+			if len(mp.Data) == 0 {
+				return nil
+			}
+
+			err := fn(mp)
+			if err != nil {
+				return fmt.Errorf("page %d: %w", pageNumber, err)
+			}
+
+			if uint(len(mp.Data)) < pageSize || mp.Links.Next == "" {
+				return nil
+			}
+
+			if l, ok := lastPage(mp, pageSize); ok {
+				last, known = l, true
+			}
+		}
+	}
+}
+
+// ListAll will request every page of Resources, pageSize per request, and return all of them. If a page fails, it
+// returns none of them, unless the Client has WithPartialResults.
+func (c Client) ListAll(pageSize uint) ([]Data, error) {
+	all, err := c.listAll(ListCursor{PageSize: pageSize})
+	if err != nil {
+		return all, fmt.Errorf("client.ListAll: %w", err)
+	}
+
+	return all, nil
+}
+
+// ListCursor is how far a listing of every account got: the number of the next page to request, counting from 0, and
+// the size of the pages, which has to stay the same for the page numbers to mean the same accounts.
+type ListCursor struct {
+	Page     uint `json:"page"`
+	PageSize uint `json:"page_size"`
+}
+
+// ListAllFrom works like ListAll, but starts from the page of cursor, like the one of a *PartialListError, so a listing
+// that failed part of the way through can carry on from the page that failed instead of starting over. Accounts
+// created or deleted in the meantime shift the pages, so some accounts may be listed twice, or not at all.
+func (c Client) ListAllFrom(cursor ListCursor) ([]Data, error) {
+	all, err := c.listAll(cursor)
 	if err != nil {
-		return Payload{}, fmt.Errorf("client.Fetch httpClient.Do: %w", err)
+		return all, fmt.Errorf("client.ListAllFrom: %w", err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		return Payload{}, fmt.Errorf("client.Fetch unexpected response code: %d", resp.StatusCode)
+	return all, nil
+}
+
+// listAll requests every page from the one of cursor on. If a page fails, it returns the accounts of the pages before
+// it along with a *PartialListError if the Client has WithPartialResults, and nothing but the error otherwise. Errors
+// are returned unwrapped, so the callers can add their own name.
+func (c Client) listAll(cursor ListCursor) ([]Data, error) {
+	all := make([]Data, 0)
+	next := cursor.Page
+
+	err := c.listPages(nil, cursor.Page, cursor.PageSize, func(mp MultiPayload) error {
+		all = append(all, mp.Data...)
+		next++
+
+		return nil
+	})
+
+	switch {
+	case err == nil:
+		return all, nil
+	case !c.partialResults || cursor.PageSize == 0:
+		return nil, err
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	return all, &PartialListError{Cursor: ListCursor{Page: next, PageSize: cursor.PageSize}, Records: len(all), Err: err}
+}
 
-	p, err := unmarshalPayload(resp.Body)
+// Fetch will return a Resource struct identified by an ID, if exists. With WithCache, it may be the one a previous
+// Fetch got, as WithCacheControl allows.
+func (c Client) Fetch(accountID string) (Payload, error) {
+	p, err := c.fetch(accountID)
 	if err != nil {
 		return Payload{}, fmt.Errorf("client.Fetch: %w", err)
 	}
@@ -142,66 +507,214 @@ func (c Client) Fetch(accountID string) (Payload, error) {
 	return p, nil
 }
 
+// fetch requests the account with the given ID. The callers wrap its errors.
+func (c Client) fetch(accountID string) (Payload, error) {
+	c, cancel := c.startOperation()
+	defer cancel()
+
+	defer c.profileOperation(c.context(), opFetch, "")()
+
+	ep, err := c.accountEndpoint(accountID)
+	if err != nil {
+		return Payload{}, err
+	}
+
+	return c.cachedFetch(accountID, func() (Payload, error) {
+		return c.getPayload(c.context(), ep.String())
+	})
+}
+
+// getPayload requests the account at requestPath. Errors are returned unwrapped.
+func (c Client) getPayload(ctx context.Context, requestPath string) (Payload, error) {
+	var p Payload
+
+	err := c.exchange(ctx, opFetch, http.MethodGet, requestPath, nil,
+		func(r io.Reader) (err error) {
+			p, err = unmarshalPayload(c.codec, r)
+
+			return err
+		},
+	)
+
+	return p, err
+}
+
+// Update will change the attributes of the Resource with given ID, if version is its current version. Only the
+// attributes in the map, keyed by their JSON names, are sent and changed, so the values have to be what the JSON
+// encoding of a Resource would have for them. As the rest of the Resource is not known, the service does the
+// validation. If the version is stale, the service responds with a conflict, and the returned error is a
+// *ConflictError, which matches ErrConflict.
+func (c Client) Update(accountID string, version uint, attributes map[string]interface{}) (Payload, error) {
+	p, err := c.update(accountID, version, attributes)
+	c.audit(auditUpdate, accountID, version, err)
+
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.Update: %w", err)
+	}
+
+	return p, nil
+}
+
+// update sends the changed attributes of the account to the service. Update wraps its errors.
+func (c Client) update(accountID string, version uint, attributes map[string]interface{}) (Payload, error) {
+	c, cancel := c.startOperation()
+	defer cancel()
+
+	country, _ := attributes[fieldCountry].(string)
+	defer c.profileOperation(c.context(), opUpdate, country)()
+
+	ep, err := c.accountEndpoint(accountID)
+	if err != nil {
+		return Payload{}, err
+	}
+
+	body, err := encode(c.codec, map[string]interface{}{
+		"data": map[string]interface{}{
+			"id":         accountID,
+			"type":       typeAccounts,
+			"version":    version,
+			"attributes": attributes,
+		},
+	})
+	if err != nil {
+		return Payload{}, err
+	}
+
+	var p Payload
+
+	c.forget(accountID)
+
+	err = c.exchange(c.context(), opUpdate, http.MethodPatch, ep.String(), body,
+		func(r io.Reader) (err error) {
+			p, err = unmarshalPayload(c.codec, r)
+
+			return err
+		},
+	)
+
+	return p, conflictError(err, accountID, version)
+}
+
 // Delete will remove a Resource with given ID if version that's requested to be deleted and current version of Resource
-// matches.
+// matches. If it does not, the returned error is a *ConflictError, which matches ErrConflict.
 func (c Client) Delete(accountID string, version uint) error {
-	requestPath := fmt.Sprintf(deleteEndpoint, accountID, version)
+	err := c.delete(accountID, version)
+	c.audit(auditDelete, accountID, version, err)
 
-	resp, err := c.do(http.MethodDelete, requestPath, nil)
 	if err != nil {
 		return fmt.Errorf("client.Delete: %w", err)
 	}
 
-	defer func() {
-		_ = resp.Body.Close()
-	}()
+	return nil
+}
+
+// delete removes the account from the service. Delete wraps its errors.
+func (c Client) delete(accountID string, version uint) error {
+	c, cancel := c.startOperation()
+	defer cancel()
+
+	defer c.profileOperation(c.context(), opDelete, "")()
 
-	if resp.StatusCode != http.StatusNoContent {
-		return fmt.Errorf("client.Delete unexpected response code: %d", resp.StatusCode)
+	ep, err := c.accountEndpoint(accountID)
+	if err != nil {
+		return err
 	}
 
-	return nil
+	requestPath := ep.param("version", strconv.FormatUint(uint64(version), 10)).String()
+
+	c.forget(accountID)
+
+	err = c.exchange(c.context(), opDelete, http.MethodDelete, requestPath, nil, nil)
+	if c.idempotentDelete && errors.Is(err, ErrNotFound) {
+		return nil
+	}
+
+	return conflictError(err, accountID, version)
 }
 
 // addHeaders will decorate a header with the needed key/value pairs. If the body is not empty, it also adds the
 // Content-Type header.
 //
-// Ideally the Content-Length header should not be added automatically, because per the http documentation of go, if
-// it's not set, and the total size of all written data is under a few KB and there are no Flush calls, the
-// Content-Length header will be added automatically on A Write call. I' adding it here regardless so it shows up for
-// the tests.
+// The Content-Length header is not added here: the body is marshalled to a []byte once, and http.NewRequest sets the
+// ContentLength of the request from it, which net/http sends as the header. Reading the body again to measure it
+// would double the work for every request.
 //
-// Authorization headers are not added per the spec of the take home exercise.
+// Host is set on the request rather than in the header map, which net/http ignores it in, to the host and port of the
+// URL, without the scheme. The Date header is always in UTC, so there is no location to configure.
+//
+// The Accept and Content-Type headers are the media types of WithMediaTypes, if it set them. The Authorization header
+// is only added if the Client has a token.
 func (c Client) addHeaders(r *http.Request) *http.Request {
-	r.Header.Add("Host", c.BaseURL)
+	r.Host = r.URL.Host
 	r.Header.Add("Date", c.currentHTTPDate())
-	r.Header.Add("Accept", acceptHeaderValue)
+	r.Header.Add("Accept", mediaTypeOr(c.accept))
 
-	if r.Body == nil {
-		return r
+	if c.token != "" {
+		r.Header.Add("Authorization", "Bearer "+c.token)
 	}
 
-	bodyReadCloser, _ := r.GetBody()
-
-	body, err := ioutil.ReadAll(bodyReadCloser)
-	if err == nil && len(body) > 0 {
-		r.Header.Add("Content-Type", acceptHeaderValue)
-		r.Header.Add("Content-Length", strconv.Itoa(len(body)))
+	if r.ContentLength > 0 {
+		r.Header.Add("Content-Type", mediaTypeOr(c.contentType))
 	}
 
 	return r
 }
 
-// currentHTTPDate returns the current date time in GMT, per RFC 7231/7.1.1.1.
+// mediaTypeOr returns mediaType, or the media type of the API if it is empty.
+func mediaTypeOr(mediaType string) string {
+	if mediaType == "" {
+		return defaultMediaType
+	}
+
+	return mediaType
+}
+
+// currentHTTPDate returns the current date time as http.TimeFormat, per RFC 7231/7.1.1.1. It is formatted once a second
+// at most.
 func (c Client) currentHTTPDate() string {
-	return time.Now().In(c.DateLocation).Format(time.RFC1123)
+	return httpDates.format(c.now())
+}
+
+// send sends the request with the http.Client of WithHTTPClient if there is one, or else with the HttpClient field. An
+// http.Client without a CheckRedirect of its own follows redirects by the policy of the Client, through a copy of it,
+// so the one passed in is never changed.
+func (c Client) send(req *http.Request) (*http.Response, error) {
+	hc := &c.HttpClient
+	if c.httpClient != nil {
+		hc = c.httpClient
+	}
+
+	if hc.CheckRedirect == nil {
+		withPolicy := *hc
+		withPolicy.CheckRedirect = c.checkRedirect
+		hc = &withPolicy
+	}
+
+	return hc.Do(req)
+}
+
+// now returns the current time from the clock set with WithClock, or the system clock.
+func (c Client) now() time.Time {
+	if c.clock == nil {
+		return time.Now()
+	}
+
+	return c.clock()
 }
 
-// marshalPayload will turn a Payload struct to its json representation.
-func marshalPayload(r Payload) (io.Reader, error) {
-	b := new(bytes.Buffer)
+// generateID returns a new random UUID from the generator set with WithIDGenerator, or uuid.NewRandom.
+func (c Client) generateID() (uuid.UUID, error) {
+	if c.newID == nil {
+		return uuid.NewRandom()
+	}
+
+	return c.newID()
+}
 
-	err := json.NewEncoder(b).Encode(r)
+// marshalPayload will turn the payload of a create request to its json representation with codec, or encoding/json if
+// it is nil.
+func marshalPayload(codec Codec, r createPayload) ([]byte, error) {
+	b, err := encode(codec, &r)
 	if err != nil {
 		return nil, fmt.Errorf("marshalPayload: %w", err)
 	}
@@ -209,31 +722,32 @@ func marshalPayload(r Payload) (io.Reader, error) {
 	return b, nil
 }
 
-// unmarshalPayload will turn a json in an io.Reader into a Payload struct.
-func unmarshalPayload(r io.Reader) (Payload, error) {
+// unmarshalPayload will turn a json in an io.Reader into a Payload struct with codec, or encoding/json if it is nil.
+func unmarshalPayload(codec Codec, r io.Reader) (Payload, error) {
 	var p Payload
 
-	err := json.NewDecoder(r).Decode(&p)
+	err := decode(codec, r, &p)
 	if err != nil {
 		return Payload{}, fmt.Errorf("unmarshalPayload: %w", err)
 	}
 
-	if p.Data == (Data{}) {
+	if p.Data.isZero() {
 		return Payload{}, errors.New("unmarshalPayload: Data is empty on the decoded Payload")
 	}
 
-	if p.Data.Attributes == (Resource{}) {
+	if p.Data.Attributes.isZero() {
 		return Payload{}, errors.New("unmarshalPayload: Data.Attributes is empty on the decoded Payload")
 	}
 
 	return p, nil
 }
 
-// unmarshalMultiPayload will turn a json with an array of payloads in the data part into a MultiPayload struct.
-func unmarshalMultiPayload(r io.Reader) (MultiPayload, error) {
+// unmarshalMultiPayload will turn a json with an array of payloads in the data part into a MultiPayload struct with
+// codec, or encoding/json if it is nil.
+func unmarshalMultiPayload(codec Codec, r io.Reader) (MultiPayload, error) {
 	var mp MultiPayload
 
-	err := json.NewDecoder(r).Decode(&mp)
+	err := decode(codec, r, &mp)
 	if err != nil {
 		return MultiPayload{}, fmt.Errorf("unmarshalMultiPayload: %w", err)
 	}
@@ -243,7 +757,7 @@ func unmarshalMultiPayload(r io.Reader) (MultiPayload, error) {
 	}
 
 	for _, d := range mp.Data {
-		if d.Attributes == (Resource{}) {
+		if d.Attributes.isZero() {
 			return MultiPayload{}, errors.New("unmarshalMultiPayload: Data structs are missing required fields")
 		}
 	}
@@ -251,24 +765,210 @@ func unmarshalMultiPayload(r io.Reader) (MultiPayload, error) {
 	return mp, nil
 }
 
-// do is a generic method to handle network calls.
-func (c Client) do(method, endpoint string, payload io.Reader) (*http.Response, error) {
-	req, err := http.NewRequestWithContext(
-		context.Background(),
-		method,
-		fmt.Sprintf("%s%s", c.BaseURL, endpoint),
-		payload,
-	)
+// exchange sends a request for the operation, and hands the body of the response to decode if the operation accepts
+// its status, or returns an *APIError for it if it does not. If decode fails, its error is returned as a *DecodeError,
+// along with the start of the body. Every operation on a single response goes through it, so the body is always read
+// to the end and closed before it returns, whatever happens, and the connection goes back to the pool. A nil decode
+// ignores the body, and so does a response that has none by its status, which leaves the result of the operation
+// empty. Errors are returned unwrapped, for the callers to add their own name.
+func (c Client) exchange(
+	ctx context.Context, operation, method, endpoint string, body []byte, decode func(io.Reader) error,
+) error {
+	resp, err := c.do(ctx, method, endpoint, body)
+	if err != nil {
+		return err
+	}
+
+	defer discard(resp)
+
+	if !c.accepts(operation, resp.StatusCode) {
+		return c.newAPIError(resp)
+	}
+
+	if decode == nil || !hasBody(resp) {
+		return nil
+	}
+
+	head := &bodyHead{r: resp.Body}
+	if err := decode(head); err != nil {
+		return c.newDecodeError(resp, head, err)
+	}
+
+	return nil
+}
+
+// do is a generic method to handle network calls. A nil body means the request has none. Every attempt is sent with
+// ctx, limited to the attempt timeout if there is one. With retries configured, requests that fail in a way that is
+// worth retrying are sent again after a backoff, with the same body, and only the outcome of the last attempt is
+// returned. It stops waiting to retry as soon as ctx is done, and doesn't start to if the wait would run past the
+// deadline of ctx, which is a *RetryDeadlineError. The time left is measured with the system clock, as the deadline
+// is, so a clock of WithClock that is stopped or off doesn't change the decision. The request ID comes from ctx, the
+// Client, or a new UUID, in that order, and the outcome goes to the ResponseMeta of ctx, if it has one. The caller has
+// to discard the response.
+func (c Client) do(ctx context.Context, method, endpoint string, body []byte) (*http.Response, error) {
+	cl := call{
+		ctx:       ctx,
+		method:    method,
+		endpoint:  endpoint,
+		requestID: requestIDFrom(ctx),
+		body:      body,
+		sampled:   c.sampler.sample(),
+	}
+
+	if cl.requestID == "" {
+		cl.requestID = c.requestID
+	}
+
+	if cl.requestID == "" {
+		id, err := c.generateID()
+		if err != nil {
+			return nil, fmt.Errorf("client.do new request id: %w", err)
+		}
+
+		cl.requestID = id.String()
+	}
+
+	for attempt := 0; ; attempt++ {
+		resp, err := c.attempt(cl, attempt)
+		if attempt >= c.retries || !retryable(resp, err) {
+			setResponseMeta(ctx, cl.requestID, attempt+1, resp)
+
+			return resp, err
+		}
+
+		wait := c.retryWait(attempt, resp)
+
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < wait {
+			err = &RetryDeadlineError{Attempts: attempt + 1, Wait: wait, Err: c.attemptError(resp, err)}
+			setResponseMeta(ctx, cl.requestID, attempt+1, resp)
+			discard(resp)
+
+			return nil, err
+		}
+
+		c.logRetry(method, endpoint, cl.requestID, attempt+1, wait, resp, err)
+		c.onRetry(attempt, resp, err, wait)
+		c.notify(RetryScheduled{
+			Method:     method,
+			Path:       strings.SplitN(endpoint, "?", 2)[0], //nolint:gomnd
+			RequestID:  cl.requestID,
+			Attempt:    attempt + 2, //nolint:gomnd
+			Wait:       wait,
+			StatusCode: statusCode(resp),
+			Err:        err,
+		})
+		discard(resp)
+
+		select {
+		case <-ctx.Done():
+			setResponseMeta(ctx, cl.requestID, attempt+1, resp)
+
+			return nil, ctx.Err()
+		case <-time.After(wait):
+		}
+	}
+}
+
+// call is a single call of an operation, which may take several attempts.
+type call struct {
+	ctx       context.Context
+	method    string
+	endpoint  string
+	requestID string
+	body      []byte
+
+	// sampled is set if the call is logged and dumped even if it succeeds.
+	sampled bool
+}
+
+// attempt sends the request of the call, the given numbered attempt of it counting from 0. A nil body means the
+// request has none. Every attempt reads the body from a bytes.Reader of its own, which http.NewRequestWithContext
+// also sets GetBody for, so retries and redirects send the same bytes. Unless the call is sampled, its logs, trace,
+// and dumps are only written if it fails.
+func (c Client) attempt(cl call, attempt int) (*http.Response, error) {
+	var payload io.Reader
+
+	if cl.body != nil {
+		payload = bytes.NewReader(cl.body)
+	}
+
+	target, err := c.endpointURL(cl.endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("client.do: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(cl.ctx, cl.method, target, payload)
 	if err != nil {
 		return nil, fmt.Errorf("client.do http.NewRequestWithContext: %w", err)
 	}
 
-	req = c.addHeaders(req)
+	share, err := c.throttle.acquire(cl.ctx, c.concurrency())
+	if err != nil {
+		return nil, fmt.Errorf("client.do backpressure: %w", err)
+	}
+
+	defer c.throttle.release()
+
+	if c.limiter != nil {
+		err = c.limiter.wait(cl.ctx, share)
+		if err != nil {
+			return nil, fmt.Errorf("client.do rate limit: %w", err)
+		}
+	}
+
+	ctx, cancel := c.attemptContext(cl.ctx)
+	req = c.addHeaders(req.WithContext(ctx))
+	req.Header.Set(requestIDHeader, cl.requestID)
+	req, t := c.traceRequest(req)
+
+	requestDump := c.dumpRequest(req)
+
+	if cl.sampled {
+		c.writeDump(requestDump)
+		c.logRequestStart(req, attempt)
+	}
+
+	c.notify(RequestStarted{Method: cl.method, Path: req.URL.Path, RequestID: cl.requestID, Attempt: attempt + 1})
+
+	start := time.Now()
+	resp, err := c.send(req)
+	latency := time.Since(start)
+
+	c.throttle.observe(c.concurrency(), resp, err, latency)
+	c.redaction().redactError(err)
+
+	failed := err != nil || resp.StatusCode >= http.StatusBadRequest
+
+	if !cl.sampled && failed {
+		c.writeDump(requestDump)
+	}
+
+	if cl.sampled || failed {
+		c.logRequestFinish(req, attempt, latency, resp, err)
+		c.writeTrace(req, t, resp, err)
+	}
+
+	c.notifyFinished(t, resp, RequestFinished{
+		Method:     cl.method,
+		Path:       req.URL.Path,
+		RequestID:  cl.requestID,
+		Attempt:    attempt + 1,
+		StatusCode: statusCode(resp),
+		Latency:    latency,
+		Err:        err,
+	})
 
-	resp, err := c.HttpClient.Do(req)
 	if err != nil {
-		return nil, fmt.Errorf("client.do httpClient.Do: %w", err)
+		cancel()
+
+		return nil, fmt.Errorf("client.do httpClient.Do (request id %s): %w", cl.requestID, err)
+	}
+
+	if cl.sampled || failed {
+		c.dumpResponse(resp)
 	}
 
+	resp.Body = cancelBody{ReadCloser: resp.Body, cancel: cancel}
+
 	return resp, nil
 }
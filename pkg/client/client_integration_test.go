@@ -1,3 +1,5 @@
+//go:build integration
+
 package client_test
 
 import (
@@ -5,25 +7,16 @@ import (
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/clienttest"
 	"github.com/javorszky/form3takehome/pkg/config"
-)
-
-const (
-	integrationTestURL   = "http://accountapi:8080"
-	integrationTestOrgID = "0e1445e5-2047-4a98-ad4d-55068b25359a" // generated by https://www.uuidgenerator.net/version4
-	bicExample           = "BARCGB22XXX"                          // from https://www.iban.com/search-bic
-	ibanExample          = "GB33BUKB20201555555555"               // from https://www.iban.com/structure
+	"github.com/javorszky/form3takehome/pkg/fixtures"
 )
 
 func TestClient_IntegrationCreateFetchListDelete(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	type args struct {
 		accounts []client.Resource
 	}
@@ -32,6 +25,14 @@ func TestClient_IntegrationCreateFetchListDelete(t *testing.T) {
 		name string
 		args args
 	}{
+		{
+			name: "a single account",
+			args: args{accounts: []client.Resource{fixtures.ValidGB()}},
+		},
+		{
+			name: "several accounts with the same attributes",
+			args: args{accounts: []client.Resource{fixtures.ValidDE(), fixtures.ValidDE(), fixtures.ValidDE()}},
+		},
 		{
 			name: "correctly creates, fetches, lists, and deletes resources against the service in docker",
 			args: args{
@@ -160,16 +161,22 @@ func TestClient_IntegrationCreateFetchListDelete(t *testing.T) {
 		},
 	}
 	for _, tt := range tests {
+		tt := tt
 		t.Run(tt.name, func(t *testing.T) {
-			c := client.New(
+			t.Parallel()
+
+			// Every test works in an organisation of its own, so tests running in parallel, or left over accounts of
+			// earlier runs, do not get in the way.
+			orgID := newOrganisationID(t)
+
+			c := mustNew(t,
 				config.Config{
 					AccountsAPIURL: integrationTestURL,
-					OrganisationID: integrationTestOrgID,
+					OrganisationID: orgID,
 				},
 				http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				gmtLoc,
 			)
 
 			payloadsHelper := make([]client.Payload, 0)
@@ -196,22 +203,19 @@ func TestClient_IntegrationCreateFetchListDelete(t *testing.T) {
 						stored.Data.ID, err,
 					)
 				}
-				assert.Equal(t, stored, got)
+				clienttest.AssertPayload(t, stored, got)
 			}
 
 			// Then let's list them, and compare them with the payloadsHelper slice
-			l, err := c.List(0, 100)
-			if err != nil {
-				assert.FailNowf(t, "list encountered an error", "error message: %s", err)
-			}
+			listed := organisationAccounts(t, c, orgID)
 
 			// The list should be the same length as the payloadshelper. If not, we're either bleeding data, or
 			// something is wrong in our code.
-			assert.Equal(t, len(l.Data), len(payloadsHelper))
+			assert.Equal(t, len(listed), len(payloadsHelper))
 
 			listHelper := make(map[string]client.Data)
 
-			for _, listItem := range l.Data {
+			for _, listItem := range listed {
 				listHelper[listItem.ID] = listItem
 			}
 
@@ -240,9 +244,40 @@ func TestClient_IntegrationCreateFetchListDelete(t *testing.T) {
 			}
 
 			// and with list
-			deletedList, err := c.List(0, 100)
-			assert.NoError(t, err)
-			assert.Equal(t, 0, len(deletedList.Data))
+			assert.Empty(t, organisationAccounts(t, c, orgID))
 		})
 	}
 }
+
+// newOrganisationID returns a random organisation ID for a single test.
+func newOrganisationID(t *testing.T) string {
+	t.Helper()
+
+	id, err := uuid.NewRandom()
+	if err != nil {
+		assert.FailNowf(t, "could not generate an organisation ID", "error: %s", err)
+	}
+
+	return id.String()
+}
+
+// organisationAccounts lists every account in the service, and returns the ones that belong to the organisation. The
+// service lists the accounts of every organisation, so this is what keeps tests that run at the same time apart.
+func organisationAccounts(t *testing.T, c client.Client, orgID string) []client.Data {
+	t.Helper()
+
+	all, err := c.ListAll(100)
+	if err != nil {
+		assert.FailNowf(t, "list encountered an error", "error message: %s", err)
+	}
+
+	own := make([]client.Data, 0)
+
+	for _, d := range all {
+		if d.OrganisationID == orgID {
+			own = append(own, d)
+		}
+	}
+
+	return own
+}
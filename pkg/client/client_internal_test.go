@@ -1,11 +1,9 @@
 package client
 
 import (
-	"bytes"
 	"context"
-	"encoding/json"
+	"errors"
 	"io"
-	"io/ioutil"
 	"net/http"
 	"os"
 	"strconv"
@@ -18,17 +16,12 @@ import (
 
 func TestClient_addHeaders(t *testing.T) {
 	const (
-		testURL                 = "https://atesturl"
+		testURL                 = "https://atesturl:8443/v1/organisation/accounts"
 		testJSONBody            = `{data:{key:"value"}}`
 		testContentType         = "application/vnd.api+json"
 		testHeaderDateThreshold = 15
 	)
 
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	requestNoBody, err := http.NewRequestWithContext(context.TODO(), http.MethodPost, testURL, nil)
 	if err != nil {
 		assert.FailNowf(t, "could not create test request with no body", "error: %s", err)
@@ -55,8 +48,7 @@ func TestClient_addHeaders(t *testing.T) {
 	}
 
 	type fields struct {
-		BaseURL      string
-		DateLocation *time.Location
+		BaseURL string
 	}
 
 	type args struct {
@@ -72,65 +64,64 @@ func TestClient_addHeaders(t *testing.T) {
 		{
 			name: "decorates request with headers with no body present",
 			fields: fields{
-				BaseURL:      testURL,
-				DateLocation: gmtLoc,
+				BaseURL: testURL,
 			},
 			args: args{
 				r: requestNoBody,
 			},
 			wantHeaders: map[string]string{
 				"Accept": testContentType,
-				"Host":   testURL,
 			},
 		},
 		{
 			name: "decorates request with headers with empty body present",
 			fields: fields{
-				BaseURL:      testURL,
-				DateLocation: gmtLoc,
+				BaseURL: testURL,
 			},
 			args: args{
 				r: requestEmptyBody,
 			},
 			wantHeaders: map[string]string{
 				"Accept": testContentType,
-				"Host":   testURL,
 			},
 		},
 		{
 			name: "decorates request with headers with body present",
 			fields: fields{
-				BaseURL:      testURL,
-				DateLocation: gmtLoc,
+				BaseURL: testURL,
 			},
 			args: args{
 				r: requestBody,
 			},
 			wantHeaders: map[string]string{
-				"Accept":         testContentType,
-				"Host":           testURL,
-				"Content-Type":   testContentType,
-				"Content-Length": strconv.Itoa(len(testJSONBody)),
+				"Accept":       testContentType,
+				"Content-Type": testContentType,
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			c := Client{
-				BaseURL:      tt.fields.BaseURL,
-				DateLocation: tt.fields.DateLocation,
+				BaseURL: tt.fields.BaseURL,
 			}
 			got := c.addHeaders(tt.args.r)
 			for k, v := range tt.wantHeaders {
 				assert.Equal(t, v, got.Header.Get(k))
 			}
 
+			// The length is sent from the ContentLength of the request, so it is not a header of its own.
+			assert.Empty(t, got.Header.Get("Content-Length"))
+
+			// net/http sends the Host of the request, which is the host of the URL without the scheme.
+			assert.Equal(t, "atesturl:8443", got.Host)
+			assert.Empty(t, got.Header.Get("Host"))
+
 			// Check the Date header separately
 			headerDate := got.Header.Get("Date")
 			if !strings.HasSuffix(headerDate, "GMT") {
 				assert.FailNowf(t, "header date should end with GMT. It doesn't", "error: %s", err)
 			}
-			parsedHeaderDate, err := time.Parse(time.RFC1123, headerDate)
+			parsedHeaderDate, err := http.ParseTime(headerDate)
 			if err != nil {
 				assert.FailNowf(
 					t,
@@ -180,8 +171,9 @@ func Test_unmarshalPayload(t *testing.T) {
 					OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 					Type:           "accounts",
 					Version:        0,
-					CreatedOn:      testTime,
-					ModifiedOn:     testTime,
+					VersionKnown:   true,
+					CreatedOn:      Timestamp{Time: testTime},
+					ModifiedOn:     Timestamp{Time: testTime},
 					Attributes: Resource{
 						Country:       "GB",
 						BaseCurrency:  "GBP",
@@ -229,7 +221,7 @@ func Test_unmarshalPayload(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := unmarshalPayload(tt.args.r)
+			got, err := unmarshalPayload(nil, tt.args.r)
 			if tt.wantErr {
 				assert.Error(t, err)
 			} else {
@@ -283,8 +275,9 @@ func Test_unmarshalMultiPayload(t *testing.T) {
 						OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 						Type:           "accounts",
 						Version:        0,
-						CreatedOn:      testTime,
-						ModifiedOn:     testTime,
+						VersionKnown:   true,
+						CreatedOn:      Timestamp{Time: testTime},
+						ModifiedOn:     Timestamp{Time: testTime},
 						Attributes: Resource{
 							Country:       "GB",
 							BaseCurrency:  "GBP",
@@ -318,8 +311,9 @@ func Test_unmarshalMultiPayload(t *testing.T) {
 						OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 						Type:           "accounts",
 						Version:        0,
-						CreatedOn:      testTime2,
-						ModifiedOn:     testTime2,
+						VersionKnown:   true,
+						CreatedOn:      Timestamp{Time: testTime2},
+						ModifiedOn:     Timestamp{Time: testTime2},
 						Attributes: Resource{
 							Country:       "GB",
 							BaseCurrency:  "GBP",
@@ -368,7 +362,7 @@ func Test_unmarshalMultiPayload(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := unmarshalMultiPayload(tt.args.r)
+			got, err := unmarshalMultiPayload(nil, tt.args.r)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -382,34 +376,8 @@ func Test_unmarshalMultiPayload(t *testing.T) {
 }
 
 func Test_marshalPayload(t *testing.T) {
-	testTime, err := time.Parse(time.RFC3339, "2020-05-06T09:28:13.843Z")
-	if err != nil {
-		assert.FailNowf(t, "could not parse test time", "error: %s", err)
-	}
-
-	f, err := os.Open("./testdata/payload.json")
-	if err != nil {
-		assert.FailNowf(t, "could not open file", "error: %s", err)
-	}
-
-	defer func() {
-		_ = f.Close()
-	}()
-
-	jsonPayload, err := ioutil.ReadAll(f)
-	if err != nil {
-		assert.FailNowf(t, "could not read file contents", "error: %s", err)
-	}
-
-	var b bytes.Buffer
-
-	err = json.Compact(&b, jsonPayload)
-	if err != nil {
-		assert.FailNowf(t, "could not compact json data", "error: %s", err)
-	}
-
 	type args struct {
-		r Payload
+		r createPayload
 	}
 
 	tests := []struct {
@@ -419,59 +387,36 @@ func Test_marshalPayload(t *testing.T) {
 		wantErr bool
 	}{
 		{
-			name: "marshals payload correctly",
+			name: "marshals payload correctly, without the fields the service owns",
 			args: args{
-				r: Payload{
-					Data: Data{
+				r: createPayload{
+					Data: createData{
 						ID:             "a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
 						OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 						Type:           "accounts",
-						Version:        0,
-						CreatedOn:      testTime,
-						ModifiedOn:     testTime,
 						Attributes: Resource{
-							Country:       "GB",
-							BaseCurrency:  "GBP",
-							BankID:        "89282dd",
-							BankIDCode:    "12221",
-							AccountNumber: "12345678",
-							BIC:           "bic1234",
-							IBAN:          "iban1234",
-							CustomerID:    "anuuidv4again",
-							Name: [4]string{
-								"line1",
-								"line2",
-								"line3",
-								"line4",
-							},
-							AlternativeNames: [3]string{
-								"altname1",
-								"altname2",
-								"altname3",
-							},
-							AccountClassification:   "cop",
-							JointAccount:            false,
-							AccountMatchingOptOut:   false,
-							SecondaryIdentification: "some custom name",
-							Switched:                false,
-							Status:                  "confirmed",
+							Country:      "GB",
+							BaseCurrency: "GBP",
+							BankID:       "89282dd",
+							BankIDCode:   "12221",
+							BIC:          "bic1234",
+							Name:         [4]string{"line1"},
+							Status:       "confirmed",
 						},
 					},
-					Links: Links{
-						Self:  "https://selflink.com/resource",
-						First: "https://firstlink.com/resource",
-						Next:  "https://nextlink.com/resource",
-						Last:  "https://lastlink.com/resource",
-					},
 				},
 			},
-			want:    b.String() + "\n",
+			want: `{"data":{"id":"a6c1a721-bb1b-41ef-bd11-800a1309ff9b",` +
+				`"organisation_id":"7442ea6b-164a-4818-b470-d98abfbc24ae","type":"accounts","attributes":{` +
+				`"country":"GB","base_currency":"GBP","bank_id":"89282dd","bank_id_code":"12221","bic":"bic1234",` +
+				`"joint_account":false,"account_matching_opt_out":false,"switched":false,"status":"confirmed",` +
+				`"name":["line1"]}}}` + "\n",
 			wantErr: false,
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			got, err := marshalPayload(tt.args.r)
+			got, err := marshalPayload(nil, tt.args.r)
 
 			if tt.wantErr {
 				assert.Error(t, err)
@@ -479,12 +424,432 @@ func Test_marshalPayload(t *testing.T) {
 				assert.NoError(t, err)
 			}
 
-			gotJson, err := ioutil.ReadAll(got)
-			if err != nil {
-				assert.FailNowf(t, "could not read ioutil.readall contents", "error: %s", err)
+			assert.Equal(t, tt.want, string(got))
+			assert.NotContains(t, string(got), "version")
+			assert.NotContains(t, string(got), "created_on")
+			assert.NotContains(t, string(got), "modified_on")
+		})
+	}
+}
+
+func Test_decodeEach(t *testing.T) {
+	const account = `{"id": "a", "attributes": {"country": "GB"}}`
+
+	tests := []struct {
+		name      string
+		body      string
+		wantCount uint
+		wantNext  string
+		wantIDs   []string
+		wantErr   string
+	}{
+		{
+			name:      "decodes links after the data",
+			body:      `{"data": [` + account + `, ` + account + `], "links": {"next": "/next"}}`,
+			wantCount: 2,
+			wantNext:  "/next",
+			wantIDs:   []string{"a", "a"},
+		},
+		{
+			name:      "decodes links before the data, and skips other keys",
+			body:      `{"links": {"next": "/next"}, "meta": {"total": [1, {"x": 2}]}, "data": [` + account + `]}`,
+			wantCount: 1,
+			wantNext:  "/next",
+			wantIDs:   []string{"a"},
+		},
+		{
+			name:    "takes null data as an empty page",
+			body:    `{"data": null}`,
+			wantIDs: []string{},
+		},
+		{
+			name:    "fails on data that is not an array",
+			body:    `{"data": {}}`,
+			wantIDs: []string{},
+			wantErr: "decodeEach data: unexpected {, expected an array",
+		},
+		{
+			name:      "fails on an account without attributes, after the ones before it",
+			body:      `{"data": [` + account + `, {"id": "b"}]}`,
+			wantCount: 1,
+			wantIDs:   []string{"a"},
+			wantErr:   "decodeEach data 1: Data struct is missing required fields",
+		},
+		{
+			name:    "fails on a response that is not an object",
+			body:    `[]`,
+			wantIDs: []string{},
+			wantErr: "decodeEach: unexpected [, expected {",
+		},
+		{
+			name:      "fails on a truncated response",
+			body:      `{"data": [` + account,
+			wantCount: 1,
+			wantIDs:   []string{"a"},
+			wantErr:   "decodeEach data 1: unexpected end of JSON input",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotIDs := make([]string, 0)
+
+			count, links, err := decodeEach(nil, strings.NewReader(tt.body), func(d Data) error {
+				gotIDs = append(gotIDs, d.ID)
+
+				return nil
+			})
+
+			assert.Equal(t, tt.wantCount, count)
+			assert.Equal(t, tt.wantIDs, gotIDs)
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
 			}
 
-			assert.Equal(t, tt.want, string(gotJson))
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantNext, links.Next)
+		})
+	}
+}
+
+func TestDateCache_format(t *testing.T) {
+	cet := time.FixedZone("CET", 3600)
+	noon := time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+	d := &dateCache{}
+
+	assert.Equal(t, "Fri, 01 Jan 2021 12:00:00 GMT", d.format(noon))
+
+	// A stale value for the same second shows that the cache was hit.
+	d.last.Store(&cachedDate{second: noon.Unix(), value: "cached"})
+	assert.Equal(t, "cached", d.format(noon.Add(999*time.Millisecond)))
+	assert.Equal(t, "cached", d.format(noon.In(cet)), "the same second in another location is the same date")
+	assert.Equal(t, "Fri, 01 Jan 2021 12:00:01 GMT", d.format(noon.Add(time.Second).In(cet)),
+		"the next second is formatted, in UTC")
+}
+
+func Test_isDigits(t *testing.T) {
+	tests := []struct {
+		s    string
+		min  int
+		max  int
+		want bool
+	}{
+		{s: "123456", min: 6, max: 6, want: true},
+		{s: "0123456789", min: 6, max: 17, want: true},
+		{s: "12345", min: 6, max: 6, want: false},
+		{s: "1234567", min: 6, max: 6, want: false},
+		{s: "12345a", min: 6, max: 6, want: false},
+		{s: "12 456", min: 6, max: 6, want: false},
+		{s: "12345٣", min: 6, max: 7, want: false},
+		{s: "", min: 0, max: 0, want: true},
+		{s: "", min: 1, max: 6, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.s, func(t *testing.T) {
+			assert.Equal(t, tt.want, isDigits(tt.s, tt.min, tt.max))
+		})
+	}
+}
+
+func Test_lastPage(t *testing.T) {
+	tests := []struct {
+		name   string
+		mp     MultiPayload
+		want   uint
+		wantOK bool
+	}{
+		{name: "from the total", mp: MultiPayload{Meta: &Meta{Total: 7}}, want: 3, wantOK: true},
+		{name: "from an exactly full total", mp: MultiPayload{Meta: &Meta{Total: 8}}, want: 3, wantOK: true},
+		{
+			name: "from the last link",
+			mp: MultiPayload{Links: Links{
+				Last: "/v1/organisation/accounts?page%5Bnumber%5D=12&page%5Bsize%5D=2&filter%5Bcountry%5D=GB",
+			}},
+			want:   12,
+			wantOK: true,
+		},
+		{
+			name:   "not from a last link without a number",
+			mp:     MultiPayload{Links: Links{Last: "/v1/organisation/accounts?page%5Bnumber%5D=last"}},
+			wantOK: false,
+		},
+		{name: "not from a total of 0", mp: MultiPayload{Meta: &Meta{}}, wantOK: false},
+		{name: "not from nothing", mp: MultiPayload{}, wantOK: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := lastPage(tt.mp, 2)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.want, got)
 		})
 	}
 }
+
+func Test_encodeJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		v       interface{}
+		want    string
+		wantErr string
+	}{
+		{name: "encodes with a trailing newline", v: map[string]int{"version": 1}, want: "{\"version\":1}\n"},
+		{name: "encodes more than fits in a pooled buffer", v: strings.Repeat("a", maxPooledBufferSize),
+			want: `"` + strings.Repeat("a", maxPooledBufferSize) + "\"\n"},
+		{name: "fails on what JSON can not encode", v: make(chan int), wantErr: "json: unsupported type: chan int"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := encodeJSON(tt.v)
+			if tt.wantErr != "" {
+				assert.EqualError(t, err, tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+
+			// Encoding something else reuses the buffer, which must not change what was returned before.
+			_, _ = encodeJSON(map[string]string{"overwritten": "yes"})
+			assert.Equal(t, tt.want, string(got))
+		})
+	}
+}
+
+func TestOperationCounts_stats(t *testing.T) {
+	tests := []struct {
+		name      string
+		latencies int
+		want      OperationStats
+	}{
+		{
+			name: "reports no latencies without responses",
+			want: OperationStats{Requests: 1},
+		},
+		{
+			name:      "uses the nearest rank",
+			latencies: 10,
+			want:      OperationStats{Requests: 1, P50: 5, P90: 9, P99: 10, Max: 10},
+		},
+		{
+			name:      "keeps only the latest latencies",
+			latencies: latencyWindow + 100,
+			want:      OperationStats{Requests: 1, P50: 612, P90: 1022, P99: 1114, Max: 1124},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			o := &operationCounts{requests: 1}
+
+			for i := 1; i <= tt.latencies; i++ {
+				o.addLatency(time.Duration(i))
+			}
+
+			assert.Equal(t, tt.want, o.stats())
+		})
+	}
+}
+
+func TestRedactor_redact(t *testing.T) {
+	tests := []struct {
+		name   string
+		fields []string
+		in     string
+		want   string
+	}{
+		{
+			name:   "masks JSON members of the fields",
+			fields: DefaultRedactedFields,
+			in:     `{"iban": "GB33BUKB20201555555555", "bank_id":"400300","customer_id":"a\"b1234"}`,
+			want:   `{"iban": "******************5555", "bank_id":"400300","customer_id":"****1234"}`,
+		},
+		{
+			name:   "masks filters of the fields in URLs, escaped or not",
+			fields: DefaultRedactedFields,
+			in:     "/accounts?filter%5Biban%5D=GB33BUKB20201555555555&filter[account_number]=41426819&filter[country]=GB",
+			want:   "/accounts?filter%5Biban%5D=******************5555&filter[account_number]=****6819&filter[country]=GB",
+		},
+		{
+			name: "masks nothing without fields",
+			in:   `{"iban":"GB33BUKB20201555555555"}`,
+			want: `{"iban":"GB33BUKB20201555555555"}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, newRedactor(tt.fields).redact(tt.in))
+		})
+	}
+}
+
+func TestMask(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{value: "", want: ""},
+		{value: "1234", want: "****"},
+		{value: "12345", want: "*2345"},
+		{value: "GB33BUKB20201555555555", want: "******************5555"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			assert.Equal(t, tt.want, Mask(tt.value))
+		})
+	}
+}
+
+func TestClient_retryWait(t *testing.T) {
+	now := time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)
+	c := Client{retryBackoff: time.Second, clock: func() time.Time { return now }}
+
+	tests := []struct {
+		name    string
+		attempt int
+		header  http.Header
+		want    time.Duration
+	}{
+		{name: "backs off without a header", attempt: 2, header: http.Header{}, want: 4 * time.Second},
+		{name: "seconds", header: http.Header{"Retry-After": {"3"}}, want: 3 * time.Second},
+		{name: "negative seconds back off", header: http.Header{"Retry-After": {"-3"}}, want: time.Second},
+		{name: "garbage backs off", header: http.Header{"Retry-After": {"soon"}}, want: time.Second},
+		{
+			name: "date from the date of the response",
+			header: http.Header{
+				"Retry-After": {"Fri, 01 Jan 2021 13:00:05 GMT"},
+				"Date":        {"Fri, 01 Jan 2021 13:00:00 GMT"},
+			},
+			want: 5 * time.Second,
+		},
+		{
+			name:   "date from the clock without a date on the response",
+			header: http.Header{"Retry-After": {"Fri, 01 Jan 2021 12:00:07 GMT"}},
+			want:   7 * time.Second,
+		},
+		{
+			name: "date in the past",
+			header: http.Header{
+				"Retry-After": {"Fri, 01 Jan 2021 12:59:00 GMT"},
+				"Date":        {"Fri, 01 Jan 2021 13:00:00 GMT"},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, c.retryWait(tt.attempt, &http.Response{Header: tt.header}))
+		})
+	}
+}
+
+func Test_throttle(t *testing.T) {
+	ok := &http.Response{StatusCode: http.StatusOK}
+	tooMany := &http.Response{StatusCode: http.StatusTooManyRequests}
+	ctx := context.Background()
+
+	th := newThrottle(50 * time.Millisecond)
+
+	share, err := th.acquire(ctx, 8)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, share, "starts at the concurrency")
+
+	th.observe(8, tooMany, nil, time.Millisecond)
+	th.release()
+	assert.Equal(t, 4.0, th.window, "a 429 halves the window")
+
+	th.observe(8, ok, nil, time.Millisecond)
+	assert.Equal(t, 4.25, th.window, "a response grows it by a request over the window")
+
+	th.observe(8, ok, nil, 100*time.Millisecond)
+	assert.Equal(t, 2.125, th.window, "so does a latency over the target")
+
+	for i := 0; i < 2; i++ {
+		_, err = th.acquire(ctx, 8)
+		assert.NoError(t, err)
+	}
+
+	th.observe(8, tooMany, nil, time.Millisecond)
+	th.release()
+	th.observe(8, tooMany, nil, time.Millisecond)
+	th.release()
+	assert.Equal(t, 1.0625, th.window, "429s of requests sent before the window halved are ignored")
+
+	th.observe(8, tooMany, nil, time.Millisecond)
+	assert.Equal(t, 0.53125, th.window, "later ones count")
+
+	ctxTimeout, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+
+	share, err = th.acquire(ctxTimeout, 8)
+	assert.NoError(t, err)
+	assert.InDelta(t, 0.53125/8, share, 0.0001, "the rate limit is scaled down with the window")
+
+	_, err = th.acquire(ctxTimeout, 8)
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "below one request in flight they go one at a time")
+
+	th.release()
+
+	for i := 0; i < 10; i++ {
+		th.observe(8, tooMany, nil, time.Millisecond)
+	}
+
+	assert.Equal(t, minWindow, th.window, "the window shrinks no further than minWindow")
+	assert.True(t, th.next.After(time.Now()), "requests wait part of a round trip")
+
+	th.observe(8, ok, nil, 0)
+	assert.Equal(t, 2*minWindow, th.window, "below 1 it doubles")
+
+	var none *throttle
+
+	share, err = none.acquire(ctx, 1)
+	assert.NoError(t, err)
+	assert.Equal(t, 1.0, share)
+	none.observe(1, tooMany, nil, time.Second)
+	none.release()
+}
+
+func TestAccountCache_put(t *testing.T) {
+	start := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	cache := &accountCache{ttl: time.Minute, entries: make(map[string]cachedAccount), sweepAt: minCacheSweep}
+
+	for i := 0; i < minCacheSweep; i++ {
+		cache.put(strconv.Itoa(i), cachedAccount{fetched: start}, start.Add(-time.Minute))
+	}
+
+	assert.Len(t, cache.entries, minCacheSweep, "nothing is evicted before the cache is full")
+
+	later := start.Add(2 * time.Minute)
+	cache.put("young", cachedAccount{fetched: later}, later.Add(-time.Minute))
+
+	assert.Len(t, cache.entries, 1, "accounts fetched before the oldest are evicted")
+	assert.Contains(t, cache.entries, "young")
+	assert.Equal(t, minCacheSweep, cache.sweepAt)
+
+	for i := 0; i < 2*minCacheSweep; i++ {
+		cache.put(strconv.Itoa(i), cachedAccount{fetched: later}, later.Add(-time.Minute))
+	}
+
+	assert.Len(t, cache.entries, 2*minCacheSweep+1, "young accounts are kept")
+	assert.Equal(t, 2*(2*minCacheSweep), cache.sweepAt, "the next sweep is at twice what was kept")
+}
+
+func TestLimiter_wait(t *testing.T) {
+	l := newLimiter(1)
+
+	assert.NoError(t, l.wait(context.Background(), 1), "the first request starts straight away")
+
+	reserved := l.next
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	err := l.wait(ctx, 1)
+
+	assert.True(t, errors.Is(err, context.DeadlineExceeded), "got %v", err)
+	assert.Less(t, int64(time.Since(start)), int64(500*time.Millisecond), "the wait ends with the context")
+	assert.Equal(t, reserved, l.next, "the slot of the request that never started is given back")
+}
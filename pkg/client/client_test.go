@@ -2,42 +2,48 @@ package client_test
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"os"
+	"sort"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/google/uuid"
 	"github.com/stretchr/testify/assert"
 
 	"github.com/javorszky/form3takehome/pkg/client"
 	"github.com/javorszky/form3takehome/pkg/config"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
 )
 
-const testTimeoutMs = 500
+const (
+	testTimeoutMs = 500
+	bicExample    = "BARCGB22XXX"            // from https://www.iban.com/search-bic
+	ibanExample   = "GB33BUKB20201555555555" // from https://www.iban.com/structure
+)
 
 func TestNew(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	testClient := http.Client{
 		Timeout: 30 * time.Second,
 	}
 
 	type args struct {
 		cfg config.Config
-		gmt *time.Location
 	}
 
 	tests := []struct {
-		name string
-		args args
-		want client.Client
+		name    string
+		args    args
+		want    client.Client
+		wantErr string
 	}{
 		{
 			name: "constructs a new client based on incoming data",
@@ -46,29 +52,257 @@ func TestNew(t *testing.T) {
 					AccountsAPIURL: "https://testurl",
 					OrganisationID: "orgid",
 				},
-				gmt: gmtLoc,
 			},
 			want: client.Client{
 				BaseURL:        "https://testurl",
 				OrganisationID: "orgid",
-				DateLocation:   gmtLoc,
 				HttpClient:     testClient,
 			},
 		},
+		{
+			name: "accepts a url with a path and a trailing slash",
+			args: args{
+				cfg: config.Config{AccountsAPIURL: "http://testurl:8080/accounts-api/"},
+			},
+			want: client.Client{
+				BaseURL:    "http://testurl:8080/accounts-api/",
+				HttpClient: testClient,
+			},
+		},
+		{
+			name:    "rejects a url that does not parse",
+			args:    args{cfg: config.Config{AccountsAPIURL: "htt@ps://bla"}},
+			wantErr: "client.New: base url: parse",
+		},
+		{
+			name:    "rejects a url without a scheme",
+			args:    args{cfg: config.Config{AccountsAPIURL: "testurl:8080"}},
+			wantErr: "scheme is not http or https",
+		},
+		{
+			name:    "rejects a url without a host",
+			args:    args{cfg: config.Config{AccountsAPIURL: "http:///v1"}},
+			wantErr: "no host",
+		},
+		{
+			name:    "rejects a url with a query",
+			args:    args{cfg: config.Config{AccountsAPIURL: "http://testurl?page=1"}},
+			wantErr: "query or fragment",
+		},
+		{
+			name:    "rejects an empty url",
+			args:    args{cfg: config.Config{}},
+			wantErr: "scheme is not http or https",
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			assert.Equal(t, tt.want, client.New(tt.args.cfg, testClient, tt.args.gmt))
+			got, err := client.New(tt.args.cfg, testClient)
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, fmt.Sprint(err), tt.wantErr)
+				assert.Equal(t, client.Client{}, got)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want.BaseURL, got.BaseURL)
+			assert.Equal(t, tt.want.OrganisationID, got.OrganisationID)
+			assert.Equal(t, tt.want.HttpClient, got.HttpClient)
 		})
 	}
 }
 
-func TestClient_Create(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
+// mustNew returns a Client of New, and fails the test if New fails.
+func mustNew(t *testing.T, cfg config.Config, hc http.Client, opts ...client.Option) client.Client {
+	t.Helper()
+
+	c, err := client.New(cfg, hc, opts...)
 	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
+		assert.FailNowf(t, "could not create client", "error: %s", err)
+	}
+
+	return c
+}
+
+func TestClient_endpointURL(t *testing.T) {
+	tests := []struct {
+		name     string
+		baseURL  string
+		wantPath string
+	}{
+		{
+			name:     "joins a base url without a path",
+			baseURL:  "",
+			wantPath: "/v1/organisation/accounts/ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+		},
+		{
+			name:     "joins a base url with a trailing slash",
+			baseURL:  "/",
+			wantPath: "/v1/organisation/accounts/ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+		},
+		{
+			name:     "joins a base url with a path",
+			baseURL:  "/accounts-api",
+			wantPath: "/accounts-api/v1/organisation/accounts/ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+		},
+		{
+			name:     "joins a base url with a path and a trailing slash",
+			baseURL:  "/accounts-api/",
+			wantPath: "/accounts-api/v1/organisation/accounts/ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPaths, gotQueries []string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPaths = append(gotPaths, r.URL.Path)
+				gotQueries = append(gotQueries, r.URL.RawQuery)
+
+				w.WriteHeader(http.StatusNoContent)
+			}))
+			defer ts.Close()
+
+			c := mustNew(t, config.Config{AccountsAPIURL: ts.URL + tt.baseURL}, http.Client{})
+
+			assert.NoError(t, c.Delete("ad27e265-9605-4b4b-a0e5-3003ea9cc4dc", 3))
+
+			// A struct literal parses its BaseURL on its own.
+			assert.NoError(t, client.Client{BaseURL: ts.URL + tt.baseURL}.Delete("ad27e265-9605-4b4b-a0e5-3003ea9cc4dc", 3))
+
+			assert.Equal(t, []string{tt.wantPath, tt.wantPath}, gotPaths)
+			assert.Equal(t, []string{"version=3", "version=3"}, gotQueries)
+		})
+	}
+}
+
+func TestNew_Options(t *testing.T) {
+	tests := []struct {
+		name       string
+		opts       func(debug, trace *bytes.Buffer) []client.Option
+		wantDebug  []string
+		wantTrace  []string
+		wantAuth   string
+		emptyDebug bool
+		emptyTrace bool
+	}{
+		{
+			name: "writes nothing without options",
+			opts: func(debug, trace *bytes.Buffer) []client.Option {
+				return nil
+			},
+			emptyDebug: true,
+			emptyTrace: true,
+		},
+		{
+			name: "dumps requests and responses with WithDebug",
+			opts: func(debug, trace *bytes.Buffer) []client.Option {
+				return []client.Option{client.WithDebug(debug)}
+			},
+			wantDebug: []string{
+				"debug: request:",
+				"GET /v1/organisation/accounts/uuidv4accountid HTTP/1.1",
+				"Accept: application/vnd.api+json",
+				"debug: response:",
+				"HTTP/1.1 200 OK",
+				"a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+			},
+			emptyTrace: true,
+		},
+		{
+			name: "authenticates with WithToken and keeps the token out of dumps",
+			opts: func(debug, trace *bytes.Buffer) []client.Option {
+				return []client.Option{client.WithToken("s3cret"), client.WithDebug(debug)}
+			},
+			wantDebug:  []string{"Authorization: Bearer [redacted]"},
+			wantAuth:   "Bearer s3cret",
+			emptyTrace: true,
+		},
+		{
+			name: "masks sensitive attributes in dumps",
+			opts: func(debug, trace *bytes.Buffer) []client.Option {
+				return []client.Option{client.WithDebug(debug)}
+			},
+			wantDebug: []string{
+				`"account_number":"****5678"`,
+				`"iban":"****1234"`,
+				`"customer_id":"*********gain"`,
+				`"bank_id":"89282dd"`,
+			},
+			emptyTrace: true,
+		},
+		{
+			name: "masks only the attributes set with WithRedactedFields",
+			opts: func(debug, trace *bytes.Buffer) []client.Option {
+				return []client.Option{client.WithDebug(debug), client.WithRedactedFields("bank_id")}
+			},
+			wantDebug: []string{
+				`"account_number":"12345678"`,
+				`"iban":"iban1234"`,
+				`"bank_id":"***82dd"`,
+			},
+			emptyTrace: true,
+		},
+		{
+			name: "writes request timings with WithTrace",
+			opts: func(debug, trace *bytes.Buffer) []client.Option {
+				return []client.Option{client.WithTrace(trace)}
+			},
+			emptyDebug: true,
+			wantTrace: []string{
+				"trace: GET http://",
+				"/v1/organisation/accounts/uuidv4accountid: 200 OK: dns",
+				"connect",
+				"ttfb",
+				"reused connection: false",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.wantAuth, r.Header.Get("Authorization"))
+				w.WriteHeader(http.StatusOK)
+				_, _ = fmt.Fprint(w, returnCompactFile(t, "./testdata/payload.json"))
+			}))
+			defer ts.Close()
+
+			var debug, trace bytes.Buffer
+
+			c := mustNew(t,
+				config.Config{AccountsAPIURL: ts.URL, OrganisationID: "orgid"},
+				http.Client{Timeout: testTimeoutMs * time.Millisecond},
+				tt.opts(&debug, &trace)...,
+			)
+
+			got, err := c.Fetch("uuidv4accountid")
+			assert.NoError(t, err)
+			assert.Equal(t, "a6c1a721-bb1b-41ef-bd11-800a1309ff9b", got.Data.ID)
+
+			if tt.emptyDebug {
+				assert.Empty(t, debug.String())
+			}
+
+			if tt.emptyTrace {
+				assert.Empty(t, trace.String())
+			}
+
+			for _, want := range tt.wantDebug {
+				assert.Contains(t, debug.String(), want)
+			}
+
+			for _, want := range tt.wantTrace {
+				assert.Contains(t, trace.String(), want)
+			}
+
+			assert.NotContains(t, debug.String(), "s3cret")
+		})
 	}
+}
 
+func TestClient_Create(t *testing.T) {
 	testTime, err := time.Parse(time.RFC3339, "2020-05-06T09:28:13.843Z")
 	if err != nil {
 		assert.FailNowf(t, "could not parse test time", "error: %s", err)
@@ -105,8 +339,9 @@ func TestClient_Create(t *testing.T) {
 					OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 					Type:           "accounts",
 					Version:        0,
-					CreatedOn:      testTime,
-					ModifiedOn:     testTime,
+					VersionKnown:   true,
+					CreatedOn:      client.Timestamp{Time: testTime},
+					ModifiedOn:     client.Timestamp{Time: testTime},
 					Attributes: client.Resource{
 						Country:       "GB",
 						BaseCurrency:  "GBP",
@@ -250,7 +485,7 @@ func TestClient_Create(t *testing.T) {
 			ts := httptest.NewServer(tt.handlerFunc)
 			defer ts.Close()
 
-			c := client.New(
+			c := mustNew(t,
 				config.Config{
 					AccountsAPIURL: ts.URL,
 					OrganisationID: "orgid",
@@ -258,7 +493,6 @@ func TestClient_Create(t *testing.T) {
 				http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				gmtLoc,
 			)
 
 			got, err := c.Create(tt.args.account)
@@ -274,12 +508,182 @@ func TestClient_Create(t *testing.T) {
 	}
 }
 
-func TestClient_CreateBadURL(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
+func TestClient_Create_emptyBody(t *testing.T) {
+	tests := []struct {
+		name    string
+		fetch   int
+		wantID  string
+		wantErr string
+	}{
+		{
+			name:   "fetches the created account",
+			fetch:  http.StatusOK,
+			wantID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+		},
+		{
+			name:    "fails if the fetch fails",
+			fetch:   http.StatusNotFound,
+			wantErr: "client.CreateWithID: fetching created account: ",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					w.Header().Set("Location", "/v1/organisation/accounts/a6c1a721-bb1b-41ef-bd11-800a1309ff9b")
+					w.WriteHeader(http.StatusCreated)
+
+					return
+				}
+
+				fetched = r.URL.Path
+
+				w.WriteHeader(tt.fetch)
+
+				if tt.fetch == http.StatusOK {
+					_, _ = fmt.Fprint(w, returnCompactFile(t, "./testdata/payload.json"))
+				}
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+
+			const id = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+			got, err := c.CreateWithID(id, client.Resource{
+				Country: "GB", BankIDCode: "GBDSC", BIC: "bic", BankID: "123456",
+			})
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantID, got.Data.ID)
+			assert.Equal(t, "/v1/organisation/accounts/"+id, fetched)
+		})
+	}
+}
+
+func TestWithExistingOnConflict(t *testing.T) {
+	const id = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	account := client.Resource{Country: "GB", BankIDCode: "GBDSC", BIC: "NWBKGB22", BankID: "400300"}
+
+	tests := []struct {
+		name       string
+		option     bool
+		fetch      int
+		create     func(c client.Client) (client.Payload, error)
+		wantID     string
+		wantExists bool
+		wantFetch  bool
+	}{
+		{
+			name:   "CreateWithID returns the account that exists",
+			option: true,
+			fetch:  http.StatusOK,
+			create: func(c client.Client) (client.Payload, error) {
+				return c.CreateWithID(id, account)
+			},
+			wantID:     id,
+			wantExists: true,
+			wantFetch:  true,
+		},
+		{
+			name:   "Create returns the account that exists",
+			option: true,
+			fetch:  http.StatusOK,
+			create: func(c client.Client) (client.Payload, error) {
+				client.WithIDGenerator(func() (uuid.UUID, error) { return uuid.Parse(id) })(&c)
+
+				return c.Create(account)
+			},
+			wantID:     id,
+			wantExists: true,
+			wantFetch:  true,
+		},
+		{
+			name:   "the batches return the account that exists",
+			option: true,
+			fetch:  http.StatusOK,
+			create: func(c client.Client) (p client.Payload, err error) {
+				c.CreateBatchWithIDs([]client.Data{{ID: id, Attributes: account}}, func(_ int, got client.Payload, e error) {
+					p, err = got, e
+				})
+
+				return p, err
+			},
+			wantID:     id,
+			wantExists: true,
+			wantFetch:  true,
+		},
+		{
+			name:   "a conflict about something else than the ID",
+			option: true,
+			fetch:  http.StatusNotFound,
+			create: func(c client.Client) (client.Payload, error) {
+				return c.CreateWithID(id, account)
+			},
+			wantFetch: true,
+		},
+		{
+			name:  "without the option",
+			fetch: http.StatusOK,
+			create: func(c client.Client) (client.Payload, error) {
+				return c.CreateWithID(id, account)
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fetched bool
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost {
+					w.WriteHeader(http.StatusConflict)
+					_, _ = w.Write([]byte(`{"error_message": "Account cannot be created as it violates a duplicate constraint"}`))
+
+					return
+				}
+
+				fetched = r.URL.Path == "/v1/organisation/accounts/"+id
+
+				w.WriteHeader(tt.fetch)
+
+				if tt.fetch == http.StatusOK {
+					_, _ = fmt.Fprint(w, returnCompactFile(t, "./testdata/payload.json"))
+				}
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			if tt.option {
+				client.WithExistingOnConflict()(&c)
+			}
+
+			got, err := tt.create(c)
+
+			var existsErr *client.AlreadyExistsError
+
+			assert.True(t, errors.Is(err, client.ErrConflict), "got %v", err)
+			assert.Equal(t, tt.wantExists, errors.Is(err, client.ErrAlreadyExists))
+			assert.Equal(t, tt.wantExists, errors.As(err, &existsErr))
+			assert.Equal(t, tt.wantID, got.ID())
+			assert.Equal(t, tt.wantFetch, fetched)
+
+			if tt.wantExists {
+				assert.Equal(t, id, existsErr.ID)
+				assert.Contains(t, err.Error(), "account "+id+" already exists: unexpected response code: 409")
+			}
+		})
 	}
+}
 
+func TestClient_CreateBadURL(t *testing.T) {
 	type args struct {
 		account client.Resource
 	}
@@ -311,16 +715,13 @@ func TestClient_CreateBadURL(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := client.New(
-				config.Config{
-					AccountsAPIURL: "htt@ps://bla",
-					OrganisationID: "orgid",
-				},
-				http.Client{
+			c := client.Client{
+				BaseURL:        "htt@ps://bla",
+				OrganisationID: "orgid",
+				HttpClient: http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				gmtLoc,
-			)
+			}
 
 			got, err := c.Create(tt.args.account)
 
@@ -336,11 +737,6 @@ func TestClient_CreateBadURL(t *testing.T) {
 }
 
 func TestClient_Fetch(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	testTime, err := time.Parse(time.RFC3339, "2020-05-06T09:28:13.843Z")
 	if err != nil {
 		assert.FailNowf(t, "could not parse test time", "error: %s", err)
@@ -372,8 +768,9 @@ func TestClient_Fetch(t *testing.T) {
 					OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 					Type:           "accounts",
 					Version:        0,
-					CreatedOn:      testTime,
-					ModifiedOn:     testTime,
+					VersionKnown:   true,
+					CreatedOn:      client.Timestamp{Time: testTime},
+					ModifiedOn:     client.Timestamp{Time: testTime},
 					Attributes: client.Resource{
 						Country:       "GB",
 						BaseCurrency:  "GBP",
@@ -506,7 +903,6 @@ func TestClient_Fetch(t *testing.T) {
 				HttpClient: http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				DateLocation: gmtLoc,
 			}
 
 			got, err := c.Fetch(tt.args.accountID)
@@ -523,11 +919,6 @@ func TestClient_Fetch(t *testing.T) {
 }
 
 func TestClient_FetchBadURL(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	type args struct {
 		accountID string
 	}
@@ -554,16 +945,13 @@ func TestClient_FetchBadURL(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			c := client.New(
-				config.Config{
-					AccountsAPIURL: "htt@ps://bla",
-					OrganisationID: "orgid",
-				},
-				http.Client{
+			c := client.Client{
+				BaseURL:        "htt@ps://bla",
+				OrganisationID: "orgid",
+				HttpClient: http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				gmtLoc,
-			)
+			}
 
 			got, err := c.Fetch(tt.args.accountID)
 
@@ -579,11 +967,6 @@ func TestClient_FetchBadURL(t *testing.T) {
 }
 
 func TestClient_Delete(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	type args struct {
 		accountID string
 		version   uint
@@ -593,6 +976,7 @@ func TestClient_Delete(t *testing.T) {
 		name        string
 		handlerFunc http.HandlerFunc
 		args        args
+		opts        []client.Option
 		wantErr     bool
 	}{
 		{
@@ -617,6 +1001,41 @@ func TestClient_Delete(t *testing.T) {
 			}, // does not matter what we pass in for these tests.
 			wantErr: true,
 		},
+		{
+			name: "returns error if the account is not found",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			args: args{
+				accountID: "uuidv4accountid",
+				version:   3,
+			}, // does not matter what we pass in for these tests.
+			wantErr: true,
+		},
+		{
+			name: "succeeds if the account is not found with WithIdempotentDelete",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusNotFound)
+			},
+			args: args{
+				accountID: "uuidv4accountid",
+				version:   3,
+			}, // does not matter what we pass in for these tests.
+			opts:    []client.Option{client.WithIdempotentDelete()},
+			wantErr: false,
+		},
+		{
+			name: "returns error on a conflict with WithIdempotentDelete",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+			},
+			args: args{
+				accountID: "uuidv4accountid",
+				version:   3,
+			}, // does not matter what we pass in for these tests.
+			opts:    []client.Option{client.WithIdempotentDelete()},
+			wantErr: true,
+		},
 		{
 			name: "returns error if the response takes longer than the timeout",
 			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
@@ -641,7 +1060,10 @@ func TestClient_Delete(t *testing.T) {
 				HttpClient: http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				DateLocation: gmtLoc,
+			}
+
+			for _, opt := range tt.opts {
+				opt(&c)
 			}
 
 			err := c.Delete(tt.args.accountID, tt.args.version)
@@ -656,11 +1078,6 @@ func TestClient_Delete(t *testing.T) {
 }
 
 func TestClient_DeleteBadURL(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	type args struct {
 		accountID string
 		version   uint
@@ -688,7 +1105,6 @@ func TestClient_DeleteBadURL(t *testing.T) {
 				HttpClient: http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				DateLocation: gmtLoc,
 			}
 
 			err := c.Delete(tt.args.accountID, tt.args.version)
@@ -703,11 +1119,6 @@ func TestClient_DeleteBadURL(t *testing.T) {
 }
 
 func TestClient_List(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load GMT location", "error: %s", err)
-	}
-
 	testTime, err := time.Parse(time.RFC3339, "2020-05-06T09:28:13.843Z")
 	if err != nil {
 		assert.FailNowf(t, "could not parse test time", "error: %s", err)
@@ -747,8 +1158,9 @@ func TestClient_List(t *testing.T) {
 						OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 						Type:           "accounts",
 						Version:        0,
-						CreatedOn:      testTime,
-						ModifiedOn:     testTime,
+						VersionKnown:   true,
+						CreatedOn:      client.Timestamp{Time: testTime},
+						ModifiedOn:     client.Timestamp{Time: testTime},
 						Attributes: client.Resource{
 							Country:       "GB",
 							BaseCurrency:  "GBP",
@@ -782,8 +1194,9 @@ func TestClient_List(t *testing.T) {
 						OrganisationID: "7442ea6b-164a-4818-b470-d98abfbc24ae",
 						Type:           "accounts",
 						Version:        0,
-						CreatedOn:      testTime2,
-						ModifiedOn:     testTime2,
+						VersionKnown:   true,
+						CreatedOn:      client.Timestamp{Time: testTime2},
+						ModifiedOn:     client.Timestamp{Time: testTime2},
 						Attributes: client.Resource{
 							Country:       "GB",
 							BaseCurrency:  "GBP",
@@ -925,7 +1338,6 @@ func TestClient_List(t *testing.T) {
 				HttpClient: http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				DateLocation: gmtLoc,
 			}
 
 			got, err := c.List(tt.args.pageNumber, tt.args.pageSize)
@@ -942,11 +1354,6 @@ func TestClient_List(t *testing.T) {
 }
 
 func TestClient_ListBadURL(t *testing.T) {
-	gmtLoc, err := time.LoadLocation("GMT")
-	if err != nil {
-		assert.FailNowf(t, "could not load gmt location", "error: %s", err)
-	}
-
 	type args struct {
 		pageNumber uint
 		pageSize   uint
@@ -976,7 +1383,6 @@ func TestClient_ListBadURL(t *testing.T) {
 				HttpClient: http.Client{
 					Timeout: testTimeoutMs * time.Millisecond,
 				},
-				DateLocation: gmtLoc,
 			}
 
 			got, err := c.List(tt.args.pageNumber, tt.args.pageSize)
@@ -1018,3 +1424,871 @@ func returnCompactFile(t *testing.T, filename string) string {
 
 	return b.String()
 }
+
+func TestClient_ListAll(t *testing.T) {
+	tests := []struct {
+		name        string
+		records     int
+		pageSize    uint
+		concurrency int
+		failPage    string
+		hint        string
+		wantPages   []string
+		wantErr     bool
+	}{
+		{
+			name:      "pages until the first page that is not full",
+			records:   5,
+			pageSize:  2,
+			wantPages: []string{"0", "1", "2"},
+		},
+		{
+			name:      "stops on an exactly full last page without a next link",
+			records:   4,
+			pageSize:  2,
+			wantPages: []string{"0", "1"},
+		},
+		{
+			name:      "makes a single request when there are no records",
+			records:   0,
+			pageSize:  2,
+			wantPages: []string{"0"},
+		},
+		{
+			name:      "returns error when a page fails",
+			records:   5,
+			pageSize:  2,
+			failPage:  "1",
+			wantPages: []string{"0", "1"},
+			wantErr:   true,
+		},
+		{
+			name:        "requests the first page alone, then as many at once as the concurrency allows",
+			records:     5,
+			pageSize:    2,
+			concurrency: 4,
+			wantPages:   []string{"0", "1", "2", "3", "4"},
+		},
+		{
+			name:        "requests no pages past the last one of the total in the meta",
+			records:     5,
+			pageSize:    2,
+			concurrency: 4,
+			hint:        "meta",
+			wantPages:   []string{"0", "1", "2"},
+		},
+		{
+			name:        "requests no pages past the last link",
+			records:     8,
+			pageSize:    2,
+			concurrency: 2,
+			hint:        "last",
+			wantPages:   []string{"0", "1", "2", "3"},
+		},
+		{
+			name:        "returns error when a page fails with concurrency",
+			records:     5,
+			pageSize:    2,
+			concurrency: 2,
+			failPage:    "1",
+			wantPages:   []string{"0", "1", "2"},
+			wantErr:     true,
+		},
+		{
+			name:      "returns error for a zero page size without making requests",
+			records:   5,
+			pageSize:  0,
+			wantPages: []string{},
+			wantErr:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPages := make([]string, 0)
+			ts := httptest.NewServer(pagingHandler(t, tt.records, tt.failPage, tt.hint, &gotPages))
+			defer ts.Close()
+
+			c := client.Client{
+				BaseURL:        ts.URL,
+				OrganisationID: "orgid",
+				HttpClient: http.Client{
+					Timeout: testTimeoutMs * time.Millisecond,
+				},
+			}
+			client.WithConcurrency(tt.concurrency)(&c)
+
+			got, err := c.ListAll(tt.pageSize)
+
+			sort.Strings(gotPages)
+			assert.Equal(t, tt.wantPages, gotPages)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+				assert.Nil(t, got)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Len(t, got, tt.records)
+
+			for i, d := range got {
+				assert.Equal(t, fmt.Sprintf("account-%d", i), d.ID)
+			}
+		})
+	}
+}
+
+func TestClient_ListAll_partialResults(t *testing.T) {
+	tests := []struct {
+		name        string
+		concurrency int
+	}{
+		{name: "one page at a time"},
+		{name: "with concurrency", concurrency: 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPages := make([]string, 0)
+			handler := pagingHandler(t, 9, "2", "", &gotPages)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				handler(w, r)
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithConcurrency(tt.concurrency)(&c)
+
+			got, err := c.ListAll(2)
+			assert.Error(t, err)
+			assert.Nil(t, got, "without WithPartialResults nothing is returned")
+
+			client.WithPartialResults()(&c)
+
+			got, err = c.ListAll(2)
+
+			var partialErr *client.PartialListError
+
+			assert.True(t, errors.As(err, &partialErr), "got %v", err)
+			assert.Equal(t, client.ListCursor{Page: 2, PageSize: 2}, partialErr.Cursor)
+			assert.Equal(t, 4, partialErr.Records)
+			assert.Equal(t, []string{"account-0", "account-1", "account-2", "account-3"}, client.MultiPayload{
+				Data: got,
+			}.IDs())
+
+			var apiErr *client.APIError
+
+			assert.True(t, errors.As(err, &apiErr), "the error of the page is kept")
+
+			gotPages = gotPages[:0]
+			handler = pagingHandler(t, 9, "", "", &gotPages)
+
+			rest, err := c.ListAllFrom(partialErr.Cursor)
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"account-4", "account-5", "account-6", "account-7", "account-8"},
+				client.MultiPayload{Data: rest}.IDs())
+			assert.NotContains(t, gotPages, "0", "pages before the cursor are not requested again")
+		})
+	}
+}
+
+func TestClient_ListEach(t *testing.T) {
+	errStop := errors.New("stop")
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	tests := []struct {
+		name      string
+		ctx       context.Context
+		records   int
+		pageSize  uint
+		failPage  string
+		stopAt    int
+		wantPages []string
+		wantIDs   int
+		wantErr   string
+	}{
+		{
+			name:      "streams every account of every page",
+			records:   5,
+			pageSize:  2,
+			wantPages: []string{"0", "1", "2"},
+			wantIDs:   5,
+		},
+		{
+			name:      "stops on an exactly full last page without a next link",
+			records:   4,
+			pageSize:  2,
+			wantPages: []string{"0", "1"},
+			wantIDs:   4,
+		},
+		{
+			name:      "makes a single request when there are no records",
+			records:   0,
+			pageSize:  2,
+			wantPages: []string{"0"},
+		},
+		{
+			name:      "returns error when a page fails",
+			records:   5,
+			pageSize:  2,
+			failPage:  "1",
+			wantPages: []string{"0", "1"},
+			wantIDs:   2,
+			wantErr:   "client.ListEach: page 1: ",
+		},
+		{
+			name:      "stops in the middle of a page when fn returns an error",
+			records:   5,
+			pageSize:  3,
+			stopAt:    2,
+			wantPages: []string{"0"},
+			wantIDs:   2,
+			wantErr:   "client.ListEach: page 0: stop",
+		},
+		{
+			name:      "makes no requests with a done context",
+			ctx:       canceled,
+			records:   5,
+			pageSize:  2,
+			wantPages: []string{},
+			wantErr:   "client.ListEach: page 0: context canceled",
+		},
+		{
+			name:      "returns error for a zero page size without making requests",
+			records:   5,
+			pageSize:  0,
+			wantPages: []string{},
+			wantErr:   "client.ListEach: pageSize has to be at least 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPages := make([]string, 0)
+			ts := httptest.NewServer(pagingHandler(t, tt.records, tt.failPage, "", &gotPages))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+
+			ctx := tt.ctx
+			if ctx == nil {
+				ctx = context.Background()
+			}
+
+			gotIDs := make([]string, 0)
+
+			err := c.ListEach(ctx, tt.pageSize, func(d client.Data) error {
+				gotIDs = append(gotIDs, d.ID)
+				if len(gotIDs) == tt.stopAt {
+					return errStop
+				}
+
+				return nil
+			})
+
+			assert.Equal(t, tt.wantPages, gotPages)
+
+			if assert.Len(t, gotIDs, tt.wantIDs) {
+				for i, id := range gotIDs {
+					assert.Equal(t, fmt.Sprintf("account-%d", i), id)
+				}
+			}
+
+			if tt.wantErr != "" {
+				assert.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+
+	t.Run("returns the error of fn", func(t *testing.T) {
+		ts := httptest.NewServer(pagingHandler(t, 1, "", "", new([]string)))
+		defer ts.Close()
+
+		c := client.Client{BaseURL: ts.URL}
+
+		err := c.ListEach(context.Background(), 1, func(client.Data) error { return errStop })
+		assert.True(t, errors.Is(err, errStop))
+	})
+}
+
+// pagingHandler serves the given number of accounts page by page the way the accounts API does, recording the page
+// numbers requested. A request for failPage gets a 500 response. With a hint of "meta", pages have the total number of
+// accounts in their meta, and with "last", a link to the last page by number.
+func pagingHandler(t *testing.T, records int, failPage, hint string, gotPages *[]string) http.HandlerFunc {
+	t.Helper()
+
+	var mu sync.Mutex
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		pageNumber := r.URL.Query().Get("page[number]")
+
+		mu.Lock()
+		*gotPages = append(*gotPages, pageNumber)
+		mu.Unlock()
+
+		if pageNumber == failPage {
+			w.WriteHeader(http.StatusInternalServerError)
+
+			return
+		}
+
+		var number, size int
+
+		_, _ = fmt.Sscan(pageNumber, &number)
+		_, _ = fmt.Sscan(r.URL.Query().Get("page[size]"), &size)
+
+		mp := client.MultiPayload{Data: make([]client.Data, 0)}
+
+		for i := number * size; i < records && i < (number+1)*size; i++ {
+			mp.Data = append(mp.Data, client.Data{
+				ID:         fmt.Sprintf("account-%d", i),
+				Type:       "accounts",
+				Attributes: client.Resource{Country: "GB"},
+			})
+		}
+
+		if (number+1)*size < records {
+			mp.Links.Next = fmt.Sprintf("/v1/organisation/accounts?page[number]=%d&page[size]=%d", number+1, size)
+		}
+
+		switch hint {
+		case "meta":
+			mp.Meta = &client.Meta{Total: records}
+		case "last":
+			mp.Links.Last = fmt.Sprintf("/v1/organisation/accounts?page[number]=%d&page[size]=%d", (records-1)/size,
+				size)
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_ = json.NewEncoder(w).Encode(mp)
+	}
+}
+
+func TestClient_ListPagesFrom(t *testing.T) {
+	gotPages := make([]string, 0)
+	ts := httptest.NewServer(pagingHandler(t, 5, "", "", &gotPages))
+
+	defer ts.Close()
+
+	c := client.Client{
+		BaseURL:        ts.URL,
+		OrganisationID: "orgid",
+		HttpClient: http.Client{
+			Timeout: testTimeoutMs * time.Millisecond,
+		},
+	}
+
+	got := make([]string, 0)
+
+	err := c.ListPagesFrom(1, 2, func(mp client.MultiPayload) error {
+		for _, d := range mp.Data {
+			got = append(got, d.ID)
+		}
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"1", "2"}, gotPages)
+	assert.Equal(t, []string{"account-2", "account-3", "account-4"}, got)
+}
+
+func TestClient_Update(t *testing.T) {
+	tests := []struct {
+		name        string
+		handlerFunc http.HandlerFunc
+		wantErr     error
+	}{
+		{
+			name: "sends only the given attributes with the version and returns the updated account",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPatch, r.Method)
+				assert.Equal(t, "/v1/organisation/accounts/accountid", r.URL.Path)
+
+				body, _ := ioutil.ReadAll(r.Body)
+				assert.JSONEq(t, `{"data": {"id": "accountid", "type": "accounts", "version": 3,
+					"attributes": {"status": "closed", "joint_account": true}}}`, string(body))
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+			},
+		},
+		{
+			name: "returns a conflict on a stale version",
+			handlerFunc: func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusConflict)
+			},
+			wantErr: client.ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(tt.handlerFunc)
+			defer ts.Close()
+
+			c := client.Client{
+				BaseURL:        ts.URL,
+				OrganisationID: "orgid",
+				HttpClient: http.Client{
+					Timeout: testTimeoutMs * time.Millisecond,
+				},
+			}
+
+			p, err := c.Update("accountid", 3, map[string]interface{}{"status": "closed", "joint_account": true})
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.NotEmpty(t, p.Data.ID)
+		})
+	}
+}
+
+func TestClient_ListFiltered(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    client.Filter
+		status    int
+		wantQuery url.Values
+		wantErr   error
+	}{
+		{
+			name:   "sends every pair of the filter next to the page",
+			filter: client.Filter{"iban": "GB33 BUKB", "bank_id": "400300"},
+			status: http.StatusOK,
+			wantQuery: url.Values{
+				"page[number]":    {"1"},
+				"page[size]":      {"2"},
+				"filter[iban]":    {"GB33 BUKB"},
+				"filter[bank_id]": {"400300"},
+			},
+		},
+		{
+			name:   "sends only the page without a filter",
+			status: http.StatusOK,
+			wantQuery: url.Values{
+				"page[number]": {"1"},
+				"page[size]":   {"2"},
+			},
+		},
+		{
+			name:    "returns errors matching the status code",
+			filter:  client.Filter{"country": "GB"},
+			status:  http.StatusNotFound,
+			wantErr: client.ErrNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.wantQuery != nil {
+					assert.Equal(t, tt.wantQuery, r.URL.Query())
+				}
+
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(`{"data": [], "links": {}}`))
+			}))
+			defer ts.Close()
+
+			c := client.Client{
+				BaseURL:        ts.URL,
+				OrganisationID: "orgid",
+				HttpClient: http.Client{
+					Timeout: testTimeoutMs * time.Millisecond,
+				},
+			}
+
+			_, err := c.ListFiltered(tt.filter, 1, 2)
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestClient_ListPagesFiltered(t *testing.T) {
+	gotPages := make([]string, 0)
+	paging := pagingHandler(t, 3, "", "", &gotPages)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "GB", r.URL.Query().Get("filter[country]"))
+		paging(w, r)
+	}))
+
+	defer ts.Close()
+
+	c := client.Client{
+		BaseURL:        ts.URL,
+		OrganisationID: "orgid",
+		HttpClient: http.Client{
+			Timeout: testTimeoutMs * time.Millisecond,
+		},
+	}
+
+	got := 0
+
+	err := c.ListPagesFiltered(client.Filter{"country": "GB"}, 2, func(mp client.MultiPayload) error {
+		got += len(mp.Data)
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0", "1"}, gotPages)
+	assert.Equal(t, 3, got)
+}
+
+func TestWithClock_WithIDGenerator(t *testing.T) {
+	var gotDate, gotRequestID, gotAccountID string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var p client.Payload
+
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		gotDate, gotRequestID, gotAccountID = r.Header.Get("Date"), r.Header.Get("X-Request-Id"), p.Data.ID
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	ids := []uuid.UUID{
+		uuid.MustParse("00000000-0000-4000-8000-000000000001"),
+		uuid.MustParse("00000000-0000-4000-8000-000000000002"),
+	}
+
+	var records []client.AuditRecord
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithClock(func() time.Time {
+		return time.Date(2021, time.March, 4, 5, 6, 7, 0, time.FixedZone("CET", 3600))
+	})(&c)
+	client.WithIDGenerator(func() (uuid.UUID, error) {
+		if len(ids) == 0 {
+			return uuid.UUID{}, errors.New("out of ids")
+		}
+
+		id := ids[0]
+		ids = ids[1:]
+
+		return id, nil
+	})(&c)
+	client.WithAuditSink(client.AuditSinkFunc(func(r client.AuditRecord) {
+		records = append(records, r)
+	}))(&c)
+
+	_, err := c.Create(fixtures.ValidGB())
+	assert.NoError(t, err)
+	assert.Equal(t, "Thu, 04 Mar 2021 04:06:07 GMT", gotDate)
+	assert.Equal(t, "00000000-0000-4000-8000-000000000001", gotAccountID)
+	assert.Equal(t, "00000000-0000-4000-8000-000000000002", gotRequestID)
+
+	if assert.Len(t, records, 1) {
+		assert.Equal(t, time.Date(2021, time.March, 4, 4, 6, 7, 0, time.UTC), records[0].Time)
+	}
+
+	_, err = c.Create(fixtures.ValidGB())
+	assert.EqualError(t, err, "client.Create new uuid: out of ids")
+}
+
+// countingTransport counts the requests it sends with http.DefaultTransport.
+type countingTransport struct {
+	mu       sync.Mutex
+	requests int
+}
+
+func (ct *countingTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	ct.mu.Lock()
+	ct.requests++
+	ct.mu.Unlock()
+
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestWithHTTPClient_WithTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	shared := &countingTransport{}
+	hc := &http.Client{Transport: shared}
+
+	// The HttpClient field would fail every request, so they only succeed if they are sent with hc.
+	c1 := mustNew(t, config.Config{AccountsAPIURL: ts.URL}, http.Client{Transport: failingTransport{}},
+		client.WithHTTPClient(hc))
+	c2 := mustNew(t, config.Config{AccountsAPIURL: ts.URL}, http.Client{}, client.WithHTTPClient(hc),
+		client.WithTransport(failingTransport{}))
+
+	for _, c := range []client.Client{c1, c2, c1.WithRequestID("derived")} {
+		_, err := c.Fetch(accountID)
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, 3, shared.requests, "every client and copy shares the http.Client")
+
+	own := &countingTransport{}
+	c3 := mustNew(t, config.Config{AccountsAPIURL: ts.URL}, http.Client{Timeout: time.Second},
+		client.WithTransport(own))
+
+	_, err := c3.Fetch(accountID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, own.requests)
+	assert.Equal(t, time.Second, c3.HttpClient.Timeout, "the rest of the http.Client stays")
+
+	client.WithHTTPClient(nil)(&c1)
+
+	_, err = c1.Fetch(accountID)
+	assert.Error(t, err, "without an http.Client, the HttpClient field is used again")
+}
+
+// failingTransport fails every request.
+type failingTransport struct{}
+
+func (failingTransport) RoundTrip(*http.Request) (*http.Response, error) {
+	return nil, errors.New("failing transport")
+}
+
+func TestWithAcceptedStatuses(t *testing.T) {
+	account := client.Resource{Country: "GB", BankIDCode: "GBDSC", BIC: "bic", BankID: "123456"}
+
+	tests := []struct {
+		name    string
+		status  int
+		body    bool
+		opts    []client.Option
+		wantID  string
+		wantErr bool
+	}{
+		{
+			name:   "201 Created by default",
+			status: http.StatusCreated,
+			body:   true,
+			wantID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+		},
+		{
+			name:    "200 OK is an error by default",
+			status:  http.StatusOK,
+			body:    true,
+			wantErr: true,
+		},
+		{
+			name:   "200 OK once accepted",
+			status: http.StatusOK,
+			body:   true,
+			opts:   []client.Option{client.WithAcceptedStatuses("create", http.StatusCreated, http.StatusOK)},
+			wantID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+		},
+		{
+			name:   "202 Accepted without a body leaves the payload empty",
+			status: http.StatusAccepted,
+			opts:   []client.Option{client.WithAcceptedStatuses("create", http.StatusAccepted)},
+		},
+		{
+			name:    "201 Created is an error once not accepted",
+			status:  http.StatusCreated,
+			body:    true,
+			opts:    []client.Option{client.WithAcceptedStatuses("create", http.StatusAccepted)},
+			wantErr: true,
+		},
+		{
+			name:   "no statuses mean the default again",
+			status: http.StatusCreated,
+			body:   true,
+			opts: []client.Option{
+				client.WithAcceptedStatuses("create", http.StatusAccepted),
+				client.WithAcceptedStatuses("create"),
+			},
+			wantID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+		},
+		{
+			name:    "statuses of other operations do not apply",
+			status:  http.StatusOK,
+			body:    true,
+			opts:    []client.Option{client.WithAcceptedStatuses("fetch", http.StatusOK, http.StatusAccepted)},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+
+				if tt.body {
+					_, _ = fmt.Fprint(w, returnCompactFile(t, "./testdata/payload.json"))
+				}
+			}))
+			defer ts.Close()
+
+			c := mustNew(t, config.Config{AccountsAPIURL: ts.URL}, http.Client{}, tt.opts...)
+
+			got, err := c.Create(account)
+			if tt.wantErr {
+				var apiErr *client.APIError
+
+				assert.True(t, errors.As(err, &apiErr))
+				assert.Equal(t, tt.status, apiErr.StatusCode)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantID, got.Data.ID)
+		})
+	}
+}
+
+func TestWithMediaTypes(t *testing.T) {
+	tests := []struct {
+		name            string
+		opts            []client.Option
+		wantAccept      string
+		wantContentType string
+	}{
+		{
+			name:            "the media type of the API by default",
+			wantAccept:      "application/vnd.api+json",
+			wantContentType: "application/vnd.api+json",
+		},
+		{
+			name: "versioned media types",
+			opts: []client.Option{
+				client.WithMediaTypes("application/vnd.api+json; version=2", "application/vnd.api+json; version=1"),
+			},
+			wantAccept:      "application/vnd.api+json; version=2",
+			wantContentType: "application/vnd.api+json; version=1",
+		},
+		{
+			name:            "an empty one is the default",
+			opts:            []client.Option{client.WithMediaTypes("application/vnd.api+json; version=2", "")},
+			wantAccept:      "application/vnd.api+json; version=2",
+			wantContentType: "application/vnd.api+json",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accept, contentType []string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				accept = append(accept, r.Header.Get("Accept"))
+				contentType = append(contentType, r.Header.Get("Content-Type"))
+
+				if r.Method == http.MethodPost {
+					w.WriteHeader(http.StatusCreated)
+				}
+
+				_, _ = fmt.Fprint(w, returnCompactFile(t, "./testdata/payload.json"))
+			}))
+			defer ts.Close()
+
+			c := mustNew(t, config.Config{AccountsAPIURL: ts.URL}, http.Client{}, tt.opts...)
+
+			_, err := c.Create(fixtures.ValidGB())
+			assert.NoError(t, err)
+
+			_, err = c.Fetch("a6c1a721-bb1b-41ef-bd11-800a1309ff9b")
+			assert.NoError(t, err)
+
+			assert.Equal(t, []string{tt.wantAccept, tt.wantAccept}, accept)
+			assert.Equal(t, []string{tt.wantContentType, ""}, contentType, "a request without a body has no Content-Type")
+		})
+	}
+}
+
+func TestClient_requestBody(t *testing.T) {
+	type received struct {
+		contentLength int64
+		contentType   string
+		body          string
+	}
+
+	var got []received
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		got = append(got, received{contentLength: r.ContentLength, contentType: r.Header.Get("Content-Type"),
+			body: string(body)})
+
+		if len(got) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusCreated)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithRetries(1, time.Millisecond)(&c)
+
+	_, err := c.Create(fixtures.ValidGB())
+	assert.NoError(t, err)
+
+	if assert.Len(t, got, 2) {
+		assert.NotEmpty(t, got[0].body)
+		assert.NotContains(t, got[0].body, "created_on", "only the fields the client owns are sent")
+		assert.NotContains(t, got[0].body, "version")
+		assert.Equal(t, int64(len(got[0].body)), got[0].contentLength)
+		assert.Equal(t, "application/vnd.api+json", got[0].contentType)
+		assert.Equal(t, got[0], got[1], "a retry sends the same body")
+	}
+}
+
+func TestClient_requestBody_redirect(t *testing.T) {
+	tests := []struct {
+		name   string
+		status int
+	}{
+		{name: "307 Temporary Redirect", status: http.StatusTemporaryRedirect},
+		{name: "308 Permanent Redirect", status: http.StatusPermanentRedirect},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var bodies []string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := ioutil.ReadAll(r.Body)
+				bodies = append(bodies, string(body))
+
+				if r.URL.Path != "/moved/v1/organisation/accounts/accountid" {
+					http.Redirect(w, r, "/moved"+r.URL.Path, tt.status)
+
+					return
+				}
+
+				assert.Equal(t, http.MethodPatch, r.Method)
+				_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+
+			_, err := c.Update("accountid", 1, map[string]interface{}{"status": "closed"})
+			assert.NoError(t, err)
+
+			if assert.Len(t, bodies, 2) {
+				assert.Contains(t, bodies[0], `"status":"closed"`)
+				assert.Equal(t, bodies[0], bodies[1], "the redirect replays the body")
+			}
+		})
+	}
+}
@@ -0,0 +1,41 @@
+package client
+
+import (
+	"encoding/json"
+	"io"
+	"io/ioutil"
+)
+
+// Codec encodes the bodies of requests and decodes the bodies of responses, for users whose profiles show
+// encoding/json dominating bulk imports and exports. It has the Marshal and Unmarshal functions of encoding/json, so
+// the configurations of drop-in replacements, like jsoniter.ConfigCompatibleWithStandardLibrary, are Codecs as they
+// are, and code-generated marshalers can be wrapped in a few lines. Payload, MultiPayload, Data, and the map of
+// attributes of Update go through it, and it has to encode them the way encoding/json does.
+type Codec interface {
+	Marshal(v interface{}) ([]byte, error)
+	Unmarshal(data []byte, v interface{}) error
+}
+
+// encode encodes v with codec, or with encoding/json into a pooled buffer if codec is nil.
+func encode(codec Codec, v interface{}) ([]byte, error) {
+	if codec == nil {
+		return encodeJSON(v)
+	}
+
+	return codec.Marshal(v)
+}
+
+// decode decodes the JSON value in r into v with codec, which needs all of it in memory first, or with a json.Decoder
+// reading r if codec is nil.
+func decode(codec Codec, r io.Reader, v interface{}) error {
+	if codec == nil {
+		return json.NewDecoder(r).Decode(v)
+	}
+
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return err
+	}
+
+	return codec.Unmarshal(data, v)
+}
@@ -0,0 +1,148 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// countingCodec is encoding/json, counting the values it marshals and unmarshals.
+type countingCodec struct {
+	mu         sync.Mutex
+	marshals   int
+	unmarshals int
+}
+
+func (cc *countingCodec) Marshal(v interface{}) ([]byte, error) {
+	cc.mu.Lock()
+	cc.marshals++
+	cc.mu.Unlock()
+
+	return json.Marshal(v)
+}
+
+func (cc *countingCodec) Unmarshal(data []byte, v interface{}) error {
+	cc.mu.Lock()
+	cc.unmarshals++
+	cc.mu.Unlock()
+
+	return json.Unmarshal(data, v)
+}
+
+func TestWithCodec(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	var pages []string
+
+	list := pagingHandler(t, 3, "", "", &pages)
+	payload := returnCompactFile(t, "./testdata/payload.json")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/accounts") && r.Method == http.MethodGet {
+			list(w, r)
+
+			return
+		}
+
+		if r.Method == http.MethodPost {
+			w.WriteHeader(http.StatusCreated)
+		}
+
+		_, _ = fmt.Fprint(w, payload)
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name          string
+		call          func(c client.Client) error
+		wantMarshals  int
+		wantUnmarshal int
+	}{
+		{
+			name: "Create",
+			call: func(c client.Client) error {
+				_, err := c.Create(client.Resource{Country: "GB", BankIDCode: "GBDSC", BIC: "bic", BankID: "123456"})
+
+				return err
+			},
+			wantMarshals:  1,
+			wantUnmarshal: 1,
+		},
+		{
+			name: "Fetch",
+			call: func(c client.Client) error {
+				_, err := c.Fetch(accountID)
+
+				return err
+			},
+			wantUnmarshal: 1,
+		},
+		{
+			name: "Update",
+			call: func(c client.Client) error {
+				_, err := c.Update(accountID, 0, map[string]interface{}{"bank_id": "654321"})
+
+				return err
+			},
+			wantMarshals:  1,
+			wantUnmarshal: 1,
+		},
+		{
+			name: "List",
+			call: func(c client.Client) error {
+				_, err := c.List(0, 5)
+
+				return err
+			},
+			wantUnmarshal: 1,
+		},
+		{
+			name: "ListEach decodes each account",
+			call: func(c client.Client) error {
+				return c.ListEach(context.Background(), 5, func(client.Data) error { return nil })
+			},
+			wantUnmarshal: 3,
+		},
+		{
+			name: "Iterate decodes each account",
+			call: func(c client.Client) error {
+				it := c.Iterate(context.Background(), 5)
+				defer it.Close()
+
+				for it.Next() {
+					assert.NotEmpty(t, it.Data().ID)
+				}
+
+				return it.Err()
+			},
+			wantUnmarshal: 3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			codec := &countingCodec{}
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithCodec(codec)(&c)
+
+			assert.NoError(t, tt.call(c))
+			assert.Equal(t, tt.wantMarshals, codec.marshals)
+			assert.Equal(t, tt.wantUnmarshal, codec.unmarshals)
+
+			client.WithCodec(nil)(&c)
+
+			assert.NoError(t, tt.call(c), "encoding/json again")
+			assert.Equal(t, tt.wantMarshals, codec.marshals)
+			assert.Equal(t, tt.wantUnmarshal, codec.unmarshals)
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package client
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// latencyWindow is how many of the latest latencies of an operation the percentiles are computed from, so a long
+// running Client doesn't keep every latency it has ever seen.
+const latencyWindow = 1024
+
+// Stats is a snapshot of what a Client did since Since, per operation: create, list, fetch, update, and delete.
+type Stats struct {
+	Since      time.Time
+	Operations map[string]OperationStats
+}
+
+// OperationStats aggregates the requests of a single operation. Requests counts every attempt, including retries, and
+// Errors the ones that failed without a response or with a 4xx or 5xx status. The latencies are of the latest 1024
+// requests that got a response.
+type OperationStats struct {
+	Requests  int
+	Errors    int
+	Retries   int
+	ErrorRate float64
+	P50       time.Duration
+	P90       time.Duration
+	P99       time.Duration
+	Max       time.Duration
+}
+
+// collector aggregates the events of a Client for Stats. It is shared by every copy of the Client it was configured
+// on, and by every goroutine of a batch.
+type collector struct {
+	mu         sync.Mutex
+	since      time.Time
+	operations map[string]*operationCounts
+}
+
+// operationCounts is what the collector keeps for a single operation. latencies is a ring of the latest latencies,
+// next is where the one after them goes.
+type operationCounts struct {
+	requests  int
+	errors    int
+	retries   int
+	latencies []time.Duration
+	next      int
+}
+
+// newCollector returns an empty collector that starts counting now.
+func newCollector() *collector {
+	return &collector{since: time.Now(), operations: map[string]*operationCounts{}}
+}
+
+// HandleEvent counts finished requests and scheduled retries.
+func (c *collector) HandleEvent(e Event) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	switch ev := e.(type) {
+	case RequestFinished:
+		op := c.operation(ev.Method, ev.Path)
+		op.requests++
+
+		if ev.Err != nil || ev.StatusCode >= http.StatusBadRequest {
+			op.errors++
+		}
+
+		if ev.Err == nil {
+			op.addLatency(ev.Latency)
+		}
+	case RetryScheduled:
+		c.operation(ev.Method, ev.Path).retries++
+	}
+}
+
+// operation returns the counts of the operation the method and path belong to, creating them if needed.
+func (c *collector) operation(method, path string) *operationCounts {
+	name := operationName(method, path)
+
+	op, ok := c.operations[name]
+	if !ok {
+		op = &operationCounts{}
+		c.operations[name] = op
+	}
+
+	return op
+}
+
+// snapshot returns the aggregated stats of every operation.
+func (c *collector) snapshot() Stats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	s := Stats{Since: c.since, Operations: make(map[string]OperationStats, len(c.operations))}
+
+	for name, op := range c.operations {
+		s.Operations[name] = op.stats()
+	}
+
+	return s
+}
+
+// reset forgets everything counted so far, and starts counting again now.
+func (c *collector) reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.since = time.Now()
+	c.operations = map[string]*operationCounts{}
+}
+
+// addLatency keeps the latency, replacing the oldest one once the window is full.
+func (o *operationCounts) addLatency(d time.Duration) {
+	if len(o.latencies) < latencyWindow {
+		o.latencies = append(o.latencies, d)
+
+		return
+	}
+
+	o.latencies[o.next] = d
+	o.next = (o.next + 1) % latencyWindow
+}
+
+// stats computes the error rate and the latency percentiles.
+func (o *operationCounts) stats() OperationStats {
+	s := OperationStats{Requests: o.requests, Errors: o.errors, Retries: o.retries}
+
+	if o.requests > 0 {
+		s.ErrorRate = float64(o.errors) / float64(o.requests)
+	}
+
+	if len(o.latencies) == 0 {
+		return s
+	}
+
+	sorted := append([]time.Duration(nil), o.latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	s.P50 = percentile(sorted, 50) //nolint:gomnd
+	s.P90 = percentile(sorted, 90) //nolint:gomnd
+	s.P99 = percentile(sorted, 99) //nolint:gomnd
+	s.Max = sorted[len(sorted)-1]
+
+	return s
+}
+
+// percentile returns the p-th percentile of the sorted latencies with the nearest rank method.
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100 //nolint:gomnd
+
+	return sorted[rank-1]
+}
+
+// The names of the operations in Stats and profiles.
+const (
+	opCreate = "create"
+	opList   = "list"
+	opFetch  = "fetch"
+	opUpdate = "update"
+	opDelete = "delete"
+)
+
+// operationName names the operation a request with the method and path belongs to.
+func operationName(method, path string) string {
+	switch method {
+	case http.MethodPost:
+		return opCreate
+	case http.MethodPatch:
+		return opUpdate
+	case http.MethodDelete:
+		return opDelete
+	case http.MethodGet:
+		if strings.HasSuffix(path, "/accounts") {
+			return opList
+		}
+
+		return opFetch
+	}
+
+	return strings.ToLower(method)
+}
+
+// Stats returns what the Client did since it was created with WithStats, or since the last ResetStats. Every copy of
+// the Client counts towards the same Stats. Without WithStats it returns no operations.
+func (c Client) Stats() Stats {
+	if c.collector == nil {
+		return Stats{Operations: map[string]OperationStats{}}
+	}
+
+	return c.collector.snapshot()
+}
+
+// ResetStats forgets the Stats collected so far and starts collecting anew.
+func (c Client) ResetStats() {
+	if c.collector != nil {
+		c.collector.reset()
+	}
+}
@@ -0,0 +1,148 @@
+package client_test
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+// TestClient_ConcurrentUse calls every method of a single Client, with every option that keeps state, from many
+// goroutines at once. It is meant to be run with -race, which fails it on any unguarded state.
+func TestClient_ConcurrentUse(t *testing.T) {
+	const (
+		goroutines = 20
+		rounds     = 5
+		accountID  = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+	)
+
+	payload := []byte(returnCompactFile(t, "./testdata/payload.json"))
+	multiPayload := []byte(returnCompactFile(t, "./testdata/multipayload.json"))
+
+	var (
+		requests int64
+		failed   sync.Map
+	)
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// Every seventh request fails with a temporary error, so retries happen along the way, but the retry of a
+		// request that failed once does not.
+		if atomic.AddInt64(&requests, 1)%7 == 0 {
+			if _, ok := failed.LoadOrStore(r.Header.Get("X-Request-Id"), true); !ok {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return
+			}
+		}
+
+		switch {
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write(payload)
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/accounts"):
+			_, _ = w.Write(multiPayload)
+		default:
+			_, _ = w.Write(payload)
+		}
+	}))
+	defer ts.Close()
+
+	var (
+		output  bytes.Buffer
+		events  int64
+		auditMu sync.Mutex
+		audits  int
+	)
+
+	c := client.Client{BaseURL: ts.URL}
+	for _, opt := range []client.Option{
+		client.WithDebug(&output),
+		client.WithTrace(&output),
+		client.WithLogger(slog.New(slog.NewTextHandler(ioutil.Discard, nil))),
+		client.WithStats(),
+		client.WithStatsHandler(client.StatsHandlerFunc(func(client.Event) { atomic.AddInt64(&events, 1) })),
+		client.WithPhaseTimings(),
+		client.WithAuditSink(client.AuditSinkFunc(func(client.AuditRecord) {
+			auditMu.Lock()
+			defer auditMu.Unlock()
+
+			audits++
+		})),
+		client.WithLogSampling(3),
+		client.WithRateLimit(100000),
+		client.WithRetries(3, time.Millisecond),
+		client.WithConcurrency(4),
+	} {
+		opt(&c)
+	}
+
+	var wg sync.WaitGroup
+
+	for g := 0; g < goroutines; g++ {
+		wg.Add(1)
+
+		go func(g int) {
+			defer wg.Done()
+
+			rc := c.WithRequestID(fmt.Sprintf("request-%d", g))
+
+			for i := 0; i < rounds; i++ {
+				_, err := c.Create(fixtures.ValidGB())
+				assert.NoError(t, err)
+
+				_, err = rc.Fetch(accountID)
+				assert.NoError(t, err)
+
+				_, err = c.List(0, 10)
+				assert.NoError(t, err)
+
+				_, err = c.ListFiltered(client.Filter{"country": "GB"}, 0, 10)
+				assert.NoError(t, err)
+
+				_, err = c.Update(accountID, 0, map[string]interface{}{"bic": "NWBKGB22"})
+				assert.NoError(t, err)
+
+				assert.NoError(t, c.Delete(accountID, 0))
+
+				c.CreateBatch([]client.Resource{fixtures.ValidGB(), fixtures.ValidDE()}, func(_ int, _ client.Payload,
+					err error) {
+					assert.NoError(t, err)
+				})
+
+				_ = c.Stats()
+
+				if i == rounds-1 && g%5 == 0 {
+					c.ResetStats()
+				}
+			}
+		}(g)
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, goroutines*rounds*5, audits, "a record for every create, update, and delete")
+	assert.True(t, atomic.LoadInt64(&events) >= 2*goroutines*rounds*8, "a start and a finish of every request")
+
+	// Every dump and summary is written whole, so each starts on a line of its own.
+	for _, line := range strings.Split(output.String(), "\n") {
+		for _, prefix := range []string{"debug: request:", "debug: response:", "trace: "} {
+			if i := strings.Index(line, prefix); i > 0 {
+				assert.Failf(t, "interleaved output", "%q in the middle of %q", prefix, line)
+			}
+		}
+	}
+}
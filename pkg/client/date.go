@@ -0,0 +1,38 @@
+package client
+
+import (
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// httpDates is the dateCache of every Client, so Clients built as struct literals, and their copies, share it too.
+var httpDates dateCache //nolint:gochecknoglobals // a cache of a pure function, shared on purpose
+
+// dateCache keeps the last formatted Date header, so a Client sending thousands of requests per second formats it once
+// a second instead of once per request.
+type dateCache struct {
+	last atomic.Pointer[cachedDate]
+}
+
+// cachedDate is a formatted Date header, and the second it was formatted for.
+type cachedDate struct {
+	second int64
+	value  string
+}
+
+// format returns t in UTC in the IMF-fixdate format of RFC 7231/7.1.1.1, which is http.TimeFormat. The cache is keyed
+// on the second of t rather than on the system clock, so it works the same with the clock of WithClock. Goroutines
+// that miss the cache at the same time each format the header, and the last one to finish is kept.
+func (d *dateCache) format(t time.Time) string {
+	second := t.Unix()
+
+	if last := d.last.Load(); last != nil && last.second == second {
+		return last.value
+	}
+
+	value := t.UTC().Format(http.TimeFormat)
+	d.last.Store(&cachedDate{second: second, value: value})
+
+	return value
+}
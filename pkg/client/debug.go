@@ -0,0 +1,302 @@
+package client
+
+import (
+	"bytes"
+	"crypto/tls"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptrace"
+	"net/http/httputil"
+	"sync"
+	"time"
+)
+
+// timings collects the points in time at which the phases of a single request happened. Phases that did not happen,
+// like DNS resolution on a reused connection, are left at their zero value. The transport can keep dialing a
+// connection for a request after the request got another one, and report the phases of the dial from another
+// goroutine, so every field but start is guarded by mu.
+type timings struct {
+	mu           sync.Mutex
+	start        time.Time
+	dnsStart     time.Time
+	dnsDone      time.Time
+	connectStart time.Time
+	connectDone  time.Time
+	tlsStart     time.Time
+	tlsDone      time.Time
+	firstByte    time.Time
+	reused       bool
+}
+
+// Phases are the durations of the phases of a single request, for telemetry that needs to tell network problems from
+// slow responses. Phases that did not happen, like DNS resolution and connecting on a reused connection, are zero.
+type Phases struct {
+	DNS              time.Duration
+	Connect          time.Duration
+	TLS              time.Duration
+	TTFB             time.Duration
+	BodyRead         time.Duration
+	ReusedConnection bool
+}
+
+// clientTrace returns an httptrace.ClientTrace that records the phases of a request into t.
+func (t *timings) clientTrace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) {
+			t.mark(&t.dnsStart)
+		},
+		DNSDone: func(httptrace.DNSDoneInfo) {
+			t.mark(&t.dnsDone)
+		},
+		ConnectStart: func(string, string) {
+			t.mark(&t.connectStart)
+		},
+		ConnectDone: func(string, string, error) {
+			t.mark(&t.connectDone)
+		},
+		TLSHandshakeStart: func() {
+			t.mark(&t.tlsStart)
+		},
+		TLSHandshakeDone: func(tls.ConnectionState, error) {
+			t.mark(&t.tlsDone)
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+
+			t.reused = info.Reused
+		},
+		GotFirstResponseByte: func() {
+			t.mark(&t.firstByte)
+		},
+	}
+}
+
+// mark sets the point in time to now.
+func (t *timings) mark(at *time.Time) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	*at = time.Now()
+}
+
+// phases returns the durations of the phases that happened so far. BodyRead is left to the caller.
+func (t *timings) phases() *Phases {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return &Phases{
+		DNS:              between(t.dnsStart, t.dnsDone),
+		Connect:          between(t.connectStart, t.connectDone),
+		TLS:              between(t.tlsStart, t.tlsDone),
+		TTFB:             between(t.start, t.firstByte),
+		ReusedConnection: t.reused,
+	}
+}
+
+// String formats the durations of each phase of the request.
+func (t *timings) String() string {
+	p := t.phases()
+
+	return fmt.Sprintf(
+		"dns %s, connect %s, tls %s, ttfb %s, total %s, reused connection: %t",
+		p.DNS, p.Connect, p.TLS, p.TTFB, time.Since(t.start), p.ReusedConnection,
+	)
+}
+
+// between returns the duration between two points in time, or zero if either of them did not happen.
+func between(start, end time.Time) time.Duration {
+	if start.IsZero() || end.IsZero() {
+		return 0
+	}
+
+	return end.Sub(start)
+}
+
+// traceRequest attaches a timings collector to the request if tracing or phase timings are enabled on the Client. The
+// returned timings is nil when both are disabled.
+func (c Client) traceRequest(req *http.Request) (*http.Request, *timings) {
+	if c.trace == nil && !c.phased {
+		return req, nil
+	}
+
+	t := &timings{start: time.Now()}
+
+	return req.WithContext(httptrace.WithClientTrace(req.Context(), t.clientTrace())), t
+}
+
+// notifyFinished sends the RequestFinished event of an attempt, with the Phases of the request if phase timings are
+// enabled on the Client. The body of a response that failed is small, and read whole anyway, so it's read into memory
+// to time it, and replaced, so callers can still consume it. An error reading it is returned by the replacement once
+// the part that could be read is consumed. The body of a response that succeeded can be a page that is streamed account
+// by account, so it's timed as the caller reads it instead, and the event is sent once the body reaches its end or is
+// closed, whichever comes first.
+func (c Client) notifyFinished(t *timings, resp *http.Response, e RequestFinished) {
+	if !c.phased || t == nil || resp == nil {
+		c.notify(e)
+
+		return
+	}
+
+	e.Phases = t.phases()
+
+	if resp.StatusCode < http.StatusBadRequest {
+		resp.Body = &timedBody{ReadCloser: resp.Body, start: time.Now(), done: func(d time.Duration) {
+			e.Phases.BodyRead = d
+			c.notify(e)
+		}}
+
+		return
+	}
+
+	start := time.Now()
+	body, err := ioutil.ReadAll(resp.Body)
+	e.Phases.BodyRead = time.Since(start)
+
+	_ = resp.Body.Close()
+
+	var rest io.Reader = bytes.NewReader(body)
+	if err != nil {
+		rest = io.MultiReader(rest, failingReader{err: err})
+	}
+
+	resp.Body = ioutil.NopCloser(rest)
+
+	c.notify(e)
+}
+
+// timedBody is the body of a response that calls done with the time it took to read once it reaches its end or is
+// closed, whichever comes first.
+type timedBody struct {
+	io.ReadCloser
+	start time.Time
+	done  func(time.Duration)
+	once  sync.Once
+}
+
+// Read reads from the body, and finishes the timing at its end.
+func (b *timedBody) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.finish()
+	}
+
+	return n, err
+}
+
+// Close finishes the timing, if the end was not reached, and closes the body.
+func (b *timedBody) Close() error {
+	b.finish()
+
+	return b.ReadCloser.Close()
+}
+
+// finish calls done the first time it's called.
+func (b *timedBody) finish() {
+	b.once.Do(func() {
+		b.done(time.Since(b.start))
+	})
+}
+
+// failingReader fails every read with err.
+type failingReader struct {
+	err error
+}
+
+// Read returns the error.
+func (r failingReader) Read([]byte) (int, error) {
+	return 0, r.err
+}
+
+// writeTrace writes the timing summary of a finished request to the trace writer. Either resp or err is expected to be
+// set.
+func (c Client) writeTrace(req *http.Request, t *timings, resp *http.Response, err error) {
+	if c.trace == nil || t == nil {
+		return
+	}
+
+	outcome := "error: " + fmt.Sprint(err)
+	if resp != nil {
+		outcome = resp.Status
+	}
+
+	_, _ = fmt.Fprintf(c.trace, "trace: %s %s: %s: %s\n", req.Method, c.redaction().redact(req.URL.String()), outcome, t)
+}
+
+// dumpRequest returns a full dump of the outgoing request for the debug writer, or nothing if no debug writer is
+// configured. The bearer token is redacted, and sensitive attributes masked. The dump is returned rather than written,
+// so it can be left out when the request is not sampled and succeeds.
+func (c Client) dumpRequest(req *http.Request) string {
+	if c.debug == nil {
+		return ""
+	}
+
+	dump, err := httputil.DumpRequestOut(req, true)
+	if err != nil {
+		return fmt.Sprintf("debug: could not dump request: %s\n", err)
+	}
+
+	if c.token != "" {
+		dump = bytes.ReplaceAll(dump, []byte(c.token), []byte("[redacted]"))
+	}
+
+	return fmt.Sprintf("debug: request:\n%s\n", c.redaction().redact(string(dump)))
+}
+
+// writeDump writes a dump returned by dumpRequest to the debug writer.
+func (c Client) writeDump(dump string) {
+	if c.debug == nil || dump == "" {
+		return
+	}
+
+	_, _ = io.WriteString(c.debug, dump)
+}
+
+// dumpResponse writes the full incoming response to the debug writer, if one is configured, with sensitive attributes
+// masked. The body is read into memory and replaced on the response, so callers can still consume it.
+func (c Client) dumpResponse(resp *http.Response) {
+	if c.debug == nil {
+		return
+	}
+
+	dump, err := httputil.DumpResponse(resp, true)
+	if err != nil {
+		_, _ = fmt.Fprintf(c.debug, "debug: could not dump response: %s\n", err)
+
+		return
+	}
+
+	_, _ = fmt.Fprintf(c.debug, "debug: response:\n%s\n", c.redaction().redact(string(dump)))
+}
+
+// lockedWriter serialises the writes of every goroutine that uses a Client to its debug and trace writers, so dumps and
+// summaries of requests that run at the same time do not interleave, and writers that are not safe for concurrent use,
+// like a bytes.Buffer, can be used. Every dump and summary is written in a single Write.
+type lockedWriter struct {
+	mu *sync.Mutex
+	w  io.Writer
+}
+
+// Write writes p while holding the lock.
+func (l lockedWriter) Write(p []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.w.Write(p)
+}
+
+// lockedWriter wraps w in a lockedWriter that shares its lock with the other writers of the Client. A nil w stays nil,
+// so the Client keeps treating it as disabled.
+func (c *Client) lockedWriter(w io.Writer) io.Writer {
+	if w == nil {
+		return nil
+	}
+
+	if c.writeMu == nil {
+		c.writeMu = &sync.Mutex{}
+	}
+
+	return lockedWriter{mu: c.writeMu, w: w}
+}
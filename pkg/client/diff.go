@@ -0,0 +1,118 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// Change is a single value that differs between two Payloads. Path is where the value is in the JSON encoding of the
+// Payload, like data.attributes.name[1]. From is the value in the first Payload, and To the one in the second, both as
+// encoding/json decodes them into an interface{}, so numbers are float64. A value missing from one of them is nil.
+type Change struct {
+	Path string      `json:"path"`
+	From interface{} `json:"from"`
+	To   interface{} `json:"to"`
+}
+
+// String formats the change as the path, and the two values in JSON.
+func (c Change) String() string {
+	from, _ := json.Marshal(c.From)
+	to, _ := json.Marshal(c.To)
+
+	return fmt.Sprintf("%s: %s -> %s", c.Path, from, to)
+}
+
+// Diff compares two Payloads value by value, and returns the values that differ, ordered by their path. Payloads that
+// are the same have no changes. Values are compared in their JSON encoding, so they differ only if they would be sent
+// or received differently.
+func Diff(a, b Payload) []Change {
+	changes := make([]Change, 0)
+	diffValues("", jsonValue(a), jsonValue(b), &changes)
+
+	return changes
+}
+
+// jsonValue returns the Payload as encoding/json decodes its encoding into an interface{}. Payloads always encode, so
+// errors cannot happen.
+func jsonValue(p Payload) interface{} {
+	content, _ := json.Marshal(p)
+
+	var v interface{}
+
+	_ = json.Unmarshal(content, &v)
+
+	return v
+}
+
+// diffValues appends the differences between two decoded JSON values at path to changes. Objects are compared key by
+// key in sorted order, and arrays element by element, anything else as a whole.
+func diffValues(path string, a, b interface{}, changes *[]Change) {
+	switch av := a.(type) {
+	case map[string]interface{}:
+		bv, ok := b.(map[string]interface{})
+		if !ok {
+			break
+		}
+
+		for _, key := range unionKeys(av, bv) {
+			diffValues(joinPath(path, key), av[key], bv[key], changes)
+		}
+
+		return
+	case []interface{}:
+		bv, ok := b.([]interface{})
+		if !ok {
+			break
+		}
+
+		for i := 0; i < len(av) || i < len(bv); i++ {
+			diffValues(path+"["+strconv.Itoa(i)+"]", elementAt(av, i), elementAt(bv, i), changes)
+		}
+
+		return
+	}
+
+	if !reflect.DeepEqual(a, b) {
+		*changes = append(*changes, Change{Path: path, From: a, To: b})
+	}
+}
+
+// unionKeys returns the keys of both objects, sorted.
+func unionKeys(a, b map[string]interface{}) []string {
+	keys := make([]string, 0, len(a)+len(b))
+
+	for k := range a {
+		keys = append(keys, k)
+	}
+
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+
+	sort.Strings(keys)
+
+	return keys
+}
+
+// joinPath appends the key of an object to the path.
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+
+	return path + "." + key
+}
+
+// elementAt returns the element of the array at index i, or nil if the array is shorter.
+func elementAt(values []interface{}, i int) interface{} {
+	if i >= len(values) {
+		return nil
+	}
+
+	return values[i]
+}
@@ -0,0 +1,74 @@
+package client_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestDiff(t *testing.T) {
+	base := client.Payload{
+		Data: client.Data{
+			ID:         "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+			Version:    1,
+			CreatedOn:  client.Timestamp{Time: time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)},
+			Attributes: fixtures.ValidGB(fixtures.WithName("Jane", "Doe")),
+		},
+	}
+
+	tests := []struct {
+		name   string
+		change func(p *client.Payload)
+		want   []client.Change
+	}{
+		{
+			name:   "same payloads",
+			change: func(p *client.Payload) {},
+			want:   []client.Change{},
+		},
+		{
+			name: "changed values, ordered by path",
+			change: func(p *client.Payload) {
+				p.Data.Version = 2
+				p.Data.Attributes.Name[1] = "Smith"
+				p.Data.Attributes.BIC = "NWBKGB22"
+				p.Data.Attributes.JointAccount = true
+			},
+			want: []client.Change{
+				{Path: "data.attributes.bic", From: base.Data.Attributes.BIC, To: "NWBKGB22"},
+				{Path: "data.attributes.joint_account", From: false, To: true},
+				{Path: "data.attributes.name[1]", From: "Doe", To: "Smith"},
+				{Path: "data.version", From: float64(1), To: float64(2)},
+			},
+		},
+		{
+			name: "values that are only in one of them",
+			change: func(p *client.Payload) {
+				p.Data.Attributes.CustomerID = "customer"
+				p.Links.First = "/v1/organisation/accounts"
+			},
+			want: []client.Change{
+				{Path: "data.attributes.customer_id", To: "customer"},
+				{Path: "links.first", To: "/v1/organisation/accounts"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := base
+			tt.change(&changed)
+
+			assert.Equal(t, tt.want, client.Diff(base, changed))
+		})
+	}
+}
+
+func TestChange_String(t *testing.T) {
+	c := client.Change{Path: "data.attributes.name[1]", From: "Doe", To: nil}
+
+	assert.Equal(t, `data.attributes.name[1]: "Doe" -> null`, c.String())
+}
@@ -0,0 +1,172 @@
+//go:build integration
+
+package client_test
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+const (
+	accountAPIImage = "form3tech/interview-accountapi:v1.0.0-4-g63cf8434"
+	postgresImage   = "postgres:9.5-alpine"
+	vaultImage      = "vault:0.9.3"
+	vaultToken      = "8fb95528-57c6-422e-9722-d2147bcba8ed"
+	readyTimeout    = 2 * time.Minute
+)
+
+// integrationTestURL is the address of the service the integration tests run against: ACCOUNTS_ADDRESS if it is set,
+// like in docker-compose, or the stack TestMain starts otherwise.
+var integrationTestURL string
+
+// TestMain starts the service with its database and vault in docker, unless ACCOUNTS_ADDRESS points at one already,
+// waits until it is healthy, runs the tests, and removes the containers again.
+func TestMain(m *testing.M) {
+	integrationTestURL = os.Getenv(config.AccountsAPIURLKey)
+	if integrationTestURL != "" {
+		if err := waitForHealth(integrationTestURL+"/v1/health", readyTimeout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+
+		os.Exit(m.Run())
+	}
+
+	s, err := startStack()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "starting the service in docker: %s\n", err)
+		s.stop()
+		os.Exit(1)
+	}
+
+	integrationTestURL = s.url
+	code := m.Run()
+
+	s.stop()
+	os.Exit(code)
+}
+
+// stack is the service, its database, and vault, running in docker on a network of their own.
+type stack struct {
+	name       string
+	containers []string
+	network    bool
+	url        string
+}
+
+// startStack starts the containers of the service with names unique to this run, so runs on the same machine do not
+// clash, and waits until the service responds to health checks. The stack is returned even if it failed to start, so
+// the containers that did start can be stopped.
+func startStack() (*stack, error) {
+	s := &stack{name: fmt.Sprintf("accountsclient-it-%d", time.Now().UnixNano())}
+
+	initScripts, err := filepath.Abs("../../scripts/db")
+	if err != nil {
+		return s, fmt.Errorf("startStack finding database scripts: %w", err)
+	}
+
+	if _, err := docker("network", "create", s.name); err != nil {
+		return s, err
+	}
+
+	s.network = true
+
+	containers := [][]string{
+		{
+			"postgresql", "-e", "POSTGRES_USER=root", "-e", "POSTGRES_PASSWORD=password",
+			"-v", initScripts + ":/docker-entrypoint-initdb.d/", postgresImage,
+		},
+		{"vault", "-e", "SKIP_SETCAP=1", "-e", "VAULT_DEV_ROOT_TOKEN_ID=" + vaultToken, vaultImage},
+		{
+			"accountapi", "--restart", "on-failure", "-p", "127.0.0.1::8080",
+			"-e", "VAULT_ADDR=http://vault:8200", "-e", "VAULT_TOKEN=" + vaultToken,
+			"-e", "PSQL_USER=root", "-e", "PSQL_PASSWORD=password", "-e", "PSQL_HOST=postgresql", "-e", "PSQL_PORT=5432",
+			"-e", "STACK_NAME=f3-interview-accountapi", "-e", "DATABASE-HOST=postgresql",
+			"-e", "DATABASE-SSL-MODE=disable", "-e", "DATABASE-USERNAME=interview_accountapi_user",
+			"-e", "DATABASE-PASSWORD=123", accountAPIImage,
+		},
+	}
+
+	for _, c := range containers {
+		alias, args := c[0], c[1:]
+		name := s.name + "-" + alias
+
+		run := append([]string{"run", "-d", "--name", name, "--network", s.name, "--network-alias", alias}, args...)
+		if _, err := docker(run...); err != nil {
+			return s, err
+		}
+
+		s.containers = append(s.containers, name)
+	}
+
+	port, err := docker("port", s.name+"-accountapi", "8080/tcp")
+	if err != nil {
+		return s, err
+	}
+
+	// docker port lists every address the port is published on, one per line, like 127.0.0.1:49153.
+	s.url = "http://" + strings.SplitN(port, "\n", 2)[0]
+
+	return s, waitForHealth(s.url+"/v1/health", readyTimeout)
+}
+
+// stop removes the containers and the network of the stack.
+func (s *stack) stop() {
+	if len(s.containers) > 0 {
+		_, _ = docker(append([]string{"rm", "-f", "-v"}, s.containers...)...)
+	}
+
+	if s.network {
+		_, _ = docker("network", "rm", s.name)
+	}
+}
+
+// waitForHealth polls the health endpoint of the service until it responds with 200 OK, or the timeout runs out.
+func waitForHealth(url string, timeout time.Duration) error {
+	c := http.Client{Timeout: time.Second}
+	deadline := time.Now().Add(timeout)
+
+	for time.Now().Before(deadline) {
+		resp, err := c.Get(url)
+		if err == nil {
+			_ = resp.Body.Close()
+
+			if resp.StatusCode == http.StatusOK {
+				return nil
+			}
+		}
+
+		time.Sleep(time.Second)
+	}
+
+	return fmt.Errorf("waitForHealth: %s not healthy after %s", url, timeout)
+}
+
+// docker runs the docker command line with the arguments, and returns what it printed without surrounding whitespace.
+func docker(args ...string) (string, error) {
+	var stdout, stderr bytes.Buffer
+
+	cmd := exec.Command("docker", args...)
+	cmd.Stdout, cmd.Stderr = &stdout, &stderr
+
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if errors.As(err, &exitErr) {
+			return "", fmt.Errorf("docker %s: %w: %s", args[0], err, strings.TrimSpace(stderr.String()))
+		}
+
+		return "", fmt.Errorf("docker %s: %w", args[0], err)
+	}
+
+	return strings.TrimSpace(stdout.String()), nil
+}
@@ -0,0 +1,45 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"sync"
+)
+
+const (
+	// typicalPayloadSize is a little over the size of the JSON of a Payload with every attribute set, so most
+	// encodings fit in a pooled buffer without growing it.
+	typicalPayloadSize = 1 << 10
+
+	// maxPooledBufferSize is the capacity over which a buffer is not put back into the pool, so a single unusually
+	// large request does not keep its memory around for good.
+	maxPooledBufferSize = 64 << 10
+)
+
+// bufferPool holds the buffers request bodies are encoded into, so bulk creates and updates do not allocate and grow a
+// new one for every request.
+var bufferPool = sync.Pool{
+	New: func() interface{} {
+		return bytes.NewBuffer(make([]byte, 0, typicalPayloadSize))
+	},
+}
+
+// encodeJSON encodes v into a pooled buffer, and returns a copy of the encoding. The buffer goes back into the pool,
+// so the copy is the only allocation that outlives the call.
+func encodeJSON(v interface{}) ([]byte, error) {
+	b, _ := bufferPool.Get().(*bytes.Buffer)
+	b.Reset()
+
+	defer func() {
+		if b.Cap() <= maxPooledBufferSize {
+			bufferPool.Put(b)
+		}
+	}()
+
+	err := json.NewEncoder(b).Encode(v)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte(nil), b.Bytes()...), nil
+}
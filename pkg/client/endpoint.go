@@ -0,0 +1,88 @@
+package client
+
+import (
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// endpointVersion is the version of the API at the start of the path of every endpoint.
+const endpointVersion = "v1"
+
+// endpoint builds the path and query of a request to the service, which endpointURL then puts under the BaseURL: the
+// version of the API, the segments of the path, each escaped so an ID with a slash, a question mark, or a space in it
+// stays a single segment, and the query parameters, in the order they were added, so the request path is stable. Its
+// methods return a copy, so an endpoint can be the common start of others, the way accountsEndpoint is for every
+// operation on accounts, and the endpoints of other resources can be built the same way.
+type endpoint struct {
+	segments []string
+	query    []string
+}
+
+// newEndpoint returns the endpoint with the path of segments under the version of the API.
+func newEndpoint(segments ...string) endpoint {
+	return endpoint{}.path(append([]string{endpointVersion}, segments...)...)
+}
+
+// accountsEndpoint returns the endpoint of the accounts of the organisation, which is where they are created and
+// listed, and the parent of the endpoint of each of them.
+func accountsEndpoint() endpoint {
+	return newEndpoint("organisation", typeAccounts)
+}
+
+// path returns a copy of the endpoint with segments added to the end of its path, each escaped.
+func (e endpoint) path(segments ...string) endpoint {
+	escaped := make([]string, len(e.segments), len(e.segments)+len(segments))
+	copy(escaped, e.segments)
+
+	for _, s := range segments {
+		escaped = append(escaped, url.PathEscape(s))
+	}
+
+	e.segments = escaped
+
+	return e
+}
+
+// param returns a copy of the endpoint with the query parameter key added, and value escaped. The key is sent as it is,
+// so the brackets of keys like page[number] stay the way the service expects them.
+func (e endpoint) param(key, value string) endpoint {
+	e.query = append(e.query[:len(e.query):len(e.query)], key+"="+url.QueryEscape(value))
+
+	return e
+}
+
+// page returns a copy of the endpoint with the number and size of a page of a list.
+func (e endpoint) page(number, size uint) endpoint {
+	return e.param("page[number]", strconv.FormatUint(uint64(number), 10)).
+		param("page[size]", strconv.FormatUint(uint64(size), 10))
+}
+
+// filter returns a copy of the endpoint with a filter[key]=value parameter for every pair of f, sorted by key. The
+// keys are escaped too, as they come from the caller.
+func (e endpoint) filter(f Filter) endpoint {
+	keys := make([]string, 0, len(f))
+
+	for k := range f {
+		keys = append(keys, k)
+	}
+
+	sort.Strings(keys)
+
+	for _, k := range keys {
+		e = e.param("filter["+url.QueryEscape(k)+"]", f[k])
+	}
+
+	return e
+}
+
+// String returns the escaped path of the endpoint, followed by its query if it has one.
+func (e endpoint) String() string {
+	path := "/" + strings.Join(e.segments, "/")
+	if len(e.query) == 0 {
+		return path
+	}
+
+	return path + "?" + strings.Join(e.query, "&")
+}
@@ -0,0 +1,67 @@
+package client
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEndpoint(t *testing.T) {
+	tests := []struct {
+		name     string
+		endpoint endpoint
+		want     string
+	}{
+		{
+			name:     "the accounts",
+			endpoint: accountsEndpoint(),
+			want:     "/v1/organisation/accounts",
+		},
+		{
+			name:     "a page of the accounts",
+			endpoint: accountsEndpoint().page(3, 100),
+			want:     "/v1/organisation/accounts?page[number]=3&page[size]=100",
+		},
+		{
+			name:     "a filtered page, with the filters sorted and escaped",
+			endpoint: accountsEndpoint().page(0, 2).filter(Filter{"country": "GB", "customer_id": "a&b=c", "b[x]": "1"}),
+			want: "/v1/organisation/accounts?page[number]=0&page[size]=2&filter[b%5Bx%5D]=1&filter[country]=GB" +
+				"&filter[customer_id]=a%26b%3Dc",
+		},
+		{
+			name:     "an account with an ID that would change the request",
+			endpoint: accountsEndpoint().path("../a b?version=7#x"),
+			want:     "/v1/organisation/accounts/..%2Fa%20b%3Fversion=7%23x",
+		},
+		{
+			name:     "a version",
+			endpoint: accountsEndpoint().path("accountid").param("version", "2"),
+			want:     "/v1/organisation/accounts/accountid?version=2",
+		},
+		{
+			name:     "another resource",
+			endpoint: newEndpoint("health"),
+			want:     "/v1/health",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.endpoint.String())
+		})
+	}
+}
+
+func TestEndpoint_copies(t *testing.T) {
+	base := accountsEndpoint().page(0, 1)
+
+	first := base.param("a", "1")
+	second := base.param("b", "2")
+	account := accountsEndpoint().path("x")
+	other := accountsEndpoint().path("y")
+
+	assert.Equal(t, "/v1/organisation/accounts?page[number]=0&page[size]=1", base.String())
+	assert.Equal(t, "/v1/organisation/accounts?page[number]=0&page[size]=1&a=1", first.String())
+	assert.Equal(t, "/v1/organisation/accounts?page[number]=0&page[size]=1&b=2", second.String())
+	assert.Equal(t, "/v1/organisation/accounts/x", account.String())
+	assert.Equal(t, "/v1/organisation/accounts/y", other.String())
+}
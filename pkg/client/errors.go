@@ -0,0 +1,380 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+)
+
+var (
+	// ErrValidation is matched by errors returned when a Resource fails client side validation, so it was never sent
+	// to the service.
+	ErrValidation = errors.New("validation failed")
+
+	// ErrNotFound is matched by errors returned when the service responds with 404 Not Found.
+	ErrNotFound = errors.New("not found")
+
+	// ErrConflict is matched by errors returned when the service responds with 409 Conflict, for example because the
+	// version passed to Delete is not the current version of the account.
+	ErrConflict = errors.New("conflict")
+
+	// ErrRateLimited is matched by errors returned when the service responds with 429 Too Many Requests.
+	ErrRateLimited = errors.New("rate limited")
+
+	// ErrForeignLink is matched by errors returned when a link to follow is on another host than the BaseURL of the
+	// Client, so it was never requested.
+	ErrForeignLink = errors.New("link to another host")
+
+	// ErrAlreadyExists is matched by errors returned when a create conflicts with an account of the same ID, with
+	// WithExistingOnConflict.
+	ErrAlreadyExists = errors.New("already exists")
+
+	// ErrTooManyRedirects is matched by errors returned when a request is redirected more times than WithMaxRedirects
+	// allows, or back to a URL it was already sent to.
+	ErrTooManyRedirects = errors.New("too many redirects")
+
+	// ErrInvalidTransition is matched by errors returned when ConfirmAccount, FailAccount, or CloseAccount would move
+	// an account to a status it can't get to from the one it is in, so the update was never sent.
+	ErrInvalidTransition = errors.New("invalid status transition")
+
+	// ErrVersionUnknown is matched by errors returned when an account to update or delete in a batch came from a
+	// response without a version, so the request was never sent, rather than sent at version 0 on a guess.
+	ErrVersionUnknown = errors.New("version unknown")
+)
+
+// ValidationError is returned by ValidateResource when a Resource would be rejected by the service. It matches
+// ErrValidation with errors.Is.
+type ValidationError struct {
+	Err    error
+	Fields []FieldError
+}
+
+// FieldError is a single rule a Resource broke, tied to the attribute at fault by its JSON name, like bank_id.
+type FieldError struct {
+	Field   string `json:"field"`
+	Message string `json:"message"`
+}
+
+// Error returns the message of the finding.
+func (e FieldError) Error() string {
+	return e.Message
+}
+
+// fieldErrors collects every finding of a validation run in the order the checks ran.
+type fieldErrors []FieldError
+
+// Error joins the messages of every finding.
+func (e fieldErrors) Error() string {
+	messages := make([]string, 0, len(e))
+
+	for _, f := range e {
+		messages = append(messages, f.Message)
+	}
+
+	return strings.Join(messages, "; ")
+}
+
+// Error returns the reasons the Resource failed validation.
+func (e *ValidationError) Error() string {
+	return fmt.Sprintf("%s: %s", ErrValidation, e.Err)
+}
+
+// Unwrap returns the underlying validation failure.
+func (e *ValidationError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrValidation.
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// maxErrorBody is how much of the body of an unexpected response APIError keeps. Error bodies of the service are a
+// short JSON object, and the start of an HTML page of a proxy is enough to tell which one it is.
+const maxErrorBody = 4 << 10
+
+// maxErrorMessageBody is how much of the body the message of an APIError quotes.
+const maxErrorMessageBody = 256
+
+// APIError is returned when the service responds with a status code the operation does not expect. Depending on the
+// status code it matches ErrNotFound, ErrConflict, or ErrRateLimited with errors.Is. RequestID is the X-Request-Id the
+// request was sent with, to find it in the logs of the service. Body is the start of the body of the response, which
+// usually says why, up to 4 KiB, with the attributes of WithRedactedFields masked, and ContentType its Content-Type,
+// so the error page of a proxy, which is text/html, can be told apart from an error of the service.
+type APIError struct {
+	StatusCode  int
+	RequestID   string
+	ContentType string
+	Body        string
+
+	// Truncated is set if the body was longer than Body.
+	Truncated bool
+}
+
+// newAPIError returns the APIError for an unexpected response, and reads the start of its body. The caller still has
+// to discard the response.
+func (c Client) newAPIError(resp *http.Response) *APIError {
+	e := &APIError{StatusCode: resp.StatusCode, ContentType: resp.Header.Get("Content-Type")}
+	if resp.Request != nil {
+		e.RequestID = resp.Request.Header.Get(requestIDHeader)
+	}
+
+	if resp.Body == nil {
+		return e
+	}
+
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody+1))
+	if len(body) > maxErrorBody {
+		body, e.Truncated = body[:maxErrorBody], true
+	}
+
+	e.Body = c.redaction().redact(string(body))
+
+	return e
+}
+
+// Error returns the unexpected status code, and the Content-Type and start of the body if it has one, on a single
+// line.
+func (e *APIError) Error() string {
+	if strings.TrimSpace(e.Body) == "" {
+		return fmt.Sprintf("unexpected response code: %d", e.StatusCode)
+	}
+
+	body := quoteBody(e.Body, e.Truncated)
+
+	if e.ContentType == "" {
+		return fmt.Sprintf("unexpected response code: %d: %s", e.StatusCode, body)
+	}
+
+	return fmt.Sprintf("unexpected response code: %d (%s): %s", e.StatusCode, e.ContentType, body)
+}
+
+// quoteBody returns the start of body, up to maxErrorMessageBody runes, on a single line, for an error message.
+func quoteBody(body string, truncated bool) string {
+	body = strings.Join(strings.Fields(body), " ")
+	if runes := []rune(body); len(runes) > maxErrorMessageBody {
+		return string(runes[:maxErrorMessageBody]) + "..."
+	}
+
+	if truncated {
+		body += "..."
+	}
+
+	return body
+}
+
+// DecodeError is returned when the service responds with a status the operation expects, but a body it can't decode
+// into the result, like the HTML page of a proxy that intercepted the request, or an error of the service sent with
+// 200 OK. Err is why decoding failed, and the other fields describe the response the way they do on an APIError, with
+// the start of the body, up to 4 KiB, and the attributes of WithRedactedFields masked.
+type DecodeError struct {
+	StatusCode  int
+	RequestID   string
+	ContentType string
+	Body        string
+	Err         error
+
+	// Truncated is set if the body was longer than Body.
+	Truncated bool
+}
+
+// newDecodeError returns the DecodeError for err, which decoding the body of resp read through head failed with. It
+// reads the body on through head until it has the start of it, if decoding stopped short of that. The caller still has
+// to discard the response.
+func (c Client) newDecodeError(resp *http.Response, head *bodyHead, err error) *DecodeError {
+	_, _ = io.Copy(ioutil.Discard, io.LimitReader(head, int64(maxErrorBody-len(head.b)+1)))
+
+	e := &DecodeError{
+		StatusCode:  resp.StatusCode,
+		ContentType: resp.Header.Get("Content-Type"),
+		Body:        c.redaction().redact(string(head.b)),
+		Err:         err,
+		Truncated:   head.n > int64(len(head.b)),
+	}
+	if resp.Request != nil {
+		e.RequestID = resp.Request.Header.Get(requestIDHeader)
+	}
+
+	return e
+}
+
+// Error returns why decoding failed, the status code, and the Content-Type and start of the body, on a single line.
+func (e *DecodeError) Error() string {
+	response := fmt.Sprintf("%d", e.StatusCode)
+	if e.ContentType != "" {
+		response += " (" + e.ContentType + ")"
+	}
+
+	if strings.TrimSpace(e.Body) == "" {
+		return fmt.Sprintf("%s: response %s with an empty body", e.Err, response)
+	}
+
+	return fmt.Sprintf("%s: response %s: %s", e.Err, response, quoteBody(e.Body, e.Truncated))
+}
+
+// Unwrap returns why decoding failed.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
+}
+
+// bodyHead reads a body, and keeps the first maxErrorBody bytes of it, for the DecodeError of a body that could not be
+// decoded.
+type bodyHead struct {
+	r io.Reader
+	b []byte
+
+	// n is the number of bytes read, which is more than the length of b once the body is longer than maxErrorBody.
+	n int64
+}
+
+// Read reads from the body, and keeps what it read until it has maxErrorBody bytes.
+func (h *bodyHead) Read(p []byte) (int, error) {
+	n, err := h.r.Read(p)
+	h.n += int64(n)
+
+	if room := maxErrorBody - len(h.b); room > 0 {
+		h.b = append(h.b, p[:min(n, room)]...)
+	}
+
+	return n, err
+}
+
+// Is reports whether target is the sentinel error that corresponds to the status code.
+func (e *APIError) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrRateLimited
+	}
+
+	return false
+}
+
+// ConflictError is returned by Update and Delete when the service responds with 409 Conflict, which means version is
+// not the current version of the account any more. Callers can fetch the account again, and retry with its version.
+// It matches ErrConflict with errors.Is, and Err is the *APIError of the response.
+type ConflictError struct {
+	AccountID string
+	Version   uint
+	Err       error
+}
+
+// Error returns the account and the version that was stale.
+func (e *ConflictError) Error() string {
+	return fmt.Sprintf("%s: version %d of account %s: %s", ErrConflict, e.Version, e.AccountID, e.Err)
+}
+
+// Unwrap returns the error of the response.
+func (e *ConflictError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrConflict.
+func (e *ConflictError) Is(target error) bool {
+	return target == ErrConflict
+}
+
+// AlreadyExistsError is returned by the creates of a Client with WithExistingOnConflict when an account with the ID
+// they create already exists, along with the Payload of that account. It matches ErrAlreadyExists with errors.Is, and
+// ErrConflict too, through Err, which is the *APIError of the response.
+type AlreadyExistsError struct {
+	ID  string
+	Err error
+}
+
+// Error returns the ID of the account that exists.
+func (e *AlreadyExistsError) Error() string {
+	return fmt.Sprintf("account %s %s: %s", e.ID, ErrAlreadyExists, e.Err)
+}
+
+// Unwrap returns the error of the response.
+func (e *AlreadyExistsError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is ErrAlreadyExists.
+func (e *AlreadyExistsError) Is(target error) bool {
+	return target == ErrAlreadyExists
+}
+
+// TransitionError is returned by ConfirmAccount, FailAccount, and CloseAccount when the account is in a status it
+// can't be moved to To from, which CanTransition tells. It matches ErrInvalidTransition with errors.Is.
+type TransitionError struct {
+	AccountID string
+	From      string
+	To        string
+}
+
+// Error returns the account, and the statuses it can't move between.
+func (e *TransitionError) Error() string {
+	return fmt.Sprintf("%s: account %s is %q, can't be %q", ErrInvalidTransition, e.AccountID, e.From, e.To)
+}
+
+// Is reports whether target is ErrInvalidTransition.
+func (e *TransitionError) Is(target error) bool {
+	return target == ErrInvalidTransition
+}
+
+// RetryDeadlineError is returned when a request failed in a way that is worth retrying, but the wait before the next
+// attempt would run past the deadline of its context, like the one of WithOperationTimeout, so the Client gave up
+// without waiting for nothing. Attempts is the number of attempts made, Wait the wait it skipped, and Err why the last
+// attempt failed, like an *APIError. It matches context.DeadlineExceeded with errors.Is, like running out of time while
+// waiting would, and what Err matches too.
+type RetryDeadlineError struct {
+	Attempts int
+	Wait     time.Duration
+	Err      error
+}
+
+// Error returns the number of attempts, the wait that would run past the deadline, and why the last attempt failed.
+func (e *RetryDeadlineError) Error() string {
+	return fmt.Sprintf("%s: gave up after %d attempts, as the retry in %s would be too late: %s",
+		context.DeadlineExceeded, e.Attempts, e.Wait, e.Err)
+}
+
+// Unwrap returns why the last attempt failed.
+func (e *RetryDeadlineError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether target is context.DeadlineExceeded.
+func (e *RetryDeadlineError) Is(target error) bool {
+	return target == context.DeadlineExceeded
+}
+
+// PartialListError is returned by ListAll and ListAllFrom of a Client with WithPartialResults when a page fails after
+// the ones before it came through, along with the accounts of those. Cursor is the page that failed, to carry on from
+// with ListAllFrom, and Records the number of accounts returned. Err is why the page failed, so the error still
+// matches what that one does, like ErrRateLimited.
+type PartialListError struct {
+	Cursor  ListCursor
+	Records int
+	Err     error
+}
+
+// Error returns where the listing stopped, and why.
+func (e *PartialListError) Error() string {
+	return fmt.Sprintf("stopped after %d accounts, carry on from page %d: %s", e.Records, e.Cursor.Page, e.Err)
+}
+
+// Unwrap returns why the page failed.
+func (e *PartialListError) Unwrap() error {
+	return e.Err
+}
+
+// conflictError returns a *ConflictError for the account and version if err is a conflict, and err otherwise.
+func conflictError(err error, accountID string, version uint) error {
+	if !errors.Is(err, ErrConflict) {
+		return err
+	}
+
+	return &ConflictError{AccountID: accountID, Version: version, Err: err}
+}
@@ -0,0 +1,322 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestAPIError_Is(t *testing.T) {
+	sentinels := []error{client.ErrNotFound, client.ErrConflict, client.ErrRateLimited, client.ErrValidation}
+
+	tests := []struct {
+		name       string
+		statusCode int
+		want       error
+	}{
+		{
+			name:       "404 matches ErrNotFound",
+			statusCode: http.StatusNotFound,
+			want:       client.ErrNotFound,
+		},
+		{
+			name:       "409 matches ErrConflict",
+			statusCode: http.StatusConflict,
+			want:       client.ErrConflict,
+		},
+		{
+			name:       "429 matches ErrRateLimited",
+			statusCode: http.StatusTooManyRequests,
+			want:       client.ErrRateLimited,
+		},
+		{
+			name:       "500 matches none of the sentinel errors",
+			statusCode: http.StatusInternalServerError,
+			want:       nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := fmt.Errorf("client.Fetch: %w", &client.APIError{StatusCode: tt.statusCode})
+
+			for _, sentinel := range sentinels {
+				assert.Equal(t, sentinel == tt.want, errors.Is(err, sentinel), "sentinel: %s", sentinel)
+			}
+
+			var apiErr *client.APIError
+			assert.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.statusCode, apiErr.StatusCode)
+		})
+	}
+}
+
+func TestAPIError_body(t *testing.T) {
+	htmlStart := "<html> <head><title>502 Bad Gateway</title></head> <body>"
+	htmlPage := strings.ReplaceAll(htmlStart, "> <", ">\n<") + strings.Repeat("x", 5000) + "</body>\n</html>"
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		wantBody      string
+		wantTruncated bool
+		wantMessage   string
+	}{
+		{
+			name:        "an error of the service",
+			contentType: "application/json",
+			body:        `{"error_message": "id is not a valid uuid"}`,
+			wantBody:    `{"error_message": "id is not a valid uuid"}`,
+			wantMessage: `unexpected response code: 400 (application/json): {"error_message": "id is not a valid uuid"}`,
+		},
+		{
+			name:          "the error page of a proxy",
+			contentType:   "text/html",
+			body:          htmlPage,
+			wantBody:      htmlPage[:4096],
+			wantTruncated: true,
+			wantMessage: "unexpected response code: 400 (text/html): " +
+				htmlStart + strings.Repeat("x", 256-len(htmlStart)) + "...",
+		},
+		{
+			name:        "attributes of WithRedactedFields are masked",
+			contentType: "application/json",
+			body:        `{"error_message": "duplicate", "account_number": "41426819"}`,
+			wantBody:    `{"error_message": "duplicate", "account_number": "****6819"}`,
+			wantMessage: `unexpected response code: 400 (application/json): ` +
+				`{"error_message": "duplicate", "account_number": "****6819"}`,
+		},
+		{
+			name:        "no body",
+			wantMessage: "unexpected response code: 400",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			_, err := client.Client{BaseURL: ts.URL}.Fetch("accountid")
+
+			var apiErr *client.APIError
+
+			assert.True(t, errors.As(err, &apiErr))
+			assert.Equal(t, tt.contentType, apiErr.ContentType)
+			assert.Equal(t, tt.wantBody, apiErr.Body)
+			assert.Equal(t, tt.wantTruncated, apiErr.Truncated)
+			assert.Equal(t, tt.wantMessage, apiErr.Error())
+		})
+	}
+}
+
+func TestDecodeError(t *testing.T) {
+	htmlPage := "<html>\n<body>Please sign in</body>\n</html>" + strings.Repeat(" ", 5000)
+
+	tests := []struct {
+		name          string
+		contentType   string
+		body          string
+		call          func(c client.Client) error
+		wantBody      string
+		wantTruncated bool
+		wantMessage   string
+	}{
+		{
+			name:          "the sign in page of a proxy",
+			contentType:   "text/html",
+			body:          htmlPage,
+			wantBody:      htmlPage[:4096],
+			wantTruncated: true,
+			wantMessage: "client.Fetch: unmarshalPayload: invalid character '<' looking for beginning of value: " +
+				"response 200 (text/html): <html> <body>Please sign in</body> </html>...",
+		},
+		{
+			name:        "an error of the service with 200 OK, masked",
+			contentType: "application/json",
+			body:        `{"error_message": "gone", "iban": "GB11NWBK40030041426819"}`,
+			wantBody:    `{"error_message": "gone", "iban": "******************6819"}`,
+			wantMessage: "client.Fetch: unmarshalPayload: Data is empty on the decoded Payload: " +
+				`response 200 (application/json): {"error_message": "gone", "iban": "******************6819"}`,
+		},
+		{
+			name: "a page that breaks off",
+			body: `{"data": [{"id": "1", "attributes": {"country": "GB"}}, {"id": `,
+			call: func(c client.Client) error {
+				return c.ListEach(context.Background(), 2, func(client.Data) error { return nil })
+			},
+			wantBody: `{"data": [{"id": "1", "attributes": {"country": "GB"}}, {"id": `,
+			wantMessage: "client.ListEach: page 0: decodeEach data 1: unexpected EOF: response 200: " +
+				`{"data": [{"id": "1", "attributes": {"country": "GB"}}, {"id":`,
+		},
+		{
+			name: "an iterated page that breaks off",
+			body: `{"data": [{"id": "1", "attributes": {"country": "GB"}}, {"id": `,
+			call: func(c client.Client) error {
+				it := c.Iterate(context.Background(), 2)
+				for it.Next() {
+				}
+
+				return it.Err()
+			},
+			wantBody: `{"data": [{"id": "1", "attributes": {"country": "GB"}}, {"id": `,
+			wantMessage: "client.AccountIterator: page 0: decodeEach data 1: unexpected EOF: response 200: " +
+				`{"data": [{"id": "1", "attributes": {"country": "GB"}}, {"id":`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header()["Content-Type"] = nil
+				if tt.contentType != "" {
+					w.Header().Set("Content-Type", tt.contentType)
+				}
+
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer ts.Close()
+
+			call := tt.call
+			if call == nil {
+				call = func(c client.Client) error {
+					_, err := c.Fetch("accountid")
+
+					return err
+				}
+			}
+
+			err := call(client.Client{BaseURL: ts.URL})
+
+			var decodeErr *client.DecodeError
+
+			assert.True(t, errors.As(err, &decodeErr), "got %v", err)
+			assert.Equal(t, http.StatusOK, decodeErr.StatusCode)
+			assert.NotEmpty(t, decodeErr.RequestID)
+			assert.Equal(t, tt.contentType, decodeErr.ContentType)
+			assert.Equal(t, tt.wantBody, decodeErr.Body)
+			assert.Equal(t, tt.wantTruncated, decodeErr.Truncated)
+			assert.Equal(t, tt.wantMessage, err.Error())
+		})
+	}
+}
+
+func TestDecodeError_callbackErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": [{"id": "1", "attributes": {"country": "GB"}}]}`))
+	}))
+	defer ts.Close()
+
+	errStop := errors.New("stop")
+
+	err := client.Client{BaseURL: ts.URL}.ListEach(context.Background(), 2, func(client.Data) error { return errStop })
+
+	var decodeErr *client.DecodeError
+
+	assert.True(t, errors.Is(err, errStop))
+	assert.False(t, errors.As(err, &decodeErr), "errors of fn are not decode errors")
+}
+
+func TestConflictError(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusConflict)
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+
+	_, updateErr := c.Update("accountid", 3, map[string]interface{}{"status": "closed"})
+	deleteErr := c.Delete("accountid", 4)
+
+	for version, err := range map[uint]error{3: updateErr, 4: deleteErr} {
+		var (
+			conflictErr *client.ConflictError
+			apiErr      *client.APIError
+		)
+
+		assert.True(t, errors.Is(err, client.ErrConflict))
+		assert.True(t, errors.As(err, &conflictErr))
+		assert.Equal(t, "accountid", conflictErr.AccountID)
+		assert.Equal(t, version, conflictErr.Version)
+		assert.True(t, errors.As(err, &apiErr))
+		assert.Equal(t, http.StatusConflict, apiErr.StatusCode)
+		assert.Contains(t, err.Error(),
+			fmt.Sprintf("conflict: version %d of account accountid: unexpected response code: 409", version))
+	}
+
+	ts.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+
+	var conflictErr *client.ConflictError
+
+	assert.False(t, errors.As(c.Delete("accountid", 4), &conflictErr), "only conflicts are ConflictErrors")
+}
+
+func TestValidateResource_ErrValidation(t *testing.T) {
+	err := client.ValidateResource(client.Resource{Country: "XX"})
+
+	var validationErr *client.ValidationError
+
+	assert.True(t, errors.Is(err, client.ErrValidation))
+	assert.True(t, errors.As(err, &validationErr))
+	assert.EqualError(t, err, "validation failed: unsupported country code: XX")
+	assert.NoError(t, client.ValidateResource(client.Resource{
+		Country:    "GB",
+		BankID:     "123456",
+		BIC:        bicExample,
+		BankIDCode: "GBDSC",
+	}))
+}
+
+func TestValidateResource_Fields(t *testing.T) {
+	tests := []struct {
+		name    string
+		account client.Resource
+		want    []client.FieldError
+		wantErr string
+	}{
+		{
+			name:    "unsupported country",
+			account: client.Resource{Country: "XX"},
+			want:    []client.FieldError{{Field: "country", Message: "unsupported country code: XX"}},
+			wantErr: "validation failed: unsupported country code: XX",
+		},
+		{
+			name:    "every broken rule of a country is reported",
+			account: client.Resource{Country: "GB", BankID: "12", BankIDCode: "FR", AccountNumber: "123456789"},
+			want: []client.FieldError{
+				{Field: "bic", Message: "BIC is required, was empty"},
+				{Field: "bank_id", Message: "GB bank id is not in correct format. '12'"},
+				{Field: "bank_id_code", Message: "bank ID Code is not 'GBDSC', got FR"},
+				{Field: "account_number", Message: "GB account number is not in correct format. '*****6789'"},
+			},
+			wantErr: "validation failed: BIC is required, was empty; GB bank id is not in correct format. '12'; " +
+				"bank ID Code is not 'GBDSC', got FR; GB account number is not in correct format. '*****6789'",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := client.ValidateResource(tt.account)
+
+			var validationErr *client.ValidationError
+
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.want, validationErr.Fields)
+			assert.EqualError(t, err, tt.wantErr)
+		})
+	}
+}
@@ -0,0 +1,242 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// resourceKeys are the JSON names of the attributes Resource has a field for, and metaKeys the ones of the members of
+// the meta section Meta has one for, in lower case, as encoding/json matches them to the fields without regard to case.
+var (
+	resourceKeys = jsonKeys(reflect.TypeOf(Resource{})) //nolint:gochecknoglobals // read only lookup table
+	metaKeys     = jsonKeys(reflect.TypeOf(Meta{}))     //nolint:gochecknoglobals // read only lookup table
+)
+
+// jsonKeys returns the JSON names of the fields of the struct type t, in lower case.
+func jsonKeys(t reflect.Type) map[string]bool {
+	keys := make(map[string]bool, t.NumField())
+
+	for i := 0; i < t.NumField(); i++ {
+		if name := strings.Split(t.Field(i).Tag.Get("json"), ",")[0]; name != "-" {
+			keys[strings.ToLower(name)] = true
+		}
+	}
+
+	return keys
+}
+
+// appendExtra adds the members of extra that are not in known, the keys of the struct b is the encoding of, to the
+// encoded object b.
+func appendExtra(b []byte, extra map[string]json.RawMessage, known map[string]bool) ([]byte, error) {
+	names := make([]string, 0, len(extra))
+
+	for name := range extra {
+		if !known[strings.ToLower(name)] {
+			names = append(names, name)
+		}
+	}
+
+	sort.Strings(names)
+
+	buf := bytes.NewBuffer(b[:len(b)-1])
+
+	for _, name := range names {
+		key, err := json.Marshal(name)
+		if err != nil {
+			return nil, err
+		}
+
+		value, err := json.Marshal(extra[name])
+		if err != nil {
+			return nil, err
+		}
+
+		buf.WriteByte(',')
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+
+	buf.WriteByte('}')
+
+	return buf.Bytes(), nil
+}
+
+// equalExtra reports whether a and b have the same attributes with the same JSON values, whatever the whitespace.
+func equalExtra(a, b map[string]json.RawMessage) bool {
+	if len(a) != len(b) {
+		return false
+	}
+
+	var ca, cb bytes.Buffer
+
+	for name, av := range a {
+		bv, ok := b[name]
+		if !ok {
+			return false
+		}
+
+		ca.Reset()
+		cb.Reset()
+
+		if json.Compact(&ca, av) != nil || json.Compact(&cb, bv) != nil || !bytes.Equal(ca.Bytes(), cb.Bytes()) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// extraAttributes returns the members of the encoded object b whose keys are not in known, or nil if it has none.
+// Accounts rarely have any, so the keys are scanned first, without allocating, and the object is only decoded again if
+// one of them is unknown.
+func extraAttributes(b []byte, known map[string]bool) (map[string]json.RawMessage, error) {
+	if !hasUnknownKey(b, known) {
+		return nil, nil
+	}
+
+	var attributes map[string]json.RawMessage
+
+	if err := json.Unmarshal(b, &attributes); err != nil {
+		return nil, err
+	}
+
+	var extra map[string]json.RawMessage
+
+	for name, value := range attributes {
+		if known[strings.ToLower(name)] {
+			continue
+		}
+
+		if extra == nil {
+			extra = make(map[string]json.RawMessage)
+		}
+
+		extra[name] = value
+	}
+
+	return extra, nil
+}
+
+// hasUnknownKey reports whether the valid JSON value b is an object with a key that may not be in known. Keys with
+// escapes or upper case letters count as unknown, so extraAttributes takes a closer look at them.
+func hasUnknownKey(b []byte, known map[string]bool) bool {
+	i := skipSpace(b, 0)
+	if i == len(b) || b[i] != '{' {
+		return false
+	}
+
+	for i++; ; {
+		if i = skipSpace(b, i); i == len(b) {
+			return false
+		}
+
+		switch b[i] {
+		case '}':
+			return false
+		case ',':
+			i++
+
+			continue
+		}
+
+		end := skipString(b, i)
+		key := b[i+1 : end-1]
+
+		if bytes.IndexByte(key, '\\') >= 0 || hasUpper(key) || !known[string(key)] {
+			return true
+		}
+
+		i = skipValue(b, skipSpace(b, end)+1)
+	}
+}
+
+// hasUpper reports whether s has an upper case ASCII letter.
+func hasUpper(s []byte) bool {
+	for _, c := range s {
+		if 'A' <= c && c <= 'Z' {
+			return true
+		}
+	}
+
+	return false
+}
+
+// skipSpace returns the index of the first byte of b from i on that is not JSON whitespace.
+func skipSpace(b []byte, i int) int {
+	for i < len(b) && isSpace(b[i]) {
+		i++
+	}
+
+	return i
+}
+
+// isSpace reports whether c is JSON whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r'
+}
+
+// skipString returns the index after the JSON string that starts at i.
+func skipString(b []byte, i int) int {
+	for i++; i < len(b); i++ {
+		switch b[i] {
+		case '\\':
+			i++
+		case '"':
+			return i + 1
+		}
+	}
+
+	return i
+}
+
+// skipValue returns the index after the JSON value that starts at or after i.
+func skipValue(b []byte, i int) int {
+	i = skipSpace(b, i)
+	if i == len(b) {
+		return i
+	}
+
+	switch b[i] {
+	case '"':
+		return skipString(b, i)
+	case '{', '[':
+		for depth := 0; i < len(b); i++ {
+			switch b[i] {
+			case '"':
+				i = skipString(b, i) - 1
+			case '{', '[':
+				depth++
+			case '}', ']':
+				if depth--; depth == 0 {
+					return i + 1
+				}
+			}
+		}
+
+		return i
+	default:
+		for i < len(b) && !isSpace(b[i]) && b[i] != ',' && b[i] != '}' && b[i] != ']' {
+			i++
+		}
+
+		return i
+	}
+}
+
+// cloneExtra returns a copy of extra that shares nothing with it, not even the bytes of its values.
+func cloneExtra(extra map[string]json.RawMessage) map[string]json.RawMessage {
+	if extra == nil {
+		return nil
+	}
+
+	clone := make(map[string]json.RawMessage, len(extra))
+	for name, value := range extra {
+		clone[name] = append(json.RawMessage(nil), value...)
+	}
+
+	return clone
+}
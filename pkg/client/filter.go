@@ -0,0 +1,6 @@
+package client
+
+// Filter narrows down List to the accounts whose attributes have the given values. Keys are the JSON names of the
+// attributes the service can filter on, like iban, account_number, bank_id, bank_id_code, country, and customer_id.
+// Every pair has to match. They are sent as filter[key]=value query parameters, sorted by key.
+type Filter map[string]string
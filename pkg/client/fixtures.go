@@ -0,0 +1,52 @@
+package client
+
+import (
+	"fmt"
+	"math/rand"
+)
+
+const bicLetters = "ABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// GenerateResource returns a random Resource for the country that passes ValidateResource, with every attribute the
+// rules of the country allow filled in, except for the IBAN and the name. It is meant for seeding test environments
+// and demos. Pass a rand.Rand with a fixed seed to get the same Resources every time.
+func GenerateResource(country string, rnd *rand.Rand) (Resource, error) {
+	rules, ok := rulesRegistry[country]
+	if !ok {
+		return Resource{}, fmt.Errorf("client.GenerateResource: unsupported country code: %s", country)
+	}
+
+	r := Resource{
+		Country:       country,
+		BaseCurrency:  rules.currency,
+		BIC:           generateBIC(country, rnd),
+		AccountNumber: rules.accountNumber.generate(rnd),
+	}
+
+	if rules.bankID != forbidden {
+		bankIDFormat := rules.bankIDFormat
+		if rules.bankIDWithAccountNumber != nil {
+			bankIDFormat = *rules.bankIDWithAccountNumber
+		}
+
+		r.BankID = bankIDFormat.generate(rnd)
+	}
+
+	if rules.bankIDCode != forbidden {
+		r.BankIDCode = rules.bankIDCodeWant
+	}
+
+	return r, nil
+}
+
+// generateBIC returns an 11 character BIC of a made up bank in the country: four letters of bank code, the country, a
+// location code, and a branch code.
+func generateBIC(country string, rnd *rand.Rand) string {
+	b := make([]byte, 0, 11) //nolint:gomnd
+
+	for i := 0; i < 4; i++ {
+		b = append(b, bicLetters[rnd.Intn(len(bicLetters))])
+	}
+
+	return string(b) + country + "2LXXX"
+}
@@ -0,0 +1,49 @@
+package client_test
+
+import (
+	"math/rand"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestGenerateResource(t *testing.T) {
+	rnd := rand.New(rand.NewSource(1))
+
+	for _, country := range client.SupportedCountries() {
+		t.Run(country, func(t *testing.T) {
+			for i := 0; i < 50; i++ {
+				r, err := client.GenerateResource(country, rnd)
+
+				assert.NoError(t, err)
+				assert.Equal(t, country, r.Country)
+				assert.NotEmpty(t, r.BaseCurrency)
+				assert.NoError(t, client.ValidateResource(r), "%+v", r)
+			}
+		})
+	}
+}
+
+func TestGenerateResource_Reproducible(t *testing.T) {
+	first, err := client.GenerateResource("FR", rand.New(rand.NewSource(42)))
+	assert.NoError(t, err)
+
+	second, err := client.GenerateResource("FR", rand.New(rand.NewSource(42)))
+	assert.NoError(t, err)
+
+	assert.Equal(t, first, second)
+}
+
+func TestGenerateResource_UnsupportedCountry(t *testing.T) {
+	_, err := client.GenerateResource("XX", rand.New(rand.NewSource(1)))
+
+	assert.EqualError(t, err, "client.GenerateResource: unsupported country code: XX")
+}
+
+func TestSupportedCountries(t *testing.T) {
+	assert.Equal(t, []string{
+		"AU", "BE", "CA", "CH", "DE", "ES", "FR", "GB", "GR", "HK", "IT", "LU", "NL", "PL", "PT", "US",
+	}, client.SupportedCountries())
+}
@@ -0,0 +1,152 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// AccountIterator pulls every account out of the list endpoint one at a time, for code that would rather loop than
+// pass a callback to ListEach. Like ListEach, it decodes the accounts of a page one by one as the response arrives, so
+// it never holds more than one of them. It is not safe for concurrent use.
+//
+//	it := c.Iterate(ctx, 100)
+//	defer it.Close()
+//
+//	for it.Next() {
+//		d := it.Data()
+//		...
+//	}
+//
+//	if err := it.Err(); err != nil {
+//		...
+//	}
+type AccountIterator struct {
+	c        Client
+	ctx      context.Context
+	cancel   context.CancelFunc
+	pageSize uint
+	page     uint
+
+	resp *http.Response
+	head *bodyHead
+	pd   *pageDecoder
+	data Data
+	err  error
+	done bool
+}
+
+// Iterate returns an AccountIterator over every account, requesting pageSize of them at a time, one page after the
+// other. Requests are sent with ctx, so cancelling it stops the iteration with its error. The timeout of
+// WithOperationTimeout covers the whole iteration. The response of the page in progress stays open until the iteration
+// ends, or Close is called.
+func (c Client) Iterate(ctx context.Context, pageSize uint) *AccountIterator {
+	c, cancel := c.WithContext(ctx).startOperation()
+	it := &AccountIterator{c: c, ctx: c.context(), cancel: cancel, pageSize: pageSize}
+
+	if pageSize == 0 {
+		it.fail(errors.New("pageSize has to be at least 1"))
+	}
+
+	return it
+}
+
+// Next moves to the next account, which Data then returns. It returns false at the end of the last page, which is the
+// first one that is not full or has no next link, or when a request or decoding fails, which Err then returns.
+func (it *AccountIterator) Next() bool {
+	for !it.done {
+		if it.pd == nil && !it.open() {
+			return false
+		}
+
+		d, ok, err := it.pd.next()
+		if err != nil {
+			it.fail(it.c.newDecodeError(it.resp, it.head, err))
+
+			return false
+		}
+
+		if ok {
+			it.data = d
+
+			return true
+		}
+
+		last := it.pd.count < it.pageSize || it.pd.links.Next == ""
+
+		it.closePage()
+		it.page++
+
+		if last {
+			it.done = true
+			it.cancel()
+		}
+	}
+
+	return false
+}
+
+// Data returns the account Next moved to.
+func (it *AccountIterator) Data() Data {
+	return it.data
+}
+
+// Err returns the error that ended the iteration, or nil if it went through every page, or has not ended yet.
+func (it *AccountIterator) Err() error {
+	return it.err
+}
+
+// Close ends the iteration, and closes the response of the page in progress. It can be called any number of times,
+// and after the iteration ended on its own.
+func (it *AccountIterator) Close() error {
+	it.closePage()
+	it.done = true
+	it.cancel()
+
+	return nil
+}
+
+// open requests the next page, and reports whether the iteration can go on.
+func (it *AccountIterator) open() bool {
+	if err := it.ctx.Err(); err != nil {
+		it.fail(err)
+
+		return false
+	}
+
+	resp, err := it.c.do(it.ctx, http.MethodGet, accountsEndpoint().page(it.page, it.pageSize).String(), nil)
+	if err != nil {
+		it.fail(err)
+
+		return false
+	}
+
+	if !it.c.accepts(opList, resp.StatusCode) {
+		it.fail(it.c.newAPIError(resp))
+		discard(resp)
+
+		return false
+	}
+
+	it.resp = resp
+	it.head = &bodyHead{r: resp.Body}
+	it.pd = &pageDecoder{dec: json.NewDecoder(it.head), codec: it.c.codec}
+
+	return true
+}
+
+// closePage reads the rest of the response of the page in progress, if there is one, and closes it.
+func (it *AccountIterator) closePage() {
+	discard(it.resp)
+	it.resp, it.head, it.pd = nil, nil, nil
+}
+
+// fail ends the iteration with err.
+func (it *AccountIterator) fail(err error) {
+	it.closePage()
+	it.err = fmt.Errorf("client.AccountIterator: page %d: %w", it.page, err)
+	it.done = true
+	it.cancel()
+}
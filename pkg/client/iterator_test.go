@@ -0,0 +1,123 @@
+package client_test
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestClient_Iterate(t *testing.T) {
+	tests := []struct {
+		name      string
+		records   int
+		pageSize  uint
+		failPage  string
+		closeAt   int
+		cancelAt  int
+		wantPages []string
+		wantIDs   int
+		wantErr   string
+	}{
+		{
+			name:      "iterates over every account of every page",
+			records:   5,
+			pageSize:  2,
+			wantPages: []string{"0", "1", "2"},
+			wantIDs:   5,
+		},
+		{
+			name:      "stops on an exactly full last page without a next link",
+			records:   4,
+			pageSize:  2,
+			wantPages: []string{"0", "1"},
+			wantIDs:   4,
+		},
+		{
+			name:      "makes a single request when there are no records",
+			pageSize:  2,
+			wantPages: []string{"0"},
+		},
+		{
+			name:      "ends with the error of a page that fails",
+			records:   5,
+			pageSize:  2,
+			failPage:  "1",
+			wantPages: []string{"0", "1"},
+			wantIDs:   2,
+			wantErr:   "client.AccountIterator: page 1: unexpected response code: 500",
+		},
+		{
+			name:      "ends when it is closed",
+			records:   5,
+			pageSize:  3,
+			closeAt:   2,
+			wantPages: []string{"0"},
+			wantIDs:   2,
+		},
+		{
+			name:      "ends when the context is done",
+			records:   5,
+			pageSize:  2,
+			cancelAt:  2,
+			wantPages: []string{"0"},
+			wantIDs:   2,
+			wantErr:   "client.AccountIterator: page 1: context canceled",
+		},
+		{
+			name:      "fails on a zero page size without making requests",
+			records:   5,
+			wantPages: []string{},
+			wantErr:   "client.AccountIterator: page 0: pageSize has to be at least 1",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotPages := make([]string, 0)
+			ts := httptest.NewServer(pagingHandler(t, tt.records, tt.failPage, "", &gotPages))
+			defer ts.Close()
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			c := client.Client{BaseURL: ts.URL}
+			it := c.Iterate(ctx, tt.pageSize)
+
+			gotIDs := make([]string, 0)
+
+			for it.Next() {
+				gotIDs = append(gotIDs, it.Data().ID)
+
+				if len(gotIDs) == tt.closeAt {
+					assert.NoError(t, it.Close())
+				}
+
+				if len(gotIDs) == tt.cancelAt {
+					cancel()
+				}
+			}
+
+			assert.NoError(t, it.Close())
+			assert.False(t, it.Next(), "a closed iterator stays closed")
+			assert.Equal(t, tt.wantPages, gotPages)
+
+			if assert.Len(t, gotIDs, tt.wantIDs) {
+				for i, id := range gotIDs {
+					assert.Equal(t, fmt.Sprintf("account-%d", i), id)
+				}
+			}
+
+			if tt.wantErr != "" {
+				assert.EqualError(t, it.Err(), tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, it.Err())
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// FetchLink requests the account at link, like the self link of a Payload, instead of building its path from the ID.
+// The link can be relative, or absolute with the scheme and host of the BaseURL. A link to anywhere else fails with
+// an error that matches ErrForeignLink, without sending a request.
+func (c Client) FetchLink(ctx context.Context, link string) (Payload, error) {
+	c, cancel := c.WithContext(ctx).startOperation()
+	defer cancel()
+
+	ctx = c.context()
+	defer c.profileOperation(ctx, opFetch, "")()
+
+	endpoint, err := c.linkEndpoint(link)
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.FetchLink: %w", err)
+	}
+
+	p, err := c.getPayload(ctx, endpoint)
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.FetchLink: %w", err)
+	}
+
+	return p, nil
+}
+
+// ListLink requests the page of accounts at link, like the next, first, or last link of a MultiPayload, instead of
+// building its path from the page number and size. Links are checked the same way as by FetchLink.
+func (c Client) ListLink(ctx context.Context, link string) (MultiPayload, error) {
+	c, cancel := c.WithContext(ctx).startOperation()
+	defer cancel()
+
+	ctx = c.context()
+	defer c.profileOperation(ctx, opList, "")()
+
+	endpoint, err := c.linkEndpoint(link)
+	if err != nil {
+		return MultiPayload{}, fmt.Errorf("client.ListLink: %w", err)
+	}
+
+	mp, err := c.getPage(ctx, endpoint)
+	if err != nil {
+		return MultiPayload{}, fmt.Errorf("client.ListLink: %w", err)
+	}
+
+	return mp, nil
+}
+
+// Refresh fetches the account of the Payload again with c, from its self link, or by its ID if it has none, and
+// returns it as it is now.
+func (p Payload) Refresh(ctx context.Context, c Client) (Payload, error) {
+	link := p.Links.Self
+	if link == "" && p.Data.ID == "" {
+		return Payload{}, errors.New("client.Payload.Refresh: the payload has neither a self link nor an ID")
+	}
+
+	if link == "" {
+		ep, err := c.accountEndpoint(p.Data.ID)
+		if err != nil {
+			return Payload{}, fmt.Errorf("client.Payload.Refresh: %w", err)
+		}
+
+		link = ep.String()
+	}
+
+	fresh, err := c.FetchLink(ctx, link)
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.Payload.Refresh: %w", err)
+	}
+
+	return fresh, nil
+}
@@ -0,0 +1,135 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestClient_FetchLink(t *testing.T) {
+	const accountPath = "/v1/organisation/accounts/ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+
+	tests := []struct {
+		name     string
+		basePath string
+		link     func(serverURL string) string
+		wantPath string
+		wantErr  error
+	}{
+		{
+			name:     "a relative link",
+			link:     func(string) string { return accountPath },
+			wantPath: accountPath,
+		},
+		{
+			name:     "an absolute link on the host of the client",
+			link:     func(serverURL string) string { return serverURL + accountPath },
+			wantPath: accountPath,
+		},
+		{
+			name:     "a relative link of a service that doesn't know its path",
+			basePath: "/accounts-api/",
+			link:     func(string) string { return accountPath },
+			wantPath: "/accounts-api" + accountPath,
+		},
+		{
+			name:     "an absolute link of a service that knows its path",
+			basePath: "/accounts-api",
+			link:     func(serverURL string) string { return serverURL + "/accounts-api" + accountPath },
+			wantPath: "/accounts-api" + accountPath,
+		},
+		{
+			name:    "a link to another host",
+			link:    func(string) string { return "https://example.com" + accountPath },
+			wantErr: client.ErrForeignLink,
+		},
+		{
+			name: "a link to another port",
+			link: func(serverURL string) string {
+				return serverURL[:strings.LastIndex(serverURL, ":")] + ":1" + accountPath
+			},
+			wantErr: client.ErrForeignLink,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotPath string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotPath = r.URL.Path
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL + tt.basePath}
+
+			got, err := c.FetchLink(context.Background(), tt.link(ts.URL))
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "error: %v", err)
+				assert.Equal(t, "", gotPath, "no request is sent")
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantPath, gotPath)
+			assert.Equal(t, "a6c1a721-bb1b-41ef-bd11-800a1309ff9b", got.ID())
+		})
+	}
+}
+
+func TestClient_ListLink(t *testing.T) {
+	var gotQuery string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotQuery = r.URL.RawQuery
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/multipayload.json")))
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+
+	mp, err := c.ListLink(context.Background(), "/v1/organisation/accounts?page%5Bnumber%5D=3&page%5Bsize%5D=2")
+	assert.NoError(t, err)
+	assert.Equal(t, "page%5Bnumber%5D=3&page%5Bsize%5D=2", gotQuery)
+	assert.Len(t, mp.Data, 2)
+}
+
+func TestPayload_Refresh(t *testing.T) {
+	var gotPaths []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotPaths = append(gotPaths, r.URL.Path)
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+
+	withLink := client.Payload{Links: client.Links{Self: "/v1/organisation/accounts/from-link"}}
+	_, err := withLink.Refresh(context.Background(), c)
+	assert.NoError(t, err)
+
+	withID := client.Payload{Data: client.Data{ID: "from-id"}}
+	fresh, err := withID.Refresh(context.Background(), c)
+	assert.NoError(t, err)
+	assert.Equal(t, "a6c1a721-bb1b-41ef-bd11-800a1309ff9b", fresh.ID())
+
+	_, err = client.Payload{}.Refresh(context.Background(), c)
+	assert.Error(t, err)
+
+	assert.Equal(t, []string{"/v1/organisation/accounts/from-link", "/v1/organisation/accounts/from-id"}, gotPaths)
+}
@@ -0,0 +1,174 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Logger is what the Client logs to. Every method takes a message and any number of alternating keys and values, the
+// way *slog.Logger and zap's *SugaredLogger (with its Debugw, Infow, Warnw, and Errorw methods wrapped in LoggerFuncs)
+// expect them. Loggers that take a map of fields instead, like logrus, can be plugged in with FieldsLoggerFunc.
+type Logger interface {
+	Debug(msg string, keysAndValues ...interface{})
+	Info(msg string, keysAndValues ...interface{})
+	Warn(msg string, keysAndValues ...interface{})
+	Error(msg string, keysAndValues ...interface{})
+}
+
+// LoggerFuncs adapts a function per level to Logger. A nil function drops the messages of its level.
+type LoggerFuncs struct {
+	DebugFunc func(msg string, keysAndValues ...interface{})
+	InfoFunc  func(msg string, keysAndValues ...interface{})
+	WarnFunc  func(msg string, keysAndValues ...interface{})
+	ErrorFunc func(msg string, keysAndValues ...interface{})
+}
+
+// Debug calls DebugFunc.
+func (l LoggerFuncs) Debug(msg string, keysAndValues ...interface{}) {
+	if l.DebugFunc != nil {
+		l.DebugFunc(msg, keysAndValues...)
+	}
+}
+
+// Info calls InfoFunc.
+func (l LoggerFuncs) Info(msg string, keysAndValues ...interface{}) {
+	if l.InfoFunc != nil {
+		l.InfoFunc(msg, keysAndValues...)
+	}
+}
+
+// Warn calls WarnFunc.
+func (l LoggerFuncs) Warn(msg string, keysAndValues ...interface{}) {
+	if l.WarnFunc != nil {
+		l.WarnFunc(msg, keysAndValues...)
+	}
+}
+
+// Error calls ErrorFunc.
+func (l LoggerFuncs) Error(msg string, keysAndValues ...interface{}) {
+	if l.ErrorFunc != nil {
+		l.ErrorFunc(msg, keysAndValues...)
+	}
+}
+
+// FieldsLoggerFunc adapts a function that takes the level as one of debug, info, warn, or error, the message, and the
+// keys and values as a map of fields to Logger. A key that is not a string is formatted with fmt, and a key without a
+// value gets nil.
+type FieldsLoggerFunc func(level, msg string, fields map[string]interface{})
+
+// Debug calls f with the debug level.
+func (f FieldsLoggerFunc) Debug(msg string, keysAndValues ...interface{}) {
+	f("debug", msg, fields(keysAndValues))
+}
+
+// Info calls f with the info level.
+func (f FieldsLoggerFunc) Info(msg string, keysAndValues ...interface{}) {
+	f("info", msg, fields(keysAndValues))
+}
+
+// Warn calls f with the warn level.
+func (f FieldsLoggerFunc) Warn(msg string, keysAndValues ...interface{}) {
+	f("warn", msg, fields(keysAndValues))
+}
+
+// Error calls f with the error level.
+func (f FieldsLoggerFunc) Error(msg string, keysAndValues ...interface{}) {
+	f("error", msg, fields(keysAndValues))
+}
+
+// fields turns alternating keys and values into a map.
+func fields(keysAndValues []interface{}) map[string]interface{} {
+	m := make(map[string]interface{}, (len(keysAndValues)+1)/2) //nolint:gomnd
+
+	for i := 0; i < len(keysAndValues); i += 2 {
+		var value interface{}
+		if i+1 < len(keysAndValues) {
+			value = keysAndValues[i+1]
+		}
+
+		m[fmt.Sprint(keysAndValues[i])] = value
+	}
+
+	return m
+}
+
+// logRequestStart logs that a request is about to be sent.
+func (c Client) logRequestStart(req *http.Request, attempt int) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.Debug("request started", "method", req.Method, "path", req.URL.Path, "attempt", attempt+1,
+		"request_id", req.Header.Get(requestIDHeader))
+}
+
+// logRequestFinish logs how a request ended. A response is logged at info level, or warn if its status is an error,
+// and a request that got no response at all at error level.
+func (c Client) logRequestFinish(
+	req *http.Request,
+	attempt int,
+	latency time.Duration,
+	resp *http.Response,
+	err error,
+) {
+	if c.logger == nil {
+		return
+	}
+
+	keysAndValues := []interface{}{
+		"method", req.Method,
+		"path", req.URL.Path,
+		"attempt", attempt + 1,
+		"request_id", req.Header.Get(requestIDHeader),
+		"latency", latency,
+	}
+
+	switch {
+	case err != nil:
+		c.logger.Error("request failed", append(keysAndValues, "error", err.Error())...)
+	case resp.StatusCode >= http.StatusBadRequest:
+		c.logger.Warn("request finished", append(keysAndValues, "status", resp.StatusCode)...)
+	default:
+		c.logger.Info("request finished", append(keysAndValues, "status", resp.StatusCode)...)
+	}
+}
+
+// logRetry logs that a request is about to be sent again after wait, as the given numbered attempt counting from 0, and
+// why.
+func (c Client) logRetry(
+	method, endpoint, requestID string,
+	attempt int,
+	wait time.Duration,
+	resp *http.Response,
+	err error,
+) {
+	if c.logger == nil {
+		return
+	}
+
+	keysAndValues := []interface{}{
+		"method", method,
+		"endpoint", c.redaction().redact(endpoint),
+		"attempt", attempt + 1,
+		"request_id", requestID,
+		"wait", wait,
+	}
+
+	if err != nil {
+		keysAndValues = append(keysAndValues, "error", err.Error())
+	} else {
+		keysAndValues = append(keysAndValues, "status", resp.StatusCode)
+	}
+
+	c.logger.Warn("retrying request", keysAndValues...)
+}
+
+// logValidationFailure logs that the account with the ID was not sent to the service because it failed validation.
+func (c Client) logValidationFailure(id string, err error) {
+	if c.logger == nil {
+		return
+	}
+
+	c.logger.Warn("validation failed", "id", id, "error", err.Error())
+}
@@ -0,0 +1,160 @@
+package client_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestWithLogger(t *testing.T) {
+	var attempts int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	var buf bytes.Buffer
+
+	c := client.Client{BaseURL: ts.URL, OrganisationID: "orgid"}
+	client.WithRetries(1, time.Millisecond)(&c)
+	client.WithLogger(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})))(&c)
+
+	_, err := c.Fetch("accountid")
+	assert.NoError(t, err)
+
+	_, err = c.Create(client.Resource{Country: "GB"})
+	assert.Error(t, err)
+
+	type record struct {
+		Level   string `json:"level"`
+		Msg     string `json:"msg"`
+		Method  string `json:"method"`
+		Attempt int    `json:"attempt"`
+		Status  int    `json:"status"`
+		Error   string `json:"error"`
+	}
+
+	var got []record
+
+	for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+		var r record
+
+		assert.NoError(t, json.Unmarshal([]byte(line), &r))
+
+		r.Error = strings.SplitN(r.Error, ":", 2)[0]
+		got = append(got, r)
+	}
+
+	assert.Equal(t, []record{
+		{Level: "DEBUG", Msg: "request started", Method: http.MethodGet, Attempt: 1},
+		{Level: "WARN", Msg: "request finished", Method: http.MethodGet, Attempt: 1, Status: http.StatusServiceUnavailable},
+		{Level: "WARN", Msg: "retrying request", Method: http.MethodGet, Attempt: 2, Status: http.StatusServiceUnavailable},
+		{Level: "DEBUG", Msg: "request started", Method: http.MethodGet, Attempt: 2},
+		{Level: "INFO", Msg: "request finished", Method: http.MethodGet, Attempt: 2, Status: http.StatusOK},
+		{Level: "WARN", Msg: "validation failed", Error: "validation failed"},
+	}, got)
+}
+
+func TestLoggerFuncs(t *testing.T) {
+	var got []string
+
+	record := func(level string) func(string, ...interface{}) {
+		return func(msg string, keysAndValues ...interface{}) {
+			got = append(got, fmt.Sprint(level, " ", msg, keysAndValues))
+		}
+	}
+
+	var l client.Logger = client.LoggerFuncs{
+		DebugFunc: record("debug"),
+		WarnFunc:  record("warn"),
+		ErrorFunc: record("error"),
+	}
+
+	l.Debug("one", "k", 1)
+	l.Info("dropped")
+	l.Warn("two")
+	l.Error("three", "k", "v", "odd")
+
+	assert.Equal(t, []string{"debug one[k 1]", "warn two[]", "error three[k v odd]"}, got)
+}
+
+func TestFieldsLoggerFunc(t *testing.T) {
+	type entry struct {
+		level  string
+		msg    string
+		fields map[string]interface{}
+	}
+
+	var got []entry
+
+	var l client.Logger = client.FieldsLoggerFunc(func(level, msg string, fields map[string]interface{}) {
+		got = append(got, entry{level: level, msg: msg, fields: fields})
+	})
+
+	l.Debug("one", "k", 1)
+	l.Info("two")
+	l.Warn("three", 4, "four")
+	l.Error("five", "k", "v", "odd")
+
+	assert.Equal(t, []entry{
+		{level: "debug", msg: "one", fields: map[string]interface{}{"k": 1}},
+		{level: "info", msg: "two", fields: map[string]interface{}{}},
+		{level: "warn", msg: "three", fields: map[string]interface{}{"4": "four"}},
+		{level: "error", msg: "five", fields: map[string]interface{}{"k": "v", "odd": nil}},
+	}, got)
+}
+
+func TestWithLogSampling(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/organisation/accounts/missing" {
+			w.WriteHeader(http.StatusNotFound)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	var logs, debug bytes.Buffer
+
+	c := client.Client{BaseURL: ts.URL, OrganisationID: "orgid"}
+	client.WithLogger(slog.New(slog.NewTextHandler(&logs, &slog.HandlerOptions{Level: slog.LevelDebug})))(&c)
+	client.WithDebug(&debug)(&c)
+	client.WithLogSampling(3)(&c)
+
+	for i := 0; i < 5; i++ {
+		_, err := c.Fetch("accountid")
+		assert.NoError(t, err)
+	}
+
+	_, err := c.Fetch("missing")
+	assert.Error(t, err)
+
+	assert.Equal(t, 2, strings.Count(logs.String(), "request started"), "only sampled calls")
+	assert.Equal(t, 2, strings.Count(logs.String(), "level=INFO msg=\"request finished\""), "only sampled calls")
+	assert.Equal(t, 1, strings.Count(logs.String(), "level=WARN msg=\"request finished\""), "every failure")
+	assert.Equal(t, 3, strings.Count(debug.String(), "debug: request:"))
+	assert.Equal(t, 3, strings.Count(debug.String(), "debug: response:"))
+	assert.Contains(t, debug.String(), "GET /v1/organisation/accounts/missing")
+}
@@ -0,0 +1,300 @@
+package client
+
+import (
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Option configures optional behaviour on a Client created by New. The zero value of every setting an Option touches
+// is a working default, so a Client struct literal without any options keeps working.
+type Option func(*Client)
+
+// WithDebug makes the Client write a full dump of every outgoing request and incoming response, including headers and
+// bodies, to w. Dumps are written one at a time, so w does not need to be safe for concurrent use.
+func WithDebug(w io.Writer) Option {
+	return func(c *Client) {
+		c.debug = c.lockedWriter(w)
+	}
+}
+
+// WithTrace makes the Client write a one line timing summary of every request to w. The phases are collected via
+// net/http/httptrace, so DNS, connect, TLS, and time to first byte are reported separately. Summaries are written one
+// at a time, and never in the middle of a dump of WithDebug, so the same writer can be passed to both.
+func WithTrace(w io.Writer) Option {
+	return func(c *Client) {
+		c.trace = c.lockedWriter(w)
+	}
+}
+
+// WithHTTPClient makes the Client send its requests with hc instead of its HttpClient field, so a single http.Client,
+// with its connection pool, instrumentation, and proxy settings, can be shared by any number of Clients and the copies
+// they return, like the ones of WithRequestID. A nil hc means the HttpClient field again.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) {
+		c.httpClient = hc
+	}
+}
+
+// WithTransport sets the http.RoundTripper the Client sends its requests with, leaving the rest of its http.Client,
+// like the timeout, as it is. A RoundTripper is shared by every Client it is passed to, so this is the way to reuse a
+// single connection pool without building the http.Client by hand. It sets the Transport of the HttpClient field, so
+// it has no effect on a Client that has an http.Client of WithHTTPClient, which comes with its own.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) {
+		c.HttpClient.Transport = rt
+	}
+}
+
+// WithCodec makes the Client encode request bodies and decode response bodies with codec instead of encoding/json.
+// Lists that are streamed, by ListEach and Iterate, still walk the response with encoding/json, but decode each account
+// with codec. A nil codec means encoding/json again, which is the default.
+func WithCodec(codec Codec) Option {
+	return func(c *Client) {
+		c.codec = codec
+	}
+}
+
+// WithMediaTypes sets the media types the Client sends in the Accept header of its requests, and the Content-Type
+// header of the ones with a body, for deployments of the service that are versioned by a parameter of the media type,
+// like application/vnd.api+json; version=2. They are sent as they are. An empty one means application/vnd.api+json
+// again, which is the default for both.
+func WithMediaTypes(accept, contentType string) Option {
+	return func(c *Client) {
+		c.accept = accept
+		c.contentType = contentType
+	}
+}
+
+// WithAcceptedStatuses sets the statuses of a successful response to operation, which is one of create, list, fetch,
+// update, and delete, for deployments of the service that answer with, say, 200 OK to a create, or 202 Accepted when
+// they process it later. Any other status is an *APIError. A 204 No Content, or a 202 Accepted without a body, is a
+// success with an empty result. Without statuses, the operation accepts what the service documents again: 201
+// Created for create, 204 No Content for delete, and 200 OK for the rest, which is the default.
+func WithAcceptedStatuses(operation string, statuses ...int) Option {
+	return func(c *Client) {
+		accepted := make(map[string][]int, len(c.statuses)+1)
+		for op, s := range c.statuses {
+			accepted[op] = s
+		}
+
+		if len(statuses) == 0 {
+			delete(accepted, operation)
+		} else {
+			accepted[operation] = append([]int(nil), statuses...)
+		}
+
+		c.statuses = accepted
+	}
+}
+
+// WithAPIVersion makes Create, and the batches of creates, validate accounts with the rules of the given version of
+// the API, as ValidateResourceFor does, for services that check more than the first version did. APIVersion1 is the
+// default.
+func WithAPIVersion(version APIVersion) Option {
+	return func(c *Client) {
+		c.apiVersion = version
+	}
+}
+
+// WithIdempotentDelete makes Delete, and DeleteBatch, succeed when the service responds with 404 Not Found, as the
+// account is gone either way, so cleanup jobs that run again after a partial failure don't stop at the accounts they
+// already deleted. A delete at a stale version still fails with ErrConflict.
+func WithIdempotentDelete() Option {
+	return func(c *Client) {
+		c.idempotentDelete = true
+	}
+}
+
+// WithExistingOnConflict makes Create, CreateWithID, and the batches of creates fetch the account with the ID of the
+// account they create when the service responds with 409 Conflict, and if there is one, return it along with an
+// *AlreadyExistsError, which matches ErrAlreadyExists and ErrConflict, so provisioning that runs again converges on
+// the accounts it created before instead of failing. Whether the account that exists is the one that was to be
+// created is up to the caller, with Resource.Equal. Without it, a conflict only returns the error, which is the
+// default.
+func WithExistingOnConflict() Option {
+	return func(c *Client) {
+		c.existingOnConflict = true
+	}
+}
+
+// WithPartialResults makes ListAll and ListAllFrom, when a page fails, return the accounts of the pages before it along
+// with the error, which is then a *PartialListError with the ListCursor to carry on from with ListAllFrom, so a long
+// listing that fails part of the way through doesn't have to start over. Without it, they return no accounts when a
+// page fails, which is the default.
+func WithPartialResults() Option {
+	return func(c *Client) {
+		c.partialResults = true
+	}
+}
+
+// WithConcurrency sets how many requests CreateBatch, DeleteBatch, ListPages, and ListAll may have in flight at once,
+// which is also how many accounts CreateBatch encodes ahead of them. Values below 1 mean one at a time, which is also
+// the default.
+func WithConcurrency(n int) Option {
+	return func(c *Client) {
+		c.workers = n
+	}
+}
+
+// WithRateLimit caps the number of requests the Client starts per second, across every goroutine and batch that uses
+// it, so bulk work stays under the rate limit of the API. A request waits for its turn only as long as its context
+// allows, like the deadline of WithOperationTimeout. Zero or less means no cap, which is the default.
+func WithRateLimit(perSecond float64) Option {
+	return func(c *Client) {
+		c.limiter = nil
+		if perSecond > 0 {
+			c.limiter = newLimiter(perSecond)
+		}
+	}
+}
+
+// WithBackpressure makes the Client slow down on its own when the service shows signs of overload, instead of keeping
+// every request WithConcurrency allows in flight. A response of 429 Too Many Requests or 503 Service Unavailable, or
+// one that took longer than target, halves the number of requests it lets into flight, and every other response
+// grows it back by about one per round trip, up to the concurrency. Below one request in flight, requests wait part of
+// a round trip before they start. The rate of WithRateLimit is scaled down along with it. A zero target only reacts
+// to the status of responses. The limit applies to every request of the Client and its copies, batch or not, so
+// goroutines that share a Client without WithConcurrency go one at a time.
+func WithBackpressure(target time.Duration) Option {
+	return func(c *Client) {
+		c.throttle = newThrottle(target)
+	}
+}
+
+// WithPprofLabels labels the goroutine of every operation with its name and, for creates and updates that change it,
+// the country of the account, while it validates, encodes, sends, and decodes, so CPU and goroutine profiles of bulk
+// workloads attribute their samples to API calls. The keys are LabelOperation and LabelCountry. Operations that take
+// a context, like ListEach, add the labels to the ones of the context.
+func WithPprofLabels() Option {
+	return func(c *Client) {
+		c.pprofLabels = true
+	}
+}
+
+// WithProfileHook calls hook at the start of every operation, and the function it returns at the end, so a profile
+// can be taken of a single API call. CreateBatch encodes accounts on a goroutine of their own, ahead of the requests,
+// so the hook sees two create operations for every account of the batch: one that encodes it, and one that sends it.
+func WithProfileHook(hook ProfileHook) Option {
+	return func(c *Client) {
+		c.profileHook = hook
+	}
+}
+
+// WithToken makes the Client authenticate every request with the bearer token. New already sets the token from the
+// Config, so this is for Clients built without it.
+func WithToken(token string) Option {
+	return func(c *Client) {
+		c.token = token
+	}
+}
+
+// WithRetries makes the Client send a request up to retries more times when the connection fails, or the service
+// responds with 429 Too Many Requests or a 5xx status that is usually temporary. The first retry waits backoff, and
+// every one after that twice as long as the one before, unless the response asks for a specific wait with a
+// Retry-After header. No retries is the default.
+func WithRetries(retries int, backoff time.Duration) Option {
+	return func(c *Client) {
+		c.retries = retries
+		c.retryBackoff = backoff
+	}
+}
+
+// WithLogger makes the Client log every request it sends to l: when it starts at debug level, and when it finishes
+// with its status and latency at info level, or warn if the status is an error. Retries are logged at warn level,
+// requests without a response at error level, and accounts that fail validation before being sent at warn level. A
+// *slog.Logger can be passed as is, other logging libraries through LoggerFuncs or FieldsLoggerFunc. The Client logs
+// nothing by default.
+func WithLogger(l Logger) Option {
+	return func(c *Client) {
+		c.logger = l
+	}
+}
+
+// WithStatsHandler makes the Client send h an Event when a request starts, when it finishes, and when a retry is
+// scheduled, for telemetry. No handler is the default.
+func WithStatsHandler(h StatsHandler) Option {
+	return func(c *Client) {
+		c.stats = h
+	}
+}
+
+// WithStats makes the Client count requests, errors, and retries, and keep latencies per operation, for Stats. It is a
+// lightweight alternative to a StatsHandler for health reporting. Stats are not collected by default.
+func WithStats() Option {
+	return func(c *Client) {
+		c.collector = newCollector()
+	}
+}
+
+// WithOnRetry makes the Client call fn before it waits to retry a request, with the number of the attempt that failed
+// counting from 1, why it failed, and how long the Client is going to wait, so degraded upstream behaviour can be
+// logged or alerted on. A request that got a response fails with an *APIError. fn is called on the goroutine that
+// sends the request, and only when WithRetries allows retries.
+func WithOnRetry(fn func(attempt int, err error, wait time.Duration)) Option {
+	return func(c *Client) {
+		c.retryHook = fn
+	}
+}
+
+// WithRedactedFields sets the JSON names of the attributes the Client masks, all but the last four characters, in
+// debug dumps, traces, logs, and the URLs in error messages. DefaultRedactedFields are masked by default, and passing
+// no fields masks none. Validation errors always mask the IBAN and account number they quote.
+func WithRedactedFields(fields ...string) Option {
+	return func(c *Client) {
+		c.redactor = newRedactor(fields)
+	}
+}
+
+// WithPhaseTimings makes the Client time the DNS, connect, TLS, time to first byte, and body read phases of every
+// request with net/http/httptrace, and pass them to the StatsHandler in the Phases of RequestFinished. The body of a
+// response that succeeded is timed as the operation reads it, so listings still stream, and RequestFinished is sent
+// once the body is read or closed. Phases are not timed by default.
+func WithPhaseTimings() Option {
+	return func(c *Client) {
+		c.phased = true
+	}
+}
+
+// WithAuditSink makes the Client send s an AuditRecord for every create, update, and delete, whether it succeeded or
+// not. No sink is the default.
+func WithAuditSink(s AuditSink) Option {
+	return func(c *Client) {
+		c.audits = s
+	}
+}
+
+// WithLogSampling makes the Client log, trace, and dump only one in every n calls that succeed, starting with the
+// first, to keep the output of WithLogger, WithTrace, and WithDebug manageable at production volume. Calls that fail,
+// and retries, are always written in full, along with the request that failed. Events of a StatsHandler are not
+// sampled. Values of n below 2 write every call, which is the default.
+func WithLogSampling(n int) Option {
+	return func(c *Client) {
+		c.sampler = nil
+		if n > 1 {
+			c.sampler = &sampler{every: uint64(n)}
+		}
+	}
+}
+
+// WithClock makes the Client take the current time from now for the Date header of its requests, the Time of its
+// AuditRecords, and waiting for a Retry-After date of a response without a Date header, so tests can compare them
+// against fixed values. Latencies and timings are still measured with the system clock, and so are the waits
+// themselves and whether a retry would run past the deadline of its context, as that deadline is set by the system
+// clock too. time.Now is the default.
+func WithClock(now func() time.Time) Option {
+	return func(c *Client) {
+		c.clock = now
+	}
+}
+
+// WithIDGenerator makes the Client take the IDs of the accounts Create makes, and of the requests it sends, from
+// generate, so tests can compare them against fixed values. generate is called from every goroutine that uses the
+// Client, so it has to be safe for concurrent use. uuid.NewRandom is the default.
+func WithIDGenerator(generate func() (uuid.UUID, error)) Option {
+	return func(c *Client) {
+		c.newID = generate
+	}
+}
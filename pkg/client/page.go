@@ -0,0 +1,68 @@
+package client
+
+import (
+	"net/url"
+	"strconv"
+)
+
+// TotalRecords returns the number of accounts in the whole list, from the total of the meta section, and whether the
+// response had one. Not every deployment of the service sends it.
+func (mp MultiPayload) TotalRecords() (int, bool) {
+	if mp.Meta == nil {
+		return 0, false
+	}
+
+	return mp.Meta.Total, true
+}
+
+// TotalPages returns the number of pages in the whole list, and whether the response says. That is one more than the
+// page number of the last link if it has one, or else the total of the meta section split into pages of the size in
+// the links. Deployments of the service that link to the last page with a word instead of a number, and send no meta,
+// say nothing about it.
+func (mp MultiPayload) TotalPages() (uint, bool) {
+	if last, ok := linkParam(mp.Links.Last, "page[number]"); ok {
+		return last + 1, true
+	}
+
+	total, ok := mp.TotalRecords()
+	if !ok {
+		return 0, false
+	}
+
+	for _, link := range []string{mp.Links.Self, mp.Links.First, mp.Links.Last, mp.Links.Next} {
+		if size, ok := linkParam(link, "page[size]"); ok && size > 0 {
+			return (uint(total) + size - 1) / size, true
+		}
+	}
+
+	return 0, false
+}
+
+// lastPage returns the number of the last page of a list in pages of pageSize, from the total of the meta of mp if it
+// has one, or else from the page number of its last link.
+func lastPage(mp MultiPayload, pageSize uint) (uint, bool) {
+	if total, ok := mp.TotalRecords(); ok && total > 0 {
+		return (uint(total) - 1) / pageSize, true
+	}
+
+	return linkParam(mp.Links.Last, "page[number]")
+}
+
+// linkParam returns the number in the query parameter key of link, and whether there is one.
+func linkParam(link, key string) (uint, bool) {
+	if link == "" {
+		return 0, false
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return 0, false
+	}
+
+	n, err := strconv.ParseUint(u.Query().Get(key), 10, 0)
+	if err != nil {
+		return 0, false
+	}
+
+	return uint(n), true
+}
@@ -0,0 +1,73 @@
+package client_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestMultiPayload_TotalRecords_TotalPages(t *testing.T) {
+	const base = "/v1/organisation/accounts?page%5Bnumber%5D="
+
+	tests := []struct {
+		name        string
+		mp          client.MultiPayload
+		wantRecords int
+		wantPages   uint
+		wantOK      bool
+		wantPagesOK bool
+	}{
+		{
+			name: "neither meta nor a numbered last link",
+			mp:   client.MultiPayload{Links: client.Links{Self: base + "0&page%5Bsize%5D=10", Last: base + "last"}},
+		},
+		{
+			name: "a numbered last link",
+			mp: client.MultiPayload{Links: client.Links{
+				Self: base + "0&page%5Bsize%5D=10",
+				Last: base + "4&page%5Bsize%5D=10",
+			}},
+			wantPages:   5,
+			wantPagesOK: true,
+		},
+		{
+			name: "meta with the page size in the self link",
+			mp: client.MultiPayload{
+				Links: client.Links{Self: base + "0&page%5Bsize%5D=10", Last: base + "last"},
+				Meta:  &client.Meta{Total: 41},
+			},
+			wantRecords: 41,
+			wantOK:      true,
+			wantPages:   5,
+			wantPagesOK: true,
+		},
+		{
+			name:        "meta without a page size",
+			mp:          client.MultiPayload{Meta: &client.Meta{Total: 41}},
+			wantRecords: 41,
+			wantOK:      true,
+		},
+		{
+			name: "an empty list",
+			mp: client.MultiPayload{
+				Links: client.Links{Self: base + "0&page%5Bsize%5D=10"},
+				Meta:  &client.Meta{},
+			},
+			wantOK:      true,
+			wantPagesOK: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			records, ok := tt.mp.TotalRecords()
+			assert.Equal(t, tt.wantRecords, records)
+			assert.Equal(t, tt.wantOK, ok)
+
+			pages, ok := tt.mp.TotalPages()
+			assert.Equal(t, tt.wantPages, pages)
+			assert.Equal(t, tt.wantPagesOK, ok)
+		})
+	}
+}
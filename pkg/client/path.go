@@ -0,0 +1,134 @@
+package client
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// baseURL is the BaseURL of a Client as New parsed it, along with the string it parsed, so a Client whose BaseURL was
+// changed after New, or that was built as a struct literal, parses its own instead.
+type baseURL struct {
+	raw string
+	url *url.URL
+}
+
+// parseBaseURL parses the URL of the accounts API. It has to be an absolute http or https URL without a query or
+// fragment, as the endpoints add their own.
+func parseBaseURL(raw string) (*baseURL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("base url: %w", err)
+	}
+
+	switch {
+	case u.Scheme != "http" && u.Scheme != "https":
+		return nil, fmt.Errorf("base url %q: scheme is not http or https", raw)
+	case u.Host == "":
+		return nil, fmt.Errorf("base url %q: no host", raw)
+	case u.RawQuery != "" || u.Fragment != "":
+		return nil, fmt.Errorf("base url %q: query or fragment", raw)
+	}
+
+	return &baseURL{raw: raw, url: u}, nil
+}
+
+// baseURL returns the BaseURL of the Client as New parsed it, or parses it if New didn't, or it changed since.
+func (c Client) baseURL() (*baseURL, error) {
+	if c.base != nil && c.base.raw == c.BaseURL {
+		return c.base, nil
+	}
+
+	return parseBaseURL(c.BaseURL)
+}
+
+// linkEndpoint returns the endpoint of a link of a response, like the self link of an account, or the next link of a
+// page, to send a request to it. A link with a host has to have the scheme and host of the BaseURL, so the Client
+// doesn't send its token anywhere else. A link that starts with the path of the BaseURL is taken to include it, and
+// any other is taken to be under it, as the service may not know it's deployed under a path.
+func (c Client) linkEndpoint(link string) (string, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return "", err
+	}
+
+	u, err := url.Parse(link)
+	if err != nil {
+		return "", fmt.Errorf("link: %w", err)
+	}
+
+	if u.Scheme != "" || u.Host != "" {
+		if !strings.EqualFold(u.Scheme, base.url.Scheme) || !strings.EqualFold(u.Host, base.url.Host) {
+			return "", fmt.Errorf("link %q: %w", link, ErrForeignLink)
+		}
+	}
+
+	path := u.EscapedPath()
+	if path == "" {
+		return "", fmt.Errorf("link %q: no path", link)
+	}
+
+	if prefix := strings.TrimSuffix(base.url.EscapedPath(), "/"); prefix != "" && strings.HasPrefix(path, prefix+"/") {
+		path = strings.TrimPrefix(path, prefix)
+	}
+
+	if u.RawQuery != "" {
+		path += "?" + u.RawQuery
+	}
+
+	return path, nil
+}
+
+// endpointURL returns the URL of endpoint, which is an escaped path with an optional query, under the BaseURL of the
+// Client. The paths are joined with url.JoinPath, so a BaseURL with or without a trailing slash, or with a path of its
+// own, ends up with a single slash between the two.
+func (c Client) endpointURL(endpoint string) (string, error) {
+	base, err := c.baseURL()
+	if err != nil {
+		return "", err
+	}
+
+	path, query, _ := strings.Cut(endpoint, "?")
+
+	u := base.url.JoinPath(path)
+	u.RawQuery = query
+
+	return u.String(), nil
+}
+
+// accountEndpoint returns the endpoint of the account with the given ID, which is escaped so an ID with a slash, a
+// question mark, or a space in it stays a single path segment instead of changing the request. An empty ID, ".", and
+// "..", which escaping leaves as they are, fail with a *ValidationError, as the path would be cleaned to the accounts
+// or the organisation endpoint instead. With WithIDValidation, an ID that is not a UUID fails the same way. Either way,
+// nothing is sent.
+func (c Client) accountEndpoint(accountID string) (endpoint, error) {
+	var message string
+
+	switch {
+	case accountID == "" || accountID == "." || accountID == "..":
+		message = fmt.Sprintf("account id %q is not a path segment", accountID)
+	case c.validateIDs:
+		if _, err := uuid.Parse(accountID); err != nil {
+			message = fmt.Sprintf("account id %q is not a UUID", accountID)
+		}
+	}
+
+	if message != "" {
+		finding := FieldError{Field: "id", Message: message}
+
+		return endpoint{}, &ValidationError{Err: finding, Fields: []FieldError{finding}}
+	}
+
+	return accountsEndpoint().path(accountID), nil
+}
+
+// WithIDValidation makes Fetch, Update, and Delete check that the account ID is a UUID, as the service assigns no
+// other kind, and fail with a *ValidationError, which matches ErrValidation, without sending a request if it is not.
+// IDs are escaped in the path either way. No validation is the default, for services and tests with IDs of their own.
+func WithIDValidation() Option {
+	return func(c *Client) {
+		c.validateIDs = true
+	}
+}
@@ -0,0 +1,103 @@
+package client_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestClient_accountPath(t *testing.T) {
+	tests := []struct {
+		name      string
+		accountID string
+		opts      []client.Option
+		wantPath  string
+		wantQuery string
+		wantErr   string
+	}{
+		{
+			name:      "sends a UUID as it is",
+			accountID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+			wantPath:  "/v1/organisation/accounts/a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+			wantQuery: "version=2",
+		},
+		{
+			name:      "escapes slashes, question marks, and spaces",
+			accountID: "../a b?version=7",
+			wantPath:  "/v1/organisation/accounts/..%2Fa%20b%3Fversion=7",
+			wantQuery: "version=2",
+		},
+		{
+			name:      "accepts a UUID with WithIDValidation",
+			accountID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+			opts:      []client.Option{client.WithIDValidation()},
+			wantPath:  "/v1/organisation/accounts/a6c1a721-bb1b-41ef-bd11-800a1309ff9b",
+			wantQuery: "version=2",
+		},
+		{
+			name:      "rejects anything else with WithIDValidation",
+			accountID: "accountid",
+			opts:      []client.Option{client.WithIDValidation()},
+			wantErr:   `validation failed: account id "accountid" is not a UUID`,
+		},
+		{
+			name:      "rejects the parent of the accounts endpoint instead of cleaning the path to it",
+			accountID: "..",
+			wantErr:   `validation failed: account id ".." is not a path segment`,
+		},
+		{
+			name:      "rejects the accounts endpoint itself",
+			accountID: ".",
+			wantErr:   `validation failed: account id "." is not a path segment`,
+		},
+		{
+			name:      "rejects an empty id",
+			accountID: "",
+			wantErr:   `validation failed: account id "" is not a path segment`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths, queries []string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.EscapedPath())
+
+				if r.Method == http.MethodDelete {
+					queries = append(queries, r.URL.RawQuery)
+				}
+
+				w.WriteHeader(http.StatusTeapot)
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			for _, opt := range tt.opts {
+				opt(&c)
+			}
+
+			_, fetchErr := c.Fetch(tt.accountID)
+			_, updateErr := c.Update(tt.accountID, 2, map[string]interface{}{})
+			deleteErr := c.Delete(tt.accountID, 2)
+
+			if tt.wantErr != "" {
+				for _, err := range []error{fetchErr, updateErr, deleteErr} {
+					assert.True(t, errors.Is(err, client.ErrValidation))
+					assert.Contains(t, err.Error(), tt.wantErr)
+				}
+
+				assert.Empty(t, paths, "nothing is sent")
+
+				return
+			}
+
+			assert.Equal(t, []string{tt.wantPath, tt.wantPath, tt.wantPath}, paths)
+			assert.Equal(t, []string{tt.wantQuery}, queries)
+		})
+	}
+}
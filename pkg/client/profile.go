@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"runtime/pprof"
+)
+
+// Labels the Client sets on the goroutine of an operation with WithPprofLabels, for pprof.Label and the -tagfocus
+// option of go tool pprof.
+const (
+	LabelOperation = "operation"
+	LabelCountry   = "country"
+)
+
+// ProfileHook starts a profile of a single operation, and returns the function that stops it. The operation is one of
+// create, list, fetch, update, and delete, the same names as in Stats, and ctx carries the pprof labels of the
+// operation, so the country, when it is known, is pprof.Label(ctx, LabelCountry). Operations run concurrently in
+// batches, so a hook that starts a CPU profile, which there can only be one of at a time, has to skip the operations
+// that start while one is running.
+type ProfileHook func(ctx context.Context, operation string) (stop func())
+
+// noProfile is what profileOperation returns when profiling is off, so the Client does not allocate a function for
+// every operation.
+func noProfile() {}
+
+// profileOperation labels the goroutine with the operation and country, if it is known, for pprof, and calls the
+// profile hook, until the returned function is called. The labels are added to the ones of ctx, and the goroutine goes
+// back to those afterwards, the same way pprof.Do works, so operations without a context go back to none.
+func (c Client) profileOperation(ctx context.Context, operation, country string) func() {
+	if !c.pprofLabels && c.profileHook == nil {
+		return noProfile
+	}
+
+	labels := []string{LabelOperation, operation}
+	if country != "" {
+		labels = append(labels, LabelCountry, country)
+	}
+
+	labelled := pprof.WithLabels(ctx, pprof.Labels(labels...))
+
+	if c.pprofLabels {
+		pprof.SetGoroutineLabels(labelled)
+	}
+
+	stop := noProfile
+	if c.profileHook != nil {
+		stop = c.profileHook(labelled, operation)
+	}
+
+	return func() {
+		stop()
+
+		if c.pprofLabels {
+			pprof.SetGoroutineLabels(ctx)
+		}
+	}
+}
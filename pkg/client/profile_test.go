@@ -0,0 +1,144 @@
+package client_test
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"runtime/pprof"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestWithProfileHook(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	payload := returnCompactFile(t, "./testdata/payload.json")
+	list := pagingHandler(t, 2, "", "", &[]string{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodGet && strings.HasSuffix(r.URL.Path, "/accounts"):
+			list(w, r)
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusCreated)
+			_, _ = w.Write([]byte(payload))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		default:
+			_, _ = w.Write([]byte(payload))
+		}
+	}))
+	defer ts.Close()
+
+	tests := []struct {
+		name    string
+		call    func(c client.Client) error
+		want    []string
+		country string
+	}{
+		{
+			name: "Create",
+			call: func(c client.Client) error {
+				_, err := c.Create(fixtures.ValidGB())
+
+				return err
+			},
+			want:    []string{"create"},
+			country: "GB",
+		},
+		{
+			name: "CreateBatch encodes and sends separately",
+			call: func(c client.Client) error {
+				c.CreateBatch([]client.Resource{fixtures.ValidDE()}, func(int, client.Payload, error) {})
+
+				return nil
+			},
+			want:    []string{"create", "create"},
+			country: "DE",
+		},
+		{
+			name: "Fetch",
+			call: func(c client.Client) error {
+				_, err := c.Fetch(accountID)
+
+				return err
+			},
+			want: []string{"fetch"},
+		},
+		{
+			name: "Update with a country",
+			call: func(c client.Client) error {
+				_, err := c.Update(accountID, 0, map[string]interface{}{"country": "FR"})
+
+				return err
+			},
+			want:    []string{"update"},
+			country: "FR",
+		},
+		{
+			name: "Delete",
+			call: func(c client.Client) error {
+				return c.Delete(accountID, 0)
+			},
+			want: []string{"delete"},
+		},
+		{
+			name: "ListEach profiles every page",
+			call: func(c client.Client) error {
+				return c.ListEach(context.Background(), 1, func(client.Data) error { return nil })
+			},
+			want: []string{"list", "list"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var started, stopped []string
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithPprofLabels()(&c)
+			client.WithProfileHook(func(ctx context.Context, operation string) func() {
+				started = append(started, operation)
+
+				label, _ := pprof.Label(ctx, client.LabelOperation)
+				assert.Equal(t, operation, label)
+
+				country, _ := pprof.Label(ctx, client.LabelCountry)
+				assert.Equal(t, tt.country, country)
+
+				assert.Contains(t, goroutineLabels(t), `"operation":"`+operation+`"`)
+
+				return func() {
+					stopped = append(stopped, operation)
+				}
+			})(&c)
+
+			assert.NoError(t, tt.call(c))
+			assert.Equal(t, tt.want, started)
+			assert.Equal(t, tt.want, stopped)
+			assert.NotContains(t, goroutineLabels(t), `"operation":`, "the labels are gone afterwards")
+		})
+	}
+}
+
+// goroutineLabels returns the entry of the goroutine that calls it in the goroutine profile, which has its labels.
+func goroutineLabels(t *testing.T) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	assert.NoError(t, pprof.Lookup("goroutine").WriteTo(&buf, 1))
+
+	for _, entry := range strings.Split(buf.String(), "\n\n") {
+		if strings.Contains(entry, "goroutineLabels") {
+			return entry
+		}
+	}
+
+	return ""
+}
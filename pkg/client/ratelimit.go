@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// limiter spaces requests out evenly so no more than a given number of them start in any second. It is shared by every
+// copy of the Client it was configured on, and by every goroutine of a batch.
+type limiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// newLimiter returns a limiter that lets perSecond requests through every second.
+func newLimiter(perSecond float64) *limiter {
+	return &limiter{interval: time.Duration(float64(time.Second) / perSecond)}
+}
+
+// wait blocks until the next request is allowed to start, or ctx is done, which it returns the error of. The rate is
+// scaled by share, which is between 0 and 1, and only less than 1 while backpressure holds the Client back. A wait
+// that is cancelled gives its slot back if no request reserved one after it, so a request that never starts doesn't
+// hold back the ones after it.
+func (l *limiter) wait(ctx context.Context, share float64) error {
+	l.mu.Lock()
+
+	now := time.Now()
+	slot := l.next
+
+	if slot.Before(now) {
+		slot = now
+	}
+
+	l.next = slot.Add(time.Duration(float64(l.interval) / share))
+	reserved := l.next
+
+	l.mu.Unlock()
+
+	err := sleepContext(ctx, slot.Sub(now))
+	if err != nil {
+		l.mu.Lock()
+
+		if l.next.Equal(reserved) {
+			l.next = slot
+		}
+
+		l.mu.Unlock()
+	}
+
+	return err
+}
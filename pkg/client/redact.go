@@ -0,0 +1,99 @@
+package client
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+// maskKeep is how many characters at the end of a sensitive value are left readable, enough to tell accounts apart.
+const maskKeep = 4
+
+// DefaultRedactedFields are the JSON names of the attributes the Client masks in debug dumps, traces, logs, and error
+// messages unless WithRedactedFields says otherwise.
+var DefaultRedactedFields = []string{"account_number", "iban", "customer_id"}
+
+// defaultRedactor masks DefaultRedactedFields. It is built once, as compiling the expressions is not free.
+var defaultRedactor = newRedactor(DefaultRedactedFields)
+
+// redactor masks the values of a set of attributes wherever they show up in text the Client writes: as JSON members
+// in request and response bodies, and as filter query parameters in URLs.
+type redactor struct {
+	json  *regexp.Regexp
+	query *regexp.Regexp
+}
+
+// newRedactor returns a redactor for the attributes with the given JSON names. With no names it masks nothing.
+func newRedactor(fields []string) *redactor {
+	if len(fields) == 0 {
+		return &redactor{}
+	}
+
+	quoted := make([]string, 0, len(fields))
+
+	for _, f := range fields {
+		quoted = append(quoted, regexp.QuoteMeta(f))
+	}
+
+	names := strings.Join(quoted, "|")
+
+	return &redactor{
+		json:  regexp.MustCompile(`("(?:` + names + `)"\s*:\s*")((?:[^"\\]|\\.)*)"`),
+		query: regexp.MustCompile(`(filter(?:\[|%5B)(?:` + names + `)(?:\]|%5D)=)([^&\s"]*)`),
+	}
+}
+
+// redact returns s with the values of the attributes masked.
+func (r *redactor) redact(s string) string {
+	if r.json == nil {
+		return s
+	}
+
+	s = maskGroups(r.json, s, `"`)
+
+	return maskGroups(r.query, s, "")
+}
+
+// maskGroups masks the second group of every match of re in s. The first group, and suffix, are kept as they are.
+func maskGroups(re *regexp.Regexp, s, suffix string) string {
+	return re.ReplaceAllStringFunc(s, func(match string) string {
+		groups := re.FindStringSubmatch(match)
+
+		return groups[1] + Mask(groups[2]) + suffix
+	})
+}
+
+// redactError masks the attributes in the URL of a failed request, which the error message of http.Client includes.
+func (r *redactor) redactError(err error) {
+	var urlErr *url.Error
+	if errors.As(err, &urlErr) {
+		urlErr.URL = r.redact(urlErr.URL)
+	}
+}
+
+// redaction returns the redactor of the Client, which masks DefaultRedactedFields unless WithRedactedFields was used.
+func (c Client) redaction() *redactor {
+	if c.redactor == nil {
+		return defaultRedactor
+	}
+
+	return c.redactor
+}
+
+// Mask replaces every character of value but the last four with an asterisk. Values of four characters or fewer are
+// masked completely.
+func Mask(value string) string {
+	runes := []rune(value)
+	keep := maskKeep
+
+	if len(runes) <= keep {
+		keep = 0
+	}
+
+	for i := 0; i < len(runes)-keep; i++ {
+		runes[i] = '*'
+	}
+
+	return string(runes)
+}
@@ -0,0 +1,101 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+)
+
+// defaultMaxRedirects is how many redirects a request follows by default, the same as net/http.
+const defaultMaxRedirects = 10
+
+// redirectPolicy is how the requests of a Client follow redirects, set with WithMaxRedirects and WithoutRedirectsFor.
+type redirectPolicy struct {
+	max    int
+	refuse map[string]bool
+}
+
+// WithMaxRedirects sets how many redirects a request follows at most, before it fails with an error that matches
+// ErrTooManyRedirects. A redirect back to a URL the request was already sent to fails the same way straight away, so a
+// misconfigured gateway that loops doesn't cost a round trip per redirect. Zero or less follows none, so the redirect
+// is the response, which fails as an *APIError with its 3xx status. 10 is the default, the same as net/http.
+func WithMaxRedirects(n int) Option {
+	return func(c *Client) {
+		p := c.redirectPolicy()
+		p.max = n
+		c.redirects = &p
+	}
+}
+
+// WithoutRedirectsFor makes the requests with the given methods, like http.MethodPost and http.MethodDelete, not follow
+// any redirect, even one that keeps the method and body, so a create or a delete is only ever sent to the URL the
+// Client built for it. The redirect is the response, which fails as an *APIError with its 3xx status. Without methods,
+// requests of every method follow redirects again, which is the default.
+func WithoutRedirectsFor(methods ...string) Option {
+	return func(c *Client) {
+		p := c.redirectPolicy()
+		p.refuse = make(map[string]bool, len(methods))
+
+		for _, m := range methods {
+			p.refuse[strings.ToUpper(m)] = true
+		}
+
+		c.redirects = &p
+	}
+}
+
+// redirectPolicy returns the policy of WithMaxRedirects and WithoutRedirectsFor, or the default one.
+func (c Client) redirectPolicy() redirectPolicy {
+	if c.redirects == nil {
+		return redirectPolicy{max: defaultMaxRedirects}
+	}
+
+	return *c.redirects
+}
+
+// checkRedirect is the CheckRedirect of the http.Client the Client sends its requests with, unless that has one of its
+// own. On top of the limit and the methods of the redirect policy, it doesn't follow a redirect that would change the
+// method, like a 303 See Other after a POST, as net/http would send it again as a GET without its body. A redirect it
+// follows gets the headers of the Client again, as addHeaders sets them: a fresh Date, and the token only if it stays
+// on the scheme and host of the BaseURL, where net/http would keep it for any subdomain too, and drop it for good
+// after a redirect to another host, even on the way back.
+func (c Client) checkRedirect(req *http.Request, via []*http.Request) error {
+	p := c.redirectPolicy()
+	first := via[0]
+
+	switch {
+	case p.max <= 0, p.refuse[first.Method], req.Method != first.Method:
+		return http.ErrUseLastResponse
+	case len(via) > p.max:
+		return fmt.Errorf("stopped after %d redirects: %w", p.max, ErrTooManyRedirects)
+	}
+
+	for _, r := range via {
+		if r.URL.String() == req.URL.String() {
+			return fmt.Errorf("redirect loop: %w", ErrTooManyRedirects)
+		}
+	}
+
+	req.Header.Set("Date", c.currentHTTPDate())
+	req.Header.Del("Authorization")
+
+	if c.token != "" && c.sameOrigin(req) {
+		req.Header.Set("Authorization", "Bearer "+c.token)
+	}
+
+	if req.ContentLength == 0 {
+		req.Header.Del("Content-Type")
+	}
+
+	return nil
+}
+
+// sameOrigin reports whether req goes to the scheme and host of the BaseURL.
+func (c Client) sameOrigin(req *http.Request) bool {
+	base, err := c.baseURL()
+	if err != nil {
+		return false
+	}
+
+	return strings.EqualFold(req.URL.Scheme, base.url.Scheme) && strings.EqualFold(req.URL.Host, base.url.Host)
+}
@@ -0,0 +1,206 @@
+package client_test
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestClient_redirects(t *testing.T) {
+	tests := []struct {
+		name       string
+		options    []client.Option
+		redirect   func(r *http.Request) (string, int)
+		call       func(c client.Client) error
+		wantPaths  []string
+		wantErr    error
+		wantStatus int
+	}{
+		{
+			name: "follows redirects",
+			redirect: func(r *http.Request) (string, int) {
+				return "/moved" + r.URL.Path, http.StatusFound
+			},
+			wantPaths: []string{"/v1/organisation/accounts/accountid", "/moved/v1/organisation/accounts/accountid"},
+		},
+		{
+			name:    "stops after WithMaxRedirects",
+			options: []client.Option{client.WithMaxRedirects(2), client.WithRetries(2, time.Millisecond)},
+			redirect: func(r *http.Request) (string, int) {
+				return r.URL.Path + "/again", http.StatusFound
+			},
+			wantPaths: []string{
+				"/v1/organisation/accounts/accountid",
+				"/v1/organisation/accounts/accountid/again",
+				"/v1/organisation/accounts/accountid/again/again",
+			},
+			wantErr: client.ErrTooManyRedirects,
+		},
+		{
+			name:    "stops at a loop",
+			options: []client.Option{client.WithRetries(2, time.Millisecond)},
+			redirect: func(r *http.Request) (string, int) {
+				if strings.HasPrefix(r.URL.Path, "/moved") {
+					return strings.TrimPrefix(r.URL.Path, "/moved"), http.StatusFound
+				}
+
+				return "/moved" + r.URL.Path, http.StatusFound
+			},
+			wantPaths: []string{"/v1/organisation/accounts/accountid", "/moved/v1/organisation/accounts/accountid"},
+			wantErr:   client.ErrTooManyRedirects,
+		},
+		{
+			name:    "follows none with WithMaxRedirects of 0",
+			options: []client.Option{client.WithMaxRedirects(0)},
+			redirect: func(r *http.Request) (string, int) {
+				return "/moved" + r.URL.Path, http.StatusFound
+			},
+			wantPaths:  []string{"/v1/organisation/accounts/accountid"},
+			wantStatus: http.StatusFound,
+		},
+		{
+			name:    "follows none for the methods of WithoutRedirectsFor",
+			options: []client.Option{client.WithoutRedirectsFor(http.MethodDelete)},
+			redirect: func(r *http.Request) (string, int) {
+				return "/moved" + r.URL.Path, http.StatusTemporaryRedirect
+			},
+			call: func(c client.Client) error {
+				return c.Delete("accountid", 0)
+			},
+			wantPaths:  []string{"/v1/organisation/accounts/accountid"},
+			wantStatus: http.StatusTemporaryRedirect,
+		},
+		{
+			name: "follows none that would change the method",
+			redirect: func(r *http.Request) (string, int) {
+				return "/moved" + r.URL.Path, http.StatusSeeOther
+			},
+			call: func(c client.Client) error {
+				_, err := c.Update("accountid", 0, map[string]interface{}{"status": "closed"})
+
+				return err
+			},
+			wantPaths:  []string{"/v1/organisation/accounts/accountid"},
+			wantStatus: http.StatusSeeOther,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var paths []string
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+
+				if len(paths) > 1 && tt.wantErr == nil {
+					_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+
+					return
+				}
+
+				location, status := tt.redirect(r)
+				http.Redirect(w, r, location, status)
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			for _, opt := range tt.options {
+				opt(&c)
+			}
+
+			call := tt.call
+			if call == nil {
+				call = func(c client.Client) error {
+					_, err := c.Fetch("accountid")
+
+					return err
+				}
+			}
+
+			err := call(c)
+
+			assert.Equal(t, tt.wantPaths, paths)
+
+			var apiErr *client.APIError
+
+			switch {
+			case tt.wantErr != nil:
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			case tt.wantStatus != 0:
+				assert.True(t, errors.As(err, &apiErr), "got %v", err)
+				assert.Equal(t, tt.wantStatus, apiErr.StatusCode)
+			default:
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestClient_redirects_headers(t *testing.T) {
+	type seen struct {
+		auth, requestID, date string
+	}
+
+	var foreignSeen, backSeen seen
+
+	var back *httptest.Server
+
+	foreign := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		foreignSeen = seen{r.Header.Get("Authorization"), r.Header.Get("X-Request-Id"), r.Header.Get("Date")}
+		http.Redirect(w, r, back.URL+"/back"+r.URL.Path, http.StatusFound)
+	}))
+	defer foreign.Close()
+
+	back = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/back") {
+			http.Redirect(w, r, foreign.URL+r.URL.Path, http.StatusFound)
+
+			return
+		}
+
+		backSeen = seen{r.Header.Get("Authorization"), r.Header.Get("X-Request-Id"), r.Header.Get("Date")}
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer back.Close()
+
+	c := client.Client{BaseURL: back.URL}
+	client.WithToken("secret")(&c)
+
+	_, err := c.WithRequestID("request-1").Fetch("accountid")
+	assert.NoError(t, err)
+
+	assert.Empty(t, foreignSeen.auth, "the token is not sent to another host")
+	assert.Equal(t, "Bearer secret", backSeen.auth, "the token is sent again on the way back")
+	assert.Equal(t, "request-1", foreignSeen.requestID)
+	assert.Equal(t, "request-1", backSeen.requestID)
+	assert.NotEmpty(t, foreignSeen.date)
+	assert.NotEmpty(t, backSeen.date)
+}
+
+func TestClient_redirects_ownCheckRedirect(t *testing.T) {
+	var requests int
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		http.Redirect(w, r, "/moved"+r.URL.Path, http.StatusFound)
+	}))
+	defer ts.Close()
+
+	errOwn := errors.New("own policy")
+
+	c := client.Client{BaseURL: ts.URL, HttpClient: http.Client{
+		CheckRedirect: func(*http.Request, []*http.Request) error { return errOwn },
+	}}
+	client.WithMaxRedirects(5)(&c)
+
+	_, err := c.Fetch("accountid")
+
+	assert.True(t, errors.Is(err, errOwn), "the CheckRedirect of the http.Client wins, got %v", err)
+	assert.Equal(t, 1, requests)
+}
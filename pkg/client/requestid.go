@@ -0,0 +1,65 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// requestIDKey and responseMetaKey are the keys of the values ContextWithRequestID and ContextWithResponseMeta add.
+type (
+	requestIDKey    struct{}
+	responseMetaKey struct{}
+)
+
+// ContextWithRequestID returns a copy of ctx that makes the calls of a Client made with it send id in the X-Request-Id
+// header, for example the ID of the request a service is serving. It wins over the ID of WithRequestID. Calls take it
+// from their own context, or from the one of WithContext for calls that don't have one.
+func ContextWithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// requestIDFrom returns the ID of ContextWithRequestID, if ctx has one.
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(requestIDKey{}).(string)
+
+	return id
+}
+
+// ResponseMeta is what a call of a Client made with a context of ContextWithResponseMeta found out about its request.
+type ResponseMeta struct {
+	// RequestID is the X-Request-Id the request was sent with, generated or not.
+	RequestID string
+	// StatusCode is the status of the last response, or 0 if the last attempt got none.
+	StatusCode int
+	// Attempts is the number of times the request was sent, retries included.
+	Attempts int
+}
+
+// responseMeta is the ResponseMeta of ContextWithResponseMeta, and the lock that guards it.
+type responseMeta struct {
+	mu   sync.Mutex
+	meta *ResponseMeta
+}
+
+// ContextWithResponseMeta returns a copy of ctx that makes the calls of a Client made with it fill in meta when they
+// are done, whether they succeed or not, so a caller can log the request ID of a call that went well too. An operation
+// that sends several requests, like a listing or a create that fetches the account it created, leaves the meta of the
+// last one, and a batch the meta of whichever of its requests finished last.
+func ContextWithResponseMeta(ctx context.Context, meta *ResponseMeta) context.Context {
+	return context.WithValue(ctx, responseMetaKey{}, &responseMeta{meta: meta})
+}
+
+// setResponseMeta fills in the ResponseMeta of ContextWithResponseMeta, if ctx has one, for a call that ended with
+// resp after the given number of attempts.
+func setResponseMeta(ctx context.Context, requestID string, attempts int, resp *http.Response) {
+	rm, ok := ctx.Value(responseMetaKey{}).(*responseMeta)
+	if !ok {
+		return
+	}
+
+	rm.mu.Lock()
+	defer rm.mu.Unlock()
+
+	*rm.meta = ResponseMeta{RequestID: requestID, StatusCode: statusCode(resp), Attempts: attempts}
+}
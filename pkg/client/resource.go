@@ -1,6 +1,9 @@
 package client
 
-import "time"
+import (
+	"encoding/json"
+	"reflect"
+)
 
 // Resource in this case encodes the Organisation.Account resource as the API only deals with this.
 type Resource struct {
@@ -20,6 +23,99 @@ type Resource struct {
 	SecondaryIdentification string    `json:"secondary_identification,omitempty"`
 	Switched                bool      `json:"switched"`
 	Status                  string    `json:"status"`
+
+	// Extra holds the attributes of a decoded account that Resource has no field for, like the ones of a newer version
+	// of the service, by their JSON name, so they are sent again when the Resource is encoded, and a backup and restore
+	// keeps them. Attributes that have a field are never taken from it.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// resourceJSON is Resource without its methods, so MarshalJSON can encode it without calling itself.
+type resourceJSON Resource
+
+// MarshalJSON encodes the Resource with the name and alternative name lines up to the last one that is set, and leaves
+// out the lists that have none, instead of sending empty strings for every unused line. Empty lines before a set one
+// keep their place. Decoding takes lists of any length up to the number of lines, so both shapes are read back the
+// same. The attributes of Extra follow the rest, in the order of their names.
+func (r Resource) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(struct {
+		resourceJSON
+		Name             []string `json:"name,omitempty"`
+		AlternativeNames []string `json:"alternative_names,omitempty"`
+	}{
+		resourceJSON:     resourceJSON(r),
+		Name:             trimLines(r.Name[:]),
+		AlternativeNames: trimLines(r.AlternativeNames[:]),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(r.Extra) == 0 {
+		return b, nil
+	}
+
+	return appendExtra(b, r.Extra, resourceKeys)
+}
+
+// UnmarshalJSON decodes the Resource, and keeps the attributes it has no field for in Extra.
+func (r *Resource) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*resourceJSON)(r)); err != nil {
+		return err
+	}
+
+	extra, err := extraAttributes(b, resourceKeys)
+	if err != nil {
+		return err
+	}
+
+	r.Extra = extra
+
+	return nil
+}
+
+// isZero reports whether the Resource has no attributes at all.
+func (r Resource) isZero() bool {
+	return reflect.ValueOf(r).IsZero()
+}
+
+// Equal reports whether the Resource and other describe the same account, leaving out what the service fills in on
+// its own: an account number or IBAN that is empty in one of them matches any value in the other, as the service
+// generates them for accounts created without. Everything else has to be the same, including the attributes of Extra,
+// which are compared by their JSON value rather than their formatting, so a Resource read back from the service is
+// Equal to the one it was created from, but one that drifted since is not.
+func (r Resource) Equal(other Resource) bool {
+	r.AccountNumber, other.AccountNumber = unlessGenerated(r.AccountNumber, other.AccountNumber)
+	r.IBAN, other.IBAN = unlessGenerated(r.IBAN, other.IBAN)
+
+	if !equalExtra(r.Extra, other.Extra) {
+		return false
+	}
+
+	r.Extra, other.Extra = nil, nil
+
+	return reflect.DeepEqual(r, other)
+}
+
+// unlessGenerated returns a and b as they are if both are set, or two empty strings if either of them is not, in which
+// case the service may have generated the other.
+func unlessGenerated(a, b string) (string, string) {
+	if a == "" || b == "" {
+		return "", ""
+	}
+
+	return a, b
+}
+
+// trimLines returns the lines up to the last one that is not empty, or nil if they all are.
+func trimLines(lines []string) []string {
+	for n := len(lines); n > 0; n-- {
+		if lines[n-1] != "" {
+			return lines[:n]
+		}
+	}
+
+	return nil
 }
 
 // Data struct encodes the the data part of a create request, and data part of the responses.
@@ -28,9 +124,55 @@ type Data struct {
 	OrganisationID string    `json:"organisation_id"`
 	Type           string    `json:"type"`
 	Version        int       `json:"version"`
-	CreatedOn      time.Time `json:"created_on"`
-	ModifiedOn     time.Time `json:"modified_on"`
+	CreatedOn      Timestamp `json:"created_on"`
+	ModifiedOn     Timestamp `json:"modified_on"`
 	Attributes     Resource  `json:"attributes"`
+
+	// VersionKnown is set when Data is decoded from JSON that has a version, so a version of 0 can be told apart
+	// from a response without one. It is never encoded.
+	VersionKnown bool `json:"-"`
+}
+
+// CurrentVersion returns the version of the account, and whether it is known, which it is not for Data decoded from a
+// response without one, so a delete or update does not go ahead at version 0 by mistake.
+func (d Data) CurrentVersion() (uint, bool) {
+	return uint(d.Version), d.VersionKnown
+}
+
+// Equivalent reports whether the Data and other are the same account with the same attributes, as Resource.Equal
+// compares them, leaving out the fields the service keeps: the version, and the creation and modification times.
+func (d Data) Equivalent(other Data) bool {
+	return d.ID == other.ID &&
+		d.OrganisationID == other.OrganisationID &&
+		d.Type == other.Type &&
+		d.Attributes.Equal(other.Attributes)
+}
+
+// isZero reports whether the Data has no fields set at all.
+func (d Data) isZero() bool {
+	return reflect.ValueOf(d).IsZero()
+}
+
+// dataJSON is Data without its methods, so UnmarshalJSON can decode into it without calling itself.
+type dataJSON Data
+
+// UnmarshalJSON decodes Data, and sets VersionKnown if the JSON has a version that is not null.
+func (d *Data) UnmarshalJSON(b []byte) error {
+	aux := struct {
+		*dataJSON
+		Version *int `json:"version"`
+	}{dataJSON: (*dataJSON)(d)}
+
+	if err := json.Unmarshal(b, &aux); err != nil {
+		return err
+	}
+
+	d.Version, d.VersionKnown = 0, aux.Version != nil
+	if aux.Version != nil {
+		d.Version = *aux.Version
+	}
+
+	return nil
 }
 
 // Links is used to encode the links section from the responses.
@@ -46,6 +188,31 @@ type Links struct {
 type Payload struct {
 	Data  Data  `json:"data"`
 	Links Links `json:"links,omitempty"`
+	Meta  *Meta `json:"meta,omitempty"`
+}
+
+// ID returns the ID of the account.
+func (p Payload) ID() string {
+	return p.Data.ID
+}
+
+// Account returns the attributes of the account.
+func (p Payload) Account() Resource {
+	return p.Data.Attributes
+}
+
+// createPayload is the body of a create request. Unlike Payload, its data only has the fields the client owns, so no
+// version, zero creation and modification times, or links are sent for the service to reject.
+type createPayload struct {
+	Data createData `json:"data"`
+}
+
+// createData is the data of a createPayload.
+type createData struct {
+	ID             string   `json:"id"`
+	OrganisationID string   `json:"organisation_id"`
+	Type           string   `json:"type"`
+	Attributes     Resource `json:"attributes"`
 }
 
 // MultiPayload struct is used to encode json requests and responses where they contain an array of data objects, such
@@ -53,4 +220,70 @@ type Payload struct {
 type MultiPayload struct {
 	Data  []Data `json:"data"`
 	Links Links  `json:"links"`
+	Meta  *Meta  `json:"meta,omitempty"`
+}
+
+// IDs returns the IDs of the accounts, in the order of the list. It is empty, and not nil, for an empty list.
+func (mp MultiPayload) IDs() []string {
+	ids := make([]string, len(mp.Data))
+	for i, d := range mp.Data {
+		ids[i] = d.ID
+	}
+
+	return ids
+}
+
+// Resources returns the attributes of the accounts, in the order of the list. It is empty, and not nil, for an empty
+// list.
+func (mp MultiPayload) Resources() []Resource {
+	resources := make([]Resource, len(mp.Data))
+	for i, d := range mp.Data {
+		resources[i] = d.Attributes
+	}
+
+	return resources
+}
+
+// Meta is used to encode the meta section of responses. Not every deployment of the service sends it, so it is nil
+// when the response has none. Only the total of a list is documented, so every other member of the section is kept in
+// Extra.
+type Meta struct {
+	Total int `json:"total"`
+
+	// Extra holds the members of the meta section Meta has no field for, by their JSON name, so they are encoded again
+	// along with the Payload or MultiPayload, like the attributes in the Extra of a Resource.
+	Extra map[string]json.RawMessage `json:"-"`
+}
+
+// metaJSON is Meta without its methods, so MarshalJSON can encode it without calling itself.
+type metaJSON Meta
+
+// MarshalJSON encodes the Meta along with the members of its Extra.
+func (m Meta) MarshalJSON() ([]byte, error) {
+	b, err := json.Marshal(metaJSON(m))
+	if err != nil {
+		return nil, err
+	}
+
+	if len(m.Extra) == 0 {
+		return b, nil
+	}
+
+	return appendExtra(b, m.Extra, metaKeys)
+}
+
+// UnmarshalJSON decodes the Meta, and keeps the members it has no field for in Extra.
+func (m *Meta) UnmarshalJSON(b []byte) error {
+	if err := json.Unmarshal(b, (*metaJSON)(m)); err != nil {
+		return err
+	}
+
+	extra, err := extraAttributes(b, metaKeys)
+	if err != nil {
+		return err
+	}
+
+	m.Extra = extra
+
+	return nil
 }
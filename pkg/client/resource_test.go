@@ -0,0 +1,398 @@
+package client_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestData_CurrentVersion(t *testing.T) {
+	tests := []struct {
+		name        string
+		json        string
+		wantVersion uint
+		wantKnown   bool
+	}{
+		{name: "a version", json: `{"id": "a", "version": 3}`, wantVersion: 3, wantKnown: true},
+		{name: "version 0", json: `{"id": "a", "version": 0}`, wantVersion: 0, wantKnown: true},
+		{name: "no version", json: `{"id": "a"}`},
+		{name: "a null version", json: `{"id": "a", "version": null}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var d client.Data
+
+			assert.NoError(t, json.Unmarshal([]byte(tt.json), &d))
+			assert.Equal(t, "a", d.ID)
+
+			version, known := d.CurrentVersion()
+			assert.Equal(t, tt.wantVersion, version)
+			assert.Equal(t, tt.wantKnown, known)
+
+			encoded, err := json.Marshal(d)
+			assert.NoError(t, err)
+			assert.NotContains(t, string(encoded), "known", "VersionKnown is never encoded")
+			assert.Contains(t, string(encoded), `"version":`)
+		})
+	}
+}
+
+func TestResource_MarshalJSON(t *testing.T) {
+	tests := []struct {
+		name     string
+		resource client.Resource
+		want     string
+	}{
+		{
+			name:     "leaves out lists without lines",
+			resource: client.Resource{Country: "GB"},
+			want:     `{"country":"GB","joint_account":false,"account_matching_opt_out":false,"switched":false,"status":""}`,
+		},
+		{
+			name: "trims empty lines after the last one that is set",
+			resource: client.Resource{
+				Country:          "GB",
+				Name:             [4]string{"line1", "", "line3"},
+				AlternativeNames: [3]string{"alt1"},
+			},
+			want: `{"country":"GB","joint_account":false,"account_matching_opt_out":false,"switched":false,` +
+				`"status":"","name":["line1","","line3"],"alternative_names":["alt1"]}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := json.Marshal(tt.resource)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, string(got))
+
+			var decoded client.Resource
+
+			assert.NoError(t, json.Unmarshal(got, &decoded))
+			assert.Equal(t, tt.resource, decoded, "decodes back the same")
+		})
+	}
+
+	var padded, trimmed client.Resource
+
+	assert.NoError(t, json.Unmarshal([]byte(`{"name": ["line1", "", "", ""]}`), &padded))
+	assert.NoError(t, json.Unmarshal([]byte(`{"name": ["line1"]}`), &trimmed))
+	assert.Equal(t, padded, trimmed, "both shapes decode the same")
+}
+
+func TestPayload_accessors(t *testing.T) {
+	gb := client.Resource{Country: "GB", BankID: "400300"}
+	p := client.Payload{Data: client.Data{ID: "a", Attributes: gb}}
+
+	assert.Equal(t, "a", p.ID())
+	assert.Equal(t, gb, p.Account())
+}
+
+func TestMultiPayload_accessors(t *testing.T) {
+	gb := client.Resource{Country: "GB", BankID: "400300"}
+	de := client.Resource{Country: "DE", BankID: "10020030"}
+
+	tests := []struct {
+		name          string
+		mp            client.MultiPayload
+		wantIDs       []string
+		wantResources []client.Resource
+	}{
+		{
+			name: "accounts in the order of the list",
+			mp: client.MultiPayload{Data: []client.Data{
+				{ID: "b", Attributes: de},
+				{ID: "a", Attributes: gb},
+			}},
+			wantIDs:       []string{"b", "a"},
+			wantResources: []client.Resource{de, gb},
+		},
+		{
+			name:          "an empty list",
+			mp:            client.MultiPayload{},
+			wantIDs:       []string{},
+			wantResources: []client.Resource{},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.wantIDs, tt.mp.IDs())
+			assert.Equal(t, tt.wantResources, tt.mp.Resources())
+		})
+	}
+}
+
+func TestResource_Equal(t *testing.T) {
+	sent := client.Resource{
+		Country:    "GB",
+		BankID:     "400300",
+		BankIDCode: "GBDSC",
+		BIC:        "NWBKGB22",
+		Name:       [4]string{"Samantha Holder"},
+	}
+
+	generated := sent
+	generated.AccountNumber = "41426819"
+	generated.IBAN = "GB11NWBK40030041426819"
+
+	tests := []struct {
+		name  string
+		a     client.Resource
+		other func(client.Resource) client.Resource
+		want  bool
+	}{
+		{
+			name:  "the same attributes",
+			a:     sent,
+			other: func(r client.Resource) client.Resource { return r },
+			want:  true,
+		},
+		{
+			name:  "an account number and IBAN the service generated",
+			a:     sent,
+			other: func(r client.Resource) client.Resource { return generated },
+			want:  true,
+		},
+		{
+			name:  "compared the other way around",
+			a:     generated,
+			other: func(r client.Resource) client.Resource { return sent },
+			want:  true,
+		},
+		{
+			name: "a different account number",
+			a:    generated,
+			other: func(r client.Resource) client.Resource {
+				r.AccountNumber = "41426820"
+
+				return r
+			},
+			want: false,
+		},
+		{
+			name: "a different name",
+			a:    sent,
+			other: func(r client.Resource) client.Resource {
+				r.Name[1] = "Trading as Sam"
+
+				return r
+			},
+			want: false,
+		},
+		{
+			name: "a different flag",
+			a:    sent,
+			other: func(r client.Resource) client.Resource {
+				r.Switched = true
+
+				return r
+			},
+			want: false,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.a.Equal(tt.other(tt.a)))
+		})
+	}
+}
+
+func TestData_Equivalent(t *testing.T) {
+	created := client.Data{
+		ID:             "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc",
+		OrganisationID: "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c",
+		Type:           "accounts",
+		Attributes:     client.Resource{Country: "GB", BankID: "400300", BankIDCode: "GBDSC"},
+	}
+
+	fetched := created
+	fetched.Version, fetched.VersionKnown = 2, true
+	fetched.CreatedOn = client.Timestamp{Time: time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)}
+	fetched.ModifiedOn = client.Timestamp{Time: time.Date(2021, time.January, 2, 12, 0, 0, 0, time.UTC)}
+	fetched.Attributes.AccountNumber = "41426819"
+
+	otherOrg := fetched
+	otherOrg.OrganisationID = "3a6b0e21-0a9f-4c3e-9f0b-5c1d2e3f4a5b"
+
+	drifted := fetched
+	drifted.Attributes.BankID = "400301"
+
+	assert.True(t, created.Equivalent(fetched), "version, times, and generated fields are left out")
+	assert.True(t, fetched.Equivalent(created))
+	assert.False(t, created.Equivalent(otherOrg), "the organisation counts")
+	assert.False(t, created.Equivalent(drifted), "the attributes count")
+}
+
+func TestResource_Extra(t *testing.T) {
+	tests := []struct {
+		name      string
+		json      string
+		want      client.Resource
+		wantJSON  string
+		wantExtra map[string]string
+	}{
+		{
+			name: "no unknown attributes",
+			json: `{"country": "GB", "name": ["Sam"], "switched": false, "status": "confirmed"}`,
+			want: client.Resource{Country: "GB", Name: [4]string{"Sam"}, Status: "confirmed"},
+			wantJSON: `{"country":"GB","joint_account":false,"account_matching_opt_out":false,"switched":false,` +
+				`"status":"confirmed","name":["Sam"]}`,
+		},
+		{
+			name: "unknown attributes of every kind",
+			json: `{
+				"country": "GB",
+				"status_reason": "unspecified",
+				"user_defined_data": [{"key": "a}\"]", "value": "{"}],
+				"processing_service": null,
+				"acceptance_qualifier": 3.5e2,
+				"name": ["Sam"],
+				"validation_type": {"card": true}
+			}`,
+			want: client.Resource{Country: "GB", Name: [4]string{"Sam"}},
+			wantJSON: `{"country":"GB","joint_account":false,"account_matching_opt_out":false,"switched":false,"status":"",` +
+				`"name":["Sam"],"acceptance_qualifier":3.5e2,"processing_service":null,"status_reason":"unspecified",` +
+				`"user_defined_data":[{"key":"a}\"]","value":"{"}],"validation_type":{"card":true}}`,
+			wantExtra: map[string]string{
+				"status_reason":        `"unspecified"`,
+				"user_defined_data":    `[{"key": "a}\"]", "value": "{"}]`,
+				"processing_service":   `null`,
+				"acceptance_qualifier": `3.5e2`,
+				"validation_type":      `{"card": true}`,
+			},
+		},
+		{
+			name: "keys of known attributes in another case are not extra",
+			json: `{"Country": "GB", "BIC": "NWBKGB22", "bank_id": "400300"}`,
+			want: client.Resource{Country: "GB", BIC: "NWBKGB22", BankID: "400300"},
+			wantJSON: `{"country":"GB","bank_id":"400300","bic":"NWBKGB22","joint_account":false,` +
+				`"account_matching_opt_out":false,"switched":false,"status":""}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got client.Resource
+
+			assert.NoError(t, json.Unmarshal([]byte(tt.json), &got))
+
+			extra := got.Extra
+			got.Extra = nil
+			assert.Equal(t, tt.want, got)
+
+			gotExtra := make(map[string]string)
+			for k, v := range extra {
+				gotExtra[k] = string(v)
+			}
+
+			if tt.wantExtra == nil {
+				assert.Nil(t, extra)
+			} else {
+				assert.Equal(t, tt.wantExtra, gotExtra)
+			}
+
+			got.Extra = extra
+			encoded, err := json.Marshal(got)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantJSON, string(encoded))
+		})
+	}
+}
+
+func TestResource_Extra_fieldsWin(t *testing.T) {
+	r := client.Resource{
+		Country: "GB",
+		Extra:   map[string]json.RawMessage{"country": []byte(`"DE"`), "Bic": []byte(`"X"`), "new": []byte(`1`)},
+	}
+
+	encoded, err := json.Marshal(r)
+	assert.NoError(t, err)
+	assert.Equal(t,
+		`{"country":"GB","joint_account":false,"account_matching_opt_out":false,"switched":false,"status":"","new":1}`,
+		string(encoded))
+
+	assert.True(t, r.Equal(client.Resource{
+		Country: "GB",
+		Extra:   map[string]json.RawMessage{"country": []byte(`"DE"`), "Bic": []byte(`"X"`), "new": []byte(` 1 `)},
+	}), "extra values are compared without whitespace")
+	assert.False(t, r.Equal(client.Resource{Country: "GB"}), "extra attributes count")
+}
+
+func TestMeta(t *testing.T) {
+	data := `{"id": "accountid", "attributes": {"country": "GB"}}`
+
+	tests := []struct {
+		name         string
+		json         string
+		multi        bool
+		wantMeta     *client.Meta
+		wantMetaJSON string
+	}{
+		{
+			name: "a Payload without a meta section",
+			json: `{"data": ` + data + `}`,
+		},
+		{
+			name: "a Payload with a meta section",
+			json: `{"data": ` + data + `, "meta": {"request_id": "r-1", "deprecation": {"sunset": "2030-01-01"}}}`,
+			wantMeta: &client.Meta{Extra: map[string]json.RawMessage{
+				"request_id":  json.RawMessage(`"r-1"`),
+				"deprecation": json.RawMessage(`{"sunset": "2030-01-01"}`),
+			}},
+			wantMetaJSON: `{"total":0,"deprecation":{"sunset":"2030-01-01"},"request_id":"r-1"}`,
+		},
+		{
+			name:         "a MultiPayload with the total only",
+			json:         `{"data": [` + data + `], "meta": {"total": 42}}`,
+			multi:        true,
+			wantMeta:     &client.Meta{Total: 42},
+			wantMetaJSON: `{"total":42}`,
+		},
+		{
+			name:         "a MultiPayload with more than the total",
+			json:         `{"data": [` + data + `], "meta": {"total": 42, "pages": 5}}`,
+			multi:        true,
+			wantMeta:     &client.Meta{Total: 42, Extra: map[string]json.RawMessage{"pages": json.RawMessage(`5`)}},
+			wantMetaJSON: `{"total":42,"pages":5}`,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				p  client.Payload
+				mp client.MultiPayload
+				v  interface{} = &p
+			)
+
+			if tt.multi {
+				v = &mp
+			}
+
+			assert.NoError(t, json.Unmarshal([]byte(tt.json), v))
+
+			meta := p.Meta
+			if tt.multi {
+				meta = mp.Meta
+			}
+
+			assert.Equal(t, tt.wantMeta, meta)
+
+			encoded, err := json.Marshal(v)
+			assert.NoError(t, err)
+
+			var sections map[string]json.RawMessage
+
+			assert.NoError(t, json.Unmarshal(encoded, &sections))
+
+			if tt.wantMeta == nil {
+				assert.NotContains(t, sections, "meta")
+
+				return
+			}
+
+			assert.Equal(t, tt.wantMetaJSON, string(sections["meta"]))
+		})
+	}
+}
@@ -0,0 +1,106 @@
+package client
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// retryable reports whether a request that ended with resp or err is worth sending again: the connection failed, the
+// service is rate limiting, or it had a temporary problem. Every request of the Client is safe to repeat, as Create
+// sends the ID of the account and Update and Delete its version, so a repeat of a request that did go through ends in
+// a conflict instead of a second change. sendCreate tells a conflict of its own first attempt apart from one with an
+// account that was there already. A request that was redirected too many times would be again, so it isn't.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return !errors.Is(err, ErrTooManyRedirects)
+	}
+
+	switch resp.StatusCode {
+	case http.StatusTooManyRequests,
+		http.StatusInternalServerError,
+		http.StatusBadGateway,
+		http.StatusServiceUnavailable,
+		http.StatusGatewayTimeout:
+		return true
+	}
+
+	return false
+}
+
+// retryWait returns how long to wait before the attempt after the given one, counted from 0. The wait doubles with
+// every attempt, unless the response says how long to wait in a Retry-After header, in seconds or as a date.
+func (c Client) retryWait(attempt int, resp *http.Response) time.Duration {
+	if resp != nil {
+		if wait, ok := c.retryAfter(resp.Header); ok {
+			return wait
+		}
+	}
+
+	return c.retryBackoff << uint(attempt)
+}
+
+// retryAfter parses the Retry-After header. A date is counted from the Date header of the response rather than from
+// the clock of the Client, so a service whose clock is off does not make the Client wait too long, or not at all. Dates
+// in the past mean no wait.
+func (c Client) retryAfter(h http.Header) (time.Duration, bool) {
+	value := h.Get("Retry-After")
+
+	seconds, err := strconv.Atoi(value)
+	if err == nil {
+		return time.Duration(seconds) * time.Second, seconds >= 0
+	}
+
+	at, err := http.ParseTime(value)
+	if err != nil {
+		return 0, false
+	}
+
+	now, err := http.ParseTime(h.Get("Date"))
+	if err != nil {
+		now = c.now()
+	}
+
+	if at.Before(now) {
+		return 0, true
+	}
+
+	return at.Sub(now), true
+}
+
+// onRetry calls the hook set with WithOnRetry, if any, with the failed attempt counted from 1 and the reason it failed:
+// the transport error, or an APIError for the status of the response.
+func (c Client) onRetry(attempt int, resp *http.Response, err error, wait time.Duration) {
+	if c.retryHook == nil {
+		return
+	}
+
+	c.retryHook(attempt+1, c.attemptError(resp, err), wait)
+}
+
+// attemptError returns why an attempt that is not the last one failed: the transport error, or an APIError for the
+// status of the response, which reads the start of its body.
+func (c Client) attemptError(resp *http.Response, err error) error {
+	if err != nil {
+		return err
+	}
+
+	return c.newAPIError(resp)
+}
+
+// noticeRetries returns a copy of the Client that sets retried when it retries a request, and still calls the hook set
+// with WithOnRetry, if any.
+func (c Client) noticeRetries(retried *bool) Client {
+	hook := c.retryHook
+
+	c.retryHook = func(attempt int, err error, wait time.Duration) {
+		*retried = true
+
+		if hook != nil {
+			hook(attempt, err, wait)
+		}
+	}
+
+	return c
+}
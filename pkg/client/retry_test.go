@@ -0,0 +1,498 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/clienttest"
+)
+
+func TestWithRetries(t *testing.T) {
+	tests := []struct {
+		name         string
+		retries      int
+		failures     int32
+		status       int
+		retryAfter   string
+		wantAttempts int32
+		wantErr      error
+	}{
+		{
+			name:         "retries temporary failures until one succeeds",
+			retries:      3,
+			failures:     2,
+			status:       http.StatusServiceUnavailable,
+			wantAttempts: 3,
+		},
+		{
+			name:         "retries rate limiting, honouring Retry-After",
+			retries:      1,
+			failures:     1,
+			status:       http.StatusTooManyRequests,
+			retryAfter:   "0",
+			wantAttempts: 2,
+		},
+		{
+			name:         "returns the last failure once the retries run out",
+			retries:      2,
+			failures:     5,
+			status:       http.StatusTooManyRequests,
+			wantAttempts: 3,
+			wantErr:      client.ErrRateLimited,
+		},
+		{
+			name:         "does not retry failures that would happen again",
+			retries:      3,
+			failures:     1,
+			status:       http.StatusNotFound,
+			wantAttempts: 1,
+			wantErr:      client.ErrNotFound,
+		},
+		{
+			name:         "does not retry without the option",
+			failures:     1,
+			status:       http.StatusBadGateway,
+			wantAttempts: 1,
+			wantErr:      &client.APIError{StatusCode: http.StatusBadGateway, RequestID: "request-id"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= tt.failures {
+					if tt.retryAfter != "" {
+						w.Header().Set("Retry-After", tt.retryAfter)
+					}
+
+					w.WriteHeader(tt.status)
+
+					return
+				}
+
+				w.WriteHeader(http.StatusOK)
+				_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+			}))
+			defer ts.Close()
+
+			c := client.Client{
+				BaseURL:        ts.URL,
+				OrganisationID: "orgid",
+				HttpClient: http.Client{
+					Timeout: testTimeoutMs * time.Millisecond,
+				},
+			}
+			client.WithRetries(tt.retries, time.Millisecond)(&c)
+
+			_, err := c.WithRequestID("request-id").Fetch("accountid")
+
+			assert.Equal(t, tt.wantAttempts, atomic.LoadInt32(&attempts))
+
+			var apiErr *client.APIError
+
+			switch {
+			case tt.wantErr == nil:
+				assert.NoError(t, err)
+			case errors.As(tt.wantErr, &apiErr):
+				assert.True(t, errors.As(err, &apiErr), "got %v", err)
+				assert.Equal(t, tt.wantErr, apiErr)
+			default:
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			}
+		})
+	}
+}
+
+func TestWithRetries_Transport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithRetries(2, time.Millisecond)(&c)
+
+	start := time.Now()
+	err := c.Delete("accountid", 0)
+
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "client.do httpClient.Do")
+	assert.GreaterOrEqual(t, int64(time.Since(start)), int64(3*time.Millisecond))
+}
+
+func TestClient_WithRequestID(t *testing.T) {
+	var ids []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ids = append(ids, r.Header.Get("X-Request-Id"))
+
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithRetries(1, time.Millisecond)(&c)
+
+	var first, second, chosen *client.APIError
+
+	assert.True(t, errors.As(c.Delete("accountid", 0), &first))
+	assert.True(t, errors.As(c.Delete("accountid", 0), &second))
+	assert.True(t, errors.As(c.WithRequestID("chosen").Delete("accountid", 0), &chosen))
+
+	if !assert.Len(t, ids, 6) {
+		return
+	}
+
+	assert.Len(t, ids[0], 36)
+	assert.Equal(t, ids[0], ids[1], "retries send the same ID")
+	assert.NotEqual(t, ids[0], ids[2], "every call gets a new ID")
+	assert.Equal(t, []string{"chosen", "chosen"}, ids[4:])
+
+	assert.Equal(t, ids[0], first.RequestID)
+	assert.Equal(t, ids[2], second.RequestID)
+	assert.Equal(t, "chosen", chosen.RequestID)
+}
+
+func TestContextWithRequestID(t *testing.T) {
+	tests := []struct {
+		name          string
+		clientID      string
+		contextID     string
+		wantID        string
+		wantGenerated bool
+	}{
+		{
+			name:      "the ID of the context wins over the one of the Client",
+			clientID:  "of the client",
+			contextID: "of the context",
+			wantID:    "of the context",
+		},
+		{
+			name:     "the ID of the Client without one in the context",
+			clientID: "of the client",
+			wantID:   "of the client",
+		},
+		{
+			name:          "a generated ID is reported when the call succeeds",
+			wantGenerated: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				mu  sync.Mutex
+				ids []string
+			)
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				ids = append(ids, r.Header.Get("X-Request-Id"))
+				first := len(ids) == 1
+				mu.Unlock()
+
+				if first {
+					w.WriteHeader(http.StatusServiceUnavailable)
+
+					return
+				}
+
+				_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithRetries(1, time.Millisecond)(&c)
+
+			if tt.clientID != "" {
+				c = c.WithRequestID(tt.clientID)
+			}
+
+			var meta client.ResponseMeta
+
+			ctx := client.ContextWithResponseMeta(context.Background(), &meta)
+			if tt.contextID != "" {
+				ctx = client.ContextWithRequestID(ctx, tt.contextID)
+			}
+
+			_, err := c.WithContext(ctx).Fetch("accountid")
+			assert.NoError(t, err)
+
+			if !assert.Len(t, ids, 2) {
+				return
+			}
+
+			assert.Equal(t, ids[0], ids[1], "retries send the same ID")
+
+			if tt.wantGenerated {
+				assert.Len(t, ids[0], 36)
+			} else {
+				assert.Equal(t, tt.wantID, ids[0])
+			}
+
+			assert.Equal(t, client.ResponseMeta{RequestID: ids[0], StatusCode: http.StatusOK, Attempts: 2}, meta)
+		})
+	}
+}
+
+func TestWithOnRetry(t *testing.T) {
+	type call struct {
+		attempt int
+		err     error
+		wait    time.Duration
+	}
+
+	statuses := []int{http.StatusTooManyRequests, http.StatusServiceUnavailable, http.StatusOK}
+
+	var attempts int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		status := statuses[atomic.AddInt32(&attempts, 1)-1]
+		if status == http.StatusTooManyRequests {
+			w.Header().Set("Retry-After", "0")
+		}
+
+		w.WriteHeader(status)
+
+		if status == http.StatusOK {
+			_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+		}
+	}))
+	defer ts.Close()
+
+	var calls []call
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithRetries(3, time.Millisecond)(&c)
+	client.WithOnRetry(func(attempt int, err error, wait time.Duration) {
+		calls = append(calls, call{attempt: attempt, err: err, wait: wait})
+	})(&c)
+
+	_, err := c.WithRequestID("id").Fetch("accountid")
+	assert.NoError(t, err)
+
+	assert.Equal(t, []call{
+		{attempt: 1, err: &client.APIError{StatusCode: http.StatusTooManyRequests, RequestID: "id"}, wait: 0},
+		{
+			attempt: 2,
+			err:     &client.APIError{StatusCode: http.StatusServiceUnavailable, RequestID: "id"},
+			wait:    2 * time.Millisecond,
+		},
+	}, calls)
+}
+
+func TestWithRetries_deadline(t *testing.T) {
+	tests := []struct {
+		name         string
+		backoff      time.Duration
+		retryAfter   string
+		deadline     time.Duration
+		clock        time.Time
+		wantAttempts int32
+		wantWait     time.Duration
+	}{
+		{
+			name:         "skips a wait that would run past the deadline",
+			backoff:      100 * time.Millisecond,
+			deadline:     50 * time.Millisecond,
+			wantAttempts: 1,
+			wantWait:     100 * time.Millisecond,
+		},
+		{
+			name:         "skips a Retry-After that would run past the deadline",
+			backoff:      time.Millisecond,
+			retryAfter:   "120",
+			deadline:     time.Minute,
+			wantAttempts: 1,
+			wantWait:     2 * time.Minute,
+		},
+		{
+			name:         "takes the time left from the system clock, not from a clock of the Client that is off",
+			backoff:      100 * time.Millisecond,
+			deadline:     50 * time.Millisecond,
+			clock:        clienttest.DeterministicTime,
+			wantAttempts: 1,
+			wantWait:     100 * time.Millisecond,
+		},
+		{
+			name:         "retries while the waits fit",
+			backoff:      time.Millisecond,
+			deadline:     time.Hour,
+			wantAttempts: 6,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				atomic.AddInt32(&attempts, 1)
+
+				if tt.retryAfter != "" {
+					w.Header().Set("Retry-After", tt.retryAfter)
+				}
+
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithRetries(5, tt.backoff)(&c)
+
+			if !tt.clock.IsZero() {
+				client.WithClock(func() time.Time { return tt.clock })(&c)
+			}
+
+			ctx, cancel := context.WithTimeout(context.Background(), tt.deadline)
+			defer cancel()
+
+			_, err := c.WithContext(ctx).Fetch("accountid")
+
+			assert.Equal(t, tt.wantAttempts, atomic.LoadInt32(&attempts))
+
+			var (
+				deadlineErr *client.RetryDeadlineError
+				apiErr      *client.APIError
+			)
+
+			assert.True(t, errors.As(err, &apiErr), "the error of the last attempt is kept, got %v", err)
+			assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+
+			if tt.wantWait == 0 {
+				assert.False(t, errors.As(err, &deadlineErr), "got %v", err)
+
+				return
+			}
+
+			assert.True(t, errors.Is(err, context.DeadlineExceeded))
+			assert.True(t, errors.As(err, &deadlineErr))
+			assert.Equal(t, int(tt.wantAttempts), deadlineErr.Attempts)
+			assert.Equal(t, tt.wantWait, deadlineErr.Wait)
+			assert.Contains(t, err.Error(), "context deadline exceeded: gave up after")
+		})
+	}
+}
+
+func TestWithRetries_create(t *testing.T) {
+	const accountID = "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc"
+
+	tests := []struct {
+		name      string
+		existing  *client.Resource
+		firstFail func(w http.ResponseWriter) bool
+		wantPosts int32
+		wantErr   error
+	}{
+		{
+			name: "the first attempt creates the account, then the connection drops",
+			firstFail: func(w http.ResponseWriter) bool {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					_ = conn.Close()
+				}
+
+				return true
+			},
+			wantPosts: 2,
+		},
+		{
+			name:     "another account with the ID was there already",
+			existing: &client.Resource{Country: "GB", BankID: "654321", BankIDCode: "GBDSC", BIC: "NWBKGB22XXX"},
+			firstFail: func(w http.ResponseWriter) bool {
+				w.WriteHeader(http.StatusServiceUnavailable)
+
+				return false
+			},
+			wantPosts: 2,
+			wantErr:   client.ErrConflict,
+		},
+		{
+			name: "a conflict without a retry is not looked into",
+			existing: func() *client.Resource {
+				r := validBatchResource()
+
+				return &r
+			}(),
+			wantPosts: 1,
+			wantErr:   client.ErrConflict,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var (
+				posts  int32
+				mu     sync.Mutex
+				stored *client.Data
+			)
+
+			if tt.existing != nil {
+				stored = &client.Data{ID: accountID, OrganisationID: "orgid", Type: "accounts", Attributes: *tt.existing}
+			}
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				mu.Lock()
+				defer mu.Unlock()
+
+				if r.Method == http.MethodGet {
+					if stored == nil {
+						w.WriteHeader(http.StatusNotFound)
+
+						return
+					}
+
+					_ = json.NewEncoder(w).Encode(client.Payload{Data: *stored})
+
+					return
+				}
+
+				if atomic.AddInt32(&posts, 1) == 1 && tt.firstFail != nil {
+					var p client.Payload
+
+					_ = json.NewDecoder(r.Body).Decode(&p)
+
+					if tt.firstFail(w) && stored == nil {
+						stored = &p.Data
+					}
+
+					return
+				}
+
+				if stored != nil {
+					w.WriteHeader(http.StatusConflict)
+
+					return
+				}
+
+				w.WriteHeader(http.StatusCreated)
+				_, _ = io.Copy(w, r.Body)
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL, OrganisationID: "orgid"}
+			client.WithRetries(1, time.Millisecond)(&c)
+
+			p, err := c.CreateWithID(accountID, validBatchResource())
+
+			assert.Equal(t, tt.wantPosts, atomic.LoadInt32(&posts))
+
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, accountID, p.Data.ID)
+			assert.True(t, validBatchResource().Equal(p.Data.Attributes))
+		})
+	}
+}
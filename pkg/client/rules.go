@@ -0,0 +1,170 @@
+package client
+
+import (
+	"math/rand"
+	"sort"
+	"strings"
+)
+
+// presence says whether an attribute of an account has to be there, may be there, or must not be there.
+type presence int
+
+const (
+	optional presence = iota
+	required
+	forbidden
+)
+
+// digits is the format of a numeric attribute: between min and max digits, starting with prefix. With nonZeroFirst the
+// first digit after the prefix cannot be 0.
+type digits struct {
+	min          int
+	max          int
+	prefix       string
+	nonZeroFirst bool
+}
+
+// exactly returns the format of a number of exactly n digits.
+func exactly(n int) digits {
+	return digits{min: n, max: n}
+}
+
+// match reports whether s is in the format.
+func (d digits) match(s string) bool {
+	if !isDigits(s, d.min, d.max) || !strings.HasPrefix(s, d.prefix) {
+		return false
+	}
+
+	return !d.nonZeroFirst || s[len(d.prefix)] != '0'
+}
+
+// isDigits reports whether s is between min and max ASCII digits long, and nothing else. It checks bytes rather than
+// runes, as any byte of a multi-byte rune is outside '0' to '9' anyway.
+func isDigits(s string, min, max int) bool {
+	if len(s) < min || len(s) > max {
+		return false
+	}
+
+	for i := 0; i < len(s); i++ {
+		if s[i] < '0' || s[i] > '9' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// generate returns a random number in the format.
+func (d digits) generate(rnd *rand.Rand) string {
+	n := d.min + rnd.Intn(d.max-d.min+1)
+	b := []byte(d.prefix)
+
+	for i := len(b); i < n; i++ {
+		b = append(b, byte('0'+rnd.Intn(10))) //nolint:gomnd
+	}
+
+	if d.nonZeroFirst && b[len(d.prefix)] == '0' {
+		b[len(d.prefix)] = byte('1' + rnd.Intn(9)) //nolint:gomnd
+	}
+
+	return string(b)
+}
+
+// countryRules is what the service expects of the attributes of an account in one country, as documented for the
+// Create endpoint.
+type countryRules struct {
+	currency       string
+	bic            presence
+	iban           presence
+	bankID         presence
+	bankIDFormat   digits
+	bankIDCode     presence
+	bankIDCodeWant string
+	accountNumber  digits
+
+	// bankIDWithAccountNumber, if set, replaces bankIDFormat when the account number is provided. Only Italy needs it.
+	bankIDWithAccountNumber *digits
+}
+
+// rulesRegistry holds the rules of every country the service supports, keyed by ISO 3166-1 code. Both validation and
+// fixture generation are driven by it, so they cannot disagree.
+//
+//nolint:gochecknoglobals,gomnd // read only lookup table
+var rulesRegistry = map[string]countryRules{
+	"GB": {
+		currency: "GBP", bic: required, bankID: required, bankIDFormat: exactly(6),
+		bankIDCode: required, bankIDCodeWant: GBBankID, accountNumber: exactly(8),
+	},
+	"AU": {
+		currency: "AUD", bic: required, iban: forbidden, bankIDFormat: exactly(6),
+		bankIDCode: required, bankIDCodeWant: AUBankID, accountNumber: digits{min: 6, max: 10, nonZeroFirst: true},
+	},
+	"BE": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(3),
+		bankIDCode: required, bankIDCodeWant: BEBankID, accountNumber: exactly(7),
+	},
+	"CA": {
+		currency: "CAD", bic: required, iban: forbidden, bankIDFormat: digits{min: 9, max: 9, prefix: "0"},
+		bankIDCodeWant: CABankID, accountNumber: digits{min: 7, max: 12},
+	},
+	"FR": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(10),
+		bankIDCode: required, bankIDCodeWant: FRBankID, accountNumber: exactly(10),
+	},
+	"DE": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(8),
+		bankIDCode: required, bankIDCodeWant: DEBankID, accountNumber: exactly(7),
+	},
+	"GR": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(7),
+		bankIDCode: required, bankIDCodeWant: GRBankID, accountNumber: exactly(16),
+	},
+	"HK": {
+		currency: "HKD", bic: required, iban: forbidden, bankIDFormat: exactly(3),
+		bankIDCodeWant: HKBankID, accountNumber: digits{min: 9, max: 12},
+	},
+	"IT": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(10), bankIDWithAccountNumber: &digits{min: 11, max: 11},
+		bankIDCode: required, bankIDCodeWant: ITBankID, accountNumber: exactly(12),
+	},
+	"LU": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(3),
+		bankIDCode: required, bankIDCodeWant: LUBankID, accountNumber: exactly(13),
+	},
+	"NL": {
+		currency: "EUR", bic: required, bankID: forbidden, bankIDCode: forbidden, accountNumber: exactly(10),
+	},
+	"PL": {
+		currency: "PLN", bankID: required, bankIDFormat: exactly(8),
+		bankIDCode: required, bankIDCodeWant: PLBankID, accountNumber: exactly(16),
+	},
+	"PT": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(8),
+		bankIDCode: required, bankIDCodeWant: PTBankID, accountNumber: exactly(11),
+	},
+	"ES": {
+		currency: "EUR", bankID: required, bankIDFormat: exactly(8),
+		bankIDCode: required, bankIDCodeWant: ESBankID, accountNumber: exactly(10),
+	},
+	"CH": {
+		currency: "CHF", bankID: required, bankIDFormat: exactly(5),
+		bankIDCode: required, bankIDCodeWant: CHBankID, accountNumber: exactly(12),
+	},
+	"US": {
+		currency: "USD", bic: required, iban: forbidden, bankID: required, bankIDFormat: exactly(9),
+		bankIDCode: required, bankIDCodeWant: USBankID, accountNumber: digits{min: 6, max: 17},
+	},
+}
+
+// SupportedCountries returns the ISO 3166-1 codes of every country the service supports, in alphabetical order.
+func SupportedCountries() []string {
+	countries := make([]string, 0, len(rulesRegistry))
+
+	for country := range rulesRegistry {
+		countries = append(countries, country)
+	}
+
+	sort.Strings(countries)
+
+	return countries
+}
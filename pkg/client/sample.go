@@ -0,0 +1,19 @@
+package client
+
+import "sync/atomic"
+
+// sampler picks one in every n calls to log in full. It is shared by every copy of the Client it was configured on,
+// and by every goroutine of a batch. A nil sampler picks every call.
+type sampler struct {
+	every uint64
+	calls uint64
+}
+
+// sample reports whether the next call is logged in full. The first call always is.
+func (s *sampler) sample() bool {
+	if s == nil {
+		return true
+	}
+
+	return (atomic.AddUint64(&s.calls, 1)-1)%s.every == 0
+}
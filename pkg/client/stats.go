@@ -0,0 +1,85 @@
+package client
+
+import (
+	"net/http"
+	"time"
+)
+
+// StatsHandler receives an Event for everything the Client does that telemetry may want to count or time, so it can be
+// forwarded to StatsD, Datadog, or anything else without the Client depending on them. HandleEvent is called on the
+// goroutine that sends the request, so it should return quickly, and be safe to call from several goroutines at once
+// when the Client is used concurrently. The type of the Event tells what happened.
+type StatsHandler interface {
+	HandleEvent(e Event)
+}
+
+// StatsHandlerFunc adapts a function to StatsHandler.
+type StatsHandlerFunc func(e Event)
+
+// HandleEvent calls f.
+func (f StatsHandlerFunc) HandleEvent(e Event) {
+	f(e)
+}
+
+// Event is one of RequestStarted, RequestFinished, or RetryScheduled.
+type Event interface {
+	event()
+}
+
+// RequestStarted is sent right before a request goes out. Attempt counts from 1, and is higher for retries.
+type RequestStarted struct {
+	Method    string
+	Path      string
+	RequestID string
+	Attempt   int
+}
+
+// RequestFinished is sent when a request got a response, or failed without one, in which case Err is set and
+// StatusCode is 0. Latency is the time until the headers of the response arrived. Phases is only set with
+// WithPhaseTimings, which sends the event of a response that succeeded once its body is read.
+type RequestFinished struct {
+	Method     string
+	Path       string
+	RequestID  string
+	Attempt    int
+	StatusCode int
+	Latency    time.Duration
+	Phases     *Phases
+	Err        error
+}
+
+// RetryScheduled is sent when a request failed in a way that is worth retrying, before waiting Wait to send it again
+// as Attempt. Either StatusCode or Err is set, depending on whether the failed request got a response.
+type RetryScheduled struct {
+	Method     string
+	Path       string
+	RequestID  string
+	Attempt    int
+	Wait       time.Duration
+	StatusCode int
+	Err        error
+}
+
+func (RequestStarted) event()  {}
+func (RequestFinished) event() {}
+func (RetryScheduled) event()  {}
+
+// notify passes the event to the StatsHandler and the collector of Stats, if there are any.
+func (c Client) notify(e Event) {
+	if c.stats != nil {
+		c.stats.HandleEvent(e)
+	}
+
+	if c.collector != nil {
+		c.collector.HandleEvent(e)
+	}
+}
+
+// statusCode returns the status code of the response, or 0 if there is none.
+func statusCode(resp *http.Response) int {
+	if resp == nil {
+		return 0
+	}
+
+	return resp.StatusCode
+}
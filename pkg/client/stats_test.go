@@ -0,0 +1,228 @@
+package client_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestWithStatsHandler(t *testing.T) {
+	var attempts int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&attempts, 1) == 1 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+
+		w.WriteHeader(http.StatusOK)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	var events []client.Event
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithRetries(1, time.Millisecond)(&c)
+	client.WithStatsHandler(client.StatsHandlerFunc(func(e client.Event) {
+		switch ev := e.(type) {
+		case client.RequestFinished:
+			assert.Greater(t, int64(ev.Latency), int64(0))
+
+			ev.Latency = 0
+			e = ev
+		case client.RetryScheduled:
+			assert.Equal(t, time.Millisecond, ev.Wait)
+		}
+
+		events = append(events, e)
+	}))(&c)
+
+	_, err := c.WithRequestID("id").Fetch("accountid")
+	assert.NoError(t, err)
+
+	path := "/v1/organisation/accounts/accountid"
+
+	assert.Equal(t, []client.Event{
+		client.RequestStarted{Method: http.MethodGet, Path: path, RequestID: "id", Attempt: 1},
+		client.RequestFinished{
+			Method: http.MethodGet, Path: path, RequestID: "id", Attempt: 1, StatusCode: http.StatusServiceUnavailable,
+		},
+		client.RetryScheduled{
+			Method: http.MethodGet, Path: path, RequestID: "id", Attempt: 2, Wait: time.Millisecond,
+			StatusCode: http.StatusServiceUnavailable,
+		},
+		client.RequestStarted{Method: http.MethodGet, Path: path, RequestID: "id", Attempt: 2},
+		client.RequestFinished{Method: http.MethodGet, Path: path, RequestID: "id", Attempt: 2, StatusCode: http.StatusOK},
+	}, events)
+}
+
+func TestClient_Stats(t *testing.T) {
+	var fetches int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusNotFound)
+		case r.URL.Path == "/v1/organisation/accounts":
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/multipayload.json")))
+		case atomic.AddInt32(&fetches, 1) == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+		default:
+			w.WriteHeader(http.StatusOK)
+			_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+		}
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	assert.Empty(t, c.Stats().Operations, "no stats without the option")
+
+	start := time.Now()
+
+	client.WithRetries(1, time.Millisecond)(&c)
+	client.WithStats()(&c)
+
+	_, err := c.Fetch("accountid")
+	assert.NoError(t, err)
+
+	_, err = c.List(0, 10)
+	assert.NoError(t, err)
+
+	assert.Error(t, c.Delete("accountid", 0))
+	assert.Error(t, c.Delete("accountid", 0))
+
+	stats := c.Stats()
+
+	assert.False(t, stats.Since.Before(start))
+
+	for name, op := range stats.Operations {
+		assert.Greater(t, int64(op.P50), int64(0), name)
+		assert.LessOrEqual(t, int64(op.P50), int64(op.P90), name)
+		assert.LessOrEqual(t, int64(op.P90), int64(op.P99), name)
+		assert.LessOrEqual(t, int64(op.P99), int64(op.Max), name)
+
+		op.P50, op.P90, op.P99, op.Max = 0, 0, 0, 0
+		stats.Operations[name] = op
+	}
+
+	assert.Equal(t, map[string]client.OperationStats{
+		"fetch":  {Requests: 2, Errors: 1, Retries: 1, ErrorRate: 0.5},
+		"list":   {Requests: 1},
+		"delete": {Requests: 2, Errors: 2, ErrorRate: 1},
+	}, stats.Operations)
+
+	c.ResetStats()
+
+	assert.Empty(t, c.Stats().Operations)
+	assert.True(t, c.Stats().Since.After(stats.Since))
+}
+
+func TestWithPhaseTimings(t *testing.T) {
+	const bodyDelay = 20 * time.Millisecond
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+		w.(http.Flusher).Flush()
+		time.Sleep(bodyDelay)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	defer ts.Close()
+
+	var phases []*client.Phases
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithPhaseTimings()(&c)
+	client.WithStatsHandler(client.StatsHandlerFunc(func(e client.Event) {
+		if ev, ok := e.(client.RequestFinished); ok {
+			phases = append(phases, ev.Phases)
+		}
+	}))(&c)
+
+	for i := 0; i < 2; i++ {
+		got, err := c.Fetch("accountid")
+		assert.NoError(t, err)
+		assert.Equal(t, "a6c1a721-bb1b-41ef-bd11-800a1309ff9b", got.Data.ID, "the body is still readable")
+	}
+
+	if !assert.Len(t, phases, 2) || !assert.NotNil(t, phases[0]) || !assert.NotNil(t, phases[1]) {
+		return
+	}
+
+	assert.False(t, phases[0].ReusedConnection)
+	assert.Greater(t, int64(phases[0].Connect), int64(0))
+	assert.Greater(t, int64(phases[0].TTFB), int64(0))
+	assert.GreaterOrEqual(t, int64(phases[0].BodyRead), int64(bodyDelay/2))
+
+	assert.True(t, phases[1].ReusedConnection)
+	assert.Zero(t, phases[1].Connect)
+}
+
+func TestWithPhaseTimings_streaming(t *testing.T) {
+	const bodyDelay = 20 * time.Millisecond
+
+	next := make(chan struct{})
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"data": [{"id": "first", "type": "accounts", "attributes": {"country": "GB"}}, `))
+		w.(http.Flusher).Flush()
+
+		select {
+		case <-next:
+		case <-time.After(time.Second):
+			return
+		}
+
+		time.Sleep(bodyDelay)
+		_, _ = w.Write([]byte(`{"id": "second", "type": "accounts", "attributes": {"country": "GB"}}], "links": {}}`))
+	}))
+	defer ts.Close()
+
+	var (
+		mu     sync.Mutex
+		phases []*client.Phases
+	)
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithPhaseTimings()(&c)
+	client.WithStatsHandler(client.StatsHandlerFunc(func(e client.Event) {
+		if ev, ok := e.(client.RequestFinished); ok {
+			mu.Lock()
+			phases = append(phases, ev.Phases)
+			mu.Unlock()
+		}
+	}))(&c)
+
+	var ids []string
+
+	err := c.ListEach(context.Background(), 100, func(d client.Data) error {
+		ids = append(ids, d.ID)
+
+		if d.ID == "first" {
+			mu.Lock()
+			assert.Empty(t, phases, "the request is finished once its body is read")
+			mu.Unlock()
+
+			close(next)
+		}
+
+		return nil
+	})
+
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"first", "second"}, ids, "the first account is handed over before the body is read")
+
+	if assert.Len(t, phases, 1) && assert.NotNil(t, phases[0]) {
+		assert.GreaterOrEqual(t, int64(phases[0].BodyRead), int64(bodyDelay))
+	}
+}
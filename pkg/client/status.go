@@ -0,0 +1,41 @@
+package client
+
+import "net/http"
+
+// defaultStatuses are the statuses each operation accepts unless WithAcceptedStatuses says otherwise.
+var defaultStatuses = map[string][]int{
+	opCreate: {http.StatusCreated},
+	opList:   {http.StatusOK},
+	opFetch:  {http.StatusOK},
+	opUpdate: {http.StatusOK},
+	opDelete: {http.StatusNoContent},
+}
+
+// accepts reports whether status is a successful response to the operation.
+func (c Client) accepts(operation string, status int) bool {
+	statuses, ok := c.statuses[operation]
+	if !ok {
+		statuses = defaultStatuses[operation]
+	}
+
+	for _, s := range statuses {
+		if s == status {
+			return true
+		}
+	}
+
+	return false
+}
+
+// hasBody reports whether resp comes with a body to decode, which a 204 No Content does not, and neither does a 202
+// Accepted with a Content-Length of 0, as the work it accepted is not done yet. Any other status has to have one.
+func hasBody(resp *http.Response) bool {
+	switch resp.StatusCode {
+	case http.StatusNoContent:
+		return false
+	case http.StatusAccepted:
+		return resp.ContentLength != 0
+	default:
+		return true
+	}
+}
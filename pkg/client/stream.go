@@ -0,0 +1,232 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// ListEach will request every page of Resources, pageSize per request, one page at a time, and call fn with each
+// account in order. Unlike ListPages, the accounts of a page are decoded one by one as the response arrives, and
+// handed to fn before the next one is decoded, so only a single account is held in memory at a time however large the
+// pages are. It stops at the last page, which is the first one that is not full or has no next link, as soon as fn or
+// a request returns an error, or when ctx is done.
+func (c Client) ListEach(ctx context.Context, pageSize uint, fn func(Data) error) error {
+	if pageSize == 0 {
+		return errors.New("client.ListEach: pageSize has to be at least 1")
+	}
+
+	c, cancel := c.WithContext(ctx).startOperation()
+	defer cancel()
+
+	ctx = c.context()
+
+	for pageNumber := uint(0); ; pageNumber++ {
+		if err := ctx.Err(); err != nil {
+			return fmt.Errorf("client.ListEach: page %d: %w", pageNumber, err)
+		}
+
+		count, links, err := c.listEach(ctx, pageNumber, pageSize, fn)
+		if err != nil {
+			return fmt.Errorf("client.ListEach: page %d: %w", pageNumber, err)
+		}
+
+		if count < pageSize || links.Next == "" {
+			return nil
+		}
+	}
+}
+
+// listEach requests a single page, and calls fn with each of its accounts as they are decoded. It returns the number
+// of accounts on the page and its links.
+func (c Client) listEach(ctx context.Context, pageNumber, pageSize uint, fn func(Data) error) (uint, Links, error) {
+	defer c.profileOperation(ctx, opList, "")()
+
+	var (
+		count uint
+		links Links
+		fnErr error
+	)
+
+	err := c.exchange(ctx, opList, http.MethodGet, accountsEndpoint().page(pageNumber, pageSize).String(), nil,
+		func(r io.Reader) (err error) {
+			count, links, err = decodeEach(c.codec, r, func(d Data) error {
+				fnErr = fn(d)
+
+				return fnErr
+			})
+
+			return err
+		},
+	)
+
+	// An error of fn is returned as it is, rather than as the *DecodeError exchange makes of it.
+	if fnErr != nil {
+		return count, links, fnErr
+	}
+
+	return count, links, err
+}
+
+// decodeEach decodes a list response from r, walking its tokens, and calls fn with each element of its data array as
+// soon as it is decoded. It returns the number of elements, and the links of the response, which may come before or
+// after the data. Elements are checked the same way unmarshalMultiPayload checks them, and decoded with codec, or
+// encoding/json if it is nil.
+func decodeEach(codec Codec, r io.Reader, fn func(Data) error) (uint, Links, error) {
+	pd := pageDecoder{dec: json.NewDecoder(r), codec: codec}
+
+	for {
+		d, ok, err := pd.next()
+		if err != nil {
+			return pd.count, Links{}, err
+		}
+
+		if !ok {
+			return pd.count, pd.links, nil
+		}
+
+		if err := fn(d); err != nil {
+			return pd.count, Links{}, err
+		}
+	}
+}
+
+// pageDecoder pulls the elements of the data array of a list response out of dec one at a time, keeping the links of
+// the response, which may come before or after the data, and skipping anything else. The tokens are always read with
+// encoding/json, but elements are decoded with codec if it is set.
+type pageDecoder struct {
+	dec     *json.Decoder
+	codec   Codec
+	links   Links
+	count   uint
+	started bool
+	inData  bool
+}
+
+// next returns the next element of the data array. Once there are no more, it reads the rest of the response, and
+// returns false.
+func (pd *pageDecoder) next() (Data, bool, error) {
+	if !pd.started {
+		if err := expectDelim(pd.dec, '{'); err != nil {
+			return Data{}, false, fmt.Errorf("decodeEach: %w", err)
+		}
+
+		pd.started = true
+	}
+
+	for {
+		if pd.inData {
+			if pd.dec.More() {
+				return pd.element()
+			}
+
+			if err := expectDelim(pd.dec, ']'); err != nil {
+				return Data{}, false, fmt.Errorf("decodeEach data: %w", err)
+			}
+
+			pd.inData = false
+
+			continue
+		}
+
+		if !pd.dec.More() {
+			if err := expectDelim(pd.dec, '}'); err != nil {
+				return Data{}, false, fmt.Errorf("decodeEach: %w", err)
+			}
+
+			return Data{}, false, nil
+		}
+
+		if err := pd.field(); err != nil {
+			return Data{}, false, err
+		}
+	}
+}
+
+// element decodes the next element of the data array.
+func (pd *pageDecoder) element() (Data, bool, error) {
+	var d Data
+
+	if err := pd.decode(&d); err != nil {
+		return Data{}, false, fmt.Errorf("decodeEach data %d: %w", pd.count, err)
+	}
+
+	if d.Attributes.isZero() {
+		return Data{}, false, fmt.Errorf("decodeEach data %d: Data struct is missing required fields", pd.count)
+	}
+
+	pd.count++
+
+	return d, true, nil
+}
+
+// decode decodes the next value into d with the codec, or the json.Decoder if there is none.
+func (pd *pageDecoder) decode(d *Data) error {
+	if pd.codec == nil {
+		return pd.dec.Decode(d)
+	}
+
+	var raw json.RawMessage
+
+	if err := pd.dec.Decode(&raw); err != nil {
+		return err
+	}
+
+	return pd.codec.Unmarshal(raw, d)
+}
+
+// field reads the key of the next field of the response, and its value, unless it is the data array, which it only
+// steps into. A null data is an empty page.
+func (pd *pageDecoder) field() error {
+	key, err := pd.dec.Token()
+	if err != nil {
+		return fmt.Errorf("decodeEach: %w", err)
+	}
+
+	switch key {
+	case "data":
+		tok, err := pd.dec.Token()
+		if err != nil {
+			return fmt.Errorf("decodeEach data: %w", err)
+		}
+
+		if tok == nil {
+			return nil
+		}
+
+		if tok != json.Delim('[') {
+			return fmt.Errorf("decodeEach data: unexpected %v, expected an array", tok)
+		}
+
+		pd.inData = true
+	case "links":
+		if err := pd.dec.Decode(&pd.links); err != nil {
+			return fmt.Errorf("decodeEach links: %w", err)
+		}
+	default:
+		var skip json.RawMessage
+
+		if err := pd.dec.Decode(&skip); err != nil {
+			return fmt.Errorf("decodeEach %s: %w", key, err)
+		}
+	}
+
+	return nil
+}
+
+// expectDelim reads the next token of dec, and fails unless it is delim.
+func expectDelim(dec *json.Decoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+
+	if tok != delim {
+		return fmt.Errorf("unexpected %v, expected %s", tok, delim)
+	}
+
+	return nil
+}
@@ -0,0 +1,87 @@
+package client
+
+import (
+	"context"
+	"io"
+	"time"
+)
+
+// attemptContext returns the context of a single attempt of a call with ctx, which ends with ctx, or after the attempt
+// timeout of WithAttemptTimeout, whichever is first. The attempt holds on to it until its response is closed.
+func (c Client) attemptContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	if c.attemptTimeout <= 0 {
+		return ctx, func() {}
+	}
+
+	return context.WithTimeout(ctx, c.attemptTimeout)
+}
+
+// cancelBody is the body of a response that cancels the context of its attempt once it is closed, so the deadline
+// covers reading the body, but nothing after it.
+type cancelBody struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+// Close closes the body, and cancels the context of the attempt.
+func (b cancelBody) Close() error {
+	err := b.ReadCloser.Close()
+	b.cancel()
+
+	return err
+}
+
+// WithAttemptTimeout limits every attempt of a request to d, from when it is sent until its response is read, within
+// whatever deadline the context of the call has. Retries get a new d each, and the wait between them doesn't count, so
+// a call with retries can take longer than d, and a long ListAll is never cut short by a timeout of the whole run.
+// Pages of ListEach and Iterate are read as their accounts are handed over, so the time spent on the accounts of a
+// page counts towards its attempt. The Timeout of the http.Client applies on top of it, so leave that at 0 to only
+// have deadlines of contexts. Zero or less means no limit, which is the default.
+func WithAttemptTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.attemptTimeout = d
+	}
+}
+
+// WithOperationTimeout limits every operation to d, from when it starts until it returns, including its retries and the
+// waits between them, and every page of a listing, like ListAll, ListPages, ListEach, or Iterate, within whatever
+// deadline the context of the operation has. Every account of a batch is an operation of its own. Use it along with
+// WithAttemptTimeout to bound both a single slow request and the whole of a call. Zero or less means no limit, which
+// is the default.
+func WithOperationTimeout(d time.Duration) Option {
+	return func(c *Client) {
+		c.operationTimeout = d
+	}
+}
+
+// WithContext returns a copy of the Client that sends the requests of its operations that take no context, like
+// Fetch, Create, and ListAll, with ctx, so cancelling ctx, or its deadline, ends them. Without it, they are sent with
+// context.Background. Operations that take a context use theirs instead.
+func (c Client) WithContext(ctx context.Context) Client {
+	c.ctx = ctx
+
+	return c
+}
+
+// context returns the context of WithContext, or context.Background.
+func (c Client) context() context.Context {
+	if c.ctx == nil {
+		return context.Background()
+	}
+
+	return c.ctx
+}
+
+// startOperation returns a copy of the Client whose context ends after the timeout of WithOperationTimeout, and the
+// function that releases it, which the operation calls when it returns. Operations that call others, like the fetch
+// after a create without a body, or the pages of a listing, pass the copy on, so those stay within the same deadline.
+func (c Client) startOperation() (Client, context.CancelFunc) {
+	if c.operationTimeout <= 0 {
+		return c, func() {}
+	}
+
+	ctx, cancel := context.WithTimeout(c.context(), c.operationTimeout)
+	c.ctx = ctx
+
+	return c, cancel
+}
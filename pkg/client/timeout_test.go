@@ -0,0 +1,244 @@
+package client_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestWithAttemptTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		slowHeaders  int32
+		slowBody     bool
+		retries      int
+		ctxTimeout   time.Duration
+		wantAttempts int32
+		wantErr      error
+	}{
+		{
+			name:         "succeeds within the timeout",
+			wantAttempts: 1,
+		},
+		{
+			name:         "retries an attempt that timed out",
+			slowHeaders:  1,
+			retries:      1,
+			wantAttempts: 2,
+		},
+		{
+			name:         "fails without retries",
+			slowHeaders:  1,
+			wantAttempts: 1,
+			wantErr:      context.DeadlineExceeded,
+		},
+		{
+			name:         "covers reading the body",
+			slowBody:     true,
+			wantAttempts: 1,
+			wantErr:      context.DeadlineExceeded,
+		},
+		{
+			name:         "keeps the deadline of the context of the call",
+			slowHeaders:  1,
+			retries:      1,
+			ctxTimeout:   timeout / 2,
+			wantAttempts: 1,
+			wantErr:      context.DeadlineExceeded,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if atomic.AddInt32(&attempts, 1) <= tt.slowHeaders {
+					time.Sleep(3 * timeout)
+				}
+
+				if tt.slowBody {
+					_, _ = w.Write([]byte(`{"data": [`))
+					w.(http.Flusher).Flush()
+					time.Sleep(3 * timeout)
+				}
+
+				_, _ = w.Write([]byte(`{"data": []}`))
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithAttemptTimeout(timeout)(&c)
+			client.WithRetries(tt.retries, time.Millisecond)(&c)
+
+			ctx := context.Background()
+
+			if tt.ctxTimeout > 0 {
+				var cancel context.CancelFunc
+
+				ctx, cancel = context.WithTimeout(ctx, tt.ctxTimeout)
+				defer cancel()
+			}
+
+			err := c.ListEach(ctx, 10, func(client.Data) error { return nil })
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantAttempts, atomic.LoadInt32(&attempts))
+		})
+	}
+}
+
+func TestWithAttemptTimeout_perAttempt(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond)
+		_, _ = w.Write([]byte(`{"data": []}`))
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithAttemptTimeout(50 * time.Millisecond)(&c)
+
+	for i := 0; i < 5; i++ {
+		_, err := c.List(uint(i), 10)
+		assert.NoError(t, err, "every call gets a deadline of its own, however long they take together")
+	}
+}
+
+func TestWithOperationTimeout(t *testing.T) {
+	const timeout = 100 * time.Millisecond
+
+	page := func(number int) string {
+		return fmt.Sprintf(`{"data": [{"id": "%d", "attributes": {"country": "GB"}}], "links": {"next": "next"}}`, number)
+	}
+
+	tests := []struct {
+		name         string
+		handler      func(attempt int32, w http.ResponseWriter)
+		call         func(c client.Client) error
+		wantAttempts int32
+		wantErr      error
+	}{
+		{
+			name: "covers retries and the waits between them",
+			handler: func(_ int32, w http.ResponseWriter) {
+				w.WriteHeader(http.StatusServiceUnavailable)
+			},
+			call: func(c client.Client) error {
+				client.WithRetries(10, 40*time.Millisecond)(&c)
+
+				_, err := c.Fetch("accountid")
+
+				return err
+			},
+			wantAttempts: 2,
+			wantErr:      context.DeadlineExceeded,
+		},
+		{
+			name: "covers every page of a listing",
+			handler: func(attempt int32, w http.ResponseWriter) {
+				time.Sleep(timeout * 2 / 5)
+				_, _ = w.Write([]byte(page(int(attempt))))
+			},
+			call: func(c client.Client) error {
+				_, err := c.ListAll(1)
+
+				return err
+			},
+			wantAttempts: 3,
+			wantErr:      context.DeadlineExceeded,
+		},
+		{
+			name: "covers every page of an iteration",
+			handler: func(attempt int32, w http.ResponseWriter) {
+				time.Sleep(timeout * 2 / 5)
+				_, _ = w.Write([]byte(page(int(attempt))))
+			},
+			call: func(c client.Client) error {
+				it := c.Iterate(context.Background(), 1)
+				defer func() { _ = it.Close() }()
+
+				for it.Next() {
+				}
+
+				return it.Err()
+			},
+			wantAttempts: 3,
+			wantErr:      context.DeadlineExceeded,
+		},
+		{
+			name: "a new timeout for every operation",
+			handler: func(_ int32, w http.ResponseWriter) {
+				time.Sleep(timeout / 3)
+				_, _ = w.Write([]byte(`{"data": []}`))
+			},
+			call: func(c client.Client) error {
+				for i := 0; i < 4; i++ {
+					if _, err := c.List(uint(i), 10); err != nil {
+						return err
+					}
+				}
+
+				return nil
+			},
+			wantAttempts: 4,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts int32
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tt.handler(atomic.AddInt32(&attempts, 1), w)
+			}))
+			defer ts.Close()
+
+			c := client.Client{BaseURL: ts.URL}
+			client.WithOperationTimeout(timeout)(&c)
+
+			err := tt.call(c)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.wantAttempts, atomic.LoadInt32(&attempts))
+		})
+	}
+}
+
+func TestClient_WithContext(t *testing.T) {
+	var attempts int32
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&attempts, 1)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	err := c.WithContext(ctx).Delete("accountid", 0)
+	assert.True(t, errors.Is(err, context.Canceled), "got %v", err)
+	assert.Equal(t, int32(0), atomic.LoadInt32(&attempts))
+
+	assert.NoError(t, c.Delete("accountid", 0), "the Client it was copied from keeps its context")
+	assert.Equal(t, int32(1), atomic.LoadInt32(&attempts))
+}
@@ -0,0 +1,66 @@
+package client
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// timestampLayouts are the formats of created_on and modified_on that deployments of the service are known to send,
+// tried in order. Fractional seconds are optional in every one of them. Layouts without an offset are read as UTC.
+var timestampLayouts = []string{ //nolint:gochecknoglobals // a constant list of layouts
+	time.RFC3339Nano,
+	"2006-01-02T15:04:05.999999999Z0700",
+	"2006-01-02T15:04:05.999999999Z07",
+	"2006-01-02T15:04:05.999999999",
+	"2006-01-02 15:04:05.999999999Z07:00",
+	"2006-01-02 15:04:05.999999999Z0700",
+	"2006-01-02 15:04:05.999999999",
+}
+
+// Timestamp is a time of a response, like the created_on and modified_on of an account. It decodes every format of
+// timestampLayouts, with or without fractional seconds, and with any offset, and is always in UTC, so timestamps of
+// different deployments compare equal with == when they are the same instant. It encodes as time.RFC3339Nano. A null
+// or empty string decodes to the zero time.
+type Timestamp struct {
+	time.Time
+}
+
+// MarshalJSON encodes the Timestamp in UTC as time.RFC3339Nano.
+func (t Timestamp) MarshalJSON() ([]byte, error) {
+	b, err := t.Time.UTC().MarshalJSON()
+	if err != nil {
+		return nil, fmt.Errorf("client.Timestamp.MarshalJSON: %w", err)
+	}
+
+	return b, nil
+}
+
+// UnmarshalJSON decodes a string in any of the formats of timestampLayouts into the Timestamp, in UTC.
+func (t *Timestamp) UnmarshalJSON(b []byte) error {
+	if bytes.Equal(b, []byte("null")) {
+		return nil
+	}
+
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return fmt.Errorf("client.Timestamp.UnmarshalJSON: %w", err)
+	}
+
+	if s == "" {
+		t.Time = time.Time{}
+
+		return nil
+	}
+
+	for _, layout := range timestampLayouts {
+		if parsed, err := time.Parse(layout, s); err == nil {
+			t.Time = parsed.UTC()
+
+			return nil
+		}
+	}
+
+	return fmt.Errorf("client.Timestamp.UnmarshalJSON: %q is not a known time format", s)
+}
@@ -0,0 +1,63 @@
+package client_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestTimestamp_UnmarshalJSON(t *testing.T) {
+	want := time.Date(2020, time.May, 6, 9, 28, 13, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		json    string
+		want    time.Time
+		wantErr bool
+	}{
+		{name: "fractional seconds", json: `"2020-05-06T09:28:13.843Z"`, want: want.Add(843 * time.Millisecond)},
+		{name: "no fractional seconds", json: `"2020-05-06T09:28:13Z"`, want: want},
+		{name: "an offset", json: `"2020-05-06T11:28:13+02:00"`, want: want},
+		{name: "an offset without a colon", json: `"2020-05-06T11:28:13.843+0200"`, want: want.Add(843 * time.Millisecond)},
+		{name: "an offset in hours", json: `"2020-05-06T04:28:13-05"`, want: want},
+		{name: "no offset", json: `"2020-05-06T09:28:13.843"`, want: want.Add(843 * time.Millisecond)},
+		{name: "a space instead of a T", json: `"2020-05-06 09:28:13+00:00"`, want: want},
+		{name: "null", json: `null`},
+		{name: "an empty string", json: `""`},
+		{name: "not a time", json: `"yesterday"`, wantErr: true},
+		{name: "not a string", json: `1588757293`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got client.Timestamp
+
+			err := json.Unmarshal([]byte(tt.json), &got)
+			if tt.wantErr {
+				assert.Error(t, err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.True(t, got.Time == tt.want, "got %s, want %s", got.Time, tt.want)
+			assert.Equal(t, time.UTC, got.Location())
+		})
+	}
+}
+
+func TestTimestamp_MarshalJSON(t *testing.T) {
+	ts := client.Timestamp{Time: time.Date(2020, time.May, 6, 11, 28, 13, 843000000, time.FixedZone("CEST", 7200))}
+
+	got, err := json.Marshal(ts)
+	assert.NoError(t, err)
+	assert.Equal(t, `"2020-05-06T09:28:13.843Z"`, string(got))
+
+	var back client.Timestamp
+
+	assert.NoError(t, json.Unmarshal(got, &back))
+	assert.True(t, back.Equal(ts.Time))
+}
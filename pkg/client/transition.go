@@ -0,0 +1,102 @@
+package client
+
+import (
+	"context"
+	"fmt"
+)
+
+// The statuses of an account.
+const (
+	StatusPending   = "pending"
+	StatusConfirmed = "confirmed"
+	StatusFailed    = "failed"
+	StatusClosed    = "closed"
+)
+
+const (
+	fieldStatus       = "status"
+	fieldStatusReason = "status_reason"
+)
+
+// transitions are the statuses an account can move to from each status. Failed and closed accounts stay that way.
+var transitions = map[string][]string{
+	StatusPending:   {StatusConfirmed, StatusFailed},
+	StatusConfirmed: {StatusClosed},
+}
+
+// CanTransition reports whether an account in status from can be moved to status to: a pending account can be
+// confirmed or failed, and a confirmed one closed. An empty from is pending, as that is what accounts are created as.
+func CanTransition(from, to string) bool {
+	if from == "" {
+		from = StatusPending
+	}
+
+	for _, s := range transitions[from] {
+		if s == to {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ConfirmAccount moves a pending account to confirmed. It fails with a *TransitionError, which matches
+// ErrInvalidTransition, without changing the account if it is in any other status.
+func (c Client) ConfirmAccount(ctx context.Context, accountID string, version uint) (Payload, error) {
+	p, err := c.transition(ctx, accountID, version, StatusConfirmed, "")
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.ConfirmAccount: %w", err)
+	}
+
+	return p, nil
+}
+
+// FailAccount moves a pending account to failed, with reason as its status_reason, if it is not empty. It fails with a
+// *TransitionError, which matches ErrInvalidTransition, without changing the account if it is in any other status.
+func (c Client) FailAccount(ctx context.Context, accountID string, version uint, reason string) (Payload, error) {
+	p, err := c.transition(ctx, accountID, version, StatusFailed, reason)
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.FailAccount: %w", err)
+	}
+
+	return p, nil
+}
+
+// CloseAccount moves a confirmed account to closed, with reason as its status_reason, if it is not empty. It fails with
+// a *TransitionError, which matches ErrInvalidTransition, without changing the account if it is in any other status.
+func (c Client) CloseAccount(ctx context.Context, accountID string, version uint, reason string) (Payload, error) {
+	p, err := c.transition(ctx, accountID, version, StatusClosed, reason)
+	if err != nil {
+		return Payload{}, fmt.Errorf("client.CloseAccount: %w", err)
+	}
+
+	return p, nil
+}
+
+// transition fetches the account, past the cache of WithCache, to check that its status can move to status, and
+// updates it with version if it can. The update is audited like one of Update. The callers wrap its errors.
+func (c Client) transition(
+	ctx context.Context, accountID string, version uint, status, reason string,
+) (Payload, error) {
+	c = c.WithContext(ctx)
+
+	current, err := c.WithCacheControl(CacheControl{NoCache: true}).fetch(accountID)
+	if err != nil {
+		return Payload{}, err
+	}
+
+	from := current.Data.Attributes.Status
+	if !CanTransition(from, status) {
+		return Payload{}, &TransitionError{AccountID: accountID, From: from, To: status}
+	}
+
+	attributes := map[string]interface{}{fieldStatus: status}
+	if reason != "" {
+		attributes[fieldStatusReason] = reason
+	}
+
+	p, err := c.update(accountID, version, attributes)
+	c.audit(auditUpdate, accountID, version, err)
+
+	return p, err
+}
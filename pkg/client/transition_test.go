@@ -0,0 +1,150 @@
+package client_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+func TestCanTransition(t *testing.T) {
+	tests := []struct {
+		from string
+		to   string
+		want bool
+	}{
+		{from: client.StatusPending, to: client.StatusConfirmed, want: true},
+		{from: client.StatusPending, to: client.StatusFailed, want: true},
+		{from: "", to: client.StatusConfirmed, want: true},
+		{from: client.StatusConfirmed, to: client.StatusClosed, want: true},
+		{from: client.StatusPending, to: client.StatusClosed},
+		{from: client.StatusConfirmed, to: client.StatusFailed},
+		{from: client.StatusConfirmed, to: client.StatusConfirmed},
+		{from: client.StatusFailed, to: client.StatusConfirmed},
+		{from: client.StatusClosed, to: client.StatusConfirmed},
+		{from: "unknown", to: client.StatusClosed},
+	}
+	for _, tt := range tests {
+		t.Run(tt.from+" to "+tt.to, func(t *testing.T) {
+			assert.Equal(t, tt.want, client.CanTransition(tt.from, tt.to))
+		})
+	}
+}
+
+func TestClient_transitions(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	tests := []struct {
+		name       string
+		status     string
+		call       func(c client.Client) (client.Payload, error)
+		wantUpdate map[string]interface{}
+		wantErr    error
+	}{
+		{
+			name:   "confirms a pending account",
+			status: client.StatusPending,
+			call: func(c client.Client) (client.Payload, error) {
+				return c.ConfirmAccount(context.Background(), accountID, 2)
+			},
+			wantUpdate: map[string]interface{}{"status": "confirmed"},
+		},
+		{
+			name:   "fails a pending account with a reason",
+			status: client.StatusPending,
+			call: func(c client.Client) (client.Payload, error) {
+				return c.FailAccount(context.Background(), accountID, 2, "invalid-account-name")
+			},
+			wantUpdate: map[string]interface{}{"status": "failed", "status_reason": "invalid-account-name"},
+		},
+		{
+			name:   "closes a confirmed account with a reason",
+			status: client.StatusConfirmed,
+			call: func(c client.Client) (client.Payload, error) {
+				return c.CloseAccount(context.Background(), accountID, 2, "customer-request")
+			},
+			wantUpdate: map[string]interface{}{"status": "closed", "status_reason": "customer-request"},
+		},
+		{
+			name:   "closes without a reason",
+			status: client.StatusConfirmed,
+			call: func(c client.Client) (client.Payload, error) {
+				return c.CloseAccount(context.Background(), accountID, 2, "")
+			},
+			wantUpdate: map[string]interface{}{"status": "closed"},
+		},
+		{
+			name:   "doesn't close a pending account",
+			status: client.StatusPending,
+			call: func(c client.Client) (client.Payload, error) {
+				return c.CloseAccount(context.Background(), accountID, 2, "customer-request")
+			},
+			wantErr: client.ErrInvalidTransition,
+		},
+		{
+			name:   "doesn't confirm a closed account",
+			status: client.StatusClosed,
+			call: func(c client.Client) (client.Payload, error) {
+				return c.ConfirmAccount(context.Background(), accountID, 2)
+			},
+			wantErr: client.ErrInvalidTransition,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var update map[string]interface{}
+
+			ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				status := tt.status
+
+				if r.Method == http.MethodPatch {
+					var body struct {
+						Data struct {
+							Version    uint                   `json:"version"`
+							Attributes map[string]interface{} `json:"attributes"`
+						} `json:"data"`
+					}
+
+					b, _ := ioutil.ReadAll(r.Body)
+					assert.NoError(t, json.Unmarshal(b, &body))
+					assert.Equal(t, uint(2), body.Data.Version)
+
+					update = body.Data.Attributes
+					status, _ = update["status"].(string)
+				}
+
+				b, _ := json.Marshal(client.Payload{Data: client.Data{
+					ID:         accountID,
+					Version:    2,
+					Attributes: client.Resource{Country: "GB", Status: status},
+				}})
+				_, _ = w.Write(b)
+			}))
+			defer ts.Close()
+
+			got, err := tt.call(client.Client{BaseURL: ts.URL})
+
+			assert.Equal(t, tt.wantUpdate, update)
+
+			if tt.wantErr != nil {
+				var tErr *client.TransitionError
+
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+				assert.True(t, errors.As(err, &tErr))
+				assert.Equal(t, tt.status, tErr.From)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantUpdate["status"], got.Data.Attributes.Status)
+		})
+	}
+}
@@ -0,0 +1,151 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+)
+
+// WithForceAttemptHTTP2 sets whether the transport of the Client tries HTTP/2 when it has a custom TLS configuration or
+// dialer, which would otherwise make it stay on HTTP/1.1. The default transport of net/http already tries it, and
+// negotiates HTTP/2 with every server that supports it over TLS, so this is for transports built by hand.
+//
+// Like the other transport options, it applies to the http.Transport of the HttpClient field. If that has none, it
+// starts from a copy of http.DefaultTransport, and if it is an *http.Transport, from a copy of it, so a transport that
+// other Clients share is never changed. Any other http.RoundTripper, and the http.Client of WithHTTPClient, are left
+// as they are, as their settings are not the Client's to change.
+func WithForceAttemptHTTP2(force bool) Option {
+	return func(c *Client) {
+		c.tuneTransport(func(t *http.Transport) {
+			t.ForceAttemptHTTP2 = force
+		})
+	}
+}
+
+// WithReadBufferSize sets the size of the buffer the transport of the Client reads each connection with, 4KB by
+// default. Large list pages are read in fewer system calls with a larger one. Values below 1 keep the default.
+func WithReadBufferSize(n int) Option {
+	return func(c *Client) {
+		c.tuneTransport(func(t *http.Transport) {
+			t.ReadBufferSize = n
+		})
+	}
+}
+
+// WithMaxIdleConnsPerHost sets how many idle connections to the service the transport of the Client keeps open for
+// keep-alive, 2 by default. With a concurrency above that, every request past the second one in flight would open a
+// new connection and close it afterwards, so it should be at least the concurrency. Values below 1 keep the default.
+func WithMaxIdleConnsPerHost(n int) Option {
+	return func(c *Client) {
+		if n < 1 {
+			n = 0
+		}
+
+		c.tuneTransport(func(t *http.Transport) {
+			t.MaxIdleConnsPerHost = n
+		})
+	}
+}
+
+// Warmup opens n connections to the service ahead of real traffic, so the first burst of it after a deployment does not
+// wait for TCP and TLS handshakes. It sends n HEAD requests to the health endpoint at once, and keeps every response
+// open until all of them arrived, so each one needs a connection of its own, which then goes back into the pool of the
+// transport. Any response counts, whatever its status. Only WithMaxIdleConnsPerHost of the connections stay in the pool
+// afterwards, 2 by default, so it should be at least n. Over HTTP/2 the requests share a single connection.
+//
+// It returns once every request finished, with the error of the first one that failed, if any did.
+func (c Client) Warmup(ctx context.Context, n int) error {
+	if n < 1 {
+		return errors.New("client.Warmup: n has to be at least 1")
+	}
+
+	var (
+		arrived, finished sync.WaitGroup
+		mu                sync.Mutex
+		failed            int
+		firstErr          error
+	)
+
+	arrived.Add(n)
+	finished.Add(n)
+
+	for i := 0; i < n; i++ {
+		go func() {
+			defer finished.Done()
+
+			resp, err := c.do(ctx, http.MethodHead, healthEndpoint().String(), nil)
+			arrived.Done()
+
+			if err != nil {
+				mu.Lock()
+				defer mu.Unlock()
+
+				failed++
+				if firstErr == nil {
+					firstErr = err
+				}
+
+				return
+			}
+
+			arrived.Wait()
+			discard(resp)
+		}()
+	}
+
+	finished.Wait()
+
+	if firstErr != nil {
+		return fmt.Errorf("client.Warmup: %d of %d failed: %w", failed, n, firstErr)
+	}
+
+	return nil
+}
+
+// healthEndpoint returns where Warmup sends its requests. Any response will do, so it works with services without one.
+func healthEndpoint() endpoint {
+	return newEndpoint("health")
+}
+
+// tuneTransport calls tune with a copy of the http.Transport of the HttpClient field, or of http.DefaultTransport if
+// it has none, and sets the copy as its transport. Anything else is left as it is.
+func (c *Client) tuneTransport(tune func(*http.Transport)) {
+	if c.httpClient != nil {
+		return
+	}
+
+	rt := c.HttpClient.Transport
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+
+	t, ok := rt.(*http.Transport)
+	if !ok {
+		return
+	}
+
+	t = t.Clone()
+	tune(t)
+	c.HttpClient.Transport = t
+}
+
+// maxDiscard is how much of the rest of a body discard reads at most. A connection with more than that left to read
+// is cheaper to close than to drain.
+const maxDiscard = 64 << 10
+
+// discard reads the rest of the body of a response, up to maxDiscard, and closes it. Every response goes through it
+// once the Client is done with it, whether it is going to be retried, failed, or was decoded, as net/http only puts a
+// connection back into the pool for keep-alive if its body was read to the end before it was closed. A JSON decoder
+// stops at the end of the value, before the newline most encoders write after it.
+func discard(resp *http.Response) {
+	if resp == nil {
+		return
+	}
+
+	_, _ = io.CopyN(ioutil.Discard, resp.Body, maxDiscard)
+	_ = resp.Body.Close()
+}
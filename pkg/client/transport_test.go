@@ -0,0 +1,251 @@
+package client_test
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestClient_KeepAlive(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	payload := []byte(returnCompactFile(t, "./testdata/payload.json"))
+	multiPayload := []byte(returnCompactFile(t, "./testdata/multipayload.json"))
+
+	var unavailable int32
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case strings.HasSuffix(r.URL.Path, "/flaky") && atomic.AddInt32(&unavailable, 1)%2 == 1:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"error_message": "try again"}`))
+		case r.Method == http.MethodPost:
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"error_message": "` + strings.Repeat("invalid ", 100) + `"}`))
+		case r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusConflict)
+			_, _ = w.Write([]byte(`{"error_message": "invalid version"}`))
+		case strings.HasSuffix(r.URL.Path, "/missing"):
+			w.WriteHeader(http.StatusNotFound)
+			_, _ = w.Write([]byte(`{"error_message": "record does not exist"}`))
+		case strings.HasSuffix(r.URL.Path, "/accounts"):
+			_, _ = w.Write(multiPayload)
+		default:
+			_, _ = w.Write(payload)
+		}
+	}))
+
+	var (
+		mu          sync.Mutex
+		connections = map[net.Conn]http.ConnState{}
+	)
+
+	ts.Config.ConnState = func(conn net.Conn, state http.ConnState) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		connections[conn] = state
+	}
+
+	ts.Start()
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+	client.WithRetries(1, time.Millisecond)(&c)
+
+	for i := 0; i < 3; i++ {
+		_, err := c.Fetch(accountID)
+		assert.NoError(t, err)
+
+		_, err = c.Fetch("missing")
+		assert.Error(t, err)
+
+		_, err = c.Fetch("flaky")
+		assert.NoError(t, err, "the 503 is retried")
+
+		_, err = c.List(0, 2)
+		assert.NoError(t, err)
+
+		_, err = c.Create(fixtures.ValidGB())
+		assert.Error(t, err)
+
+		_, err = c.Update(accountID, 0, map[string]interface{}{"bank_id": "400300"})
+		assert.NoError(t, err)
+
+		assert.Error(t, c.Delete(accountID, 1))
+
+		assert.Error(t, c.ListEach(context.Background(), 10, func(client.Data) error {
+			return errors.New("stop")
+		}), "stops on the first account")
+
+		it := c.Iterate(context.Background(), 10)
+		assert.True(t, it.Next())
+		assert.NoError(t, it.Close(), "closed half way through the page")
+	}
+
+	// A leaked body keeps its connection active, and makes the next request open a new one.
+	assert.Eventually(t, func() bool {
+		mu.Lock()
+		defer mu.Unlock()
+
+		for _, state := range connections {
+			if state != http.StateIdle {
+				return false
+			}
+		}
+
+		return true
+	}, time.Second, time.Millisecond, "every connection goes back to idle")
+
+	mu.Lock()
+	defer mu.Unlock()
+
+	assert.Len(t, connections, 1, "every response is read to the end, so the connection is reused")
+}
+
+func TestClient_Warmup(t *testing.T) {
+	const accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+
+	payload := []byte(returnCompactFile(t, "./testdata/payload.json"))
+
+	var (
+		connections int64
+		health      int64
+	)
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v1/health" {
+			assert.Equal(t, http.MethodHead, r.Method)
+			atomic.AddInt64(&health, 1)
+
+			return
+		}
+
+		time.Sleep(10 * time.Millisecond)
+		_, _ = w.Write(payload)
+	}))
+
+	ts.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&connections, 1)
+		}
+	}
+
+	ts.StartTLS()
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL, HttpClient: *ts.Client()}
+	client.WithMaxIdleConnsPerHost(4)(&c)
+
+	assert.NoError(t, c.Warmup(context.Background(), 4))
+	assert.Equal(t, int64(4), atomic.LoadInt64(&health))
+	assert.Equal(t, int64(4), atomic.LoadInt64(&connections), "every request gets a connection of its own")
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+
+		go func() {
+			defer wg.Done()
+
+			_, err := c.Fetch(accountID)
+			assert.NoError(t, err)
+		}()
+	}
+
+	wg.Wait()
+
+	assert.Equal(t, int64(4), atomic.LoadInt64(&connections), "the burst uses the warm connections")
+
+	ts.Close()
+
+	err := c.Warmup(context.Background(), 2)
+	assert.Error(t, err)
+	assert.Contains(t, err.Error(), "client.Warmup: 2 of 2 failed: ")
+
+	assert.EqualError(t, c.Warmup(context.Background(), 0), "client.Warmup: n has to be at least 1")
+}
+
+func TestTransportOptions(t *testing.T) {
+	defaults := http.DefaultTransport.(*http.Transport)
+	wantForce, wantBuffer, wantIdle := defaults.ForceAttemptHTTP2, defaults.ReadBufferSize, defaults.MaxIdleConnsPerHost
+
+	c := client.Client{}
+	for _, opt := range []client.Option{
+		client.WithForceAttemptHTTP2(false),
+		client.WithReadBufferSize(64 << 10),
+		client.WithMaxIdleConnsPerHost(16),
+	} {
+		opt(&c)
+	}
+
+	tuned, ok := c.HttpClient.Transport.(*http.Transport)
+	if assert.True(t, ok) {
+		assert.False(t, tuned.ForceAttemptHTTP2)
+		assert.Equal(t, 64<<10, tuned.ReadBufferSize)
+		assert.Equal(t, 16, tuned.MaxIdleConnsPerHost)
+	}
+
+	assert.Equal(t, wantForce, defaults.ForceAttemptHTTP2, "the default transport is not changed")
+	assert.Equal(t, wantBuffer, defaults.ReadBufferSize)
+	assert.Equal(t, wantIdle, defaults.MaxIdleConnsPerHost)
+
+	shared := &http.Transport{}
+	c = client.Client{HttpClient: http.Client{Transport: shared}}
+	client.WithMaxIdleConnsPerHost(-1)(&c)
+	assert.NotSame(t, shared, c.HttpClient.Transport, "a transport of the HttpClient is copied")
+	assert.Equal(t, 0, shared.MaxIdleConnsPerHost)
+	assert.Equal(t, 0, c.HttpClient.Transport.(*http.Transport).MaxIdleConnsPerHost)
+
+	custom := failingTransport{}
+	c = client.Client{HttpClient: http.Client{Transport: custom}}
+	client.WithReadBufferSize(1)(&c)
+	assert.Equal(t, custom, c.HttpClient.Transport, "any other RoundTripper is left as it is")
+
+	c = client.Client{}
+	client.WithHTTPClient(&http.Client{})(&c)
+	client.WithReadBufferSize(1)(&c)
+	assert.Nil(t, c.HttpClient.Transport, "the HttpClient field is not used with WithHTTPClient")
+}
+
+func TestWithForceAttemptHTTP2(t *testing.T) {
+	var protocols []int
+
+	ts := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		protocols = append(protocols, r.ProtoMajor)
+		_, _ = w.Write([]byte(returnCompactFile(t, "./testdata/payload.json")))
+	}))
+	ts.EnableHTTP2 = true
+	ts.StartTLS()
+
+	defer ts.Close()
+
+	roots := x509.NewCertPool()
+	roots.AddCert(ts.Certificate())
+
+	for _, force := range []bool{false, true} {
+		transport := &http.Transport{TLSClientConfig: &tls.Config{RootCAs: roots, MinVersion: tls.VersionTLS12}}
+		c := client.Client{BaseURL: ts.URL, HttpClient: http.Client{Transport: transport}}
+		client.WithForceAttemptHTTP2(force)(&c)
+
+		_, err := c.Fetch("a6c1a721-bb1b-41ef-bd11-800a1309ff9b")
+		assert.NoError(t, err)
+	}
+
+	assert.Equal(t, []int{1, 2}, protocols, "a TLS configuration of its own keeps a transport on HTTP/1.1 unless forced")
+}
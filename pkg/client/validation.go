@@ -1,10 +1,8 @@
-//nolint:dupl
 package client
 
 import (
-	"errors"
 	"fmt"
-	"regexp"
+	"unicode/utf8"
 )
 
 const (
@@ -25,285 +23,196 @@ const (
 	USBankID = "USABA"
 )
 
-var (
-	reThreeDigits     = regexp.MustCompile(`^\d{3}$`)
-	reFiveDigits      = regexp.MustCompile(`^\d{5}$`)
-	reSixDigits       = regexp.MustCompile(`^\d{6}$`)
-	reSevenDigits     = regexp.MustCompile(`^\d{7}$`)
-	reEightDigits     = regexp.MustCompile(`^\d{8}$`)
-	reNineDigits      = regexp.MustCompile(`^\d{9}$`)
-	reTenDigits       = regexp.MustCompile(`^\d{10}$`)
-	reElevenDigits    = regexp.MustCompile(`^\d{11}$`)
-	reTwelveDigits    = regexp.MustCompile(`^\d{12}$`)
-	reThirteenDigits  = regexp.MustCompile(`^\d{13}$`)
-	reSixteenDigits   = regexp.MustCompile(`^\d{16}$`)
-	reAUAccountNumber = regexp.MustCompile(`^[1-9]\d{5,9}$`)
-	reCARoutingNumber = regexp.MustCompile(`^0\d{8}$`)
-	reCAAccountNumber = regexp.MustCompile(`^\d{7,12}$`)
-	reHKAccountNumber = regexp.MustCompile(`^\d{9,12}$`)
-	reUSAccountNumber = regexp.MustCompile(`^\d{6,17}$`)
+const (
+	fieldCountry       = "country"
+	fieldBankID        = "bank_id"
+	fieldBankIDCode    = "bank_id_code"
+	fieldBIC           = "bic"
+	fieldAccountNumber = "account_number"
+	fieldIBAN          = "iban"
+	fieldName          = "name"
+	fieldAltNames      = "alternative_names"
 )
 
-//nolint:gocyclo
-func ValidateResource(account Resource) error {
-	switch account.Country {
-	case "GB":
-		return validateGB(account)
-	case "AU":
-		return validateAU(account)
-	case "BE":
-		return validateBE(account)
-	case "CA":
-		return validateCA(account)
-	case "FR":
-		return validateFR(account)
-	case "DE":
-		return validateDE(account)
-	case "GR":
-		return validateGR(account)
-	case "HK":
-		return validateHK(account)
-	case "IT":
-		return validateIT(account)
-	case "LU":
-		return validateLU(account)
-	case "NL":
-		return validateNL(account)
-	case "PL":
-		return validatePL(account)
-	case "PT":
-		return validatePT(account)
-	case "ES":
-		return validateES(account)
-	case "CH":
-		return validateCH(account)
-	case "US":
-		return validateUS(account)
-	}
-
-	return fmt.Errorf("unsupported country code: %s", account.Country)
-}
-
-func validateGB(account Resource) error {
-	r, err := bicRequired(account, nil)
-	r, err = bankIDRequiredMust(r, err, reSixDigits)
-	r, err = bankIDCodeMust(r, err, GBBankID)
-	_, err = accountNumberOptionalMust(r, err, reEightDigits)
-
-	return err
-}
-
-func validateAU(account Resource) error {
-	r, err := ibanNotSupported(bicRequired(account, nil))
-	r, err = bankIDOptionalMust(r, err, reSixDigits)
-	r, err = bankIDCodeMust(r, err, AUBankID)
-	_, err = accountNumberOptionalMust(r, err, reAUAccountNumber)
-
-	return err
-}
-
-func validateBE(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reThreeDigits)
-	r, err = bankIDCodeMust(r, err, BEBankID)
-	_, err = accountNumberOptionalMust(r, err, reSevenDigits)
-
-	return err
-}
-
-func validateCA(account Resource) error {
-	r, err := ibanNotSupported(bicRequired(account, nil))
-	r, err = bankIDOptionalMust(r, err, reCARoutingNumber)
-	r, err = bankIDCodeOptionalMust(r, err, CABankID)
-	_, err = accountNumberOptionalMust(r, err, reCAAccountNumber)
+// APIVersion is the version of the accounts API the accounts are validated for. Later versions of the service check
+// more of the attributes, and ValidateResourceFor checks them too, so a Create fails before it is sent rather than with
+// a 400 Bad Request that doesn't say why.
+type APIVersion int
 
-	return err
-}
-
-func validateFR(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reTenDigits)
-	r, err = bankIDCodeMust(r, err, FRBankID)
-	_, err = accountNumberOptionalMust(r, err, reTenDigits)
-
-	return err
-}
+const (
+	// APIVersion1 checks the attributes the country rules cover, and nothing else. It is the default.
+	APIVersion1 APIVersion = iota + 1
+	// APIVersion2 also requires the first line of the name, and limits every name and alternative name line to
+	// MaxNameLength characters.
+	APIVersion2
+)
 
-func validateDE(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reEightDigits)
-	r, err = bankIDCodeMust(r, err, DEBankID)
-	_, err = accountNumberOptionalMust(r, err, reSevenDigits)
+// MaxNameLength is the number of characters a line of the name or the alternative names can have from APIVersion2.
+const MaxNameLength = 140
 
-	return err
+// ValidateResource checks the Resource against the rules the service applies to the country of the account. The
+// returned error is a *ValidationError, and matches ErrValidation. Its Fields list every rule the Resource broke.
+//
+// Valid accounts are the common case, so the checks only compare and scan the attributes, and nothing is allocated or
+// formatted unless one of them fails.
+func ValidateResource(account Resource) error {
+	return ValidateResourceFor(account, APIVersion1)
 }
 
-func validateGR(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reSevenDigits)
-	r, err = bankIDCodeMust(r, err, GRBankID)
-	_, err = accountNumberOptionalMust(r, err, reSixteenDigits)
-
-	return err
-}
+// ValidateResourceFor works like ValidateResource, but with the rules of the given version of the API. Versions up to
+// APIVersion1, including 0, mean APIVersion1.
+func ValidateResourceFor(account Resource, version APIVersion) error {
+	fields := validateCountry(&account)
+	if version >= APIVersion2 {
+		fields = validateNames(&account, fields)
+	}
 
-func validateHK(account Resource) error {
-	r, err := ibanNotSupported(bicRequired(account, nil))
-	r, err = bankIDOptionalMust(r, err, reThreeDigits)
-	r, err = bankIDCodeOptionalMust(r, err, HKBankID)
-	_, err = accountNumberOptionalMust(r, err, reHKAccountNumber)
+	if fields != nil {
+		return &ValidationError{Err: fields, Fields: fields}
+	}
 
-	return err
+	return nil
 }
 
-func validateIT(account Resource) error {
-	var accErr error
-
-	accountPresent := false
-	reAccount := reTenDigits
+// validateCountry checks the account against the rules of its country in the registry. Every check runs, so the
+// findings list every rule the account broke. They are nil if it broke none.
+func validateCountry(r *Resource) fieldErrors {
+	rules, ok := rulesRegistry[r.Country]
+	if !ok {
+		return addFinding(nil, fieldCountry, fmt.Sprintf("unsupported country code: %s", r.Country))
+	}
 
-	// Account number optional, 12 characters, generated if not provided
-	if account.AccountNumber != "" {
-		accountPresent = true
+	var errs fieldErrors
 
-		if !reTwelveDigits.MatchString(account.AccountNumber) {
-			accErr = fmt.Errorf("account number was provided, but not 12 numbers: '%s'", account.AccountNumber)
-		}
+	if rules.bic == required {
+		errs = bicRequired(r, errs)
 	}
 
-	if accountPresent {
-		reAccount = reElevenDigits
+	if rules.iban == forbidden {
+		errs = ibanNotSupported(r, errs)
 	}
 
-	r, err := bankIDRequiredMust(account, accErr, reAccount)
-	_, err = bankIDCodeMust(r, err, ITBankID)
-
-	return err
-}
-
-func validateLU(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reThreeDigits)
-	r, err = bankIDCodeMust(r, err, LUBankID)
-	_, err = accountNumberOptionalMust(r, err, reThirteenDigits)
+	bankIDFormat := rules.bankIDFormat
+	if rules.bankIDWithAccountNumber != nil && r.AccountNumber != "" {
+		bankIDFormat = *rules.bankIDWithAccountNumber
+	}
 
-	return err
-}
+	switch rules.bankID {
+	case required:
+		errs = bankIDRequiredMust(r, errs, bankIDFormat)
+	case optional:
+		errs = bankIDOptionalMust(r, errs, bankIDFormat)
+	case forbidden:
+		errs = bankIDNotSupported(r, errs)
+	}
 
-func validateNL(account Resource) error {
-	r, err := bicRequired(bankIDNotSupported(account, nil))
-	r, err = bankIDCodeMust(r, err, "")
-	_, err = accountNumberOptionalMust(r, err, reTenDigits)
+	switch rules.bankIDCode {
+	case required:
+		errs = bankIDCodeMust(r, errs, rules.bankIDCodeWant)
+	case optional:
+		errs = bankIDCodeOptionalMust(r, errs, rules.bankIDCodeWant)
+	case forbidden:
+		errs = bankIDCodeMust(r, errs, "")
+	}
 
-	return err
+	return accountNumberOptionalMust(r, errs, rules.accountNumber)
 }
 
-func validatePL(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reEightDigits)
-	r, err = bankIDCodeMust(r, err, PLBankID)
-	_, err = accountNumberOptionalMust(r, err, reSixteenDigits)
+func bicRequired(r *Resource, errs fieldErrors) fieldErrors {
+	if r.BIC == "" {
+		return addFinding(errs, fieldBIC, "BIC is required, was empty")
+	}
 
-	return err
+	return errs
 }
 
-func validatePT(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reEightDigits)
-	r, err = bankIDCodeMust(r, err, PTBankID)
-	_, err = accountNumberOptionalMust(r, err, reElevenDigits)
+func ibanNotSupported(r *Resource, errs fieldErrors) fieldErrors {
+	if r.IBAN != "" {
+		return addFinding(errs, fieldIBAN, fmt.Sprintf("IBAN is not supported, got '%s'", Mask(r.IBAN)))
+	}
 
-	return err
+	return errs
 }
 
-func validateES(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reEightDigits)
-	r, err = bankIDCodeMust(r, err, ESBankID)
-	_, err = accountNumberOptionalMust(r, err, reTenDigits)
+func bankIDNotSupported(r *Resource, errs fieldErrors) fieldErrors {
+	if r.BankID != "" {
+		return addFinding(errs, fieldBankID, fmt.Sprintf("bank ID is not supported, has to be empty. Got '%s'", r.BankID))
+	}
 
-	return err
+	return errs
 }
 
-func validateCH(account Resource) error {
-	r, err := bankIDRequiredMust(account, nil, reFiveDigits)
-	r, err = bankIDCodeMust(r, err, CHBankID)
-	_, err = accountNumberOptionalMust(r, err, reTwelveDigits)
+func bankIDCodeMust(r *Resource, errs fieldErrors, bankIDCode string) fieldErrors {
+	if r.BankIDCode != bankIDCode {
+		return addFinding(errs, fieldBankIDCode, fmt.Sprintf("bank ID Code is not '%s', got %s", bankIDCode, r.BankIDCode))
+	}
 
-	return err
+	return errs
 }
 
-func validateUS(account Resource) error {
-	r, err := ibanNotSupported(bicRequired(account, nil))
-	r, err = accountNumberOptionalMust(r, err, reUSAccountNumber)
-	r, err = bankIDCodeMust(r, err, USBankID)
-	_, err = bankIDRequiredMust(r, err, reNineDigits)
-
-	return err
-}
+func bankIDCodeOptionalMust(r *Resource, errs fieldErrors, bankIDCode string) fieldErrors {
+	if r.BankIDCode != "" && r.BankIDCode != bankIDCode {
+		message := fmt.Sprintf("bank ID Code is not '%s', got '%s'", bankIDCode, r.BankIDCode)
 
-func bicRequired(r Resource, e error) (Resource, error) {
-	if r.BIC == "" {
-		return r, returnError("BIC is required, was empty", e)
+		return addFinding(errs, fieldBankIDCode, message)
 	}
 
-	return r, e
+	return errs
 }
 
-func ibanNotSupported(r Resource, e error) (Resource, error) {
-	if r.IBAN != "" {
-		return r, returnError(fmt.Sprintf("IBAN is not supported, got '%s'", r.IBAN), e)
+func bankIDRequiredMust(r *Resource, errs fieldErrors, format digits) fieldErrors {
+	if !format.match(r.BankID) {
+		return addFinding(errs, fieldBankID, fmt.Sprintf("%s bank id is not in correct format. '%s'", r.Country, r.BankID))
 	}
 
-	return r, e
+	return errs
 }
 
-func bankIDNotSupported(r Resource, e error) (Resource, error) {
-	if r.BankID != "" {
-		return r, returnError(fmt.Sprintf("bank ID is not supported, has to be empty. Got '%s'", r.BankID), e)
+func bankIDOptionalMust(r *Resource, errs fieldErrors, format digits) fieldErrors {
+	if r.BankID != "" && !format.match(r.BankID) {
+		return addFinding(errs, fieldBankID, fmt.Sprintf("%s bank id is not in correct format. '%s'", r.Country, r.BankID))
 	}
 
-	return r, e
+	return errs
 }
 
-func bankIDCodeMust(r Resource, e error, bankIDCode string) (Resource, error) {
-	if r.BankIDCode != bankIDCode {
-		return r, returnError(fmt.Sprintf("bank ID Code is not '%s', got %s", bankIDCode, r.BankIDCode), e)
-	}
-
-	return r, e
-}
+func accountNumberOptionalMust(r *Resource, errs fieldErrors, format digits) fieldErrors {
+	if r.AccountNumber != "" && !format.match(r.AccountNumber) {
+		message := fmt.Sprintf("%s account number is not in correct format. '%s'", r.Country, Mask(r.AccountNumber))
 
-func bankIDCodeOptionalMust(r Resource, e error, bankIDCode string) (Resource, error) {
-	if r.BankIDCode != "" && r.BankIDCode != bankIDCode {
-		return r, returnError(fmt.Sprintf("bank ID Code is not '%s', got '%s'", bankIDCode, r.BankIDCode), e)
+		return addFinding(errs, fieldAccountNumber, message)
 	}
 
-	return r, e
+	return errs
 }
 
-func bankIDRequiredMust(r Resource, e error, pattern *regexp.Regexp) (Resource, error) {
-	if !pattern.MatchString(r.BankID) {
-		return r, returnError(fmt.Sprintf("%s bank id is not in correct format. '%s'", r.Country, r.BankID), e)
+// validateNames checks the name and alternative names of the account against the rules of APIVersion2.
+func validateNames(r *Resource, errs fieldErrors) fieldErrors {
+	if r.Name[0] == "" {
+		errs = addFinding(errs, fieldName, "name[0] is required, was empty")
 	}
 
-	return r, e
-}
+	for i, line := range r.Name {
+		errs = lineLength(errs, fieldName, i, line)
+	}
 
-func bankIDOptionalMust(r Resource, e error, pattern *regexp.Regexp) (Resource, error) {
-	if r.BankID != "" && !pattern.MatchString(r.BankID) {
-		return r, returnError(fmt.Sprintf("%s bank id is not in correct format. '%s'", r.Country, r.BankID), e)
+	for i, line := range r.AlternativeNames {
+		errs = lineLength(errs, fieldAltNames, i, line)
 	}
 
-	return r, e
+	return errs
 }
 
-func accountNumberOptionalMust(r Resource, e error, pattern *regexp.Regexp) (Resource, error) {
-	if r.AccountNumber != "" && !pattern.MatchString(r.AccountNumber) {
-		return r, returnError(fmt.Sprintf("%s account number is not in correct format. '%s'", r.Country, r.AccountNumber), e)
+func lineLength(errs fieldErrors, field string, i int, line string) fieldErrors {
+	if len(line) > MaxNameLength && utf8.RuneCountInString(line) > MaxNameLength {
+		return addFinding(errs, field, fmt.Sprintf("%s[%d] is longer than %d characters", field, i, MaxNameLength))
 	}
 
-	return r, e
+	return errs
 }
 
-// returnError is a convenience function that handles optional wrapped errors. If the error passed into the func is nil,
-// the returned error will not try to wrap a nil error with fmt.Errorf.
-func returnError(message string, wrapped error) error {
-	if wrapped == nil {
-		return errors.New(message)
-	}
-
-	return fmt.Errorf(message+": %w", wrapped)
+// addFinding adds a finding for the field to the ones already in errs. It is only called once a check failed, so a
+// valid account never allocates the slice.
+func addFinding(errs fieldErrors, field, message string) fieldErrors {
+	return append(errs, FieldError{Field: field, Message: message})
 }
@@ -1,11 +1,14 @@
 package client_test
 
 import (
+	"errors"
+	"strings"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
 
 	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
 )
 
 func TestValidateResource(t *testing.T) {
@@ -2140,3 +2143,101 @@ func TestValidateResource(t *testing.T) {
 		})
 	}
 }
+
+func TestValidateResource_allocations(t *testing.T) {
+	for _, country := range client.SupportedCountries() {
+		account := fixtures.Valid(country)
+
+		allocs := testing.AllocsPerRun(100, func() {
+			_ = client.ValidateResourceFor(account, client.APIVersion2)
+		})
+
+		assert.Zero(t, allocs, "%s is valid, so nothing is allocated", country)
+	}
+
+	broken := fixtures.ValidGB()
+	broken.BIC = ""
+
+	allocs := testing.AllocsPerRun(100, func() {
+		_ = client.ValidateResource(broken)
+	})
+
+	assert.NotZero(t, allocs, "findings are only built when a check fails")
+}
+
+func TestValidateResourceFor(t *testing.T) {
+	long := strings.Repeat("é", client.MaxNameLength+1)
+
+	tests := []struct {
+		name     string
+		override fixtures.Override
+		version  client.APIVersion
+		want     []client.FieldError
+	}{
+		{
+			name:     "accepts an account without a name by default",
+			override: fixtures.WithName(),
+		},
+		{
+			name:     "accepts an account without a name in version 1",
+			override: fixtures.WithName(),
+			version:  client.APIVersion1,
+		},
+		{
+			name:     "requires the first line of the name in version 2",
+			override: fixtures.WithName("", "Jane Doe"),
+			version:  client.APIVersion2,
+			want:     []client.FieldError{{Field: "name", Message: "name[0] is required, was empty"}},
+		},
+		{
+			name:     "accepts a name of the maximum length in version 2",
+			override: fixtures.WithName(long[len("é"):]),
+			version:  client.APIVersion2,
+		},
+		{
+			name: "limits the length of every line in version 2",
+			override: func(r *client.Resource) {
+				r.Name = [4]string{"Jane Doe", "", long}
+				r.AlternativeNames = [3]string{long}
+			},
+			version: client.APIVersion2,
+			want: []client.FieldError{
+				{Field: "name", Message: "name[2] is longer than 140 characters"},
+				{Field: "alternative_names", Message: "alternative_names[0] is longer than 140 characters"},
+			},
+		},
+		{
+			name: "does not limit the length of lines in version 1",
+			override: func(r *client.Resource) {
+				r.Name = [4]string{long}
+			},
+			version: client.APIVersion1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			account := fixtures.ValidGB(tt.override)
+
+			err := client.ValidateResourceFor(account, tt.version)
+			if tt.want == nil {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			var validationErr *client.ValidationError
+
+			assert.True(t, errors.As(err, &validationErr))
+			assert.Equal(t, tt.want, validationErr.Fields)
+		})
+	}
+}
+
+func TestWithAPIVersion(t *testing.T) {
+	c := client.Client{BaseURL: "http://127.0.0.1:0"}
+	client.WithAPIVersion(client.APIVersion2)(&c)
+
+	_, err := c.Create(fixtures.ValidGB(fixtures.WithName()))
+	assert.True(t, errors.Is(err, client.ErrValidation), "fails before anything is sent: %v", err)
+	assert.Contains(t, err.Error(), "name[0] is required, was empty")
+}
@@ -0,0 +1,5 @@
+package client
+
+// Version is the version of the client library. Release builds stamp it with
+// -ldflags "-X github.com/javorszky/form3takehome/pkg/client.Version=v1.2.3", see the build target of the Makefile.
+var Version = "dev" //nolint:gochecknoglobals // set with -ldflags at build time
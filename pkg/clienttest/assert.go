@@ -0,0 +1,28 @@
+package clienttest
+
+import (
+	"strings"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// AssertPayload fails t if got is not the same as want, with only the values that differ, one per line, instead of a
+// dump of both Payloads. It reports whether they are the same.
+func AssertPayload(t TestingT, want, got client.Payload) bool {
+	t.Helper()
+
+	changes := client.Diff(want, got)
+	if len(changes) == 0 {
+		return true
+	}
+
+	lines := make([]string, 0, len(changes))
+
+	for _, c := range changes {
+		lines = append(lines, "\t"+c.String())
+	}
+
+	t.Errorf("clienttest: payloads differ, want -> got:\n%s", strings.Join(lines, "\n"))
+
+	return false
+}
@@ -0,0 +1,31 @@
+package clienttest_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/clienttest"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestAssertPayload(t *testing.T) {
+	want := client.Payload{Data: client.Data{ID: accountID, Version: 1, Attributes: fixtures.ValidGB()}}
+
+	var r recorder
+
+	assert.True(t, clienttest.AssertPayload(&r, want, want))
+	assert.Empty(t, r.failures)
+
+	got := want
+	got.Data.Version = 2
+	got.Data.Attributes.BIC = "NWBKGB22"
+
+	assert.False(t, clienttest.AssertPayload(&r, want, got))
+	assert.Equal(t, []string{
+		"clienttest: payloads differ, want -> got:\n" +
+			"\tdata.attributes.bic: \"" + want.Data.Attributes.BIC + "\" -> \"NWBKGB22\"\n" +
+			"\tdata.version: 1 -> 2",
+	}, r.failures)
+}
@@ -0,0 +1,41 @@
+package clienttest
+
+import (
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// DeterministicTime is the time a Client configured with Deterministic thinks it always is.
+var DeterministicTime = time.Date(2021, time.January, 1, 12, 0, 0, 0, time.UTC)
+
+// Deterministic makes a Client send the same requests every time a test runs, so they can be compared against golden
+// files: the clock is stopped at DeterministicTime, and the IDs of accounts and requests are generated from seed, in
+// the order the Client needs them. Every Client the option is applied to starts over from seed, and copies of a
+// Client share its sequence. IDs are only reproducible if the Client is used from a single goroutine.
+func Deterministic(seed int64) client.Option {
+	return func(c *client.Client) {
+		ids := &idSequence{rnd: rand.New(rand.NewSource(seed))} //nolint:gosec // the IDs have to be reproducible
+
+		client.WithClock(func() time.Time { return DeterministicTime })(c)
+		client.WithIDGenerator(ids.next)(c)
+	}
+}
+
+// idSequence generates random UUIDs from a seeded generator.
+type idSequence struct {
+	mu  sync.Mutex
+	rnd *rand.Rand
+}
+
+// next returns the next UUID of the sequence.
+func (s *idSequence) next() (uuid.UUID, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return uuid.NewRandomFromReader(s.rnd)
+}
@@ -0,0 +1,54 @@
+package clienttest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/http/httputil"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/clienttest"
+	"github.com/javorszky/form3takehome/pkg/config"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestDeterministic(t *testing.T) {
+	var requests []string
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		dump, err := httputil.DumpRequest(r, true)
+		assert.NoError(t, err)
+
+		requests = append(requests, string(dump))
+
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer ts.Close()
+
+	send := func(seed int64) []string {
+		requests = nil
+
+		c, err := client.New(config.Config{AccountsAPIURL: ts.URL}, http.Client{}, clienttest.Deterministic(seed))
+		assert.NoError(t, err)
+
+		_, _ = c.Create(fixtures.ValidGB())
+		_, _ = c.Create(fixtures.ValidDE())
+		_ = c.Delete("ad27e265-9605-4b4b-a0e5-3003ea9cc4dc", 1)
+
+		return requests
+	}
+
+	first := send(42)
+	if !assert.Len(t, first, 3) {
+		return
+	}
+
+	assert.Equal(t, first, send(42), "the same seed sends the same requests")
+	assert.NotEqual(t, first, send(7), "another seed sends other IDs")
+
+	for _, r := range first {
+		assert.Contains(t, r, "Date: Fri, 01 Jan 2021 12:00:00 GMT\r\n")
+	}
+}
@@ -0,0 +1,98 @@
+package clienttest
+
+import (
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Distribution returns how long to delay the next request. It is called from every goroutine that sends requests
+// through a LatencyTransport, so it has to be safe for concurrent use.
+type Distribution func() time.Duration
+
+// Fixed delays every request by d.
+func Fixed(d time.Duration) Distribution {
+	return func() time.Duration {
+		return d
+	}
+}
+
+// Sequence delays requests by the durations in order, and every request after the last one by the last one, to
+// script slow responses the way Server scripts responses. No durations means no delay.
+func Sequence(durations ...time.Duration) Distribution {
+	var (
+		mu   sync.Mutex
+		next int
+	)
+
+	return func() time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if len(durations) == 0 {
+			return 0
+		}
+
+		d := durations[next]
+		if next < len(durations)-1 {
+			next++
+		}
+
+		return d
+	}
+}
+
+// Uniform delays requests by durations spread evenly between min and max, generated from seed, so a test sees the same
+// delays every run.
+func Uniform(min, max time.Duration, seed int64) Distribution {
+	var mu sync.Mutex
+
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec // the delays have to be reproducible
+
+	return func() time.Duration {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if max <= min {
+			return min
+		}
+
+		return min + time.Duration(rnd.Int63n(int64(max-min)+1))
+	}
+}
+
+// LatencyTransport returns an http.RoundTripper that delays every request by the Distribution before sending it with
+// next, or http.DefaultTransport if next is nil, to test timeouts and backoff against a slow network. A request whose
+// context is done while it is delayed fails with the error of the context, like it would on a slow connection. Pass it
+// to client.WithTransport.
+func LatencyTransport(next http.RoundTripper, latency Distribution) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+
+	return latencyTransport{next: next, latency: latency}
+}
+
+type latencyTransport struct {
+	next    http.RoundTripper
+	latency Distribution
+}
+
+// RoundTrip waits for the delay, then sends the request.
+func (t latencyTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	timer := time.NewTimer(t.latency())
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+	case <-req.Context().Done():
+		if req.Body != nil {
+			_ = req.Body.Close()
+		}
+
+		return nil, req.Context().Err()
+	}
+
+	return t.next.RoundTrip(req)
+}
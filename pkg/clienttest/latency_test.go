@@ -0,0 +1,93 @@
+package clienttest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/clienttest"
+)
+
+func TestDistributions(t *testing.T) {
+	draw := func(d clienttest.Distribution, n int) []time.Duration {
+		got := make([]time.Duration, 0, n)
+		for i := 0; i < n; i++ {
+			got = append(got, d())
+		}
+
+		return got
+	}
+
+	assert.Equal(t, []time.Duration{time.Second, time.Second}, draw(clienttest.Fixed(time.Second), 2))
+	assert.Equal(t, []time.Duration{0, 0}, draw(clienttest.Sequence(), 2))
+	assert.Equal(t,
+		[]time.Duration{time.Second, time.Millisecond, time.Millisecond},
+		draw(clienttest.Sequence(time.Second, time.Millisecond), 3),
+	)
+	assert.Equal(t, []time.Duration{time.Second}, draw(clienttest.Uniform(time.Second, time.Millisecond, 1), 1))
+
+	uniform := draw(clienttest.Uniform(10*time.Millisecond, 20*time.Millisecond, 1), 100)
+	for _, d := range uniform {
+		assert.True(t, d >= 10*time.Millisecond && d <= 20*time.Millisecond, "%s out of range", d)
+	}
+
+	assert.Equal(t, uniform, draw(clienttest.Uniform(10*time.Millisecond, 20*time.Millisecond, 1), 100))
+	assert.NotEqual(t, uniform, draw(clienttest.Uniform(10*time.Millisecond, 20*time.Millisecond, 2), 100))
+}
+
+func TestLatencyTransport(t *testing.T) {
+	s := clienttest.NewServer(t)
+	defer s.Close()
+
+	s.ExpectGET("/v1/organisation/accounts/" + accountID).RespondFile(payload)
+
+	c := client.Client{BaseURL: s.URL}
+	c.HttpClient.Timeout = 50 * time.Millisecond
+	client.WithTransport(clienttest.LatencyTransport(nil, clienttest.Sequence(time.Hour, time.Millisecond)))(&c)
+
+	start := time.Now()
+	_, err := c.Fetch(accountID)
+
+	var urlErr *url.Error
+	if assert.True(t, errors.As(err, &urlErr)) {
+		assert.True(t, urlErr.Timeout())
+	}
+
+	assert.Less(t, int64(time.Since(start)), int64(time.Second), "gives up with the request")
+
+	_, err = c.Fetch(accountID)
+	assert.NoError(t, err)
+
+	s.AssertExpectations(t)
+}
+
+func TestServer_SkewClock(t *testing.T) {
+	s := clienttest.NewServer(t).SkewClock(-time.Hour)
+	defer s.Close()
+
+	s.ExpectGET("/skewed")
+	s.ExpectGET("/fixed").Header("Date", "Fri, 01 Jan 2021 12:00:00 GMT")
+
+	resp, err := http.Get(s.URL + "/skewed")
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+
+		date, err := http.ParseTime(resp.Header.Get("Date"))
+		assert.NoError(t, err)
+		assert.WithinDuration(t, time.Now().Add(-time.Hour), date, 2*time.Second)
+	}
+
+	resp, err = http.Get(s.URL + "/fixed")
+	if assert.NoError(t, err) {
+		_ = resp.Body.Close()
+
+		assert.Equal(t, "Fri, 01 Jan 2021 12:00:00 GMT", resp.Header.Get("Date"))
+	}
+
+	s.AssertExpectations(t)
+}
@@ -0,0 +1,406 @@
+// Package clienttest provides test doubles for code that talks to the accounts API through client.API, so it can be
+// tested without a running service.
+package clienttest
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// ErrUnexpectedCall is matched by the error a MockClient returns for a call that matches none of its expectations.
+var ErrUnexpectedCall = errors.New("unexpected call")
+
+// TestingT is the part of *testing.T that AssertExpectations needs.
+type TestingT interface {
+	Helper()
+	Errorf(format string, args ...interface{})
+}
+
+// Matcher matches a single argument of a call.
+type Matcher interface {
+	Matches(arg interface{}) bool
+	String() string
+}
+
+// MockClient is a client.API that answers calls with canned responses set up as expectations with On, and records
+// calls nobody expected. It is safe to use from several goroutines at once.
+type MockClient struct {
+	mu           sync.Mutex
+	expectations []*Call
+	ordered      bool
+	next         int
+	unexpected   []string
+}
+
+var _ client.API = &MockClient{}
+
+// Call is a single expectation of a MockClient: the method, the arguments it has to be called with, what it returns,
+// and how many times it may be called.
+type Call struct {
+	method   string
+	args     []interface{}
+	returns  []interface{}
+	times    int
+	anyTimes bool
+	calls    int
+}
+
+// NewMockClient returns a MockClient without expectations, which fails every call.
+func NewMockClient() *MockClient {
+	return &MockClient{}
+}
+
+// InOrder makes the MockClient require its expectations to be met in the order they were added with On. An
+// expectation may be skipped once it was called as many times as it has to.
+func (m *MockClient) InOrder() *MockClient {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.ordered = true
+
+	return m
+}
+
+// On adds an expectation of a call to the method with the given name, like Fetch, with arguments that match args. An
+// argument can be a Matcher, or a value the argument has to be deeply equal to. The expectation is met once by
+// default.
+func (m *MockClient) On(method string, args ...interface{}) *Call {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := &Call{method: method, args: args, times: 1}
+	m.expectations = append(m.expectations, c)
+
+	return c
+}
+
+// Return sets what the call returns, in the order the method returns them: a client.Payload or client.MultiPayload
+// and an error, or only an error for Delete. Values left out are returned as their zero value.
+func (c *Call) Return(values ...interface{}) *Call {
+	c.returns = values
+
+	return c
+}
+
+// Times sets how many times the call has to be made.
+func (c *Call) Times(n int) *Call {
+	c.times, c.anyTimes = n, false
+
+	return c
+}
+
+// AnyTimes lets the call be made any number of times, none included.
+func (c *Call) AnyTimes() *Call {
+	c.anyTimes = true
+
+	return c
+}
+
+// AssertExpectations fails t for every expectation that was called fewer times than it has to, and for every call
+// that matched no expectation. It reports whether everything was as expected.
+func (m *MockClient) AssertExpectations(t TestingT) bool {
+	t.Helper()
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	ok := true
+
+	for _, c := range m.expectations {
+		if !c.satisfied() {
+			t.Errorf("clienttest: expected %s to be called %d times, was called %d times", c, c.times, c.calls)
+
+			ok = false
+		}
+	}
+
+	for _, u := range m.unexpected {
+		t.Errorf("clienttest: %s", u)
+
+		ok = false
+	}
+
+	return ok
+}
+
+// Create returns what the matching expectation of Create returns.
+func (m *MockClient) Create(account client.Resource) (client.Payload, error) {
+	return m.payload(m.called("Create", account))
+}
+
+// CreateWithID returns what the matching expectation of CreateWithID returns.
+func (m *MockClient) CreateWithID(id string, account client.Resource) (client.Payload, error) {
+	return m.payload(m.called("CreateWithID", id, account))
+}
+
+// Fetch returns what the matching expectation of Fetch returns.
+func (m *MockClient) Fetch(accountID string) (client.Payload, error) {
+	return m.payload(m.called("Fetch", accountID))
+}
+
+// List returns what the matching expectation of List returns.
+func (m *MockClient) List(pageNumber, pageSize uint) (client.MultiPayload, error) {
+	return m.multiPayload(m.called("List", pageNumber, pageSize))
+}
+
+// ListFiltered returns what the matching expectation of ListFiltered returns.
+func (m *MockClient) ListFiltered(filter client.Filter, pageNumber, pageSize uint) (client.MultiPayload, error) {
+	return m.multiPayload(m.called("ListFiltered", filter, pageNumber, pageSize))
+}
+
+// Update returns what the matching expectation of Update returns.
+func (m *MockClient) Update(accountID string, version uint, attributes map[string]interface{}) (client.Payload, error) {
+	return m.payload(m.called("Update", accountID, version, attributes))
+}
+
+// Delete returns what the matching expectation of Delete returns.
+func (m *MockClient) Delete(accountID string, version uint) error {
+	returns, err := m.called("Delete", accountID, version)
+	if err != nil {
+		return err
+	}
+
+	return returned(returns, 0)
+}
+
+// called finds the expectation the call matches, counts the call, and returns what the expectation returns. A call that
+// matches none is recorded for AssertExpectations and fails with ErrUnexpectedCall.
+func (m *MockClient) called(method string, args ...interface{}) ([]interface{}, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	c := m.match(method, args)
+	if c == nil {
+		msg := fmt.Sprintf("%s(%s)", method, formatArgs(args))
+		m.unexpected = append(m.unexpected, ErrUnexpectedCall.Error()+": "+msg)
+
+		return nil, fmt.Errorf("%w: %s", ErrUnexpectedCall, msg)
+	}
+
+	c.calls++
+
+	return c.returns, nil
+}
+
+// match returns the expectation the call matches, or nil. In order, only the next expectation, or ones after it that
+// every expectation before them was satisfied for, can match.
+func (m *MockClient) match(method string, args []interface{}) *Call {
+	if !m.ordered {
+		for _, c := range m.expectations {
+			if !c.exhausted() && c.matches(method, args) {
+				return c
+			}
+		}
+
+		return nil
+	}
+
+	for i := m.next; i < len(m.expectations); i++ {
+		c := m.expectations[i]
+
+		if !c.exhausted() && c.matches(method, args) {
+			m.next = i
+
+			return c
+		}
+
+		if !c.satisfied() {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// payload converts what an expectation returns to the results of a method that returns a single account.
+func (m *MockClient) payload(returns []interface{}, err error) (client.Payload, error) {
+	if err != nil {
+		return client.Payload{}, err
+	}
+
+	p, _ := valueAt(returns, 0).(client.Payload)
+
+	return p, returned(returns, 1)
+}
+
+// multiPayload converts what an expectation returns to the results of a method that returns a page of accounts.
+func (m *MockClient) multiPayload(returns []interface{}, err error) (client.MultiPayload, error) {
+	if err != nil {
+		return client.MultiPayload{}, err
+	}
+
+	mp, _ := valueAt(returns, 0).(client.MultiPayload)
+
+	return mp, returned(returns, 1)
+}
+
+// matches reports whether the call is of the method, with arguments that match the expected ones.
+func (c *Call) matches(method string, args []interface{}) bool {
+	if c.method != method || len(c.args) != len(args) {
+		return false
+	}
+
+	for i, want := range c.args {
+		if !toMatcher(want).Matches(args[i]) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// satisfied reports whether the call was made as many times as it has to.
+func (c *Call) satisfied() bool {
+	return c.anyTimes || c.calls >= c.times
+}
+
+// exhausted reports whether the call may not be made again.
+func (c *Call) exhausted() bool {
+	return !c.anyTimes && c.calls >= c.times
+}
+
+// String describes the expected call.
+func (c *Call) String() string {
+	matchers := make([]string, 0, len(c.args))
+
+	for _, a := range c.args {
+		matchers = append(matchers, toMatcher(a).String())
+	}
+
+	return fmt.Sprintf("%s(%s)", c.method, strings.Join(matchers, ", "))
+}
+
+// valueAt returns the value at index i, or nil if there are fewer values.
+func valueAt(values []interface{}, i int) interface{} {
+	if i >= len(values) {
+		return nil
+	}
+
+	return values[i]
+}
+
+// returned returns the error at index i, or nil if there is none.
+func returned(values []interface{}, i int) error {
+	err, _ := valueAt(values, i).(error)
+
+	return err
+}
+
+// formatArgs formats the arguments of an unexpected call.
+func formatArgs(args []interface{}) string {
+	formatted := make([]string, 0, len(args))
+
+	for _, a := range args {
+		formatted = append(formatted, fmt.Sprintf("%#v", a))
+	}
+
+	return strings.Join(formatted, ", ")
+}
+
+// toMatcher returns the argument if it is a Matcher, and an Eq of it otherwise.
+func toMatcher(arg interface{}) Matcher {
+	if m, ok := arg.(Matcher); ok {
+		return m
+	}
+
+	return Eq(arg)
+}
+
+// Any matches every argument.
+func Any() Matcher {
+	return anyMatcher{}
+}
+
+// Eq matches arguments deeply equal to value.
+func Eq(value interface{}) Matcher {
+	return eqMatcher{value: value}
+}
+
+// MatchedBy matches arguments fn returns true for. fn has to take a single argument of the type of the argument it
+// matches, like func(r client.Resource) bool, or it matches nothing.
+func MatchedBy(fn interface{}) Matcher {
+	return funcMatcher{fn: reflect.ValueOf(fn)}
+}
+
+type anyMatcher struct{}
+
+// Matches returns true.
+func (anyMatcher) Matches(interface{}) bool {
+	return true
+}
+
+// String describes the matcher.
+func (anyMatcher) String() string {
+	return "Any()"
+}
+
+type eqMatcher struct {
+	value interface{}
+}
+
+// Matches reports whether arg is deeply equal to the value. Numbers of different types are compared by value, so an
+// untyped constant like 2 matches the uint version of Delete.
+func (m eqMatcher) Matches(arg interface{}) bool {
+	want, got := reflect.ValueOf(m.value), reflect.ValueOf(arg)
+	if want.IsValid() && got.IsValid() && want.Type() != got.Type() && isNumber(want) && isNumber(got) &&
+		want.Type().ConvertibleTo(got.Type()) {
+		return reflect.DeepEqual(want.Convert(got.Type()).Interface(), arg) &&
+			reflect.DeepEqual(got.Convert(want.Type()).Interface(), m.value)
+	}
+
+	return reflect.DeepEqual(m.value, arg)
+}
+
+// isNumber reports whether v is an integer or a floating point number.
+func isNumber(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr,
+		reflect.Float32, reflect.Float64:
+		return true
+	}
+
+	return false
+}
+
+// String describes the matcher.
+func (m eqMatcher) String() string {
+	return fmt.Sprintf("%#v", m.value)
+}
+
+type funcMatcher struct {
+	fn reflect.Value
+}
+
+// Matches calls the function with arg if it takes an argument of its type and returns a bool.
+func (m funcMatcher) Matches(arg interface{}) bool {
+	if m.fn.Kind() != reflect.Func {
+		return false
+	}
+
+	t := m.fn.Type()
+	if t.NumIn() != 1 || t.NumOut() != 1 || t.Out(0).Kind() != reflect.Bool {
+		return false
+	}
+
+	v := reflect.ValueOf(arg)
+	if !v.IsValid() || !v.Type().AssignableTo(t.In(0)) {
+		return false
+	}
+
+	return m.fn.Call([]reflect.Value{v})[0].Bool()
+}
+
+// String describes the matcher.
+func (m funcMatcher) String() string {
+	if m.fn.Kind() != reflect.Func {
+		return "MatchedBy(<not a function>)"
+	}
+
+	return fmt.Sprintf("MatchedBy(%s)", m.fn.Type())
+}
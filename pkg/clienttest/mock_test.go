@@ -0,0 +1,128 @@
+package clienttest_test
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/clienttest"
+)
+
+// recorder is a clienttest.TestingT that keeps the failures instead of failing the test.
+type recorder struct {
+	failures []string
+}
+
+func (r *recorder) Helper() {}
+
+func (r *recorder) Errorf(format string, args ...interface{}) {
+	r.failures = append(r.failures, fmt.Sprintf(format, args...))
+}
+
+func TestMockClient(t *testing.T) {
+	payload := client.Payload{Data: client.Data{ID: "id", Version: 2}}
+
+	tests := []struct {
+		name         string
+		setup        func(m *clienttest.MockClient)
+		exercise     func(t *testing.T, api client.API)
+		wantFailures []string
+	}{
+		{
+			name: "returns canned responses to matching calls",
+			setup: func(m *clienttest.MockClient) {
+				m.On("Fetch", "id").Return(payload, nil)
+				m.On("Delete", "id", 2).Return(client.ErrConflict)
+				m.On("List", clienttest.Any(), uint(10)).Return(client.MultiPayload{Data: []client.Data{payload.Data}})
+			},
+			exercise: func(t *testing.T, api client.API) {
+				got, err := api.Fetch("id")
+				assert.NoError(t, err)
+				assert.Equal(t, payload, got)
+
+				assert.True(t, errors.Is(api.Delete("id", 2), client.ErrConflict))
+
+				page, err := api.List(3, 10)
+				assert.NoError(t, err)
+				assert.Len(t, page.Data, 1)
+			},
+		},
+		{
+			name: "matches arguments with functions",
+			setup: func(m *clienttest.MockClient) {
+				m.On("Create", clienttest.MatchedBy(func(r client.Resource) bool {
+					return r.Country == "GB"
+				})).Return(payload, nil).Times(2)
+			},
+			exercise: func(t *testing.T, api client.API) {
+				for i := 0; i < 2; i++ {
+					got, err := api.Create(client.Resource{Country: "GB", BIC: fmt.Sprint(i)})
+					assert.NoError(t, err)
+					assert.Equal(t, payload, got)
+				}
+
+				_, err := api.Create(client.Resource{Country: "GB"})
+				assert.True(t, errors.Is(err, clienttest.ErrUnexpectedCall))
+			},
+			wantFailures: []string{`clienttest: unexpected call: Create(client.Resource{Country:"GB"`},
+		},
+		{
+			name: "reports calls that were not made",
+			setup: func(m *clienttest.MockClient) {
+				m.On("Fetch", "id").Times(2)
+				m.On("Fetch", "other").AnyTimes()
+			},
+			exercise: func(t *testing.T, api client.API) {
+				_, _ = api.Fetch("id")
+			},
+			wantFailures: []string{`clienttest: expected Fetch("id") to be called 2 times, was called 1 times`},
+		},
+		{
+			name: "requires calls in the order of the expectations with InOrder",
+			setup: func(m *clienttest.MockClient) {
+				m.InOrder()
+				m.On("Fetch", "id").Return(payload, nil)
+				m.On("Update", "id", 2, clienttest.Any()).Return(payload, nil)
+				m.On("Delete", "id", 3)
+			},
+			exercise: func(t *testing.T, api client.API) {
+				assert.True(t, errors.Is(api.Delete("id", 3), clienttest.ErrUnexpectedCall), "too early")
+
+				_, err := api.Fetch("id")
+				assert.NoError(t, err)
+
+				_, err = api.Update("id", 2, map[string]interface{}{"bic": "NWBKGB22"})
+				assert.NoError(t, err)
+
+				assert.NoError(t, api.Delete("id", 3))
+
+				_, err = api.Fetch("id")
+				assert.True(t, errors.Is(err, clienttest.ErrUnexpectedCall), "too late")
+			},
+			wantFailures: []string{
+				`clienttest: unexpected call: Delete("id", 0x3)`,
+				`clienttest: unexpected call: Fetch("id")`,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := clienttest.NewMockClient()
+			tt.setup(m)
+			tt.exercise(t, m)
+
+			var r recorder
+
+			assert.Equal(t, len(tt.wantFailures) == 0, m.AssertExpectations(&r))
+
+			if assert.Len(t, r.failures, len(tt.wantFailures), "failures: %v", r.failures) {
+				for i, want := range tt.wantFailures {
+					assert.Contains(t, r.failures[i], want)
+				}
+			}
+		})
+	}
+}
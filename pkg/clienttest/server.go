@@ -0,0 +1,282 @@
+package clienttest
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+)
+
+// garbageBody is what GarbageBody responds with: neither JSON nor complete.
+const garbageBody = `{"data": {"id": "ad27e265-9605-4b4b-a0e5-3003ea9cc4dc", "attrib`
+
+// Server is an HTTP server that answers requests following a script of expected requests, set up with Expect or one of
+// its shorthands, and the responses to them. Requests have to arrive in the order of the script. A request that does
+// not, or that arrives after the script ran out, gets a 500 Internal Server Error and is reported by
+// AssertExpectations.
+//
+// Point a Client at URL, and Close the Server once the test is done.
+type Server struct {
+	URL string
+
+	t          TestingT
+	server     *httptest.Server
+	done       chan struct{}
+	mu         sync.Mutex
+	steps      []*Step
+	next       int
+	unexpected []string
+	skew       time.Duration
+}
+
+// Step is a single expected request of a Server script and the response to it. By default it responds with 200 OK
+// and an empty body, once.
+type Step struct {
+	t      TestingT
+	method string
+	path   string
+	status int
+	header http.Header
+	body   []byte
+	delay  time.Duration
+	hang   bool
+	times  int
+	calls  int
+}
+
+// NewServer starts a Server with an empty script, which answers every request with an error. Failures to set up the
+// script, like a file for RespondFile that cannot be read, are reported to t.
+func NewServer(t TestingT) *Server {
+	s := &Server{t: t, done: make(chan struct{})}
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+
+	return s
+}
+
+// Close releases requests that hang, and shuts the Server down.
+func (s *Server) Close() {
+	close(s.done)
+	s.server.Close()
+}
+
+// Expect adds a request with the method to the path to the script. The path matches the path of the request, and its
+// query too if the path has one, like "/v1/organisation/accounts/id?version=0".
+func (s *Server) Expect(method, path string) *Step {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	st := &Step{t: s.t, method: method, path: path, status: http.StatusOK, header: http.Header{}, times: 1}
+	s.steps = append(s.steps, st)
+
+	return st
+}
+
+// ExpectGET adds a GET request to the path to the script.
+func (s *Server) ExpectGET(path string) *Step {
+	return s.Expect(http.MethodGet, path)
+}
+
+// ExpectPOST adds a POST request to the path to the script.
+func (s *Server) ExpectPOST(path string) *Step {
+	return s.Expect(http.MethodPost, path)
+}
+
+// ExpectPATCH adds a PATCH request to the path to the script.
+func (s *Server) ExpectPATCH(path string) *Step {
+	return s.Expect(http.MethodPatch, path)
+}
+
+// ExpectDELETE adds a DELETE request to the path to the script.
+func (s *Server) ExpectDELETE(path string) *Step {
+	return s.Expect(http.MethodDelete, path)
+}
+
+// Status sets the status code of the response.
+func (st *Step) Status(code int) *Step {
+	st.status = code
+
+	return st
+}
+
+// Header sets a header of the response.
+func (st *Step) Header(key, value string) *Step {
+	st.header.Set(key, value)
+
+	return st
+}
+
+// Respond sets the body of the response.
+func (st *Step) Respond(body string) *Step {
+	st.body = []byte(body)
+
+	return st
+}
+
+// RespondFile sets the body of the response to the contents of the file, like "testdata/payload.json".
+func (st *Step) RespondFile(filename string) *Step {
+	st.t.Helper()
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		st.t.Errorf("clienttest: reading response file: %s", err)
+	}
+
+	st.body = b
+
+	return st
+}
+
+// GarbageBody sets the body of the response to a truncated document that is not valid JSON, to test how the Client
+// copes with a broken response.
+func (st *Step) GarbageBody() *Step {
+	st.body = []byte(garbageBody)
+
+	return st
+}
+
+// Delay makes the Server wait d before it responds, to make the Client time out.
+func (st *Step) Delay(d time.Duration) *Step {
+	st.delay = d
+
+	return st
+}
+
+// Hang makes the Server never respond, until the Client gives up on the request or the Server is closed.
+func (st *Step) Hang() *Step {
+	st.hang = true
+
+	return st
+}
+
+// Times makes the request expected n times in a row, with the same response, like for retries.
+func (st *Step) Times(n int) *Step {
+	st.times = n
+
+	return st
+}
+
+// String describes the expected request.
+func (st *Step) String() string {
+	return st.method + " " + st.path
+}
+
+// SkewClock makes the Server send a Date header that is d ahead of the real time, or behind if d is negative, with
+// every response, to test how the Client copes with a service whose clock is off. A Date set with Header on a step
+// takes precedence.
+func (s *Server) SkewClock(d time.Duration) *Server {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.skew = d
+
+	return s
+}
+
+// AssertExpectations fails t for every request of the script that did not arrive as many times as expected, and for
+// every request that was not expected. It reports whether everything was as expected.
+func (s *Server) AssertExpectations(t TestingT) bool {
+	t.Helper()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	ok := true
+
+	for _, st := range s.steps {
+		if st.calls < st.times {
+			t.Errorf("clienttest: expected %s %d times, got it %d times", st, st.times, st.calls)
+
+			ok = false
+		}
+	}
+
+	for _, u := range s.unexpected {
+		t.Errorf("clienttest: %s", u)
+
+		ok = false
+	}
+
+	return ok
+}
+
+// serveHTTP answers a request with the response of the next step of the script, if the request matches it.
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	st := s.step(r)
+	if st == nil {
+		http.Error(w, "clienttest: unexpected request", http.StatusInternalServerError)
+
+		return
+	}
+
+	if st.hang {
+		select {
+		case <-r.Context().Done():
+		case <-s.done:
+		}
+
+		return
+	}
+
+	if st.delay > 0 {
+		select {
+		case <-time.After(st.delay):
+		case <-r.Context().Done():
+			return
+		case <-s.done:
+			return
+		}
+	}
+
+	w.Header().Set("Date", s.now().Format(http.TimeFormat))
+
+	for k, v := range st.header {
+		w.Header()[k] = v
+	}
+
+	w.WriteHeader(st.status)
+	_, _ = w.Write(st.body)
+}
+
+// now returns the time of the clock of the Server, with the skew set by SkewClock.
+func (s *Server) now() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return time.Now().Add(s.skew).UTC()
+}
+
+// step returns the next step of the script if the request matches it, and counts the request. A request that does not
+// match is recorded for AssertExpectations.
+func (s *Server) step(r *http.Request) *Step {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for s.next < len(s.steps) && s.steps[s.next].calls >= s.steps[s.next].times {
+		s.next++
+	}
+
+	if s.next < len(s.steps) && s.steps[s.next].matches(r) {
+		st := s.steps[s.next]
+		st.calls++
+
+		return st
+	}
+
+	s.unexpected = append(s.unexpected, fmt.Sprintf("unexpected request: %s %s", r.Method, r.URL.RequestURI()))
+
+	return nil
+}
+
+// matches reports whether the request has the method and path of the step.
+func (st *Step) matches(r *http.Request) bool {
+	path := r.URL.Path
+	if strings.Contains(st.path, "?") {
+		path = r.URL.RequestURI()
+	}
+
+	return r.Method == st.method && path == st.path
+}
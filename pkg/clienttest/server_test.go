@@ -0,0 +1,134 @@
+package clienttest_test
+
+import (
+	"errors"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/clienttest"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+const (
+	accountID = "a6c1a721-bb1b-41ef-bd11-800a1309ff9b"
+	payload   = "../client/testdata/payload.json"
+)
+
+func TestServer(t *testing.T) {
+	tests := []struct {
+		name         string
+		script       func(s *clienttest.Server)
+		exercise     func(t *testing.T, c client.Client)
+		wantFailures []string
+	}{
+		{
+			name: "answers the requests of the script",
+			script: func(s *clienttest.Server) {
+				s.ExpectPOST("/v1/organisation/accounts").RespondFile(payload).Status(http.StatusCreated)
+				s.ExpectGET("/v1/organisation/accounts/" + accountID).RespondFile(payload)
+				s.ExpectDELETE("/v1/organisation/accounts/" + accountID + "?version=0").Status(http.StatusNoContent)
+			},
+			exercise: func(t *testing.T, c client.Client) {
+				created, err := c.Create(fixtures.ValidGB())
+				assert.NoError(t, err)
+				assert.Equal(t, accountID, created.Data.ID)
+
+				fetched, err := c.Fetch(accountID)
+				assert.NoError(t, err)
+				assert.Equal(t, created, fetched)
+
+				assert.NoError(t, c.Delete(accountID, 0))
+			},
+		},
+		{
+			name: "responds with garbage",
+			script: func(s *clienttest.Server) {
+				s.ExpectGET("/v1/organisation/accounts/" + accountID).GarbageBody()
+			},
+			exercise: func(t *testing.T, c client.Client) {
+				_, err := c.Fetch(accountID)
+				assert.Error(t, err)
+			},
+		},
+		{
+			name: "hangs until the client times out",
+			script: func(s *clienttest.Server) {
+				s.ExpectGET("/v1/organisation/accounts/" + accountID).Hang()
+				s.ExpectGET("/v1/organisation/accounts/" + accountID).Delay(time.Second)
+			},
+			exercise: func(t *testing.T, c client.Client) {
+				c.HttpClient.Timeout = 20 * time.Millisecond
+
+				for i := 0; i < 2; i++ {
+					_, err := c.Fetch(accountID)
+
+					var urlErr *url.Error
+					if assert.True(t, errors.As(err, &urlErr)) {
+						assert.True(t, urlErr.Timeout())
+					}
+				}
+			},
+		},
+		{
+			name: "repeats responses for retries",
+			script: func(s *clienttest.Server) {
+				s.ExpectGET("/v1/organisation/accounts/"+accountID).Status(http.StatusServiceUnavailable).
+					Header("Retry-After", "0").Times(2)
+				s.ExpectGET("/v1/organisation/accounts/" + accountID).RespondFile(payload)
+			},
+			exercise: func(t *testing.T, c client.Client) {
+				client.WithRetries(2, time.Millisecond)(&c)
+
+				_, err := c.Fetch(accountID)
+				assert.NoError(t, err)
+			},
+		},
+		{
+			name: "reports requests out of order and missing ones",
+			script: func(s *clienttest.Server) {
+				s.ExpectGET("/v1/organisation/accounts/" + accountID).RespondFile(payload)
+				s.ExpectDELETE("/v1/organisation/accounts/" + accountID + "?version=0").Status(http.StatusNoContent)
+			},
+			exercise: func(t *testing.T, c client.Client) {
+				assert.Error(t, c.Delete(accountID, 0))
+			},
+			wantFailures: []string{
+				"clienttest: expected GET /v1/organisation/accounts/" + accountID + " 1 times, got it 0 times",
+				"clienttest: expected DELETE /v1/organisation/accounts/" + accountID + "?version=0 1 times, got it 0 times",
+				"clienttest: unexpected request: DELETE /v1/organisation/accounts/" + accountID + "?version=0",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := clienttest.NewServer(t)
+			defer s.Close()
+
+			tt.script(s)
+			tt.exercise(t, client.Client{BaseURL: s.URL})
+
+			var r recorder
+
+			assert.Equal(t, len(tt.wantFailures) == 0, s.AssertExpectations(&r))
+			assert.Equal(t, tt.wantFailures, r.failures)
+		})
+	}
+}
+
+func TestServer_RespondFile(t *testing.T) {
+	var r recorder
+
+	s := clienttest.NewServer(&r)
+	defer s.Close()
+
+	s.ExpectGET("/").RespondFile("testdata/missing.json")
+
+	if assert.Len(t, r.failures, 1) {
+		assert.Contains(t, r.failures[0], "clienttest: reading response file:")
+	}
+}
@@ -3,16 +3,33 @@ package config
 import (
 	"fmt"
 	"os"
+	"time"
 )
 
 const (
 	AccountsAPIURLKey = "ACCOUNTS_ADDRESS"
 	OrganisationIDKey = "ORGANISATION_ID"
+	TimeoutKey        = "ACCOUNTS_TIMEOUT"
+	TokenKey          = "ACCOUNTS_TOKEN"
+	AuditLogKey       = "ACCOUNTS_AUDIT_LOG"
+	BackupKeyFileKey  = "ACCOUNTS_BACKUP_KEY_FILE"
 )
 
 type Config struct {
 	AccountsAPIURL string
 	OrganisationID string
+
+	// Timeout is the request timeout requested by the configuration. Zero means the caller should pick a default.
+	Timeout time.Duration
+
+	// Token is the bearer token sent with every request. Empty means requests are not authenticated.
+	Token string
+
+	// AuditLog is the file the command line tool appends a line to for every request. Empty means no audit log.
+	AuditLog string
+
+	// BackupKeyFile is the file with the key backups are encrypted with. Empty means backups are not encrypted.
+	BackupKeyFile string
 }
 
 type validationFunc func(string) error
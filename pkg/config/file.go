@@ -0,0 +1,253 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"time"
+)
+
+const (
+	// FileKey is the environment variable that overrides the location of the config file.
+	FileKey = "ACCOUNTS_CONFIG"
+
+	// ProfileKey is the environment variable that picks the profile to use instead of the one selected in the file.
+	ProfileKey = "ACCOUNTS_PROFILE"
+
+	// DefaultProfile is the name of the profile the config file uses when none has been chosen.
+	DefaultProfile = "default"
+
+	fileDir         = "accountsclient"
+	fileName        = "config.json"
+	fileDirMode     = 0o700
+	fileMode        = 0o600
+	fileIndentation = "  "
+)
+
+// Profile holds the settings of a single named environment in the config file.
+type Profile struct {
+	AccountsAPIURL string `json:"accounts_address,omitempty"`
+	OrganisationID string `json:"organisation_id,omitempty"`
+	Timeout        string `json:"timeout,omitempty"`
+	Token          string `json:"token,omitempty"`
+	AuditLog       string `json:"audit_log,omitempty"`
+	BackupKeyFile  string `json:"backup_key_file,omitempty"`
+}
+
+// File is the on disk configuration of the accountsclient command line tool. It can hold any number of named profiles,
+// of which Profile is the one in use.
+type File struct {
+	Profile  string             `json:"profile"`
+	Profiles map[string]Profile `json:"profiles"`
+}
+
+// DefaultFilePath returns the location of the config file: the value of the ACCOUNTS_CONFIG environment variable if
+// set, otherwise accountsclient/config.json in the user's configuration directory.
+func DefaultFilePath() (string, error) {
+	if path := os.Getenv(FileKey); path != "" {
+		return path, nil
+	}
+
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", fmt.Errorf("config.DefaultFilePath: %w", err)
+	}
+
+	return filepath.Join(dir, fileDir, fileName), nil
+}
+
+// LoadFile reads and decodes the config file at path. If the file does not exist, it returns an empty File with the
+// default profile selected, and no error.
+func LoadFile(path string) (File, error) {
+	content, err := ioutil.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return File{Profile: DefaultProfile, Profiles: map[string]Profile{}}, nil
+	}
+
+	if err != nil {
+		return File{}, fmt.Errorf("config.LoadFile: %w", err)
+	}
+
+	var f File
+
+	err = json.Unmarshal(content, &f)
+	if err != nil {
+		return File{}, fmt.Errorf("config.LoadFile %s: %w", path, err)
+	}
+
+	if f.Profile == "" {
+		f.Profile = DefaultProfile
+	}
+
+	if f.Profiles == nil {
+		f.Profiles = map[string]Profile{}
+	}
+
+	return f, nil
+}
+
+// Save writes the config file to path, creating its directory if needed. The file is only readable by the current user
+// as profiles can end up holding credentials.
+func (f File) Save(path string) error {
+	err := os.MkdirAll(filepath.Dir(path), fileDirMode)
+	if err != nil {
+		return fmt.Errorf("config.File.Save: %w", err)
+	}
+
+	content, err := json.MarshalIndent(f, "", fileIndentation)
+	if err != nil {
+		return fmt.Errorf("config.File.Save: %w", err)
+	}
+
+	err = ioutil.WriteFile(path, append(content, '\n'), fileMode)
+	if err != nil {
+		return fmt.Errorf("config.File.Save: %w", err)
+	}
+
+	return nil
+}
+
+// Current returns the settings of the profile in use.
+func (f File) Current() Profile {
+	return f.Profiles[f.Profile]
+}
+
+// Validate checks that the settings of the profile that are set are in the correct format.
+func (p Profile) Validate() error {
+	if p.AccountsAPIURL != "" {
+		u, err := url.Parse(p.AccountsAPIURL)
+		if err != nil {
+			return fmt.Errorf("accounts address: %w", err)
+		}
+
+		if u.Scheme == "" || u.Host == "" {
+			return fmt.Errorf("accounts address has to be an absolute url, got '%s'", p.AccountsAPIURL)
+		}
+	}
+
+	if p.Timeout == "" {
+		return nil
+	}
+
+	d, err := time.ParseDuration(p.Timeout)
+	if err != nil {
+		return fmt.Errorf("timeout: %w", err)
+	}
+
+	if d <= 0 {
+		return fmt.Errorf("timeout has to be positive, got %s", p.Timeout)
+	}
+
+	return nil
+}
+
+// Load returns the configuration from the current profile of the config file at path, with every setting that is also
+// present in the environment taking precedence. An empty path or a missing file is not an error: in that case only the
+// environment is used, the same way as Get.
+func Load(path string) (Config, error) {
+	cfg, err := load(path, "", Profile{})
+	if err != nil {
+		return Config{}, fmt.Errorf("config.Load: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// LoadProfile works like Load, but uses the named profile instead of the current one. An empty name falls back to the
+// ACCOUNTS_PROFILE environment variable, then to the current profile. A profile chosen either way has to exist in the
+// file. Every setting of overrides that is not empty takes precedence over both the profile and the environment.
+func LoadProfile(path, profile string, overrides Profile) (Config, error) {
+	cfg, err := load(path, profile, overrides)
+	if err != nil {
+		return Config{}, fmt.Errorf("config.LoadProfile: %w", err)
+	}
+
+	return cfg, nil
+}
+
+// load does the work of Load and LoadProfile, and returns errors unwrapped so they can add their own name.
+func load(path, profile string, overrides Profile) (Config, error) {
+	f := File{Profile: DefaultProfile}
+
+	if path != "" {
+		var err error
+
+		f, err = LoadFile(path)
+		if err != nil {
+			return Config{}, err
+		}
+	}
+
+	if profile == "" {
+		profile = os.Getenv(ProfileKey)
+	}
+
+	if profile != "" {
+		if _, ok := f.Profiles[profile]; !ok {
+			return Config{}, fmt.Errorf("profile %s is not in the config file, add it with "+
+				"'accountsclient config init'", profile)
+		}
+
+		f.Profile = profile
+	}
+
+	p := f.Current()
+
+	for key, setting := range map[string]*string{
+		AccountsAPIURLKey: &p.AccountsAPIURL,
+		OrganisationIDKey: &p.OrganisationID,
+		TimeoutKey:        &p.Timeout,
+		TokenKey:          &p.Token,
+		AuditLogKey:       &p.AuditLog,
+		BackupKeyFileKey:  &p.BackupKeyFile,
+	} {
+		if value := os.Getenv(key); value != "" {
+			*setting = value
+		}
+	}
+
+	for setting, value := range map[*string]string{
+		&p.AccountsAPIURL: overrides.AccountsAPIURL,
+		&p.OrganisationID: overrides.OrganisationID,
+		&p.Timeout:        overrides.Timeout,
+		&p.Token:          overrides.Token,
+		&p.AuditLog:       overrides.AuditLog,
+		&p.BackupKeyFile:  overrides.BackupKeyFile,
+	} {
+		if value != "" {
+			*setting = value
+		}
+	}
+
+	err := p.Validate()
+	if err != nil {
+		return Config{}, fmt.Errorf("profile %s: %w", f.Profile, err)
+	}
+
+	if p.AccountsAPIURL == "" || p.OrganisationID == "" {
+		return Config{}, fmt.Errorf(
+			"profile %s: accounts address and organisation id are required, set them with "+
+				"'accountsclient config init' or the %s and %s environment variables",
+			f.Profile, AccountsAPIURLKey, OrganisationIDKey,
+		)
+	}
+
+	cfg := Config{
+		AccountsAPIURL: p.AccountsAPIURL,
+		OrganisationID: p.OrganisationID,
+		Token:          p.Token,
+		AuditLog:       p.AuditLog,
+		BackupKeyFile:  p.BackupKeyFile,
+	}
+
+	if p.Timeout != "" {
+		// Validate has already made sure this parses.
+		cfg.Timeout, _ = time.ParseDuration(p.Timeout)
+	}
+
+	return cfg, nil
+}
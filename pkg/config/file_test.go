@@ -0,0 +1,268 @@
+package config_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+func TestFile_SaveLoadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "config.json")
+
+	f, err := config.LoadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, config.File{Profile: config.DefaultProfile, Profiles: map[string]config.Profile{}}, f)
+
+	f.Profile = "sandbox"
+	f.Profiles["sandbox"] = config.Profile{
+		AccountsAPIURL: "http://localhost:8080",
+		OrganisationID: "an-uuidv4",
+		Timeout:        "2s",
+	}
+
+	assert.NoError(t, f.Save(path))
+
+	info, err := os.Stat(path)
+	assert.NoError(t, err)
+	assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+
+	got, err := config.LoadFile(path)
+	assert.NoError(t, err)
+	assert.Equal(t, f, got)
+	assert.Equal(t, "an-uuidv4", got.Current().OrganisationID)
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, ioutil.WriteFile(path, []byte("not a json"), 0o600))
+
+	_, err := config.LoadFile(path)
+	assert.Error(t, err)
+}
+
+func TestProfile_Validate(t *testing.T) {
+	tests := []struct {
+		name    string
+		profile config.Profile
+		wantErr bool
+	}{
+		{
+			name:    "empty profile is valid",
+			profile: config.Profile{},
+			wantErr: false,
+		},
+		{
+			name: "fully populated profile is valid",
+			profile: config.Profile{
+				AccountsAPIURL: "https://api.example.com/sub",
+				OrganisationID: "an-uuidv4",
+				Timeout:        "1m30s",
+			},
+			wantErr: false,
+		},
+		{
+			name:    "relative accounts address is invalid",
+			profile: config.Profile{AccountsAPIURL: "localhost:8080/v1"},
+			wantErr: true,
+		},
+		{
+			name:    "unparseable timeout is invalid",
+			profile: config.Profile{Timeout: "soon"},
+			wantErr: true,
+		},
+		{
+			name:    "negative timeout is invalid",
+			profile: config.Profile{Timeout: "-1s"},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.profile.Validate()
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, config.File{
+		Profile: "sandbox",
+		Profiles: map[string]config.Profile{
+			"sandbox": {
+				AccountsAPIURL: "http://sandbox:8080",
+				OrganisationID: "sandbox-org",
+				Timeout:        "3s",
+			},
+		},
+	}.Save(path))
+
+	tests := []struct {
+		name    string
+		path    string
+		setup   func()
+		want    config.Config
+		wantErr bool
+	}{
+		{
+			name:  "uses the current profile of the file",
+			path:  path,
+			setup: func() {},
+			want: config.Config{
+				AccountsAPIURL: "http://sandbox:8080",
+				OrganisationID: "sandbox-org",
+				Timeout:        3 * time.Second,
+			},
+		},
+		{
+			name: "environment variables take precedence over the file",
+			path: path,
+			setup: func() {
+				_ = os.Setenv(config.OrganisationIDKey, "env-org")
+				_ = os.Setenv(config.TimeoutKey, "250ms")
+			},
+			want: config.Config{
+				AccountsAPIURL: "http://sandbox:8080",
+				OrganisationID: "env-org",
+				Timeout:        250 * time.Millisecond,
+			},
+		},
+		{
+			name: "works from the environment alone without a file",
+			path: "",
+			setup: func() {
+				_ = os.Setenv(config.AccountsAPIURLKey, "http://env:8080")
+				_ = os.Setenv(config.OrganisationIDKey, "env-org")
+			},
+			want: config.Config{
+				AccountsAPIURL: "http://env:8080",
+				OrganisationID: "env-org",
+			},
+		},
+		{
+			name:    "returns error when required settings are missing",
+			path:    filepath.Join(t.TempDir(), "missing.json"),
+			setup:   func() {},
+			want:    config.Config{},
+			wantErr: true,
+		},
+		{
+			name: "returns error when a setting from the environment is invalid",
+			path: path,
+			setup: func() {
+				_ = os.Setenv(config.TimeoutKey, "whenever")
+			},
+			want:    config.Config{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			tt.setup()
+
+			got, err := config.Load(tt.path)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestLoadProfile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	assert.NoError(t, config.File{
+		Profile: "sandbox",
+		Profiles: map[string]config.Profile{
+			"sandbox": {AccountsAPIURL: "http://sandbox:8080", OrganisationID: "sandbox-org"},
+			"prod-eu": {AccountsAPIURL: "https://eu.example.com", OrganisationID: "eu-org", Token: "eu-token"},
+		},
+	}.Save(path))
+
+	tests := []struct {
+		name      string
+		profile   string
+		overrides config.Profile
+		setup     func()
+		want      config.Config
+		wantErr   bool
+	}{
+		{
+			name:    "uses the named profile instead of the current one",
+			profile: "prod-eu",
+			setup:   func() {},
+			want:    config.Config{AccountsAPIURL: "https://eu.example.com", OrganisationID: "eu-org", Token: "eu-token"},
+		},
+		{
+			name:  "falls back to the profile in the environment",
+			setup: func() { _ = os.Setenv(config.ProfileKey, "prod-eu") },
+			want:  config.Config{AccountsAPIURL: "https://eu.example.com", OrganisationID: "eu-org", Token: "eu-token"},
+		},
+		{
+			name:    "the name takes precedence over the environment",
+			profile: "sandbox",
+			setup:   func() { _ = os.Setenv(config.ProfileKey, "prod-eu") },
+			want:    config.Config{AccountsAPIURL: "http://sandbox:8080", OrganisationID: "sandbox-org"},
+		},
+		{
+			name:    "the token in the environment takes precedence over the profile",
+			profile: "prod-eu",
+			setup:   func() { _ = os.Setenv(config.TokenKey, "env-token") },
+			want:    config.Config{AccountsAPIURL: "https://eu.example.com", OrganisationID: "eu-org", Token: "env-token"},
+		},
+		{
+			name:      "overrides take precedence over the profile and the environment",
+			profile:   "prod-eu",
+			overrides: config.Profile{OrganisationID: "flag-org"},
+			setup:     func() { _ = os.Setenv(config.OrganisationIDKey, "env-org") },
+			want:      config.Config{AccountsAPIURL: "https://eu.example.com", OrganisationID: "flag-org", Token: "eu-token"},
+		},
+		{
+			name:      "the backup key file can come from the environment and the overrides too",
+			profile:   "sandbox",
+			overrides: config.Profile{BackupKeyFile: "flag.key"},
+			setup:     func() { _ = os.Setenv(config.BackupKeyFileKey, "env.key") },
+			want: config.Config{
+				AccountsAPIURL: "http://sandbox:8080",
+				OrganisationID: "sandbox-org",
+				BackupKeyFile:  "flag.key",
+			},
+		},
+		{
+			name:    "returns error for a profile that is not in the file",
+			profile: "prod-us",
+			setup:   func() {},
+			want:    config.Config{},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			os.Clearenv()
+			tt.setup()
+
+			got, err := config.LoadProfile(path, tt.profile, tt.overrides)
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
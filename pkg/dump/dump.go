@@ -0,0 +1,112 @@
+// Package dump reads and writes dumps of accounts: newline delimited JSON, with a header on the first line that says
+// which version of the format the rest of the file is in, and every account after it in an envelope with the SHA-256
+// checksum of its JSON, so a line that was changed or cut short is found before it is restored. A trailer with the
+// number of lines before it ends the dump, so one that lost its last lines is found too. With a Cipher, every line
+// after the header is encrypted as well, bound to the header and to its place in the dump. The backup and restore
+// commands use it, and so can any other code that needs to store accounts, or read the ones they stored.
+package dump
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+const (
+	// Format is the name of the format, in the header of every dump.
+	Format = "form3-accounts-dump"
+
+	// SchemaVersion is the newest version of the format, which Reader reads. Version 2 added encryption. Writer writes
+	// version 1 for dumps that are not encrypted, so tools that only read that one still read them.
+	SchemaVersion = 2
+
+	// schemaVersionPlain is the version of dumps that are not encrypted.
+	schemaVersionPlain = 1
+
+	// MaxLineSize is the longest line Reader reads, in bytes, far more than an account with every attribute set.
+	MaxLineSize = 1 << 20
+
+	// recordAccount is the type of the envelope of an account.
+	recordAccount = "account"
+
+	// recordTrailer is the type of the last line of a dump, with the number of lines before it.
+	recordTrailer = "trailer"
+)
+
+var (
+	// ErrUnsupportedVersion is matched by errors returned when a dump is in a newer version of the format than
+	// SchemaVersion, or isn't a dump at all.
+	ErrUnsupportedVersion = errors.New("unsupported dump version")
+
+	// ErrChecksum is matched by errors returned when the JSON of an account doesn't match the checksum of its
+	// envelope.
+	ErrChecksum = errors.New("checksum mismatch")
+
+	// ErrIncomplete is matched by errors returned when a dump doesn't end with a trailer that counts the lines before
+	// it, like one that was cut short, or has lines after its trailer. Encrypted dumps must have a trailer, and dumps
+	// that are not encrypted are checked if they have one, as older ones don't.
+	ErrIncomplete = errors.New("dump is incomplete")
+)
+
+// Header is the first line of a dump. It is never encrypted. Encryption is the Name of the Cipher the rest of the dump
+// is encrypted with, and empty if it isn't.
+type Header struct {
+	Format         string    `json:"format"`
+	SchemaVersion  int       `json:"schema_version"`
+	CreatedAt      time.Time `json:"created_at"`
+	OrganisationID string    `json:"organisation_id,omitempty"`
+	Encryption     string    `json:"encryption,omitempty"`
+}
+
+// record is the envelope of a line after the header. Type says what Data is, so a later version of the format can add
+// other kinds of lines, which Reader skips. SHA256 is the checksum of Data exactly as it is in the line. Records is
+// only set in the trailer.
+type record struct {
+	Type    string          `json:"type"`
+	SHA256  string          `json:"sha256,omitempty"`
+	Data    json.RawMessage `json:"data,omitempty"`
+	Records int             `json:"records,omitempty"`
+}
+
+// Position is where a dump that was cut short left off, so AppendWriter can carry on with it the way the Writer that
+// started it would have: the digest of its header, and the number of lines after it. Writer.Position and
+// Reader.Position return it.
+type Position struct {
+	header  []byte
+	records int
+}
+
+// ChecksumError is returned by Reader.Next when the JSON of an account doesn't match its checksum. It matches
+// ErrChecksum with errors.Is.
+type ChecksumError struct {
+	Line int
+	Want string
+	Got  string
+}
+
+// Error returns the line of the account, and both checksums.
+func (e *ChecksumError) Error() string {
+	return fmt.Sprintf("line %d: %s: want sha256 %s, got %s", e.Line, ErrChecksum, e.Want, e.Got)
+}
+
+// Is reports whether target is ErrChecksum.
+func (e *ChecksumError) Is(target error) bool {
+	return target == ErrChecksum
+}
+
+// digest returns the SHA-256 of the header line of a dump, which every encrypted line after it is bound to.
+func digest(header []byte) []byte {
+	sum := sha256.Sum256(header)
+
+	return sum[:]
+}
+
+// checksum returns the SHA-256 of b, in hex.
+func checksum(b []byte) string {
+	sum := sha256.Sum256(b)
+
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,243 @@
+package dump_test
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/dump"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestWriter_Reader(t *testing.T) {
+	created := time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)
+	accounts := []client.Data{
+		{ID: "a6c1a721-bb1b-41ef-bd11-800a1309ff9b", Version: 1, Attributes: fixtures.ValidGB()},
+		{ID: "0b8d4e0e-49c7-4a59-8e1c-4ca2a1ab5ba0", Version: 3, Attributes: fixtures.ValidDE()},
+	}
+
+	var buf bytes.Buffer
+
+	w, err := dump.NewWriter(&buf, dump.Header{CreatedAt: created, OrganisationID: "organisation"})
+	assert.NoError(t, err)
+
+	for _, d := range accounts {
+		assert.NoError(t, w.Write(d))
+	}
+
+	assert.NoError(t, w.Close())
+	assert.Equal(t, int64(buf.Len()), w.Written())
+
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if assert.Len(t, lines, 4) {
+		assert.Equal(t, `{"format":"form3-accounts-dump","schema_version":1,"created_at":"2021-03-01T12:00:00Z",`+
+			`"organisation_id":"organisation"}`, lines[0])
+		assert.True(t, strings.HasPrefix(lines[1], `{"type":"account","sha256":"`), lines[1])
+		assert.Equal(t, `{"type":"trailer","records":2}`, lines[3])
+	}
+
+	header, got, err := dump.ReadAll(&buf)
+	assert.NoError(t, err)
+	assert.Equal(t, dump.Header{
+		Format:         dump.Format,
+		SchemaVersion:  1,
+		CreatedAt:      created,
+		OrganisationID: "organisation",
+	}, header)
+
+	if assert.Len(t, got, len(accounts)) {
+		for i := range accounts {
+			assert.True(t, accounts[i].Equivalent(got[i]), "account %d", i)
+			assert.Equal(t, accounts[i].Version, got[i].Version)
+		}
+	}
+}
+
+func TestAppendWriter(t *testing.T) {
+	var buf bytes.Buffer
+
+	w, err := dump.NewWriter(&buf, dump.Header{})
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(client.Data{ID: "first"}))
+
+	resumed := dump.AppendWriter(&buf, w.Position())
+	assert.NoError(t, resumed.Write(client.Data{ID: "second"}))
+	assert.NoError(t, resumed.Close())
+	assert.True(t, strings.HasSuffix(buf.String(), `{"type":"trailer","records":2}`+"\n"), "the trailer counts both")
+
+	_, got, err := dump.ReadAll(&buf)
+	assert.NoError(t, err)
+
+	ids := make([]string, 0, len(got))
+	for _, d := range got {
+		ids = append(ids, d.ID)
+	}
+
+	assert.Equal(t, []string{"first", "second"}, ids)
+}
+
+func TestReader(t *testing.T) {
+	const (
+		header  = `{"format":"form3-accounts-dump","schema_version":1,"created_at":"2021-03-01T12:00:00Z"}`
+		data    = `{"id":"first","type":"accounts"}`
+		sum     = "1b9bbf3b8a52a3e1b6a3b3c11ef3c61e0e3b3da8a6b8a0cf20c29f1dc66ab1b7"
+		trailer = `{"type":"trailer","records":1}` + "\n"
+		comment = `{"type":"comment","sha256":"","data":"later versions may add these"}` + "\n"
+	)
+
+	valid := dumpOf(t, client.Data{ID: "first"})
+	unfinished := strings.TrimSuffix(valid, trailer)
+
+	tests := []struct {
+		name        string
+		dump        string
+		wantVersion int
+		wantIDs     []string
+		wantErr     error
+	}{
+		{
+			name:        "a dump",
+			dump:        valid,
+			wantVersion: 1,
+			wantIDs:     []string{"first"},
+		},
+		{
+			name:    "a file of accounts without a header, as backup wrote before",
+			dump:    `{"id":"first"}` + "\n\n" + `{"id":"second"}` + "\n",
+			wantIDs: []string{"first", "second"},
+		},
+		{
+			name:    "nothing",
+			wantIDs: []string{},
+		},
+		{
+			name:        "lines of other types are skipped",
+			dump:        unfinished + comment + strings.Replace(trailer, "1", "2", 1),
+			wantVersion: 1,
+			wantIDs:     []string{"first"},
+		},
+		{
+			name:        "a dump without a trailer, as backup wrote before",
+			dump:        unfinished,
+			wantVersion: 1,
+			wantIDs:     []string{"first"},
+		},
+		{
+			name:    "a trailer that doesn't count the lines before it",
+			dump:    unfinished + comment + trailer,
+			wantErr: dump.ErrIncomplete,
+		},
+		{
+			name:    "a line after the trailer",
+			dump:    valid + comment,
+			wantErr: dump.ErrIncomplete,
+		},
+		{
+			name:    "an account that was changed",
+			dump:    strings.Replace(valid, `"id":"first"`, `"id":"other"`, 1),
+			wantErr: dump.ErrChecksum,
+		},
+		{
+			name:    "a newer version",
+			dump:    strings.Replace(header, `"schema_version":1`, `"schema_version":3`, 1) + "\n",
+			wantErr: dump.ErrUnsupportedVersion,
+		},
+		{
+			name:    "another format",
+			dump:    strings.Replace(header, "form3-accounts-dump", "something-else", 1) + "\n",
+			wantErr: dump.ErrUnsupportedVersion,
+		},
+		{
+			name:    "a checksum that doesn't match",
+			dump:    header + "\n" + `{"type":"account","sha256":"` + sum + `","data":` + data + `}` + "\n",
+			wantErr: dump.ErrChecksum,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			header, got, err := dump.ReadAll(strings.NewReader(tt.dump))
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.wantVersion, header.SchemaVersion)
+
+			ids := make([]string, 0, len(got))
+			for _, d := range got {
+				ids = append(ids, d.ID)
+			}
+
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+func TestReader_Next(t *testing.T) {
+	r, err := dump.NewReader(strings.NewReader(dumpOf(t, client.Data{ID: "first"}, client.Data{ID: "second"})))
+	assert.NoError(t, err)
+	assert.Equal(t, dump.Format, r.Header().Format)
+
+	d, err := r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "first", d.ID)
+
+	d, err = r.Next()
+	assert.NoError(t, err)
+	assert.Equal(t, "second", d.ID)
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = r.Next()
+	assert.Equal(t, io.EOF, err, "it stays at the end")
+
+	changed := strings.Replace(dumpOf(t, client.Data{ID: "first"}), `"id":"first"`, `"id":"other"`, 1)
+
+	r, err = dump.NewReader(strings.NewReader(changed))
+	assert.NoError(t, err)
+
+	_, err = r.Next()
+
+	var sumErr *dump.ChecksumError
+
+	if assert.True(t, errors.As(err, &sumErr), "got %v", err) {
+		assert.Equal(t, 2, sumErr.Line)
+		assert.NotEqual(t, sumErr.Want, sumErr.Got)
+	}
+
+	valid := dumpOf(t, client.Data{ID: "first"})
+	cut := valid[:strings.LastIndex(valid, `{"type":"trailer"`)-10]
+
+	r, err = dump.NewReader(strings.NewReader(cut))
+	assert.NoError(t, err)
+
+	_, err = r.Next()
+	assert.Error(t, err, "an account that was cut short")
+}
+
+// dumpOf returns a dump of accounts.
+func dumpOf(t *testing.T, accounts ...client.Data) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := dump.NewWriter(&buf, dump.Header{CreatedAt: time.Date(2021, time.March, 1, 12, 0, 0, 0, time.UTC)})
+	assert.NoError(t, err)
+
+	for _, d := range accounts {
+		assert.NoError(t, w.Write(d))
+	}
+
+	assert.NoError(t, w.Close())
+
+	return buf.String()
+}
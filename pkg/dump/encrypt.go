@@ -0,0 +1,172 @@
+package dump
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// aes256KeySize is the size of the key of AESGCM, in bytes.
+const aes256KeySize = 32
+
+var (
+	// ErrKeySize is matched by errors returned when a key is not the size its cipher needs.
+	ErrKeySize = errors.New("wrong key size")
+
+	// ErrNoKey is matched by errors returned when a dump is encrypted, and no Cipher was given to read it with.
+	ErrNoKey = errors.New("dump is encrypted, and no key was given")
+
+	// ErrDecrypt is matched by errors returned when a line of an encrypted dump can't be decrypted: the key is wrong,
+	// or the line was changed, or moved to another place in the dump, or into another dump.
+	ErrDecrypt = errors.New("decrypting failed")
+
+	// ErrNotEncrypted is matched by errors returned when a Cipher was given to read a dump with, and the dump is not
+	// encrypted, so a dump whose header was swapped for one without encryption isn't read as if it had been checked.
+	ErrNotEncrypted = errors.New("dump is not encrypted, and a key was given")
+)
+
+// Cipher encrypts the lines of a dump after its header, one by one, so a dump stays readable and resumable line by
+// line. AESGCM is the one this package has. Others, like age, can be used by implementing it.
+type Cipher interface {
+	// Name identifies the cipher in the header of the dumps it encrypts. Reader only uses a Cipher of the same name.
+	Name() string
+
+	// Seal encrypts and authenticates plaintext, and authenticates additionalData along with it without encrypting
+	// it. The dump passes the digest of its header and the index of the line, which are not stored in the line.
+	Seal(plaintext, additionalData []byte) ([]byte, error)
+
+	// Open decrypts what Seal returned, and fails if it was changed, was sealed with another key, or with other
+	// additionalData.
+	Open(ciphertext, additionalData []byte) ([]byte, error)
+}
+
+// Option configures a Writer or a Reader.
+type Option func(*settings)
+
+// settings are what the options configure.
+type settings struct {
+	cipher Cipher
+}
+
+// WithCipher encrypts the dump a Writer writes with c, and decrypts the dump a Reader reads with it. A Reader with a
+// Cipher fails with an error that matches ErrNotEncrypted on a dump that is not encrypted.
+func WithCipher(c Cipher) Option {
+	return func(s *settings) {
+		s.cipher = c
+	}
+}
+
+// newSettings applies opts.
+func newSettings(opts []Option) settings {
+	var s settings
+
+	for _, opt := range opts {
+		opt(&s)
+	}
+
+	return s
+}
+
+// aesGCM is AES-256 in Galois/Counter Mode, with a random nonce in front of every sealed line.
+type aesGCM struct {
+	aead cipher.AEAD
+}
+
+// AESGCM returns a Cipher that encrypts with AES-256-GCM. The key has to be 32 bytes, like the output of
+// `openssl rand 32`.
+func AESGCM(key []byte) (Cipher, error) {
+	if len(key) != aes256KeySize {
+		return nil, fmt.Errorf("dump.AESGCM: %d bytes, want %d: %w", len(key), aes256KeySize, ErrKeySize)
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("dump.AESGCM: %w", err)
+	}
+
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("dump.AESGCM: %w", err)
+	}
+
+	return aesGCM{aead: aead}, nil
+}
+
+// Name returns aes-256-gcm.
+func (c aesGCM) Name() string {
+	return "aes-256-gcm"
+}
+
+// Seal encrypts plaintext with a new random nonce, which it returns in front of the ciphertext.
+func (c aesGCM) Seal(plaintext, additionalData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize(), c.aead.NonceSize()+len(plaintext)+c.aead.Overhead())
+
+	_, err := io.ReadFull(rand.Reader, nonce)
+	if err != nil {
+		return nil, fmt.Errorf("dump.aesGCM.Seal: %w", err)
+	}
+
+	return c.aead.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// Open splits the nonce off ciphertext, and decrypts the rest with it.
+func (c aesGCM) Open(ciphertext, additionalData []byte) ([]byte, error) {
+	if len(ciphertext) < c.aead.NonceSize() {
+		return nil, ErrDecrypt
+	}
+
+	nonce, sealed := ciphertext[:c.aead.NonceSize()], ciphertext[c.aead.NonceSize():]
+
+	plaintext, err := c.aead.Open(nil, nonce, sealed, additionalData)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+
+	return plaintext, nil
+}
+
+// additionalData returns what the line at index, counted from 0 after the header, is sealed along with: the digest of
+// the header, then the index, so a line can't be dropped, reordered, or moved into another dump without Open failing.
+func additionalData(header []byte, index int) []byte {
+	return binary.BigEndian.AppendUint64(append([]byte(nil), header...), uint64(index))
+}
+
+// seal encrypts line, the one at index, with c, and encodes it in base64 so the dump stays a text file with one line
+// per record.
+func seal(c Cipher, line, header []byte, index int) ([]byte, error) {
+	sealed, err := c.Seal(line, additionalData(header, index))
+	if err != nil {
+		return nil, err
+	}
+
+	encoded := make([]byte, base64.StdEncoding.EncodedLen(len(sealed)))
+	base64.StdEncoding.Encode(encoded, sealed)
+
+	return encoded, nil
+}
+
+// open reverses seal.
+func open(c Cipher, line, header []byte, index int) ([]byte, error) {
+	sealed := make([]byte, base64.StdEncoding.DecodedLen(len(line)))
+
+	n, err := base64.StdEncoding.Decode(sealed, line)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+
+	plaintext, err := c.Open(sealed[:n], additionalData(header, index))
+	if errors.Is(err, ErrDecrypt) {
+		return nil, err
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrDecrypt, err)
+	}
+
+	return plaintext, nil
+}
@@ -0,0 +1,214 @@
+package dump_test
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/dump"
+)
+
+// otherCipher is a Cipher of another kind than AESGCM.
+type otherCipher struct{}
+
+func (otherCipher) Name() string                     { return "other" }
+func (otherCipher) Seal(b, _ []byte) ([]byte, error) { return b, nil }
+func (otherCipher) Open(b, _ []byte) ([]byte, error) { return b, nil }
+
+func TestAESGCM(t *testing.T) {
+	tests := []struct {
+		name    string
+		key     []byte
+		wantErr error
+	}{
+		{
+			name: "32 bytes",
+			key:  bytes.Repeat([]byte{1}, 32),
+		},
+		{
+			name:    "16 bytes, which is AES-128",
+			key:     bytes.Repeat([]byte{1}, 16),
+			wantErr: dump.ErrKeySize,
+		},
+		{
+			name:    "no key",
+			wantErr: dump.ErrKeySize,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c, err := dump.AESGCM(tt.key)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			sealed, err := c.Seal([]byte("secret"), []byte("line 1"))
+			assert.NoError(t, err)
+			assert.NotContains(t, string(sealed), "secret")
+
+			again, err := c.Seal([]byte("secret"), []byte("line 1"))
+			assert.NoError(t, err)
+			assert.NotEqual(t, sealed, again, "every seal has a nonce of its own")
+
+			opened, err := c.Open(sealed, []byte("line 1"))
+			assert.NoError(t, err)
+			assert.Equal(t, "secret", string(opened))
+
+			_, err = c.Open(sealed, []byte("line 2"))
+			assert.True(t, errors.Is(err, dump.ErrDecrypt), "other additional data, got %v", err)
+
+			sealed[len(sealed)-1] ^= 1
+
+			_, err = c.Open(sealed, []byte("line 1"))
+			assert.True(t, errors.Is(err, dump.ErrDecrypt), "got %v", err)
+		})
+	}
+}
+
+func TestWithCipher(t *testing.T) {
+	key, err := dump.AESGCM(bytes.Repeat([]byte{1}, 32))
+	assert.NoError(t, err)
+
+	wrongKey, err := dump.AESGCM(bytes.Repeat([]byte{2}, 32))
+	assert.NoError(t, err)
+
+	var buf bytes.Buffer
+
+	w, err := dump.NewWriter(&buf, dump.Header{OrganisationID: "organisation"}, dump.WithCipher(key))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Write(client.Data{ID: "first", Attributes: client.Resource{Name: [4]string{"Jane Doe"}}}))
+
+	unfinished := buf.String()
+
+	resumed := dump.AppendWriter(&buf, w.Position(), dump.WithCipher(key))
+	assert.NoError(t, resumed.Write(client.Data{ID: "second"}))
+	assert.NoError(t, resumed.Close())
+
+	encrypted := buf.String()
+	lines := strings.SplitAfter(encrypted, "\n")
+	other := encryptedDump(t, key, dump.Header{OrganisationID: "other"}, client.Data{ID: "first"})
+
+	assert.True(t, strings.HasPrefix(encrypted, `{"format":"form3-accounts-dump","schema_version":2,`), encrypted)
+	assert.Contains(t, encrypted, `"encryption":"aes-256-gcm"`)
+	assert.NotContains(t, encrypted, "Jane Doe")
+	assert.NotContains(t, encrypted, "second")
+
+	tests := []struct {
+		name    string
+		dump    string
+		opts    []dump.Option
+		wantIDs []string
+		wantErr error
+	}{
+		{
+			name:    "with the key",
+			dump:    encrypted,
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantIDs: []string{"first", "second"},
+		},
+		{
+			name:    "without a key",
+			dump:    encrypted,
+			wantErr: dump.ErrNoKey,
+		},
+		{
+			name:    "with another kind of cipher",
+			dump:    encrypted,
+			opts:    []dump.Option{dump.WithCipher(otherCipher{})},
+			wantErr: dump.ErrNoKey,
+		},
+		{
+			name:    "with the wrong key",
+			dump:    encrypted,
+			opts:    []dump.Option{dump.WithCipher(wrongKey)},
+			wantErr: dump.ErrDecrypt,
+		},
+		{
+			name:    "a line that isn't base64",
+			dump:    lines[0] + lines[1] + "not base64\n" + lines[2] + lines[3],
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantErr: dump.ErrDecrypt,
+		},
+		{
+			name:    "a dump that isn't encrypted, with a key",
+			dump:    dumpOf(t, client.Data{ID: "first"}),
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantErr: dump.ErrNotEncrypted,
+		},
+		{
+			name:    "a dump whose header says it isn't encrypted",
+			dump:    `{"format":"form3-accounts-dump","schema_version":1}` + "\n" + strings.Join(lines[1:], ""),
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantErr: dump.ErrNotEncrypted,
+		},
+		{
+			name:    "lines in another order",
+			dump:    lines[0] + lines[2] + lines[1] + lines[3],
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantErr: dump.ErrDecrypt,
+		},
+		{
+			name:    "a line of another dump with the same key",
+			dump:    lines[0] + strings.SplitAfter(other, "\n")[1] + lines[2] + lines[3],
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantErr: dump.ErrDecrypt,
+		},
+		{
+			name:    "without its trailer",
+			dump:    lines[0] + lines[1] + lines[2],
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantErr: dump.ErrIncomplete,
+		},
+		{
+			name:    "without its last account and its trailer",
+			dump:    unfinished,
+			opts:    []dump.Option{dump.WithCipher(key)},
+			wantErr: dump.ErrIncomplete,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, got, err := dump.ReadAll(strings.NewReader(tt.dump), tt.opts...)
+			if tt.wantErr != nil {
+				assert.True(t, errors.Is(err, tt.wantErr), "got %v", err)
+
+				return
+			}
+
+			assert.NoError(t, err)
+
+			ids := make([]string, 0, len(got))
+			for _, d := range got {
+				ids = append(ids, d.ID)
+			}
+
+			assert.Equal(t, tt.wantIDs, ids)
+		})
+	}
+}
+
+// encryptedDump returns a dump of accounts with header, encrypted with c.
+func encryptedDump(t *testing.T, c dump.Cipher, header dump.Header, accounts ...client.Data) string {
+	t.Helper()
+
+	var buf bytes.Buffer
+
+	w, err := dump.NewWriter(&buf, header, dump.WithCipher(c))
+	assert.NoError(t, err)
+
+	for _, d := range accounts {
+		assert.NoError(t, w.Write(d))
+	}
+
+	assert.NoError(t, w.Close())
+
+	return buf.String()
+}
@@ -0,0 +1,243 @@
+package dump
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// Reader reads the accounts of a dump, one line at a time. It also reads the files backup wrote before there was a
+// header, with an account on every line as it is, which have a Header with a SchemaVersion of 0, and no checksums.
+// Empty lines are skipped.
+type Reader struct {
+	scanner *bufio.Scanner
+	header  Header
+	line    int
+
+	// pos is the digest of the header, and the number of lines read after it.
+	pos Position
+
+	// trailer is set once the trailer was read.
+	trailer bool
+
+	// cipher decrypts the lines after the header, and is nil if the dump is not encrypted.
+	cipher Cipher
+
+	// pending is the first line of a dump without a header, which was read while looking for one.
+	pending []byte
+}
+
+// NewReader reads the header of the dump in r. It fails with an error that matches ErrUnsupportedVersion if the dump
+// is in a newer version of the format than this package reads, or the header is of another format, with one that
+// matches ErrNoKey if the dump is encrypted, and the options have no Cipher of the kind it was encrypted with, and with
+// one that matches ErrNotEncrypted if the options have a Cipher, and the dump is not encrypted.
+func NewReader(r io.Reader, opts ...Option) (*Reader, error) {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, bufio.MaxScanTokenSize), MaxLineSize)
+
+	dr := &Reader{scanner: scanner}
+	c := newSettings(opts).cipher
+
+	first, err := dr.nextLine()
+	if err == io.EOF && c != nil {
+		return nil, fmt.Errorf("dump.NewReader: empty: %w", ErrNotEncrypted)
+	}
+
+	if err == io.EOF {
+		return dr, nil
+	}
+
+	if err != nil {
+		return nil, fmt.Errorf("dump.NewReader: %w", err)
+	}
+
+	var h Header
+
+	err = json.Unmarshal(first, &h)
+	if err != nil {
+		return nil, fmt.Errorf("dump.NewReader: line %d: %w", dr.line, err)
+	}
+
+	switch {
+	case h.Format == "" && h.SchemaVersion == 0:
+		dr.pending = first
+	case h.Format != Format:
+		return nil, fmt.Errorf("dump.NewReader: format %q: %w", h.Format, ErrUnsupportedVersion)
+	case h.SchemaVersion < 1 || h.SchemaVersion > SchemaVersion:
+		return nil, fmt.Errorf("dump.NewReader: version %d, up to %d is supported: %w", h.SchemaVersion, SchemaVersion,
+			ErrUnsupportedVersion)
+	default:
+		dr.header = h
+		dr.pos.header = digest(first)
+	}
+
+	switch {
+	case h.Encryption == "" && c != nil:
+		return nil, fmt.Errorf("dump.NewReader: %w", ErrNotEncrypted)
+	case h.Encryption == "":
+		return dr, nil
+	case c == nil:
+		return nil, fmt.Errorf("dump.NewReader: encrypted with %s: %w", h.Encryption, ErrNoKey)
+	case c.Name() != h.Encryption:
+		return nil, fmt.Errorf("dump.NewReader: encrypted with %s, not %s: %w", h.Encryption, c.Name(), ErrNoKey)
+	}
+
+	dr.cipher = c
+
+	return dr, nil
+}
+
+// Header returns the header of the dump.
+func (r *Reader) Header() Header {
+	return r.header
+}
+
+// Next returns the next account of the dump, or io.EOF after the last one. An account that doesn't match the checksum
+// of its envelope fails with a *ChecksumError, which matches ErrChecksum, and a line of an encrypted dump that can't be
+// decrypted, or was moved, with one that matches ErrDecrypt. A dump that ends without its trailer, where it has to
+// have one, or whose trailer doesn't count the lines before it, fails with one that matches ErrIncomplete instead of
+// io.EOF, and so do lines after the trailer. Lines of types other than accounts, which later versions of the format may
+// have, are skipped.
+func (r *Reader) Next() (client.Data, error) {
+	for {
+		line, err := r.nextLine()
+		if err == io.EOF && !r.trailer && r.cipher != nil {
+			return client.Data{}, fmt.Errorf("dump.Reader.Next: no trailer after line %d: %w", r.line, ErrIncomplete)
+		}
+
+		if err == io.EOF {
+			return client.Data{}, io.EOF
+		}
+
+		if err != nil {
+			return client.Data{}, fmt.Errorf("dump.Reader.Next: %w", err)
+		}
+
+		if r.trailer {
+			return client.Data{}, fmt.Errorf("dump.Reader.Next: line %d is after the trailer: %w", r.line, ErrIncomplete)
+		}
+
+		data, err := r.accountJSON(line)
+		if err != nil {
+			return client.Data{}, fmt.Errorf("dump.Reader.Next: %w", err)
+		}
+
+		if data == nil {
+			continue
+		}
+
+		var d client.Data
+
+		err = json.Unmarshal(data, &d)
+		if err != nil {
+			return client.Data{}, fmt.Errorf("dump.Reader.Next: line %d: %w", r.line, err)
+		}
+
+		return d, nil
+	}
+}
+
+// Position returns where the dump is after the last line Next read, to carry on from with AppendWriter, like after
+// reading the part of a dump that was written before it was interrupted, up to the error that matches ErrIncomplete.
+func (r *Reader) Position() Position {
+	return r.pos
+}
+
+// ReadAll reads every account of the dump in r, along with its header.
+func ReadAll(r io.Reader, opts ...Option) (Header, []client.Data, error) {
+	dr, err := NewReader(r, opts...)
+	if err != nil {
+		return Header{}, nil, err
+	}
+
+	accounts := make([]client.Data, 0)
+
+	for {
+		d, err := dr.Next()
+		if err == io.EOF {
+			return dr.Header(), accounts, nil
+		}
+
+		if err != nil {
+			return Header{}, nil, err
+		}
+
+		accounts = append(accounts, d)
+	}
+}
+
+// accountJSON returns the JSON of the account of line, after decrypting it and checking its checksum, or nil if the
+// line is of another type. A line of a dump without a header is the account as it is.
+func (r *Reader) accountJSON(line []byte) ([]byte, error) {
+	if r.header.SchemaVersion == 0 {
+		return line, nil
+	}
+
+	if r.cipher != nil {
+		var err error
+
+		line, err = open(r.cipher, line, r.pos.header, r.pos.records)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", r.line, err)
+		}
+	}
+
+	var rec record
+
+	err := json.Unmarshal(line, &rec)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line, err)
+	}
+
+	if rec.Type == recordTrailer && rec.Records != r.pos.records {
+		return nil, fmt.Errorf("line %d: trailer counts %d lines, not %d: %w", r.line, rec.Records, r.pos.records,
+			ErrIncomplete)
+	}
+
+	r.pos.records++
+
+	if rec.Type == recordTrailer {
+		r.trailer = true
+
+		return nil, nil
+	}
+
+	if rec.Type != recordAccount {
+		return nil, nil
+	}
+
+	if got := checksum(rec.Data); got != rec.SHA256 {
+		return nil, &ChecksumError{Line: r.line, Want: rec.SHA256, Got: got}
+	}
+
+	return rec.Data, nil
+}
+
+// nextLine returns the next line that isn't empty, or io.EOF at the end of the dump.
+func (r *Reader) nextLine() ([]byte, error) {
+	if r.pending != nil {
+		line := r.pending
+		r.pending = nil
+
+		return line, nil
+	}
+
+	for r.scanner.Scan() {
+		r.line++
+
+		if len(bytes.TrimSpace(r.scanner.Bytes())) > 0 {
+			return r.scanner.Bytes(), nil
+		}
+	}
+
+	err := r.scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", r.line+1, err)
+	}
+
+	return nil, io.EOF
+}
@@ -0,0 +1,133 @@
+package dump
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// Writer writes accounts to a dump, one line each, and the trailer on Close. It doesn't buffer, so wrap the io.Writer
+// in a bufio.Writer for many small writes, and flush it when done.
+type Writer struct {
+	w       io.Writer
+	cipher  Cipher
+	written int64
+
+	// pos is the digest of the header, and the number of lines written after it.
+	pos Position
+}
+
+// NewWriter starts a dump on w, by writing h as its header, with the Format of this package, and the version and
+// Encryption the options call for.
+func NewWriter(w io.Writer, h Header, opts ...Option) (*Writer, error) {
+	s := newSettings(opts)
+
+	h.Format = Format
+	h.SchemaVersion = schemaVersionPlain
+	h.Encryption = ""
+
+	if s.cipher != nil {
+		h.SchemaVersion = SchemaVersion
+		h.Encryption = s.cipher.Name()
+	}
+
+	line, err := json.Marshal(h)
+	if err != nil {
+		return nil, fmt.Errorf("dump.NewWriter: %w", err)
+	}
+
+	dw := &Writer{w: w, cipher: s.cipher, pos: Position{header: digest(line)}}
+
+	err = dw.writeLine(line)
+	if err != nil {
+		return nil, fmt.Errorf("dump.NewWriter: %w", err)
+	}
+
+	return dw, nil
+}
+
+// AppendWriter carries on with a dump on w that already has its header, like one that was interrupted, and is
+// continued from the end of its last complete line, at pos, which the Writer or the Reader of that part of it returned.
+// The options have to be the ones the dump was started with.
+func AppendWriter(w io.Writer, pos Position, opts ...Option) *Writer {
+	return &Writer{w: w, cipher: newSettings(opts).cipher, pos: pos}
+}
+
+// Write writes d as the next account of the dump, in its envelope.
+func (w *Writer) Write(d client.Data) error {
+	data, err := json.Marshal(d)
+	if err != nil {
+		return fmt.Errorf("dump.Writer.Write: encoding account %s: %w", d.ID, err)
+	}
+
+	// The envelope is put together by hand, so data is in it byte for byte as its checksum was taken.
+	line := make([]byte, 0, len(data)+128)
+	line = append(line, `{"type":"`+recordAccount+`","sha256":"`+checksum(data)+`","data":`...)
+	line = append(line, data...)
+	line = append(line, '}')
+
+	err = w.writeRecord(line)
+	if err != nil {
+		return fmt.Errorf("dump.Writer.Write: account %s: %w", d.ID, err)
+	}
+
+	return nil
+}
+
+// Close ends the dump with its trailer, which has the number of lines before it, so a Reader can tell the dump is
+// complete. It doesn't close the io.Writer, and nothing can be written after it.
+func (w *Writer) Close() error {
+	line, err := json.Marshal(record{Type: recordTrailer, Records: w.pos.records})
+	if err != nil {
+		return fmt.Errorf("dump.Writer.Close: %w", err)
+	}
+
+	err = w.writeRecord(line)
+	if err != nil {
+		return fmt.Errorf("dump.Writer.Close: %w", err)
+	}
+
+	return nil
+}
+
+// Written returns the number of bytes the Writer wrote, the header included, so a caller that stops can tell where
+// the last complete line of the dump ends.
+func (w *Writer) Written() int64 {
+	return w.written
+}
+
+// Position returns where the dump is after the last complete line, to carry on from with AppendWriter.
+func (w *Writer) Position() Position {
+	return w.pos
+}
+
+// writeRecord writes line as the next line after the header, encrypted if the Writer has a Cipher.
+func (w *Writer) writeRecord(line []byte) error {
+	if w.cipher != nil {
+		var err error
+
+		line, err = seal(w.cipher, line, w.pos.header, w.pos.records)
+		if err != nil {
+			return fmt.Errorf("encrypting: %w", err)
+		}
+	}
+
+	err := w.writeLine(line)
+	if err != nil {
+		return err
+	}
+
+	w.pos.records++
+
+	return nil
+}
+
+// writeLine writes line, and the newline after it.
+func (w *Writer) writeLine(line []byte) error {
+	n, err := w.w.Write(append(line, '\n'))
+	w.written += int64(n)
+
+	return err
+}
@@ -0,0 +1,165 @@
+// Package fixtures builds accounts that pass client side validation, for tests of this module and of code that uses
+// it. Every builder returns the same Resource every time, so tests can compare against it.
+package fixtures
+
+import (
+	"math/rand"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// seed is the seed of the generator behind every builder, fixed so the builders are deterministic.
+const seed = 1
+
+// Override changes a single attribute of a Resource a builder returns, for tests that need a specific value, or an
+// invalid one.
+type Override func(r *client.Resource)
+
+// Valid returns a Resource for the country that passes validation, with the overrides applied in order. It panics if
+// the country is not supported, like regexp.MustCompile does for a broken expression, as that is a bug in the test.
+func Valid(country string, overrides ...Override) client.Resource {
+	rnd := rand.New(rand.NewSource(seed)) //nolint:gosec // fixtures do not need a secure generator
+
+	r, err := client.GenerateResource(country, rnd)
+	if err != nil {
+		panic("fixtures.Valid: " + err.Error())
+	}
+
+	r.Name = [4]string{"Jane Doe"}
+
+	for _, o := range overrides {
+		o(&r)
+	}
+
+	return r
+}
+
+// ValidGB returns a valid account in the United Kingdom.
+func ValidGB(overrides ...Override) client.Resource {
+	return Valid("GB", overrides...)
+}
+
+// ValidAU returns a valid account in Australia.
+func ValidAU(overrides ...Override) client.Resource {
+	return Valid("AU", overrides...)
+}
+
+// ValidBE returns a valid account in Belgium.
+func ValidBE(overrides ...Override) client.Resource {
+	return Valid("BE", overrides...)
+}
+
+// ValidCA returns a valid account in Canada.
+func ValidCA(overrides ...Override) client.Resource {
+	return Valid("CA", overrides...)
+}
+
+// ValidFR returns a valid account in France.
+func ValidFR(overrides ...Override) client.Resource {
+	return Valid("FR", overrides...)
+}
+
+// ValidDE returns a valid account in Germany.
+func ValidDE(overrides ...Override) client.Resource {
+	return Valid("DE", overrides...)
+}
+
+// ValidGR returns a valid account in Greece.
+func ValidGR(overrides ...Override) client.Resource {
+	return Valid("GR", overrides...)
+}
+
+// ValidHK returns a valid account in Hong Kong.
+func ValidHK(overrides ...Override) client.Resource {
+	return Valid("HK", overrides...)
+}
+
+// ValidIT returns a valid account in Italy.
+func ValidIT(overrides ...Override) client.Resource {
+	return Valid("IT", overrides...)
+}
+
+// ValidLU returns a valid account in Luxembourg.
+func ValidLU(overrides ...Override) client.Resource {
+	return Valid("LU", overrides...)
+}
+
+// ValidNL returns a valid account in the Netherlands.
+func ValidNL(overrides ...Override) client.Resource {
+	return Valid("NL", overrides...)
+}
+
+// ValidPL returns a valid account in Poland.
+func ValidPL(overrides ...Override) client.Resource {
+	return Valid("PL", overrides...)
+}
+
+// ValidPT returns a valid account in Portugal.
+func ValidPT(overrides ...Override) client.Resource {
+	return Valid("PT", overrides...)
+}
+
+// ValidES returns a valid account in Spain.
+func ValidES(overrides ...Override) client.Resource {
+	return Valid("ES", overrides...)
+}
+
+// ValidCH returns a valid account in Switzerland.
+func ValidCH(overrides ...Override) client.Resource {
+	return Valid("CH", overrides...)
+}
+
+// ValidUS returns a valid account in the United States.
+func ValidUS(overrides ...Override) client.Resource {
+	return Valid("US", overrides...)
+}
+
+// WithBankID sets the bank ID.
+func WithBankID(bankID string) Override {
+	return func(r *client.Resource) {
+		r.BankID = bankID
+	}
+}
+
+// WithBankIDCode sets the bank ID code.
+func WithBankIDCode(code string) Override {
+	return func(r *client.Resource) {
+		r.BankIDCode = code
+	}
+}
+
+// WithBIC sets the BIC.
+func WithBIC(bic string) Override {
+	return func(r *client.Resource) {
+		r.BIC = bic
+	}
+}
+
+// WithAccountNumber sets the account number.
+func WithAccountNumber(accountNumber string) Override {
+	return func(r *client.Resource) {
+		r.AccountNumber = accountNumber
+	}
+}
+
+// WithIBAN sets the IBAN.
+func WithIBAN(iban string) Override {
+	return func(r *client.Resource) {
+		r.IBAN = iban
+	}
+}
+
+// WithCustomerID sets the customer ID.
+func WithCustomerID(customerID string) Override {
+	return func(r *client.Resource) {
+		r.CustomerID = customerID
+	}
+}
+
+// WithName sets the name of the account holder, up to four lines of it.
+func WithName(lines ...string) Override {
+	return func(r *client.Resource) {
+		r.Name = [4]string{}
+		copy(r.Name[:], lines)
+	}
+}
@@ -0,0 +1,55 @@
+package fixtures_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/fixtures"
+)
+
+func TestValid(t *testing.T) {
+	builders := map[string]func(...fixtures.Override) client.Resource{
+		"GB": fixtures.ValidGB, "AU": fixtures.ValidAU, "BE": fixtures.ValidBE, "CA": fixtures.ValidCA,
+		"FR": fixtures.ValidFR, "DE": fixtures.ValidDE, "GR": fixtures.ValidGR, "HK": fixtures.ValidHK,
+		"IT": fixtures.ValidIT, "LU": fixtures.ValidLU, "NL": fixtures.ValidNL, "PL": fixtures.ValidPL,
+		"PT": fixtures.ValidPT, "ES": fixtures.ValidES, "CH": fixtures.ValidCH, "US": fixtures.ValidUS,
+	}
+
+	for country, build := range builders {
+		t.Run(country, func(t *testing.T) {
+			r := build()
+
+			assert.Equal(t, country, r.Country)
+			assert.NoError(t, client.ValidateResource(r))
+			assert.Equal(t, r, build(), "builders are deterministic")
+		})
+	}
+}
+
+func TestValid_Overrides(t *testing.T) {
+	r := fixtures.ValidGB(
+		fixtures.WithAccountNumber("12345678"),
+		fixtures.WithIBAN("GB11NWBK40030041426819"),
+		fixtures.WithCustomerID("customer"),
+		fixtures.WithName("Jane", "Doe"),
+		fixtures.WithBIC("NWBKGB22"),
+	)
+
+	assert.NoError(t, client.ValidateResource(r))
+	assert.Equal(t, "12345678", r.AccountNumber)
+	assert.Equal(t, "GB11NWBK40030041426819", r.IBAN)
+	assert.Equal(t, "customer", r.CustomerID)
+	assert.Equal(t, [4]string{"Jane", "Doe"}, r.Name)
+	assert.Equal(t, "NWBKGB22", r.BIC)
+
+	invalid := fixtures.ValidGB(fixtures.WithBankID("1"), fixtures.WithBankIDCode("FR"))
+	assert.Error(t, client.ValidateResource(invalid))
+}
+
+func TestValid_UnsupportedCountry(t *testing.T) {
+	assert.PanicsWithValue(t, "fixtures.Valid: client.GenerateResource: unsupported country code: XX", func() {
+		fixtures.Valid("XX")
+	})
+}
@@ -0,0 +1,308 @@
+// Package loadgen drives a mix of account operations against the accounts API at a target rate, and reports the
+// latencies and error rates per operation, for capacity testing of both the client and the service.
+package loadgen
+
+import (
+	"errors"
+	"fmt"
+	"math/rand"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+)
+
+// Operations are the names of the operations a Mix can contain.
+const (
+	OpCreate = "create"
+	OpFetch  = "fetch"
+	OpList   = "list"
+	OpUpdate = "update"
+	OpDelete = "delete"
+)
+
+// ErrMix is matched by the errors of ParseMix, and of Generate for a Mix without operations.
+var ErrMix = errors.New("invalid mix")
+
+// Mix is how often each operation is run relative to the others, like create 1 and fetch 4 for four fetches to every
+// create.
+type Mix map[string]int
+
+// Plan is what Generate runs: Mix at Rate requests per second for Duration, from Workers goroutines. Rate includes
+// retries. Without enough workers to keep up with it, the rate is lower, which the Report shows.
+type Plan struct {
+	Mix      Mix
+	Rate     float64
+	Duration time.Duration
+	Workers  int
+	PageSize uint
+	Seed     int64
+}
+
+// Report is the outcome of a Plan: how long it ran, how many requests it sent at what rate, and the Stats of the
+// Client per operation. Cleanup is the number of accounts left over that were deleted afterwards, which are not part
+// of the Stats.
+type Report struct {
+	Elapsed  time.Duration
+	Requests int
+	Rate     float64
+	Stats    client.Stats
+	Cleanup  int
+}
+
+// ParseMix parses a mix in the op=weight,op=weight form, like create=1,fetch=4,list=2,delete=1. Every operation has
+// to be one of the Op constants, and its weight a whole number that is not negative. At least one weight has to be
+// positive.
+func ParseMix(s string) (Mix, error) {
+	m := Mix{}
+	total := 0
+
+	for _, part := range strings.Split(s, ",") {
+		kv := strings.SplitN(strings.TrimSpace(part), "=", 2)
+		if len(kv) != 2 {
+			return nil, fmt.Errorf("loadgen.ParseMix: %w: %q is not op=weight", ErrMix, part)
+		}
+
+		switch kv[0] {
+		case OpCreate, OpFetch, OpList, OpUpdate, OpDelete:
+		default:
+			return nil, fmt.Errorf("loadgen.ParseMix: %w: unknown operation %q", ErrMix, kv[0])
+		}
+
+		weight, err := strconv.Atoi(kv[1])
+		if err != nil || weight < 0 {
+			return nil, fmt.Errorf("loadgen.ParseMix: %w: weight of %s has to be a whole number of 0 or more", ErrMix,
+				kv[0])
+		}
+
+		m[kv[0]] = weight
+		total += weight
+	}
+
+	if total == 0 {
+		return nil, fmt.Errorf("loadgen.ParseMix: %w: every weight is 0", ErrMix)
+	}
+
+	return m, nil
+}
+
+// String formats the mix the way ParseMix parses it, with the operations in alphabetical order.
+func (m Mix) String() string {
+	ops := make([]string, 0, len(m))
+
+	for op := range m {
+		ops = append(ops, op)
+	}
+
+	sort.Strings(ops)
+
+	parts := make([]string, 0, len(ops))
+
+	for _, op := range ops {
+		parts = append(parts, op+"="+strconv.Itoa(m[op]))
+	}
+
+	return strings.Join(parts, ",")
+}
+
+// pick returns an operation with a probability proportional to its weight.
+func (m Mix) pick(rnd *rand.Rand, ops []string, total int) string {
+	n := rnd.Intn(total)
+
+	for _, op := range ops {
+		n -= m[op]
+		if n < 0 {
+			return op
+		}
+	}
+
+	return ops[len(ops)-1]
+}
+
+// Generate runs the plan against the service with c, and returns the report. Fetches, updates, and deletes work on
+// accounts the plan created, so they are replaced by a create while there are none. Accounts that are left at the end
+// are deleted, after the report has been taken. The rate limit, stats, concurrency, and idle connections of c are
+// replaced. The plan fails with ErrMix if no operation of its Mix has a positive weight.
+func Generate(c client.Client, p Plan) (Report, error) {
+	ops := make([]string, 0, len(p.Mix))
+	total := 0
+
+	for op, weight := range p.Mix {
+		if weight > 0 {
+			ops = append(ops, op)
+			total += weight
+		}
+	}
+
+	if total == 0 {
+		return Report{}, fmt.Errorf("loadgen.Generate: %w: no operation to run", ErrMix)
+	}
+
+	sort.Strings(ops)
+
+	client.WithStats()(&c)
+	client.WithRateLimit(p.Rate)(&c)
+	client.WithConcurrency(p.Workers)(&c)
+	client.WithMaxIdleConnsPerHost(p.Workers)(&c)
+
+	workers := p.Workers
+	if workers < 1 {
+		workers = 1
+	}
+
+	g := generator{c: c, pageSize: p.PageSize}
+	start := time.Now()
+	deadline := start.Add(p.Duration)
+
+	var wg sync.WaitGroup
+
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+
+		go func(seed int64) {
+			defer wg.Done()
+
+			rnd := rand.New(rand.NewSource(seed)) //nolint:gosec // picking operations is not security sensitive
+
+			for time.Now().Before(deadline) {
+				g.run(p.Mix.pick(rnd, ops, total), rnd)
+			}
+		}(p.Seed + int64(w))
+	}
+
+	wg.Wait()
+
+	r := Report{Elapsed: time.Since(start), Stats: c.Stats()}
+
+	for _, op := range r.Stats.Operations {
+		r.Requests += op.Requests
+	}
+
+	if r.Elapsed > 0 {
+		r.Rate = float64(r.Requests) / r.Elapsed.Seconds()
+	}
+
+	left := g.pool.drain()
+	r.Cleanup = len(left)
+
+	c.DeleteBatch(left, func(client.Data, error) {})
+
+	return r, nil
+}
+
+// generator runs single operations, and keeps the accounts they created.
+type generator struct {
+	c        client.Client
+	pageSize uint
+	pool     pool
+}
+
+// run runs the operation. Errors are not returned, as the Stats of the Client count them.
+func (g *generator) run(op string, rnd *rand.Rand) {
+	if op == OpList {
+		_, _ = g.c.List(0, g.pageSize)
+
+		return
+	}
+
+	if op == OpCreate {
+		g.create(rnd)
+
+		return
+	}
+
+	d, ok := g.pool.take(rnd)
+	if !ok {
+		g.create(rnd)
+
+		return
+	}
+
+	switch op {
+	case OpFetch:
+		_, _ = g.c.Fetch(d.ID)
+		g.pool.put(d)
+	case OpUpdate:
+		p, err := g.c.Update(d.ID, uint(d.Version), map[string]interface{}{"name": []string{randomName(rnd)}})
+		if err == nil {
+			d = p.Data
+		}
+
+		g.pool.put(d)
+	case OpDelete:
+		if err := g.c.Delete(d.ID, uint(d.Version)); err != nil {
+			g.pool.put(d)
+		}
+	}
+}
+
+// create creates a valid account of a random supported country, and keeps it for later operations.
+func (g *generator) create(rnd *rand.Rand) {
+	countries := client.SupportedCountries()
+
+	r, err := client.GenerateResource(countries[rnd.Intn(len(countries))], rnd)
+	if err != nil {
+		return
+	}
+
+	r.Name = [4]string{randomName(rnd)}
+
+	p, err := g.c.Create(r)
+	if err != nil {
+		return
+	}
+
+	g.pool.put(p.Data)
+}
+
+// randomName returns a name for an account.
+func randomName(rnd *rand.Rand) string {
+	return "loadgen " + strconv.Itoa(rnd.Intn(1_000_000)) //nolint:gomnd
+}
+
+// pool holds the accounts the generator created. An account that is taken is not handed out again until it is put
+// back, so workers never update or delete the same account at the same time.
+type pool struct {
+	mu       sync.Mutex
+	accounts []client.Data
+}
+
+// take removes a random account from the pool.
+func (p *pool) take(rnd *rand.Rand) (client.Data, bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if len(p.accounts) == 0 {
+		return client.Data{}, false
+	}
+
+	i := rnd.Intn(len(p.accounts))
+	d := p.accounts[i]
+	last := len(p.accounts) - 1
+	p.accounts[i] = p.accounts[last]
+	p.accounts = p.accounts[:last]
+
+	return d, true
+}
+
+// put adds an account to the pool.
+func (p *pool) put(d client.Data) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	p.accounts = append(p.accounts, d)
+}
+
+// drain removes every account from the pool.
+func (p *pool) drain() []client.Data {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	accounts := p.accounts
+	p.accounts = nil
+
+	return accounts
+}
@@ -0,0 +1,229 @@
+package loadgen_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/config"
+	"github.com/javorszky/form3takehome/pkg/loadgen"
+)
+
+// fakeService keeps accounts in memory, with just enough of the behaviour of the service for the load generator.
+type fakeService struct {
+	mu       sync.Mutex
+	accounts map[string]client.Data
+}
+
+func newFakeService() *fakeService {
+	return &fakeService{accounts: map[string]client.Data{}}
+}
+
+func (f *fakeService) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	id := strings.TrimPrefix(r.URL.Path, "/v1/organisation/accounts/")
+	d, ok := f.accounts[id]
+
+	switch {
+	case r.Method == http.MethodPost:
+		var p client.Payload
+
+		_ = json.NewDecoder(r.Body).Decode(&p)
+		f.accounts[p.Data.ID] = p.Data
+
+		w.WriteHeader(http.StatusCreated)
+		_ = json.NewEncoder(w).Encode(p)
+	case r.Method == http.MethodGet && r.URL.Path == "/v1/organisation/accounts":
+		_, _ = w.Write([]byte(`{"data": []}`))
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+	case r.Method == http.MethodGet:
+		_ = json.NewEncoder(w).Encode(client.Payload{Data: d})
+	case r.Method == http.MethodPatch:
+		d.Version++
+		f.accounts[id] = d
+
+		_ = json.NewEncoder(w).Encode(client.Payload{Data: d})
+	case r.Method == http.MethodDelete:
+		if r.URL.Query().Get("version") != strconv.Itoa(d.Version) {
+			w.WriteHeader(http.StatusConflict)
+
+			return
+		}
+
+		delete(f.accounts, id)
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+func (f *fakeService) count() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return len(f.accounts)
+}
+
+func TestParseMix(t *testing.T) {
+	tests := []struct {
+		mix     string
+		want    loadgen.Mix
+		wantErr string
+	}{
+		{mix: "create=1, fetch=4,list=2,update=0,delete=1", want: loadgen.Mix{
+			"create": 1, "fetch": 4, "list": 2, "update": 0, "delete": 1,
+		}},
+		{mix: "create", wantErr: `"create" is not op=weight`},
+		{mix: "purge=1", wantErr: `unknown operation "purge"`},
+		{mix: "create=-1", wantErr: "weight of create has to be a whole number of 0 or more"},
+		{mix: "create=0,fetch=0", wantErr: "every weight is 0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.mix, func(t *testing.T) {
+			got, err := loadgen.ParseMix(tt.mix)
+			if tt.wantErr != "" {
+				assert.True(t, errors.Is(err, loadgen.ErrMix))
+				assert.Contains(t, err.Error(), tt.wantErr)
+
+				return
+			}
+
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, "create=1,delete=1,fetch=4,list=2,update=0", got.String())
+		})
+	}
+}
+
+func TestGenerate(t *testing.T) {
+	f := newFakeService()
+
+	ts := httptest.NewServer(f)
+	defer ts.Close()
+
+	c := client.Client{BaseURL: ts.URL}
+
+	r, err := loadgen.Generate(c, loadgen.Plan{
+		Mix:      loadgen.Mix{"create": 2, "fetch": 2, "list": 1, "update": 1, "delete": 1},
+		Rate:     500,
+		Duration: 200 * time.Millisecond,
+		Workers:  4,
+		PageSize: 10,
+	})
+	assert.NoError(t, err)
+
+	assert.True(t, r.Requests > 10, "%d requests", r.Requests)
+	assert.True(t, r.Rate <= 600, "%f requests per second", r.Rate)
+	assert.Zero(t, r.ErrorRate())
+
+	for _, op := range []string{"create", "fetch", "list", "update", "delete"} {
+		assert.True(t, r.Stats.Operations[op].Requests > 0, "no %s", op)
+	}
+
+	assert.Zero(t, f.count(), "accounts left over are cleaned up")
+
+	_, err = loadgen.Generate(c, loadgen.Plan{Mix: loadgen.Mix{"create": 0}})
+	assert.True(t, errors.Is(err, loadgen.ErrMix))
+}
+
+func TestWriteReport(t *testing.T) {
+	var out bytes.Buffer
+
+	loadgen.WriteReport(&out, loadgen.Report{
+		Elapsed:  2 * time.Second,
+		Requests: 20,
+		Rate:     10,
+		Cleanup:  3,
+		Stats: client.Stats{Operations: map[string]client.OperationStats{
+			"fetch":  {Requests: 16, Errors: 1, ErrorRate: 1.0 / 16, P50: time.Millisecond, Max: time.Second},
+			"create": {Requests: 4, Retries: 1, P99: 2 * time.Millisecond},
+		}},
+	})
+
+	assert.Equal(t, `OPERATION  REQUESTS  RETRIES  ERRORS  ERROR RATE  P50  P90  P99  MAX
+create     4         1        0       0.00%       0s   0s   2ms  0s
+fetch      16        0        1       6.25%       1ms  0s   0s   1s
+
+20 requests in 2s, 10.0 requests per second, 5.00% errors, 3 accounts cleaned up
+`, out.String())
+}
+
+func TestRun(t *testing.T) {
+	ts := httptest.NewServer(newFakeService())
+	defer ts.Close()
+
+	broken := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusBadRequest)
+	}))
+	defer broken.Close()
+
+	tests := []struct {
+		name       string
+		args       []string
+		address    string
+		wantCode   int
+		wantStdout string
+		wantStderr string
+	}{
+		{
+			name:       "runs the plan and reports",
+			args:       []string{"-duration", "100ms", "-rps", "200", "-mix", "create=1,fetch=1"},
+			address:    ts.URL,
+			wantCode:   loadgen.ExitOK,
+			wantStdout: "requests per second, 0.00% errors",
+			wantStderr: "loadgen: running create=1,fetch=1 at 200 requests per second for 100ms against " + ts.URL,
+		},
+		{
+			name:       "fails over the error rate",
+			args:       []string{"-duration", "50ms", "-mix", "create=1", "-max-error-rate", "0"},
+			address:    broken.URL,
+			wantCode:   loadgen.ExitFailure,
+			wantStderr: "loadgen: error rate over the limit: 100.00% > 0.00%",
+		},
+		{
+			name:       "needs an address",
+			args:       []string{},
+			wantCode:   loadgen.ExitFailure,
+			wantStderr: "accounts address and organisation id are required",
+		},
+		{
+			name:       "rejects a broken mix",
+			args:       []string{"-mix", "purge=1"},
+			address:    ts.URL,
+			wantCode:   loadgen.ExitUsage,
+			wantStderr: `unknown operation "purge"`,
+		},
+		{
+			name:       "rejects arguments",
+			args:       []string{"now"},
+			address:    ts.URL,
+			wantCode:   loadgen.ExitUsage,
+			wantStderr: "loadgen: unexpected arguments: [now]",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv(config.FileKey, filepath.Join(t.TempDir(), "config.json"))
+			t.Setenv(config.AccountsAPIURLKey, tt.address)
+			t.Setenv(config.OrganisationIDKey, "eb0bd6f5-c3f5-44b2-b677-acd23cdde73c")
+
+			var stdout, stderr bytes.Buffer
+
+			assert.Equal(t, tt.wantCode, loadgen.Run(tt.args, &stdout, &stderr), "stderr: %s", stderr.String())
+			assert.Contains(t, stdout.String(), tt.wantStdout)
+			assert.Contains(t, stderr.String(), tt.wantStderr)
+		})
+	}
+}
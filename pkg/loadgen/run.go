@@ -0,0 +1,180 @@
+package loadgen
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/javorszky/form3takehome/pkg/client"
+	"github.com/javorszky/form3takehome/pkg/config"
+)
+
+// Exit codes returned by Run.
+const (
+	// ExitOK means the plan ran, and the error rate stayed within the limit.
+	ExitOK = 0
+
+	// ExitFailure means the plan could not run, or the error rate went over the limit.
+	ExitFailure = 1
+
+	// ExitUsage means the flags were wrong.
+	ExitUsage = 2
+)
+
+const (
+	programName     = "loadgen"
+	defaultMix      = "create=1,fetch=4,list=2,delete=1"
+	defaultRate     = 10
+	defaultDuration = 30 * time.Second
+	defaultWorkers  = 8
+	defaultPageSize = 100
+	defaultTimeout  = 10 * time.Second
+	percent         = 100
+	tabMinWidth     = 0
+	tabWidth        = 4
+	tabPadding      = 2
+)
+
+var errErrorRate = errors.New("error rate over the limit")
+
+// Run parses the flags in args, runs the plan they describe against the service of the configured profile, and writes
+// the report to stdout. It returns the exit code of the process.
+func Run(args []string, stdout, stderr io.Writer) int {
+	var (
+		configFile   string
+		profile      string
+		mix          string
+		p            Plan
+		timeout      time.Duration
+		maxErrorRate float64
+	)
+
+	fs := flag.NewFlagSet(programName, flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.StringVar(&configFile, "config", "", "path of the config file of accountsclient, "+config.FileKey+" or the "+
+		"default location if empty")
+	fs.StringVar(&profile, "profile", "", "profile of the config file to run against")
+	fs.StringVar(&mix, "mix", defaultMix, "relative weights of the operations to run, out of create, fetch, list, "+
+		"update, and delete")
+	fs.Float64Var(&p.Rate, "rps", defaultRate, "target rate of requests per second, retries included. 0 for as fast "+
+		"as the workers go")
+	fs.DurationVar(&p.Duration, "duration", defaultDuration, "how long to run for")
+	fs.IntVar(&p.Workers, "workers", defaultWorkers, "number of requests to have in flight at most")
+	fs.UintVar(&p.PageSize, "size", defaultPageSize, "page size of lists")
+	fs.Int64Var(&p.Seed, "seed", 1, "seed of the random choice of operations and accounts")
+	fs.DurationVar(&timeout, "timeout", defaultTimeout, "timeout of a single request")
+	fs.Float64Var(&maxErrorRate, "max-error-rate", 1, "fail if more than this fraction of the requests fail, "+
+		"between 0 and 1")
+
+	if err := fs.Parse(args); err != nil {
+		return ExitUsage
+	}
+
+	if fs.NArg() != 0 {
+		_, _ = fmt.Fprintf(stderr, "%s: unexpected arguments: %v\n", programName, fs.Args())
+
+		return ExitUsage
+	}
+
+	var err error
+
+	p.Mix, err = ParseMix(mix)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%s: %s\n", programName, err)
+
+		return ExitUsage
+	}
+
+	c, err := newClient(configFile, profile, timeout)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%s: %s\n", programName, err)
+
+		return ExitFailure
+	}
+
+	_, _ = fmt.Fprintf(stderr, "%s: running %s at %g requests per second for %s against %s\n", programName, p.Mix,
+		p.Rate, p.Duration, c.BaseURL)
+
+	r, err := Generate(c, p)
+	if err != nil {
+		_, _ = fmt.Fprintf(stderr, "%s: %s\n", programName, err)
+
+		return ExitFailure
+	}
+
+	WriteReport(stdout, r)
+
+	if rate := r.ErrorRate(); rate > maxErrorRate {
+		_, _ = fmt.Fprintf(stderr, "%s: %s: %.2f%% > %.2f%%\n", programName, errErrorRate, rate*percent,
+			maxErrorRate*percent)
+
+		return ExitFailure
+	}
+
+	return ExitOK
+}
+
+// newClient configures a client.Client from the config file and the environment, the same way accountsclient does.
+func newClient(configFile, profile string, timeout time.Duration) (client.Client, error) {
+	if configFile == "" {
+		configFile, _ = config.DefaultFilePath()
+	}
+
+	cfg, err := config.LoadProfile(configFile, profile, config.Profile{})
+	if err != nil {
+		return client.Client{}, fmt.Errorf("loading configuration: %w", err)
+	}
+
+	c, err := client.New(cfg, http.Client{}, client.WithAttemptTimeout(timeout))
+	if err != nil {
+		return client.Client{}, fmt.Errorf("configuring client: %w", err)
+	}
+
+	return c, nil
+}
+
+// ErrorRate returns the fraction of the requests of the report that failed.
+func (r Report) ErrorRate() float64 {
+	if r.Requests == 0 {
+		return 0
+	}
+
+	errs := 0
+
+	for _, op := range r.Stats.Operations {
+		errs += op.Errors
+	}
+
+	return float64(errs) / float64(r.Requests)
+}
+
+// WriteReport writes the report as a table of operations with their requests, errors, and latency percentiles, and a
+// summary line.
+func WriteReport(w io.Writer, r Report) {
+	tw := tabwriter.NewWriter(w, tabMinWidth, tabWidth, tabPadding, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "OPERATION\tREQUESTS\tRETRIES\tERRORS\tERROR RATE\tP50\tP90\tP99\tMAX")
+
+	ops := make([]string, 0, len(r.Stats.Operations))
+
+	for op := range r.Stats.Operations {
+		ops = append(ops, op)
+	}
+
+	sort.Strings(ops)
+
+	for _, op := range ops {
+		s := r.Stats.Operations[op]
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%d\t%.2f%%\t%s\t%s\t%s\t%s\n", op, s.Requests, s.Retries, s.Errors,
+			s.ErrorRate*percent, s.P50, s.P90, s.P99, s.Max)
+	}
+
+	_ = tw.Flush()
+
+	_, _ = fmt.Fprintf(w, "\n%d requests in %s, %.1f requests per second, %.2f%% errors, %d accounts cleaned up\n",
+		r.Requests, r.Elapsed.Round(time.Millisecond), r.Rate, r.ErrorRate()*percent, r.Cleanup)
+}